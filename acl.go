@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dgraph-io/dgo/v210"
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// aclLogin is the ACL login of a client, to log in again when the tokens of the client expire
+type aclLogin struct {
+	mu        sync.Mutex
+	client    *dgo.Dgraph
+	user      string
+	password  string
+	namespace uint64
+}
+
+// LoginACL logs in the client with Dgraph ACL credentials into a namespace, 0 being the default namespace.
+// Transactions created from the client log in again and retry queries and mutations failing with
+// permission errors, i.e: when the refresh token has also expired on long-running services,
+// as dgo only refreshes the access token with the refresh token.
+func (c *Client) LoginACL(ctx context.Context, user, password string, namespace uint64) error {
+	login := &aclLogin{client: c.dg.Dgraph, user: user, password: password, namespace: namespace}
+	if err := login.login(ctx); err != nil {
+		return err
+	}
+	c.dg.config.login = login
+	return nil
+}
+
+func (l *aclLogin) login(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.client.LoginIntoNamespace(ctx, l.user, l.password, l.namespace)
+}
+
+// isPermissionError returns whether the error is a permission error of an expired or invalid token
+func isPermissionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	code := status.Code(err)
+	return code == codes.Unauthenticated || code == codes.PermissionDenied
+}
+
+// aclTxn logs in again and retries requests failing with permission errors
+type aclTxn struct {
+	transaction
+	login  func(ctx context.Context) error
+	newTxn func() transaction
+	// sent is whether a request has been sent by the transaction, as dgo discards transactions on failed
+	// mutations, which can only be retried on a new transaction when no requests were sent before
+	sent bool
+}
+
+// withACL wraps a dgo transaction of a client to log in again on permission errors, when logged in with Client.LoginACL
+func withACL(c DgraphClient, readOnly bool, login *aclLogin) transaction {
+	newTxn := func() transaction {
+		if readOnly {
+			return c.NewReadOnlyTxn()
+		}
+		return c.NewTxn()
+	}
+	if login == nil {
+		return newTxn()
+	}
+	return &aclTxn{transaction: newTxn(), login: login.login, newTxn: newTxn}
+}
+
+// retry logs in again and retries a failed request, returning the original error when not a permission error
+func (a *aclTxn) unwrap() transaction {
+	return a.transaction
+}
+
+func (a *aclTxn) retry(ctx context.Context, err error, mutation bool, request func() (*api.Response, error)) (*api.Response, error) {
+	if !isPermissionError(err) {
+		return nil, err
+	}
+	if mutation {
+		if a.sent {
+			return nil, errors.Wrap(err, "permission denied on a mutation of a discarded transaction")
+		}
+		a.transaction = a.newTxn()
+	}
+	if loginErr := a.login(ctx); loginErr != nil {
+		return nil, errors.Wrapf(err, "acl login failed: %v", loginErr)
+	}
+	return request()
+}
+
+func (a *aclTxn) do(ctx context.Context, mutation bool, request func() (*api.Response, error)) (*api.Response, error) {
+	resp, err := request()
+	if err != nil {
+		resp, err = a.retry(ctx, err, mutation, request)
+	}
+	a.sent = true
+	return resp, err
+}
+
+func (a *aclTxn) Query(ctx context.Context, q string) (*api.Response, error) {
+	return a.do(ctx, false, func() (*api.Response, error) {
+		return a.transaction.Query(ctx, q)
+	})
+}
+
+func (a *aclTxn) QueryWithVars(ctx context.Context, q string, vars map[string]string) (*api.Response, error) {
+	return a.do(ctx, false, func() (*api.Response, error) {
+		return a.transaction.QueryWithVars(ctx, q, vars)
+	})
+}
+
+func (a *aclTxn) Mutate(ctx context.Context, mu *api.Mutation) (*api.Response, error) {
+	return a.do(ctx, true, func() (*api.Response, error) {
+		return a.transaction.Mutate(ctx, mu)
+	})
+}
+
+func (a *aclTxn) Do(ctx context.Context, req *api.Request) (*api.Response, error) {
+	return a.do(ctx, len(req.Mutations) > 0, func() (*api.Response, error) {
+		return a.transaction.Do(ctx, req)
+	})
+}
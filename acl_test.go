@@ -0,0 +1,116 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsPermissionError(t *testing.T) {
+	assert.True(t, isPermissionError(status.Error(codes.Unauthenticated, "Token is expired")))
+	assert.True(t, isPermissionError(status.Error(codes.PermissionDenied, "unauthorized to query the predicate")))
+	assert.False(t, isPermissionError(status.Error(codes.Aborted, "aborted")))
+	assert.False(t, isPermissionError(errors.New("unknown")))
+	assert.False(t, isPermissionError(nil))
+}
+
+func TestACLTxnRetryQuery(t *testing.T) {
+	_, fake := newFakeTxnContext(&api.Response{Json: []byte(`{"data":[]}`)})
+	fake.err = status.Error(codes.Unauthenticated, "Token is expired")
+
+	logins := 0
+	txn := &aclTxn{
+		transaction: fake,
+		login: func(ctx context.Context) error {
+			logins++
+			fake.err = nil
+			return nil
+		},
+		newTxn: func() transaction { return fake },
+	}
+	tx := &TxnContext{txn: txn, ctx: context.Background(), opts: &ClientOptions{}}
+
+	var result []*TestModel
+	err := tx.Get(&result).Filter("has(name)").Nodes()
+	require.NoError(t, err)
+	assert.Equal(t, 1, logins)
+	assert.Len(t, fake.requests, 2)
+}
+
+func TestACLTxnRetryMutation(t *testing.T) {
+	_, expired := newFakeTxnContext()
+	expired.err = status.Error(codes.PermissionDenied, "Token is expired")
+	_, renewed := newFakeTxnContext()
+
+	txn := &aclTxn{
+		transaction: expired,
+		login:       func(ctx context.Context) error { return nil },
+		newTxn:      func() transaction { return renewed },
+	}
+	tx := &TxnContext{txn: txn, ctx: context.Background(), opts: &ClientOptions{}}
+
+	_, err := tx.MutateBasic(&TestModel{Name: "wildan"})
+	require.NoError(t, err)
+	assert.Len(t, expired.requests, 1)
+	assert.Len(t, renewed.requests, 1)
+
+	// mutations cannot be retried after requests were sent, as the dgo transaction is discarded
+	renewed.err = status.Error(codes.Unauthenticated, "Token is expired")
+	_, err = tx.MutateBasic(&TestModel{Name: "wildan"})
+	assert.Error(t, err)
+	assert.Len(t, renewed.requests, 2)
+}
+
+func TestACLTxnLoginFailed(t *testing.T) {
+	_, fake := newFakeTxnContext()
+	fake.err = status.Error(codes.Unauthenticated, "Token is expired")
+
+	txn := &aclTxn{
+		transaction: fake,
+		login:       func(ctx context.Context) error { return errors.New("invalid password") },
+		newTxn:      func() transaction { return fake },
+	}
+	_, err := txn.Query(context.Background(), "{}")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid password")
+	assert.Len(t, fake.requests, 1)
+}
+
+func TestWithACL(t *testing.T) {
+	dc := &loginClient{}
+	c := NewClient(dc)
+	_, ok := NewTxn(c.Dgraph()).txn.(*aclTxn)
+	assert.False(t, ok)
+
+	require.NoError(t, c.LoginACL(context.Background(), "groot", "password", 1))
+	_, ok = NewTxn(c.Dgraph()).txn.(*aclTxn)
+	assert.True(t, ok)
+	_, ok = c.NewReadOnlyTxn().txn.(*aclTxn)
+	assert.True(t, ok)
+	// other clients of the same connections are not logged in
+	_, ok = NewClient(dc).NewTxn().txn.(*aclTxn)
+	assert.False(t, ok)
+	assert.Equal(t, []*api.LoginRequest{{Userid: "groot", Password: "password", Namespace: 1}}, dc.logins)
+}
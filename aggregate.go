@@ -0,0 +1,191 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	stdjson "encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	aggregateBlock = "aggregate"
+	countBlock     = "count"
+)
+
+type aggregateField struct {
+	alias     string
+	function  string
+	predicate string
+}
+
+// Aggregation is an aggregate query on the nodes matching a query, e.g: count, sum, avg, min, and max.
+// Pagination of the query is ignored, as aggregations apply to all matching nodes.
+type Aggregation struct {
+	query  *Query
+	count  string
+	fields []aggregateField
+}
+
+// Aggregate prepares an aggregate query on the nodes matching the query
+func (q *Query) Aggregate() *Aggregation {
+	return &Aggregation{query: q}
+}
+
+// Count returns the number of nodes matching the query, ignoring pagination
+func (q *Query) Count() (int, error) {
+	var result struct {
+		Count int `json:"count"`
+	}
+	if err := q.Aggregate().Count("count").Scan(&result); err != nil {
+		return 0, err
+	}
+	return result.Count, nil
+}
+
+// Count counts the nodes into the alias field
+func (a *Aggregation) Count(alias string) *Aggregation {
+	a.count = alias
+	return a
+}
+
+// Sum sums the values of a predicate into the alias field
+func (a *Aggregation) Sum(alias, predicate string) *Aggregation {
+	return a.add(alias, "sum", predicate)
+}
+
+// Avg averages the values of a predicate into the alias field
+func (a *Aggregation) Avg(alias, predicate string) *Aggregation {
+	return a.add(alias, "avg", predicate)
+}
+
+// Min gets the minimum value of a predicate into the alias field
+func (a *Aggregation) Min(alias, predicate string) *Aggregation {
+	return a.add(alias, "min", predicate)
+}
+
+// Max gets the maximum value of a predicate into the alias field
+func (a *Aggregation) Max(alias, predicate string) *Aggregation {
+	return a.add(alias, "max", predicate)
+}
+
+func (a *Aggregation) add(alias, function, predicate string) *Aggregation {
+	a.fields = append(a.fields, aggregateField{alias: alias, function: function, predicate: predicate})
+	return a
+}
+
+func (a *Aggregation) String() string {
+	q := a.query
+
+	var queryBuf strings.Builder
+	if q.vars != nil {
+		queryBuf.WriteString("query ")
+		queryBuf.WriteString(q.paramString)
+	}
+	queryBuf.WriteString("{\n")
+
+	var qr string
+	// only apply the query if the result will be cascaded
+	if q.cascade != nil {
+		qr = q.query
+	}
+	filtered := &Query{
+		as:          "filtered",
+		isVar:       true,
+		uid:         q.uid,
+		rootFunc:    q.rootFunc,
+		model:       q.model,
+		filter:      q.filter,
+		query:       qr,
+		cascade:     q.cascade,
+		withDeleted: q.withDeleted,
+		untyped:     q.untyped,
+	}
+	filtered.generateQuery(&queryBuf)
+
+	if len(a.fields) > 0 {
+		// each aggregated predicate is assigned to a value variable
+		var valuesBuf, aggregateBuf strings.Builder
+		valuesBuf.WriteString("{\n")
+		for i, field := range a.fields {
+			valueVar := "v_" + strconv.Itoa(i)
+			valuesBuf.WriteString(valueVar)
+			valuesBuf.WriteString(" as ")
+			valuesBuf.WriteString(field.predicate)
+			valuesBuf.WriteByte('\n')
+
+			aggregateBuf.WriteString(field.alias)
+			aggregateBuf.WriteString(": ")
+			aggregateBuf.WriteString(field.function)
+			aggregateBuf.WriteString("(val(")
+			aggregateBuf.WriteString(valueVar)
+			aggregateBuf.WriteString("))\n")
+		}
+		valuesBuf.WriteString("}")
+
+		values := &Query{isVar: true, uid: "filtered", query: valuesBuf.String()}
+		values.generateQuery(&queryBuf)
+
+		queryBuf.WriteString(aggregateBlock)
+		queryBuf.WriteString("() {\n")
+		queryBuf.WriteString(aggregateBuf.String())
+		queryBuf.WriteString("}\n")
+	}
+
+	if a.count != "" {
+		count := &Query{name: countBlock, uid: "filtered", query: "{\n" + a.count + ": count(uid)\n}"}
+		count.generateQuery(&queryBuf)
+	}
+
+	queryBuf.WriteString("}")
+
+	return FormatQuery(queryBuf.String())
+}
+
+// Scan runs the aggregate query, and unmarshals the aggregated values into dst by alias,
+// e.g: a struct with json tags matching the aliases
+func (a *Aggregation) Scan(dst interface{}) error {
+	q := a.query
+	if q.err != nil {
+		return q.err
+	}
+	if len(a.fields) == 0 && a.count == "" {
+		return errors.New("aggregation is empty")
+	}
+
+	resp, err := q.send(a.String())
+	if err != nil {
+		return err
+	}
+
+	var blocks map[string][]stdjson.RawMessage
+	if err := json.Unmarshal(resp.Json, &blocks); err != nil {
+		return errors.Wrap(err, "unmarshal aggregate result failed")
+	}
+
+	// dgraph returns each aggregated value as a separate object
+	for _, blockName := range []string{aggregateBlock, countBlock} {
+		for _, value := range blocks[blockName] {
+			if err := json.Unmarshal(value, dst); err != nil {
+				return errors.Wrapf(err, "unmarshal %s failed", blockName)
+			}
+		}
+	}
+	return nil
+}
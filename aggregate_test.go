@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type AggregateProduct struct {
+	UID   string   `json:"uid,omitempty"`
+	Name  string   `json:"name,omitempty" dgraph:"index=term"`
+	Price float64  `json:"price,omitempty" dgraph:"index=float"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+func TestQueryAggregate(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"aggregate":[{"total":30.5},{"average":10.2},{"cheapest":5}],"count":[{"products":3}]}`),
+	})
+
+	var result struct {
+		Products int     `json:"products"`
+		Total    float64 `json:"total"`
+		Average  float64 `json:"average"`
+		Cheapest float64 `json:"cheapest"`
+	}
+	err := tx.Get(&AggregateProduct{}).
+		Filter("anyofterms(name, $1)", "shoe").
+		First(10).
+		Aggregate().
+		Count("products").
+		Sum("total", "price").
+		Avg("average", "price").
+		Min("cheapest", "price").
+		Scan(&result)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.Products)
+	assert.Equal(t, 30.5, result.Total)
+	assert.Equal(t, 10.2, result.Average)
+	assert.Equal(t, 5.0, result.Cheapest)
+
+	require.Len(t, fake.requests, 1)
+	assert.Equal(t, `{
+	filtered as var(func: type(AggregateProduct)) @filter(has(dgraph.type) AND anyofterms(name, "shoe"))
+	var(func: uid(filtered)) @filter(has(dgraph.type)) {
+		v_0 as price
+		v_1 as price
+		v_2 as price
+	}
+	aggregate() {
+		total: sum(val(v_0))
+		average: avg(val(v_1))
+		cheapest: min(val(v_2))
+	}
+	count(func: uid(filtered)) @filter(has(dgraph.type)) {
+		products: count(uid)
+	}
+}`, fake.requests[0].Query)
+}
+
+func TestQueryCount(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"count":[{"count":42}]}`),
+	})
+
+	count, err := tx.Get(&AggregateProduct{}).Count()
+	require.NoError(t, err)
+	assert.Equal(t, 42, count)
+
+	require.Len(t, fake.requests, 1)
+	assert.NotContains(t, fake.requests[0].Query, "aggregate()")
+	assert.Contains(t, fake.requests[0].Query, "count: count(uid)")
+}
+
+func TestQueryAggregateEmpty(t *testing.T) {
+	tx, _ := newFakeTxnContext()
+
+	var result struct{}
+	err := tx.Get(&AggregateProduct{}).Aggregate().Scan(&result)
+	assert.EqualError(t, err, "aggregation is empty")
+}
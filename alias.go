@@ -0,0 +1,173 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// PredicateAliases maps node types to their predicate aliases, which map
+// the predicates in query results to the predicates of the struct fields,
+// e.g: {"User": {"user_email": "email"}} scans user_email into the email field
+type PredicateAliases map[string]map[string]string
+
+// unmarshalNodes unmarshals a query result into dst, renaming aliased predicates
+func unmarshalNodes(data []byte, dst interface{}, aliases PredicateAliases) error {
+	if dst != nil && (len(aliases) > 0 || hasSingleReverseEdges(reflect.TypeOf(dst))) {
+		remapped, err := remapPredicateKeys(data, reflect.TypeOf(dst), aliases)
+		if err != nil {
+			return errors.Wrap(err, "remap predicate keys failed")
+		}
+		data = remapped
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// remapPredicateKeys renames the aliased predicate keys of the nodes in a json result
+// to the predicates of the destination type, walking the result along the destination type
+func remapPredicateKeys(data []byte, dstType reflect.Type, aliases PredicateAliases) ([]byte, error) {
+	decoder := stdjson.NewDecoder(bytes.NewReader(data))
+	// keep numbers as is, e.g: int64 and big floats
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	remapValue(value, dstType, aliases)
+	return stdjson.Marshal(value)
+}
+
+func remapValue(value interface{}, t reflect.Type, aliases PredicateAliases) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch value := value.(type) {
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			// single node edges may be returned as a list
+			for _, elem := range value {
+				remapValue(elem, t, aliases)
+			}
+			return
+		}
+		for _, elem := range value {
+			remapValue(elem, t.Elem(), aliases)
+		}
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		for alias, predicate := range aliases[getNodeType(t)] {
+			aliased, ok := value[alias]
+			if !ok {
+				continue
+			}
+			if _, exists := value[predicate]; !exists {
+				value[predicate] = aliased
+			}
+			delete(value, alias)
+		}
+		remapFields(value, t, aliases)
+	}
+}
+
+func remapFields(node map[string]interface{}, t reflect.Type, aliases PredicateAliases) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && field.Anonymous {
+			remapFields(node, fieldType, aliases)
+			continue
+		}
+
+		predicate, _ := getPredicate(&field)
+		edge, ok := node[predicate]
+		if !ok {
+			continue
+		}
+		if nodes, isList := edge.([]interface{}); isList && fieldType.Kind() == reflect.Struct &&
+			reverseOf(field.Tag.Get(tagName)) != "" {
+			// reverse edges are returned as lists, the first node is decoded into a single node field
+			edge = nil
+			if len(nodes) > 0 {
+				edge = nodes[0]
+			}
+			node[predicate] = edge
+		}
+		remapValue(edge, field.Type, aliases)
+	}
+}
+
+// overrideAliasCache caches the predicate override aliases of model types
+var overrideAliasCache sync.Map
+
+// overrideAliases returns the fields with the predicate overridden by the predicate dgraph tag
+// of a node type and its edge types as aliases, mapping the predicate to the json field name,
+// e.g: {"Product": {"ext_id": "externalId"}} for `json:"externalId" dgraph:"predicate=ext_id"`
+func overrideAliases(modelType reflect.Type) PredicateAliases {
+	modelType = getElemType(modelType)
+	if aliases, ok := overrideAliasCache.Load(modelType); ok {
+		return aliases.(PredicateAliases)
+	}
+
+	aliases := make(PredicateAliases)
+	collectOverrideAliases(modelType, aliases, make(map[reflect.Type]bool))
+	overrideAliasCache.Store(modelType, aliases)
+	return aliases
+}
+
+func collectOverrideAliases(modelType reflect.Type, aliases PredicateAliases, visited map[reflect.Type]bool) {
+	if modelType.Kind() != reflect.Struct || visited[modelType] {
+		return
+	}
+	visited[modelType] = true
+
+	nodeType := getNodeType(modelType)
+	for _, field := range modelFields(modelType) {
+		if edgeType := edgeNodeType(field); edgeType != nil {
+			collectOverrideAliases(edgeType, aliases, visited)
+		}
+
+		dgraphTag := field.Tag.Get(tagName)
+		if dgraphTag == "" {
+			continue
+		}
+		props, err := parseStructTag(dgraphTag)
+		if err != nil || props.Predicate == "" {
+			continue
+		}
+		jsonPredicate, _ := getPredicate(&field)
+		if jsonPredicate == props.Predicate {
+			continue
+		}
+		if aliases[nodeType] == nil {
+			aliases[nodeType] = make(map[string]string)
+		}
+		aliases[nodeType][props.Predicate] = jsonPredicate
+	}
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type AliasAuthor struct {
+	UID   string   `json:"uid,omitempty"`
+	Email string   `json:"email,omitempty"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+type AliasBook struct {
+	UID     string         `json:"uid,omitempty"`
+	Title   string         `json:"title,omitempty"`
+	Pages   int64          `json:"pages,omitempty"`
+	Authors []*AliasAuthor `json:"authors,omitempty"`
+	DType   []string       `json:"dgraph.type,omitempty"`
+}
+
+var testAliases = PredicateAliases{
+	"AliasBook":   {"book_title": "title"},
+	"AliasAuthor": {"author_email": "email"},
+}
+
+func TestPredicateAliasesNodes(t *testing.T) {
+	tx, _ := newFakeTxnContext(&api.Response{Json: []byte(`{"data":[
+		{"uid":"0x1","book_title":"legacy","pages":9007199254740993,"authors":[{"uid":"0x2","author_email":"wildan@dolan.in"}]},
+		{"uid":"0x3","title":"current","book_title":"stale"}
+	]}`)})
+	tx.SetOptions(&ClientOptions{PredicateAliases: testAliases})
+
+	var books []AliasBook
+	require.NoError(t, tx.Get(&books).Nodes())
+	require.Len(t, books, 2)
+	assert.Equal(t, "legacy", books[0].Title)
+	assert.Equal(t, int64(9007199254740993), books[0].Pages)
+	assert.Equal(t, "wildan@dolan.in", books[0].Authors[0].Email)
+	// existing predicates are not overwritten by aliases
+	assert.Equal(t, "current", books[1].Title)
+}
+
+func TestPredicateAliasesScan(t *testing.T) {
+	tx, _ := newFakeTxnContext(&api.Response{Json: []byte(`{"books":[{"uid":"0x1","book_title":"legacy"}]}`)})
+	tx.SetOptions(&ClientOptions{PredicateAliases: testAliases})
+
+	var book AliasBook
+	require.NoError(t, tx.Query(NewQuery().Name("books").Model(&book).UID("0x1")).Scan())
+	assert.Equal(t, "legacy", book.Title)
+}
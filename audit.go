@@ -0,0 +1,405 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+)
+
+// Audit operations of an AuditEntry
+const (
+	AuditSet    = "set"
+	AuditDelete = "delete"
+)
+
+// auditBlankPrefix prefixes the blank node names of audit entries, removed from the created uids
+const auditBlankPrefix = "dgman.audit."
+
+// AuditEntry is a node recording a mutation of a node, linked to the node by the audit_node reverse edge,
+// the schema of audit entries is created with CreateSchema(c, &AuditEntry{})
+type AuditEntry struct {
+	UID string `json:"uid,omitempty"`
+	// Node is the audited node
+	Node *NodeRef `json:"audit_node,omitempty" dgraph:"reverse"`
+	// Actor is the actor of the mutation, see WithAuditActor
+	Actor     string    `json:"audit_actor,omitempty" dgraph:"index=exact"`
+	Operation string    `json:"audit_operation,omitempty" dgraph:"index=exact"`
+	Timestamp time.Time `json:"audit_timestamp,omitempty" dgraph:"index=hour"`
+	// Predicates are the changed predicates, empty when all predicates of a node are deleted
+	Predicates []string `json:"audit_predicates,omitempty"`
+	// Changes is the json of the AuditChange by predicate, see ChangeSet
+	Changes string   `json:"audit_changes,omitempty"`
+	DType   []string `json:"dgraph.type,omitempty"`
+}
+
+// AuditChange is the change of a predicate, the old value is set when supplied by WithAuditPrevious
+type AuditChange struct {
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// ChangeSet returns the changes of the audit entry by predicate
+func (e *AuditEntry) ChangeSet() (map[string]AuditChange, error) {
+	changes := make(map[string]AuditChange)
+	if e.Changes == "" {
+		return changes, nil
+	}
+	if err := json.Unmarshal([]byte(e.Changes), &changes); err != nil {
+		return nil, errors.Wrap(err, "unmarshal audit changes failed")
+	}
+	return changes, nil
+}
+
+// Audit configures the audit trail of the mutations of a client, see Client.SetAudit
+type Audit struct {
+	// Actor returns the actor of a request, defaults to the actor set by WithAuditActor
+	Actor func(ctx context.Context) string
+}
+
+// SetAudit enables the audit trail for transactions created from the client, writing an AuditEntry
+// for each node mutated or deleted through dgman in the same mutation, passing nil disables the audit trail
+func (c *Client) SetAudit(audit *Audit) *Client {
+	c.dg.config.audit = audit
+	return c
+}
+
+type auditActorKey struct{}
+
+type auditPreviousKey struct{}
+
+// WithAuditActor returns a context setting the actor of the audit entries of its requests
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// WithAuditPrevious returns a context supplying the previous values of nodes, by their uids,
+// recorded as the old values of the changes of the audit entries of its requests
+func WithAuditPrevious(ctx context.Context, nodes ...interface{}) (context.Context, error) {
+	previous := make(map[string]map[string]interface{})
+	for _, node := range nodes {
+		data, err := json.Marshal(node)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal previous node failed")
+		}
+		var values map[string]interface{}
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, errors.Wrap(err, "previous node must be a node")
+		}
+		if uid, ok := values[predicateUid].(string); ok && isUID(uid) {
+			previous[uid] = values
+		}
+	}
+	return context.WithValue(ctx, auditPreviousKey{}, previous), nil
+}
+
+// auditNow returns the timestamp of audit entries
+var auditNow = time.Now
+
+// auditTxn writes the audit entries of the mutations of a transaction
+type auditTxn struct {
+	transaction
+	audit *Audit
+	blank int32
+}
+
+// withAudit wraps a transaction to audit its mutations, when the audit is set
+func withAudit(txn transaction, audit *Audit) transaction {
+	if audit == nil {
+		return txn
+	}
+	return &auditTxn{transaction: txn, audit: audit}
+}
+
+func (a *auditTxn) unwrap() transaction {
+	return a.transaction
+}
+
+func (a *auditTxn) Mutate(ctx context.Context, mu *api.Mutation) (*api.Response, error) {
+	audited, err := a.auditMutation(ctx, mu)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.transaction.Mutate(ctx, audited)
+	return removeAuditUIDs(resp), err
+}
+
+func (a *auditTxn) Do(ctx context.Context, req *api.Request) (*api.Response, error) {
+	if len(req.Mutations) == 0 {
+		return a.transaction.Do(ctx, req)
+	}
+	audited := *req
+	audited.Mutations = make([]*api.Mutation, len(req.Mutations))
+	for i, mu := range req.Mutations {
+		var err error
+		if audited.Mutations[i], err = a.auditMutation(ctx, mu); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := a.transaction.Do(ctx, &audited)
+	return removeAuditUIDs(resp), err
+}
+
+// removeAuditUIDs removes the uids of the created audit entries from the created uids
+func removeAuditUIDs(resp *api.Response) *api.Response {
+	if resp == nil {
+		return nil
+	}
+	for blank := range resp.Uids {
+		if strings.HasPrefix(blank, auditBlankPrefix) {
+			delete(resp.Uids, blank)
+		}
+	}
+	return resp
+}
+
+// auditMutation returns a copy of a mutation also setting the audit entries of its nodes,
+// in the same mutation so the entries share its condition and blank node names
+func (a *auditTxn) auditMutation(ctx context.Context, mu *api.Mutation) (*api.Mutation, error) {
+	var changes []*auditChanges
+	if len(mu.SetJson) > 0 {
+		nodes, err := jsonAuditChanges(mu.SetJson, AuditSet)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, nodes...)
+	}
+	if len(mu.DeleteJson) > 0 {
+		nodes, err := jsonAuditChanges(mu.DeleteJson, AuditDelete)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, nodes...)
+	}
+	changes = append(changes, nquadsAuditChanges(mu.SetNquads, AuditSet)...)
+	changes = append(changes, nquadsAuditChanges(mu.DelNquads, AuditDelete)...)
+	if len(changes) == 0 {
+		return mu, nil
+	}
+
+	actor, _ := ctx.Value(auditActorKey{}).(string)
+	if a.audit.Actor != nil {
+		actor = a.audit.Actor(ctx)
+	}
+	previous, _ := ctx.Value(auditPreviousKey{}).(map[string]map[string]interface{})
+	timestamp := auditNow()
+
+	var setNodes []interface{}
+	if len(mu.SetJson) > 0 {
+		var set interface{}
+		if err := json.Unmarshal(mu.SetJson, &set); err != nil {
+			return nil, errors.Wrap(err, "unmarshal set json failed")
+		}
+		if nodes, ok := set.([]interface{}); ok {
+			setNodes = nodes
+		} else {
+			setNodes = []interface{}{set}
+		}
+	}
+	for _, change := range changes {
+		entry, err := change.entry(actor, timestamp, previous[change.uid])
+		if err != nil {
+			return nil, err
+		}
+		entry[predicateUid] = fmt.Sprintf("_:%s%d", auditBlankPrefix, atomic.AddInt32(&a.blank, 1))
+		setNodes = append(setNodes, entry)
+	}
+
+	setJSON, err := json.Marshal(setNodes)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal audit entries failed")
+	}
+	return &api.Mutation{
+		SetJson:    setJSON,
+		DeleteJson: mu.DeleteJson,
+		SetNquads:  mu.SetNquads,
+		DelNquads:  mu.DelNquads,
+		Set:        mu.Set,
+		Del:        mu.Del,
+		Cond:       mu.Cond,
+		CommitNow:  mu.CommitNow,
+	}, nil
+}
+
+// auditChanges are the changed predicates of a node in a mutation
+type auditChanges struct {
+	uid        string
+	operation  string
+	predicates []string
+	values     map[string]interface{}
+}
+
+func (c *auditChanges) entry(actor string, timestamp time.Time, previous map[string]interface{}) (map[string]interface{}, error) {
+	entry := map[string]interface{}{
+		predicateDgraphType: "AuditEntry",
+		"audit_node":        map[string]interface{}{predicateUid: c.uid},
+		"audit_operation":   c.operation,
+		"audit_timestamp":   timestamp,
+	}
+	if actor != "" {
+		entry["audit_actor"] = actor
+	}
+	if len(c.predicates) == 0 {
+		return entry, nil
+	}
+	sort.Strings(c.predicates)
+	entry["audit_predicates"] = c.predicates
+
+	changes := make(map[string]AuditChange, len(c.predicates))
+	for _, predicate := range c.predicates {
+		change := AuditChange{Old: previous[predicate]}
+		if c.operation == AuditSet {
+			change.New = c.values[predicate]
+		}
+		changes[predicate] = change
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal audit changes failed")
+	}
+	entry["audit_changes"] = string(changesJSON)
+	return entry, nil
+}
+
+// jsonAuditChanges returns the changes of the nodes with uids in json mutation data, including nested nodes
+func jsonAuditChanges(data []byte, operation string) ([]*auditChanges, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal %s json failed", operation)
+	}
+	var changes []*auditChanges
+	walkAuditNodes(value, func(node map[string]interface{}) {
+		uid, _ := node[predicateUid].(string)
+		if uid == "" || isAuditEntry(node) {
+			return
+		}
+		change := &auditChanges{uid: uid, operation: operation, values: make(map[string]interface{})}
+		for predicate, value := range node {
+			if predicate == predicateUid || predicate == predicateDgraphType || isFacet(predicate) {
+				continue
+			}
+			change.predicates = append(change.predicates, predicate)
+			change.values[predicate] = auditValue(value)
+		}
+		if operation == AuditSet && len(change.predicates) == 0 {
+			// edge node references are not changed
+			return
+		}
+		changes = append(changes, change)
+	})
+	return changes, nil
+}
+
+func walkAuditNodes(value interface{}, visit func(node map[string]interface{})) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		visit(value)
+		for _, v := range value {
+			walkAuditNodes(v, visit)
+		}
+	case []interface{}:
+		for _, v := range value {
+			walkAuditNodes(v, visit)
+		}
+	}
+}
+
+func isAuditEntry(node map[string]interface{}) bool {
+	switch nodeType := node[predicateDgraphType].(type) {
+	case string:
+		return nodeType == "AuditEntry"
+	case []interface{}:
+		for _, t := range nodeType {
+			if t == "AuditEntry" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// auditValue returns the value of a predicate, with the edge nodes replaced by their uids
+func auditValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if uid, ok := value[predicateUid]; ok {
+			return uid
+		}
+	case []interface{}:
+		values := make([]interface{}, len(value))
+		for i, v := range value {
+			values[i] = auditValue(v)
+		}
+		return values
+	}
+	return value
+}
+
+// nquadsAuditChanges returns the changes of the subjects of n-quads, grouping the n-quads by subject,
+// deleting all predicates of a node, i.e: <uid> * * ., has no changed predicates
+func nquadsAuditChanges(nquads []byte, operation string) []*auditChanges {
+	var changes []*auditChanges
+	bySubject := make(map[string]*auditChanges)
+	scanner := bufio.NewScanner(bytes.NewReader(nquads))
+	for scanner.Scan() {
+		fields := strings.SplitN(strings.TrimSpace(scanner.Text()), " ", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		subject := strings.Trim(fields[0], "<>")
+		predicate := strings.Trim(fields[1], "<>")
+		change, ok := bySubject[subject]
+		if !ok {
+			change = &auditChanges{uid: subject, operation: operation, values: make(map[string]interface{})}
+			bySubject[subject] = change
+			changes = append(changes, change)
+		}
+		if predicate == "*" || predicate == predicateDgraphType {
+			continue
+		}
+		object := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(fields[2]), "."))
+		change.predicates = append(change.predicates, predicate)
+		change.values[predicate] = object
+	}
+	return changes
+}
+
+// GetAuditTrail gets the audit entries of a node, ordered by their timestamps
+func (t *TxnContext) GetAuditTrail(uid string) ([]*AuditEntry, error) {
+	if err := validateUIDs(uid); err != nil {
+		return nil, err
+	}
+	var entries []*AuditEntry
+	err := t.Get(&entries).
+		Filter(fmt.Sprintf("uid_in(audit_node, %s)", uid)).
+		OrderAsc("audit_timestamp").
+		All(1).
+		Nodes()
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	auditNow = func() time.Time { return now }
+	defer func() { auditNow = time.Now }()
+
+	tx, fake := newFakeTxnContext(
+		&api.Response{Uids: map[string]string{"user": "0x1", "dgman.audit.1": "0x2"}},
+		&api.Response{},
+	)
+	tx.txn = withAudit(tx.txn, &Audit{})
+	ctx, err := WithAuditPrevious(WithAuditActor(context.Background(), "admin"), &TestModel{UID: "0x1", Name: "dolan"})
+	require.NoError(t, err)
+	tx.WithContext(ctx)
+
+	uids, err := tx.MutateBasic(&TestModel{UID: "_:user", Name: "wildan", Age: 17})
+	require.NoError(t, err)
+	// the audit entries are not created uids
+	assert.Equal(t, []string{"0x1"}, uids)
+
+	require.Len(t, fake.requests, 1)
+	var nodes []map[string]interface{}
+	require.NoError(t, json.Unmarshal(fake.requests[0].Mutations[0].SetJson, &nodes))
+	require.Len(t, nodes, 2)
+	assert.Equal(t, map[string]interface{}{
+		"uid":              "_:dgman.audit.1",
+		"dgraph.type":      "AuditEntry",
+		"audit_node":       map[string]interface{}{"uid": "_:user"},
+		"audit_actor":      "admin",
+		"audit_operation":  AuditSet,
+		"audit_timestamp":  "2021-01-01T00:00:00Z",
+		"audit_predicates": []interface{}{"age", "dead", "name"},
+		"audit_changes":    `{"age":{"new":17},"dead":{"new":false},"name":{"new":"wildan"}}`,
+	}, nodes[1])
+
+	require.NoError(t, tx.DeleteNode("0x1"))
+	require.Len(t, fake.requests, 2)
+	mu := fake.requests[1].Mutations[0]
+	assert.Equal(t, "<0x1> * * .\n", string(mu.DelNquads))
+	require.NoError(t, json.Unmarshal(mu.SetJson, &nodes))
+	require.Len(t, nodes, 1)
+	assert.Equal(t, AuditDelete, nodes[0]["audit_operation"])
+	assert.Equal(t, map[string]interface{}{"uid": "0x1"}, nodes[0]["audit_node"])
+	assert.NotContains(t, nodes[0], "audit_predicates")
+}
+
+func TestAuditBestEffort(t *testing.T) {
+	dc := &queryClient{}
+	client := NewClient(dc).SetAudit(&Audit{})
+
+	tx := client.NewTxn()
+	require.NotNil(t, tx.Txn())
+	var models []TestModel
+	require.NoError(t, tx.Get(&models).BestEffort().Nodes())
+
+	require.NoError(t, client.NewReadOnlyTxn().BestEffort().Get(&models).Nodes())
+	require.Len(t, dc.requests, 2)
+	for _, req := range dc.requests {
+		assert.True(t, req.BestEffort)
+	}
+}
+
+func TestAuditChanges(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{})
+	tx.txn = withAudit(tx.txn, &Audit{Actor: func(ctx context.Context) string { return "system" }})
+	ctx, err := WithAuditPrevious(context.Background(), &TestModel{UID: "0x1", Name: "dolan"})
+	require.NoError(t, err)
+	tx.WithContext(ctx)
+
+	_, err = tx.MutateBasic(&TestModel{UID: "0x1", Name: "wildan", Edges: []TestEdge{{UID: "0x2"}}})
+	require.NoError(t, err)
+
+	var nodes []map[string]interface{}
+	require.NoError(t, json.Unmarshal(fake.requests[0].Mutations[0].SetJson, &nodes))
+	// the edge node reference is not audited
+	require.Len(t, nodes, 2)
+	entry := &AuditEntry{Changes: nodes[1]["audit_changes"].(string)}
+	changes, err := entry.ChangeSet()
+	require.NoError(t, err)
+	assert.Equal(t, AuditChange{Old: "dolan", New: "wildan"}, changes["name"])
+	assert.Equal(t, AuditChange{New: []interface{}{"0x2"}}, changes["edges"])
+	assert.Equal(t, "system", nodes[1]["audit_actor"])
+}
+
+func TestGetAuditTrail(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{Json: []byte(`{"data":[{"uid":"0x2","audit_operation":"set"}]}`)})
+
+	entries, err := tx.GetAuditTrail("0x1")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, AuditSet, entries[0].Operation)
+	assert.Contains(t, fake.requests[0].Query, "uid_in(audit_node, 0x1)")
+	assert.Contains(t, fake.requests[0].Query, "orderasc: audit_timestamp")
+
+	_, err = tx.GetAuditTrail("_:x")
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// bigFloatPrecision is the precision of parsed bigfloat values, as in Dgraph
+const bigFloatPrecision = 200
+
+var (
+	bigFloatType = reflect.TypeOf(big.Float{})
+
+	_ ParamFormatter = (*BigFloat)(nil)
+	_ SchemaType     = (*BigFloat)(nil)
+)
+
+// BigFloat type allows defining bigfloat predicates, and passing arbitrary precision
+// amounts as query parameters, e.g: in range filters
+type BigFloat struct {
+	big.Float
+}
+
+// ParseBigFloat parses a decimal string into a BigFloat
+func ParseBigFloat(value string) (*BigFloat, error) {
+	f := &BigFloat{}
+	f.SetPrec(bigFloatPrecision)
+	if _, ok := f.SetString(value); !ok {
+		return nil, errors.Errorf("invalid bigfloat %s", value)
+	}
+	return f, nil
+}
+
+// SchemaType implements the SchemaType interface
+func (f *BigFloat) SchemaType() string {
+	return "bigfloat"
+}
+
+// FormatParams implements the ParamFormatter interface
+func (f *BigFloat) FormatParams() []byte {
+	return formatBigFloat(&f.Float)
+}
+
+// MarshalJSON marshals the value as a decimal string, to keep its precision
+func (f *BigFloat) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(f.Text('f', -1))), nil
+}
+
+// UnmarshalJSON unmarshals the value from a decimal string or number
+func (f *BigFloat) UnmarshalJSON(data []byte) error {
+	value := string(data)
+	if value == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+	if f.Prec() == 0 {
+		f.SetPrec(bigFloatPrecision)
+	}
+	if _, ok := f.SetString(value); !ok {
+		return errors.Errorf("invalid bigfloat %s", value)
+	}
+	return nil
+}
+
+// formatBigFloat formats a big.Float as an unquoted decimal, with the shortest
+// representation keeping its precision
+func formatBigFloat(f *big.Float) []byte {
+	return []byte(f.Text('f', -1))
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Payment struct {
+	UID    string     `json:"uid,omitempty"`
+	Amount *BigFloat  `json:"amount,omitempty" dgraph:"index=bigfloat"`
+	Fee    *big.Float `json:"fee,omitempty"`
+	DType  []string   `json:"dgraph.type,omitempty"`
+}
+
+func TestBigFloatSchema(t *testing.T) {
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", Payment{})
+
+	assert.Equal(t, "amount: bigfloat @index(bigfloat) .", typeSchema.Schema["amount"].String())
+	assert.Equal(t, "fee: bigfloat .", typeSchema.Schema["fee"].String())
+}
+
+func TestBigFloatParams(t *testing.T) {
+	min, err := ParseBigFloat("1000.000000000000000001")
+	require.NoError(t, err)
+	max, _, err := big.ParseFloat("99999999.99", 10, 128, big.ToNearestEven)
+	require.NoError(t, err)
+
+	query := NewQuery().Model(&Payment{}).
+		Filter("ge(amount, $1) AND le(amount, $2)", min, max)
+	assert.Contains(t, query.String(), "ge(amount, 1000.000000000000000001) AND le(amount, 99999999.99)")
+
+	filter, err := Ge("amount", min).And(Le("amount", max)).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "(ge(amount, 1000.000000000000000001) AND le(amount, 99999999.99))", filter)
+}
+
+func TestBigFloatJSON(t *testing.T) {
+	amount, err := ParseBigFloat("12345678901234567890.123456789")
+	require.NoError(t, err)
+
+	data, err := json.Marshal(Payment{Amount: amount})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"12345678901234567890.123456789"}`, string(data))
+
+	var payment Payment
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":12.5}`), &payment))
+	assert.Equal(t, "12.5", payment.Amount.Text('f', -1))
+
+	require.NoError(t, json.Unmarshal(data, &payment))
+	assert.Equal(t, amount.Text('f', -1), payment.Amount.Text('f', -1))
+
+	_, err = ParseBigFloat("abc")
+	assert.EqualError(t, err, "invalid bigfloat abc")
+}
@@ -0,0 +1,271 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/dgraph-io/dgo/v210"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultBulkBatchSize   = 1000
+	defaultBulkParallelism = 4
+	defaultBulkMaxRetries  = 3
+)
+
+// BulkBatch is the result of a committed or failed bulk mutation batch
+type BulkBatch struct {
+	// Index is the batch sequence number, starting from 0
+	Index int
+	// Nodes are the mutated nodes of the batch
+	Nodes []interface{}
+	// UIDs are the created uids of the batch
+	UIDs []string
+	// Retries is the number of retries on aborted transactions
+	Retries int
+	// Total is the total number of nodes mutated so far
+	Total int
+	Err   error
+}
+
+// BulkMutator mutates a stream of nodes in batches, each batch is mutated in parallel
+// in its own transaction, committed on mutation
+type BulkMutator struct {
+	// BatchSize is the number of nodes in a batch, defaults to 1000
+	BatchSize int
+	// Parallelism is the number of batches mutated concurrently, defaults to 4
+	Parallelism int
+	// MaxRetries is the number of retries of a batch on aborted transactions, defaults to 3.
+	// Batches are only retried with SkipUnique, as unique checking replaces node uids with uid funcs.
+	MaxRetries int
+	// Backoff specifies the exponential backoff between retries of a batch as in RunInTxn,
+	// MaxAttempts is ignored in favor of MaxRetries
+	Backoff RetryOptions
+	// Options are the mutate options of each batch, CommitNow is always set
+	Options MutateOptions
+	// OnBatch is called after each batch is committed or failed, calls are serialized
+	OnBatch func(batch *BulkBatch)
+
+	newTxn func(ctx context.Context) *TxnContext
+}
+
+// NewBulkMutator returns a bulk mutator with default options,
+// which does basic mutations without unique checking
+func NewBulkMutator(c DgraphClient) *BulkMutator {
+	return &BulkMutator{
+		BatchSize:   defaultBulkBatchSize,
+		Parallelism: defaultBulkParallelism,
+		MaxRetries:  defaultBulkMaxRetries,
+		Options:     MutateOptions{SkipUnique: true},
+		newTxn: func(ctx context.Context) *TxnContext {
+			return NewTxnContext(ctx, c)
+		},
+	}
+}
+
+// Mutate mutates nodes received from a channel until it is closed, nodes should be pointers to structs
+// for uids to be injected. When a batch fails, the remaining nodes are drained without being mutated,
+// and the first error is returned.
+func (b *BulkMutator) Mutate(ctx context.Context, nodes <-chan interface{}) error {
+	batchSize := b.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBulkBatchSize
+	}
+	parallelism := b.Parallelism
+	if parallelism <= 0 {
+		parallelism = defaultBulkParallelism
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		total    int
+		firstErr error
+	)
+	batches := make(chan *BulkBatch)
+
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				b.mutateBatch(ctx, batch)
+
+				mu.Lock()
+				if batch.Err != nil && firstErr == nil {
+					firstErr = batch.Err
+					cancel()
+				}
+				total += len(batch.UIDs)
+				batch.Total = total
+				if b.OnBatch != nil {
+					b.OnBatch(batch)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	index := 0
+	send := func(batch []interface{}) {
+		select {
+		case batches <- &BulkBatch{Index: index, Nodes: batch}:
+			index++
+		case <-ctx.Done():
+		}
+	}
+
+	batch := make([]interface{}, 0, batchSize)
+	for node := range nodes {
+		if ctx.Err() != nil {
+			// drain the remaining nodes
+			continue
+		}
+		batch = append(batch, node)
+		if len(batch) == batchSize {
+			send(batch)
+			batch = make([]interface{}, 0, batchSize)
+		}
+	}
+	if len(batch) > 0 && ctx.Err() == nil {
+		send(batch)
+	}
+	close(batches)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// MutateSlice mutates a slice of nodes in batches
+func (b *BulkMutator) MutateSlice(ctx context.Context, nodes []interface{}) error {
+	nodeChan := make(chan interface{})
+	go func() {
+		defer close(nodeChan)
+		for _, node := range nodes {
+			nodeChan <- node
+		}
+	}()
+	return b.Mutate(ctx, nodeChan)
+}
+
+func (b *BulkMutator) mutateBatch(ctx context.Context, batch *BulkBatch) {
+	opts := b.Options
+	opts.CommitNow = true
+	backoff := b.Backoff.withDefaults()
+
+	for {
+		uids, err := b.newTxn(ctx).MutateWithOptions(batch.Nodes, opts)
+		if err == nil {
+			batch.UIDs = uids
+			return
+		}
+		retry := opts.SkipUnique && errors.Cause(err) == dgo.ErrAborted && batch.Retries < b.MaxRetries
+		if !retry || ctx.Err() != nil {
+			batch.Err = errors.Wrapf(err, "bulk mutate batch %d failed", batch.Index)
+			return
+		}
+		// back off, so retries of conflicting batches don't hammer the alphas
+		if err := retrySleep(ctx, backoff.backoff(batch.Retries)); err != nil {
+			batch.Err = errors.Wrapf(err, "bulk mutate batch %d failed", batch.Index)
+			return
+		}
+		batch.Retries++
+	}
+}
+
+// ItemError is the error of an item of a slice, by its index in the slice
+type ItemError struct {
+	Index int
+	Err   error
+}
+
+func (e ItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+// MultiError is returned by UpsertEach, listing the errors of the failed items
+type MultiError struct {
+	Errors []ItemError
+}
+
+func (e *MultiError) Error() string {
+	errs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err.Error()
+	}
+	return strings.Join(errs, "; ")
+}
+
+// UpsertEach upserts each node of a slice as in Upsert, in the same transaction, so a node failing
+// on a UniqueError does not abort the other nodes. Returns the uids of the nodes by index in the slice,
+// empty for failed nodes, and a MultiError of the failed nodes. When commit now is set on the transaction,
+// it is committed after all nodes are upserted.
+// Other errors, e.g: request errors or aborted transactions, stop the upserts and are returned as is,
+// without committing, discarding the transaction when commit now is set.
+func (t *TxnContext) UpsertEach(data interface{}, predicates ...string) ([]string, error) {
+	slice := reflect.Indirect(reflect.ValueOf(data))
+	if slice.Kind() != reflect.Slice {
+		return nil, errors.New("data must be a slice or a pointer to a slice")
+	}
+
+	commitNow := t.commitNow
+	t.commitNow = false
+	defer func() { t.commitNow = commitNow }()
+
+	uids := make([]string, slice.Len())
+	multiErr := &MultiError{}
+	for i := 0; i < slice.Len(); i++ {
+		item := slice.Index(i)
+		if item.Kind() == reflect.Struct {
+			// upsert the slice element, for uids to be injected
+			item = item.Addr()
+		}
+		if _, err := t.Upsert(item.Interface(), predicates...); err != nil {
+			if _, ok := err.(*UniqueError); !ok {
+				if commitNow {
+					_ = t.Discard()
+				}
+				return uids, errors.Wrapf(err, "upsert item %d failed", i)
+			}
+			multiErr.Errors = append(multiErr.Errors, ItemError{Index: i, Err: err})
+			continue
+		}
+		uids[i] = nodeUID(item)
+	}
+
+	if commitNow {
+		if err := t.Commit(); err != nil {
+			return nil, errors.Wrap(err, "commit failed")
+		}
+	}
+	if len(multiErr.Errors) > 0 {
+		return uids, multiErr
+	}
+	return uids, nil
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210"
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeBulkMutator returns a bulk mutator which mutates on fake transactions,
+// failing the first attempts with the errors
+func newFakeBulkMutator(errs ...error) (*BulkMutator, func() []*fakeTxn) {
+	var (
+		mu    sync.Mutex
+		fakes []*fakeTxn
+	)
+	bulk := NewBulkMutator(nil)
+	bulk.newTxn = func(ctx context.Context) *TxnContext {
+		mu.Lock()
+		defer mu.Unlock()
+		tx, fake := newFakeTxnContext()
+		if len(fakes) < len(errs) {
+			fake.err = errs[len(fakes)]
+		}
+		fakes = append(fakes, fake)
+		return tx
+	}
+	return bulk, func() []*fakeTxn {
+		mu.Lock()
+		defer mu.Unlock()
+		return fakes
+	}
+}
+
+func newBulkNodes(n int) []interface{} {
+	nodes := make([]interface{}, n)
+	for i := range nodes {
+		nodes[i] = &TestModel{Name: "wildan"}
+	}
+	return nodes
+}
+
+func TestBulkMutator(t *testing.T) {
+	bulk, fakes := newFakeBulkMutator()
+	bulk.BatchSize = 3
+
+	var batches []*BulkBatch
+	bulk.OnBatch = func(batch *BulkBatch) {
+		batches = append(batches, batch)
+	}
+
+	err := bulk.MutateSlice(context.Background(), newBulkNodes(10))
+	assert.NoError(t, err)
+	assert.Len(t, fakes(), 4)
+	assert.Len(t, batches, 4)
+
+	nodes := 0
+	for _, batch := range batches {
+		nodes += len(batch.Nodes)
+		assert.NoError(t, batch.Err)
+	}
+	assert.Equal(t, 10, nodes)
+
+	for _, fake := range fakes() {
+		if assert.Len(t, fake.requests, 1) {
+			assert.True(t, fake.requests[0].CommitNow)
+		}
+	}
+}
+
+func TestBulkMutatorRetry(t *testing.T) {
+	sleeps := stubRetrySleep(t)
+	bulk, fakes := newFakeBulkMutator(dgo.ErrAborted, dgo.ErrAborted)
+	bulk.BatchSize = 5
+	bulk.Parallelism = 1
+	bulk.Backoff = RetryOptions{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 15 * time.Millisecond}
+
+	var retries int
+	bulk.OnBatch = func(batch *BulkBatch) {
+		retries += batch.Retries
+	}
+
+	err := bulk.MutateSlice(context.Background(), newBulkNodes(5))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, retries)
+	assert.Len(t, fakes(), 3)
+	// retries back off exponentially, capped by the max backoff
+	require.Len(t, *sleeps, 2)
+	assert.True(t, (*sleeps)[0] >= 5*time.Millisecond && (*sleeps)[0] <= 10*time.Millisecond)
+	assert.True(t, (*sleeps)[1] >= 7*time.Millisecond && (*sleeps)[1] <= 15*time.Millisecond)
+}
+
+func TestBulkMutatorError(t *testing.T) {
+	bulk, fakes := newFakeBulkMutator(errors.New("unavailable"))
+	bulk.BatchSize = 2
+	bulk.Parallelism = 1
+
+	err := bulk.MutateSlice(context.Background(), newBulkNodes(10))
+	assert.Error(t, err)
+	// remaining nodes are drained without being mutated
+	assert.Less(t, len(fakes()), 5)
+}
+
+func TestUpsertEach(t *testing.T) {
+	tx, fake := newFakeTxnContext(
+		&api.Response{Json: []byte(`{"q_p1_1":[{"uid":"0x5"}]}`)},
+		// the sku of the second product is taken by another node
+		&api.Response{Json: []byte(`{"q_p2_2":[{"uid":"0x9"}]}`)},
+		&api.Response{Json: []byte(`{"q_p3_1":[{"uid":"0x7"}]}`)},
+	)
+	tx.commitNow = true
+
+	products := []OverrideProduct{
+		{UID: "_:p1", ExternalID: "x1", SKU: "s1"},
+		{UID: "_:p2", ExternalID: "x2", SKU: "s2"},
+		{UID: "_:p3", ExternalID: "x3", SKU: "s3"},
+	}
+	uids, err := tx.UpsertEach(products, "ext_id")
+	require.Error(t, err)
+	assert.Equal(t, []string{"0x5", "", "0x7"}, uids)
+
+	multiErr, ok := err.(*MultiError)
+	require.True(t, ok, err.Error())
+	require.Len(t, multiErr.Errors, 1)
+	assert.Equal(t, 1, multiErr.Errors[0].Index)
+	assert.IsType(t, &UniqueError{}, multiErr.Errors[0].Err)
+
+	require.Len(t, fake.requests, 3)
+	for _, req := range fake.requests {
+		assert.False(t, req.CommitNow)
+	}
+	assert.True(t, fake.committed)
+	assert.True(t, tx.commitNow)
+}
+
+// failingTxn fails the requests after the responses of the fake transaction are used
+type failingTxn struct {
+	*fakeTxn
+}
+
+func (f *failingTxn) Do(ctx context.Context, req *api.Request) (*api.Response, error) {
+	if len(f.responses) == 0 {
+		f.requests = append(f.requests, req)
+		return nil, errors.New("connection refused")
+	}
+	return f.fakeTxn.Do(ctx, req)
+}
+
+func TestUpsertEachRequestError(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{Json: []byte(`{"q_p1_1":[{"uid":"0x5"}]}`)})
+	tx.txn = &failingTxn{fake}
+	tx.commitNow = true
+
+	products := []OverrideProduct{
+		{UID: "_:p1", ExternalID: "x1", SKU: "s1"},
+		{UID: "_:p2", ExternalID: "x2", SKU: "s2"},
+		{UID: "_:p3", ExternalID: "x3", SKU: "s3"},
+	}
+	uids, err := tx.UpsertEach(products, "ext_id")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "upsert item 1 failed")
+	assert.Equal(t, []string{"0x5", "", ""}, uids)
+
+	// the upserts stop on the failed request, without committing
+	assert.Len(t, fake.requests, 2)
+	assert.False(t, fake.committed)
+	assert.True(t, fake.discarded)
+}
+
+func TestUpsertEachNotSlice(t *testing.T) {
+	tx, _ := newFakeTxnContext()
+	_, err := tx.UpsertEach(&OverrideProduct{ExternalID: "x1"})
+	assert.Error(t, err)
+}
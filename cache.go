@@ -0,0 +1,386 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"container/list"
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+)
+
+var cacheTypeRegex = regexp.MustCompile(`type\(\s*([^)\s]+)\s*\)`)
+
+type cacheEntry struct {
+	key       string
+	result    []byte
+	nodeTypes []string
+	expires   time.Time
+}
+
+// QueryCache caches the results of queries sent by read-only transactions, keyed on the query string
+// and the query vars, for hot lookup queries, e.g: user by email. Entries expire after the TTL,
+// and the least recently used entries are evicted over the max entries.
+// Entries are invalidated by the node types the query reaches, i.e: the type(User) functions of the query,
+// and the dgraph.type of every node in the result, including the nodes of edges,
+// when a transaction created from the same client mutates nodes of the node types.
+// Queries with result nodes without a dgraph.type, or without node types, are invalidated by every mutation,
+// and mutations of nodes without a dgraph.type, including the nodes of edges, and deletes,
+// which node types are unknown, invalidate all entries.
+type QueryCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	now     func() time.Time
+}
+
+// NewQueryCache creates a query cache with the TTL of entries, and the max entries, unlimited when 0
+func NewQueryCache(ttl time.Duration, maxEntries int) *QueryCache {
+	return &QueryCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// SetQueryCache sets the query cache for read-only transactions created from the client,
+// which is invalidated by the mutations of transactions created from the client,
+// passing nil removes the query cache of the client
+func (c *Client) SetQueryCache(cache *QueryCache) *Client {
+	c.dg.config.cache = cache
+	return c
+}
+
+func cacheKey(query string, vars map[string]string) string {
+	if len(vars) == 0 {
+		return query
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var key strings.Builder
+	key.WriteString(query)
+	for _, name := range names {
+		key.WriteString("\x00")
+		key.WriteString(name)
+		key.WriteString("=")
+		key.WriteString(vars[name])
+	}
+	return key.String()
+}
+
+func (c *QueryCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if c.now().After(entry.expires) {
+		c.remove(element)
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return entry.result, true
+}
+
+func (c *QueryCache) set(key, query string, result []byte) {
+	// a result node without a dgraph.type may be of any node type
+	nodeTypes, ok := resultNodeTypes(result)
+	if ok {
+		for _, match := range cacheTypeRegex.FindAllStringSubmatch(query, -1) {
+			nodeTypes = append(nodeTypes, match[1])
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{
+		key:       key,
+		result:    result,
+		nodeTypes: nodeTypes,
+		expires:   c.now().Add(c.ttl),
+	})
+	if c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *QueryCache) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).key)
+}
+
+// Invalidate removes the entries of queries on the node types, and the entries of queries
+// without node types, passing no node types removes all entries
+func (c *QueryCache) Invalidate(nodeTypes ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(nodeTypes) == 0 {
+		c.entries = make(map[string]*list.Element)
+		c.lru.Init()
+		return
+	}
+
+	invalidated := newSet(nodeTypes...)
+	for _, element := range c.entries {
+		entry := element.Value.(*cacheEntry)
+		remove := len(entry.nodeTypes) == 0
+		for _, nodeType := range entry.nodeTypes {
+			if invalidated.Has(nodeType) {
+				remove = true
+				break
+			}
+		}
+		if remove {
+			c.remove(element)
+		}
+	}
+}
+
+// Len returns the number of cached entries, including expired entries not yet removed
+func (c *QueryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// cacheTxn caches the query results of read-only transactions, and invalidates
+// the cache on committed mutations of other transactions
+type cacheTxn struct {
+	transaction
+	cache    *QueryCache
+	readOnly bool
+	// pending are the node types mutated by the transaction, invalidated on commit
+	pending    set
+	invalidAll bool
+}
+
+// withQueryCache wraps a transaction to use a query cache, when the cache is set
+func withQueryCache(txn transaction, cache *QueryCache, readOnly bool) transaction {
+	if cached, ok := txn.(*cacheTxn); ok {
+		txn = cached.transaction
+	}
+	if cache == nil {
+		return txn
+	}
+	return &cacheTxn{transaction: txn, cache: cache, readOnly: readOnly, pending: newSet()}
+}
+
+func (c *cacheTxn) unwrap() transaction {
+	return c.transaction
+}
+
+func (c *cacheTxn) query(key, query string, send func() (*api.Response, error)) (*api.Response, error) {
+	if !c.readOnly {
+		// queries of transactions with mutations read their own writes
+		return send()
+	}
+	if result, ok := c.cache.get(key); ok {
+		return &api.Response{Json: result}, nil
+	}
+	resp, err := send()
+	if err != nil {
+		return nil, err
+	}
+	c.cache.set(key, query, resp.Json)
+	return resp, nil
+}
+
+func (c *cacheTxn) Query(ctx context.Context, q string) (*api.Response, error) {
+	return c.query(cacheKey(q, nil), q, func() (*api.Response, error) {
+		return c.transaction.Query(ctx, q)
+	})
+}
+
+func (c *cacheTxn) QueryWithVars(ctx context.Context, q string, vars map[string]string) (*api.Response, error) {
+	return c.query(cacheKey(q, vars), q, func() (*api.Response, error) {
+		return c.transaction.QueryWithVars(ctx, q, vars)
+	})
+}
+
+// mutated records the node types of mutations, invalidated when committed
+func (c *cacheTxn) mutated(mutations []*api.Mutation, commitNow bool) {
+	for _, mu := range mutations {
+		if len(mu.DelNquads) > 0 || len(mu.DeleteJson) > 0 || len(mu.SetNquads) > 0 {
+			c.invalidAll = true
+			continue
+		}
+		nodeTypes, ok := mutationNodeTypes(mu.SetJson)
+		if !ok || len(nodeTypes) == 0 {
+			c.invalidAll = true
+		}
+		for _, nodeType := range nodeTypes {
+			c.pending.Add(nodeType)
+		}
+	}
+	if commitNow {
+		c.invalidate()
+	}
+}
+
+func (c *cacheTxn) invalidate() {
+	if c.invalidAll {
+		c.cache.Invalidate()
+	} else if len(c.pending) > 0 {
+		nodeTypes := make([]string, 0, len(c.pending))
+		for nodeType := range c.pending {
+			nodeTypes = append(nodeTypes, nodeType)
+		}
+		c.cache.Invalidate(nodeTypes...)
+	}
+	c.pending = newSet()
+	c.invalidAll = false
+}
+
+func (c *cacheTxn) Mutate(ctx context.Context, mu *api.Mutation) (*api.Response, error) {
+	resp, err := c.transaction.Mutate(ctx, mu)
+	if err == nil {
+		c.mutated([]*api.Mutation{mu}, mu.CommitNow)
+	}
+	return resp, err
+}
+
+func (c *cacheTxn) Do(ctx context.Context, req *api.Request) (*api.Response, error) {
+	resp, err := c.transaction.Do(ctx, req)
+	if err == nil && len(req.Mutations) > 0 {
+		c.mutated(req.Mutations, req.CommitNow)
+	}
+	return resp, err
+}
+
+func (c *cacheTxn) Commit(ctx context.Context) error {
+	err := c.transaction.Commit(ctx)
+	if err == nil {
+		c.invalidate()
+	}
+	return err
+}
+
+// mutationNodeTypes returns the dgraph.type values of the nodes in a set json mutation, including the nodes
+// of edges, returns false when a node setting predicates has no dgraph.type, as it may be of any node type.
+// Nodes only referenced by uid in edges, e.g: {"uid": "0x1"}, are not mutated, and have no node types.
+func mutationNodeTypes(setJSON []byte) ([]string, bool) {
+	if len(setJSON) == 0 {
+		return nil, true
+	}
+	var data interface{}
+	if err := json.Unmarshal(setJSON, &data); err != nil {
+		return nil, false
+	}
+	var nodeTypes []string
+	if !collectNodeTypes(data, &nodeTypes) {
+		return nil, false
+	}
+	return nodeTypes, true
+}
+
+func collectNodeTypes(data interface{}, nodeTypes *[]string) bool {
+	switch data := data.(type) {
+	case []interface{}:
+		for _, item := range data {
+			if !collectNodeTypes(item, nodeTypes) {
+				return false
+			}
+		}
+	case map[string]interface{}:
+		nodeType, ok := data[predicateDgraphType]
+		if _, isRef := data[predicateUid]; !ok && !(isRef && len(data) == 1) {
+			return false
+		}
+		appendNodeTypes(nodeType, nodeTypes)
+		for key, value := range data {
+			if key != predicateDgraphType && !collectNodeTypes(value, nodeTypes) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// resultNodeTypes returns the dgraph.type values of the nodes in a query result, including the nodes of edges,
+// returns false when a node has no dgraph.type, e.g: aggregations or queries not fetching dgraph.type
+func resultNodeTypes(result []byte) ([]string, bool) {
+	var blocks map[string]interface{}
+	if err := json.Unmarshal(result, &blocks); err != nil {
+		return nil, false
+	}
+	var nodeTypes []string
+	for _, nodes := range blocks {
+		if !collectResultNodeTypes(nodes, &nodeTypes) {
+			return nil, false
+		}
+	}
+	return nodeTypes, true
+}
+
+func collectResultNodeTypes(data interface{}, nodeTypes *[]string) bool {
+	switch data := data.(type) {
+	case []interface{}:
+		for _, item := range data {
+			if !collectResultNodeTypes(item, nodeTypes) {
+				return false
+			}
+		}
+	case map[string]interface{}:
+		nodeType, ok := data[predicateDgraphType]
+		if !ok {
+			return false
+		}
+		appendNodeTypes(nodeType, nodeTypes)
+		for key, value := range data {
+			if key != predicateDgraphType && !collectResultNodeTypes(value, nodeTypes) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// appendNodeTypes appends the node types of a dgraph.type value
+func appendNodeTypes(value interface{}, nodeTypes *[]string) {
+	switch value := value.(type) {
+	case string:
+		*nodeTypes = append(*nodeTypes, value)
+	case []interface{}:
+		for _, nodeType := range value {
+			if nodeType, ok := nodeType.(string); ok {
+				*nodeTypes = append(*nodeTypes, nodeType)
+			}
+		}
+	}
+}
@@ -0,0 +1,213 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCachedTxn(cache *QueryCache, readOnly bool, responses ...*api.Response) (*TxnContext, *fakeTxn) {
+	if len(responses) == 0 {
+		for i := 0; i < 5; i++ {
+			responses = append(responses, &api.Response{Json: []byte(`{"data":[]}`)})
+		}
+	}
+	tx, fake := newFakeTxnContext(responses...)
+	tx.readOnly = readOnly
+	tx.commitNow = !readOnly
+	return tx.SetQueryCache(cache), fake
+}
+
+func TestQueryCacheHit(t *testing.T) {
+	cache := NewQueryCache(time.Minute, 0)
+	response := &api.Response{Json: []byte(`{"data":[{"uid":"0x1","name":"wildan"}]}`)}
+
+	tx, fake := newCachedTxn(cache, true, response)
+	var model TestModel
+	require.NoError(t, tx.Get(&model).Filter("eq(name, $1)", "wildan").Node())
+	assert.Equal(t, "wildan", model.Name)
+
+	tx, fake2 := newCachedTxn(cache, true)
+	var cached TestModel
+	require.NoError(t, tx.Get(&cached).Filter("eq(name, $1)", "wildan").Node())
+	assert.Equal(t, "wildan", cached.Name)
+	assert.Len(t, fake.requests, 1)
+	assert.Empty(t, fake2.requests)
+
+	// different vars are cached separately
+	tx, fake3 := newCachedTxn(cache, true)
+	require.Error(t, tx.Get(&cached).Filter("eq(name, $1)", "dolan").Node())
+	assert.Len(t, fake3.requests, 1)
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestQueryCacheNotReadOnly(t *testing.T) {
+	cache := NewQueryCache(time.Minute, 0)
+	tx, fake := newCachedTxn(cache, false)
+
+	var models []TestModel
+	require.NoError(t, tx.Get(&models).Nodes())
+	require.NoError(t, tx.Get(&models).Nodes())
+	assert.Len(t, fake.requests, 2)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestQueryCacheTTL(t *testing.T) {
+	now := time.Now()
+	cache := NewQueryCache(time.Minute, 0)
+	cache.now = func() time.Time { return now }
+
+	tx, fake := newCachedTxn(cache, true)
+	var models []TestModel
+	require.NoError(t, tx.Get(&models).Nodes())
+	require.NoError(t, tx.Get(&models).Nodes())
+	assert.Len(t, fake.requests, 1)
+
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, tx.Get(&models).Nodes())
+	assert.Len(t, fake.requests, 2)
+}
+
+func TestQueryCacheMaxEntries(t *testing.T) {
+	cache := NewQueryCache(time.Minute, 2)
+	tx, fake := newCachedTxn(cache, true)
+
+	var models []TestModel
+	require.NoError(t, tx.Get(&models).First(1).Nodes())
+	require.NoError(t, tx.Get(&models).First(2).Nodes())
+	// first(1) is the most recently used
+	require.NoError(t, tx.Get(&models).First(1).Nodes())
+	require.NoError(t, tx.Get(&models).First(3).Nodes())
+	assert.Len(t, fake.requests, 3)
+	assert.Equal(t, 2, cache.Len())
+
+	require.NoError(t, tx.Get(&models).First(1).Nodes())
+	assert.Len(t, fake.requests, 3)
+	require.NoError(t, tx.Get(&models).First(2).Nodes())
+	assert.Len(t, fake.requests, 4)
+}
+
+func TestQueryCacheInvalidate(t *testing.T) {
+	cache := NewQueryCache(time.Minute, 0)
+	tx, _ := newCachedTxn(cache, true)
+
+	var models []TestModel
+	var edges []TestEdge
+	require.NoError(t, tx.Get(&models).Nodes())
+	require.NoError(t, tx.Get(&edges).Nodes())
+	cache.set("untyped", "{ q(func: has(name)) { uid } }", []byte(`{"q":[]}`))
+	assert.Equal(t, 3, cache.Len())
+
+	// untyped queries are invalidated with every node type
+	cache.Invalidate("TestEdge")
+	assert.Equal(t, 1, cache.Len())
+
+	cache.Invalidate()
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestQueryCacheInvalidateEdges(t *testing.T) {
+	cache := NewQueryCache(time.Minute, 0)
+	tx, _ := newCachedTxn(cache, true, &api.Response{
+		Json: []byte(`{"data":[{"uid":"0x1","dgraph.type":["TestModel"],"edges":[{"uid":"0x2","dgraph.type":["TestEdge"]}]}]}`),
+	})
+
+	var models []TestModel
+	require.NoError(t, tx.Get(&models).Nodes())
+	cache.set("aggregate", "{ q(func: type(TestModel)) { count(uid) } }", []byte(`{"q":[{"count":1}]}`))
+	assert.Equal(t, 2, cache.Len())
+
+	// result nodes without a dgraph.type invalidate the query on every node type
+	cache.Invalidate("Other")
+	assert.Equal(t, 1, cache.Len())
+
+	// the nodes of edges in the result invalidate the query
+	cache.Invalidate("TestEdge")
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestQueryCacheMutateInvalidate(t *testing.T) {
+	cache := NewQueryCache(time.Minute, 0)
+	readTx, _ := newCachedTxn(cache, true)
+
+	var models []TestModel
+	var edges []TestEdge
+	require.NoError(t, readTx.Get(&models).Nodes())
+	require.NoError(t, readTx.Get(&edges).Nodes())
+
+	tx, _ := newCachedTxn(cache, false)
+	tx.commitNow = false
+	_, err := tx.MutateBasic(&TestEdge{Level: "one"})
+	require.NoError(t, err)
+	// invalidated on commit
+	assert.Equal(t, 2, cache.Len())
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, 1, cache.Len())
+
+	tx, _ = newCachedTxn(cache, false)
+	_, err = tx.MutateBasic(&TestModel{Name: "wildan"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestQueryCacheMutateUntypedEdge(t *testing.T) {
+	cache := NewQueryCache(time.Minute, 0)
+	readTx, _ := newCachedTxn(cache, true)
+
+	var models []TestModel
+	var edges []TestEdge
+	require.NoError(t, readTx.Get(&models).Nodes())
+	require.NoError(t, readTx.Get(&edges).Nodes())
+
+	// edge references without predicates only invalidate the node types of the mutated nodes
+	tx, _ := newCachedTxn(cache, false)
+	_, err := tx.txn.Mutate(tx.ctx, &api.Mutation{
+		SetJson:   []byte(`{"uid":"0x1","dgraph.type":"TestModel","edges":[{"uid":"0x2"}]}`),
+		CommitNow: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, cache.Len())
+
+	require.NoError(t, readTx.Get(&models).Nodes())
+	assert.Equal(t, 2, cache.Len())
+
+	// the node types of nested nodes without a dgraph.type are unknown
+	_, err = tx.txn.Mutate(tx.ctx, &api.Mutation{
+		SetJson:   []byte(`{"uid":"0x1","dgraph.type":"Other","posts":[{"uid":"0x2","title":"x"}]}`),
+		CommitNow: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestQueryCacheDeleteInvalidate(t *testing.T) {
+	cache := NewQueryCache(time.Minute, 0)
+	readTx, _ := newCachedTxn(cache, true)
+
+	var models []TestModel
+	require.NoError(t, readTx.Get(&models).Nodes())
+	assert.Equal(t, 1, cache.Len())
+
+	tx, _ := newCachedTxn(cache, false)
+	require.NoError(t, tx.DeleteNode("0x1"))
+	assert.Equal(t, 0, cache.Len())
+}
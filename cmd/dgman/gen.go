@@ -0,0 +1,187 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	predicateUid        = "uid"
+	predicateDgraphType = "dgraph.type"
+)
+
+type modelField struct {
+	Name      string
+	Predicate string
+}
+
+type model struct {
+	Name     string
+	NodeType string
+	Fields   []modelField
+	isNode   bool
+}
+
+// parseModels parses the models of the go package in dir, which are structs with a dgraph.type field,
+// or the structs named in types, skipping the generated output file
+func parseModels(dir, output string, types []string) (string, []*model, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", nil, errors.Wrap(err, "list go files failed")
+	}
+
+	typeSet := make(map[string]bool, len(types))
+	for _, t := range types {
+		typeSet[t] = true
+	}
+
+	var (
+		pkgName string
+		models  []*model
+	)
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || filepath.Base(file) == output {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "parse %s failed", file)
+		}
+		pkgName = f.Name.Name
+
+		for _, decl := range f.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				structType, ok := typeSpec.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+
+				m, err := parseModel(typeSpec.Name.Name, structType)
+				if err != nil {
+					return "", nil, err
+				}
+				if (len(typeSet) == 0 && m.isNode) || typeSet[m.Name] {
+					delete(typeSet, m.Name)
+					models = append(models, m)
+				}
+			}
+		}
+	}
+
+	for t := range typeSet {
+		return "", nil, errors.Errorf("type %s not found", t)
+	}
+
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].Name < models[j].Name
+	})
+	return pkgName, models, nil
+}
+
+func parseModel(name string, structType *ast.StructType) (*model, error) {
+	m := &model{Name: name, NodeType: name}
+	for _, field := range structType.Fields.List {
+		// skip embedded fields and fields without tags
+		if len(field.Names) != 1 || field.Tag == nil {
+			continue
+		}
+
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unquote tag of %s.%s failed", name, field.Names[0].Name)
+		}
+		structTag := reflect.StructTag(tag)
+		predicate := strings.Split(structTag.Get("json"), ",")[0]
+
+		switch {
+		case predicate == predicateDgraphType:
+			m.isNode = true
+			if nodeType := structTag.Get("dgraph"); nodeType != "" {
+				m.NodeType = nodeType
+			}
+		case predicate == "", predicate == "-", predicate == predicateUid,
+			strings.ContainsAny(predicate, "@|"):
+			// skip language tagged predicates and facets
+		default:
+			m.Fields = append(m.Fields, modelField{Name: field.Names[0].Name, Predicate: predicate})
+		}
+	}
+	return m, nil
+}
+
+// generate generates the source of typed predicates and queries of the models
+func generate(pkgName string, models []*model) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by dgman gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+	fmt.Fprintf(&buf, "import \"github.com/dolan-in/dgman/v2\"\n")
+
+	for _, m := range models {
+		fmt.Fprintf(&buf, "\n// %s predicate names\n", m.Name)
+		fmt.Fprintf(&buf, "const (\n")
+		fmt.Fprintf(&buf, "%sNodeType = %q\n", m.Name, m.NodeType)
+		for _, field := range m.Fields {
+			fmt.Fprintf(&buf, "%s%s = %q\n", m.Name, field.Name, field.Predicate)
+		}
+		fmt.Fprintf(&buf, ")\n")
+
+		fmt.Fprintf(&buf, "\n// %sFields are the typed predicates of %s, e.g: for building filters\n", m.Name, m.Name)
+		fmt.Fprintf(&buf, "var %sFields = struct {\n", m.Name)
+		for _, field := range m.Fields {
+			fmt.Fprintf(&buf, "%s dgman.Predicate\n", field.Name)
+		}
+		fmt.Fprintf(&buf, "}{\n")
+		for _, field := range m.Fields {
+			fmt.Fprintf(&buf, "%s: %s%s,\n", field.Name, m.Name, field.Name)
+		}
+		fmt.Fprintf(&buf, "}\n")
+
+		fmt.Fprintf(&buf, "\n// Get%s prepares a query for a single %s\n", m.Name, m.Name)
+		fmt.Fprintf(&buf, "func Get%s(tx *dgman.TxnContext, dst *%s) *dgman.Query {\n", m.Name, m.Name)
+		fmt.Fprintf(&buf, "return tx.Get(dst)\n}\n")
+
+		fmt.Fprintf(&buf, "\n// Get%sList prepares a query for a list of %s\n", m.Name, m.Name)
+		fmt.Fprintf(&buf, "func Get%sList(tx *dgman.TxnContext, dst *[]%s) *dgman.Query {\n", m.Name, m.Name)
+		fmt.Fprintf(&buf, "return tx.Get(dst)\n}\n")
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "format generated source failed")
+	}
+	return src, nil
+}
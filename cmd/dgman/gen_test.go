@@ -0,0 +1,61 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+func TestGenerate(t *testing.T) {
+	pkgName, models, err := parseModels("testdata", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "models", pkgName)
+	require.Len(t, models, 2)
+
+	src, err := generate(pkgName, models)
+	require.NoError(t, err)
+
+	path := filepath.Join("testdata", "models_gen.golden")
+	if *updateGolden {
+		require.NoError(t, ioutil.WriteFile(path, src, 0644))
+	}
+	expected, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(src))
+}
+
+func TestParseModelsTypes(t *testing.T) {
+	_, models, err := parseModels("testdata", "", []string{"Address"})
+	require.NoError(t, err)
+	require.Len(t, models, 1)
+	assert.Equal(t, &model{
+		Name:     "Address",
+		NodeType: "Address",
+		Fields:   []modelField{{Name: "Street", Predicate: "street"}},
+	}, models[0])
+
+	_, _, err = parseModels("testdata", "", []string{"Unknown"})
+	assert.EqualError(t, err, "type Unknown not found")
+}
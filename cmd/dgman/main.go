@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Command dgman generates typed predicates and queries from dgman models,
+// to be used with go generate:
+//
+//	//go:generate go run github.com/dolan-in/dgman/v2/cmd/dgman gen
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const usage = `usage: dgman gen [flags]
+
+Generates predicate name constants, typed predicates for building filters,
+and typed queries for the models of a go package.
+
+Flags:
+`
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("dgman: ")
+
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	dir := flags.String("dir", ".", "directory of the go package")
+	types := flags.String("type", "", "comma separated model names, defaults to all structs with a dgraph.type field")
+	output := flags.String("output", "dgman_gen.go", "output file name, in the package directory")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+
+	if len(os.Args) < 2 || os.Args[1] != "gen" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	flags.Parse(os.Args[2:])
+
+	var typeNames []string
+	if *types != "" {
+		typeNames = strings.Split(*types, ",")
+	}
+
+	pkgName, models, err := parseModels(*dir, *output, typeNames)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(models) == 0 {
+		log.Fatalf("no models found in %s", *dir)
+	}
+
+	src, err := generate(pkgName, models)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(*dir, *output), src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type User struct {
+	UID      string    `json:"uid,omitempty"`
+	Name     string    `json:"name,omitempty" dgraph:"index=term"`
+	Email    string    `json:"email,omitempty" dgraph:"index=hash unique"`
+	Bio      string    `json:"bio@en,omitempty"`
+	Since    time.Time `json:"schools|since,omitempty"`
+	Schools  []School  `json:"schools,omitempty" dgraph:"count reverse"`
+	Password string    `json:"-"`
+	DType    []string  `json:"dgraph.type,omitempty"`
+}
+
+type School struct {
+	UID   string   `json:"uid,omitempty"`
+	Name  string   `json:"name,omitempty"`
+	DType []string `json:"dgraph.type,omitempty" dgraph:"Institution"`
+}
+
+// Address is not a node, as it has no dgraph.type field
+type Address struct {
+	Street string `json:"street,omitempty"`
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/dolan-in/reflectwalk"
+	"github.com/pkg/errors"
+)
+
+var computerType = reflect.TypeOf((*Computer)(nil)).Elem()
+
+// computableTypes caches whether a type contains nodes implementing Computer
+var computableTypes sync.Map
+
+// isComputable checks whether a type or its nested types implement Computer,
+// to skip walking query results without computed fields
+func isComputable(t reflect.Type) bool {
+	if computable, ok := computableTypes.Load(t); ok {
+		return computable.(bool)
+	}
+	computable := hasComputer(t, make(map[reflect.Type]bool))
+	computableTypes.Store(t, computable)
+	return computable
+}
+
+func hasComputer(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+
+	if t.Implements(computerType) || reflect.PtrTo(t).Implements(computerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return hasComputer(t.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasComputer(t.Field(i).Type, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type computeWalker struct {
+	nodes *[]Computer
+}
+
+func (w computeWalker) Struct(v reflect.Value, level int) error {
+	if !v.CanInterface() {
+		// unexported field, skip
+		return nil
+	}
+	if v.CanAddr() {
+		if node, ok := v.Addr().Interface().(Computer); ok {
+			*w.nodes = append(*w.nodes, node)
+			return nil
+		}
+	}
+	if node, ok := v.Interface().(Computer); ok {
+		*w.nodes = append(*w.nodes, node)
+	}
+	return nil
+}
+
+func (w computeWalker) StructField(s reflect.Value, f reflect.StructField, v reflect.Value, level int) error {
+	return nil
+}
+
+// compute calls Compute on each node in data implementing Computer,
+// nested nodes are computed before their parents
+func compute(data interface{}) error {
+	if data == nil || !isComputable(reflect.TypeOf(data)) {
+		return nil
+	}
+
+	var nodes []Computer
+	if err := reflectwalk.Walk(data, computeWalker{nodes: &nodes}); err != nil {
+		return errors.Wrap(err, "compute walk failed")
+	}
+	for i := len(nodes) - 1; i >= 0; i-- {
+		nodes[i].Compute()
+	}
+	return nil
+}
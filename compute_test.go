@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+)
+
+type ComputedUser struct {
+	UID       string           `json:"uid,omitempty"`
+	FirstName string           `json:"firstName,omitempty"`
+	LastName  string           `json:"lastName,omitempty"`
+	FullName  string           `json:"-"`
+	Friends   []ComputedUser   `json:"friends,omitempty"`
+	Scores    []*ComputedScore `json:"scores,omitempty"`
+	Total     int              `json:"-"`
+	DType     []string         `json:"dgraph.type,omitempty"`
+}
+
+func (u *ComputedUser) Compute() {
+	u.FullName = u.FirstName + " " + u.LastName
+	// nested nodes are computed first
+	for _, score := range u.Scores {
+		u.Total += score.Percent
+	}
+}
+
+type ComputedScore struct {
+	UID     string `json:"uid,omitempty"`
+	Value   int    `json:"value,omitempty"`
+	Max     int    `json:"max,omitempty"`
+	Percent int    `json:"-"`
+}
+
+func (s *ComputedScore) Compute() {
+	s.Percent = s.Value * 100 / s.Max
+}
+
+func TestCompute(t *testing.T) {
+	tx, _ := newFakeTxnContext(&api.Response{Json: []byte(`{"data":[{
+		"uid": "0x1",
+		"firstName": "Wildan",
+		"lastName": "Maulana",
+		"friends": [{"uid": "0x2", "firstName": "Dolan", "lastName": "In"}],
+		"scores": [{"uid": "0x3", "value": 4, "max": 8}]
+	}]}`)})
+
+	var user ComputedUser
+	err := tx.Get(&user).UID("0x1").Node()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Wildan Maulana", user.FullName)
+		assert.Equal(t, "Dolan In", user.Friends[0].FullName)
+		assert.Equal(t, 50, user.Scores[0].Percent)
+		assert.Equal(t, 50, user.Total)
+	}
+}
+
+func TestIsComputable(t *testing.T) {
+	assert.True(t, isComputable(reflect.TypeOf(&ComputedUser{})))
+	assert.True(t, isComputable(reflect.TypeOf(&[]ComputedUser{})))
+	assert.True(t, isComputable(reflect.TypeOf(&[]ComputedScore{})))
+	assert.False(t, isComputable(reflect.TypeOf(&TestModel{})))
+	assert.False(t, isComputable(reflect.TypeOf(&[]TestModel{})))
+}
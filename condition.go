@@ -0,0 +1,53 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// getMutationCondition returns the custom mutation condition filter of a node, if defined
+func getMutationCondition(v reflect.Value) *Filter {
+	if v.CanAddr() {
+		if conditioner, ok := v.Addr().Interface().(MutationConditioner); ok {
+			return conditioner.MutationCondition()
+		}
+	}
+	if conditioner, ok := v.Interface().(MutationConditioner); ok {
+		return conditioner.MutationCondition()
+	}
+	return nil
+}
+
+// generateCondition generates the query and condition of a custom node mutation condition,
+// the query collects the existing node when it does not match the filter,
+// so the condition holds for new nodes and existing nodes matching the filter
+func generateCondition(id, idFunc string, filter *Filter) (query, condition string, err error) {
+	filterString, err := filter.Build()
+	if err != nil {
+		return "", "", errors.Wrap(err, "build mutation condition failed")
+	}
+
+	variable := fmt.Sprintf("f_%s", id)
+	nodeFunc, _ := nodeRef(idFunc)
+	query = fmt.Sprintf("\tvar(func: %s) @filter(NOT (%s)) {\n\t\t%s as uid\n\t}", nodeFunc, filterString, variable)
+	condition = fmt.Sprintf("eq(len(%s), 0)", variable)
+	return query, condition, nil
+}
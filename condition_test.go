@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ConditionalUser struct {
+	UID       string    `json:"uid,omitempty"`
+	Email     string    `json:"email,omitempty" dgraph:"index=exact unique"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" dgraph:"index=hour"`
+	DType     []string  `json:"dgraph.type,omitempty"`
+}
+
+func (u *ConditionalUser) MutationCondition() *Filter {
+	return Lt("updated_at", u.UpdatedAt)
+}
+
+func TestUpsertMutationCondition(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	updatedAt := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	user := ConditionalUser{Email: "alice@example.com", UpdatedAt: updatedAt}
+	_, err := tx.Upsert(&user)
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+
+	req := fake.requests[0]
+	assert.Regexp(t, `\tvar\(func: uid\(u_\d+_1\)\) @filter\(NOT \(lt\(updated_at, "2021-06-01T00:00:00Z"\)\)\) \{\n\t\tf_\d+ as uid\n\t\}`, req.Query)
+	require.Len(t, req.Mutations, 1)
+	// the upsert predicate has no unique condition
+	assert.Regexp(t, `^@if\(eq\(len\(f_\d+\), 0\)\)$`, req.Mutations[0].Cond)
+}
+
+func TestMutateMutationCondition(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	updatedAt := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	user := ConditionalUser{UID: "0x1", Email: "bob@example.com", UpdatedAt: updatedAt}
+	_, err := tx.Mutate(&user)
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+
+	req := fake.requests[0]
+	assert.Contains(t, req.Query, "\tvar(func: uid(0x1)) @filter(NOT (lt(updated_at, \"2021-06-01T00:00:00Z\"))) {\n\t\tf_0x1 as uid\n\t}")
+	// merged with the unique check condition
+	assert.Equal(t, "@if(eq(len(u_0x1_1), 0) AND eq(len(f_0x1), 0))", req.Mutations[0].Cond)
+}
+
+func TestMutationConditionInvalidFilter(t *testing.T) {
+	tx, _ := newFakeTxnContext()
+
+	_, err := tx.Mutate(&invalidConditionUser{UID: "0x1"})
+	assert.Error(t, err)
+}
+
+type invalidConditionUser struct {
+	UID   string   `json:"uid,omitempty"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+func (u invalidConditionUser) MutationCondition() *Filter {
+	return Has("invalid predicate")
+}
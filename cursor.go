@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor encodes a node uid into an opaque pagination cursor
+func EncodeCursor(uid string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(uid))
+}
+
+// DecodeCursor decodes a pagination cursor into the node uid
+func DecodeCursor(cursor string) (string, error) {
+	uid, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !isUID(string(uid)) {
+		return "", ErrInvalidCursor
+	}
+	return string(uid), nil
+}
+
+// Paginate returns a page of nodes after the cursor, ordered by uid, using keyset pagination
+// with after, which unlike offset pagination does not slow down on deep pages.
+// An empty cursor returns the first page. The returned cursor points to the next page,
+// and is empty when the page has less than size nodes, i.e: the last page.
+// Optional destination can be passed, otherwise bind to model, which must be a pointer to a slice.
+func (q *Query) Paginate(cursor string, size int, dst ...interface{}) (nextCursor string, err error) {
+	if size <= 0 {
+		return "", fmt.Errorf("invalid page size %d", size)
+	}
+	if len(q.order) > 0 {
+		return "", errors.New("paginate orders by uid, cannot be used with order")
+	}
+
+	model := q.model
+	if len(dst) > 0 {
+		model = dst[0]
+	}
+
+	if cursor != "" {
+		after, err := DecodeCursor(cursor)
+		if err != nil {
+			return "", err
+		}
+		q.after = after
+	}
+	q.first = size
+
+	if err := q.Nodes(model); err != nil {
+		return "", err
+	}
+
+	nodes := reflect.Indirect(reflect.ValueOf(model))
+	if nodes.Kind() != reflect.Slice {
+		return "", fmt.Errorf("paginate destination must be a pointer to a slice, got %T", model)
+	}
+	if nodes.Len() < size {
+		return "", nil
+	}
+
+	uid := nodeUID(nodes.Index(nodes.Len() - 1))
+	if uid == "" {
+		return "", errors.New("paginate requires a uid field on the node")
+	}
+	return EncodeCursor(uid), nil
+}
+
+// nodeUID returns the value of the uid field of a node struct
+func nodeUID(v reflect.Value) string {
+	v = reflect.Indirect(v)
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	vType := v.Type()
+	for i := 0; i < vType.NumField(); i++ {
+		field := vType.Field(i)
+		if predicate, _ := getPredicate(&field); predicate == predicateUid && isUIDField(field.Type.Kind()) {
+			return uidValue(v.Field(i))
+		}
+	}
+	return ""
+}
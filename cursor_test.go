@@ -0,0 +1,63 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	uid, err := DecodeCursor(EncodeCursor("0x1a"))
+	require.NoError(t, err)
+	assert.Equal(t, "0x1a", uid)
+
+	_, err = DecodeCursor("not a cursor")
+	assert.Equal(t, ErrInvalidCursor, err)
+	_, err = DecodeCursor(EncodeCursor("uid(x)"))
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestPaginate(t *testing.T) {
+	tx, fake := newFakeTxnContext(
+		&api.Response{Json: []byte(`{"data":[{"uid":"0x1"},{"uid":"0x2"}]}`)},
+		&api.Response{Json: []byte(`{"data":[{"uid":"0x3"}]}`)},
+	)
+
+	var accounts []OptionsAccount
+	next, err := tx.Get(&accounts).Paginate("", 2)
+	require.NoError(t, err)
+	assert.Len(t, accounts, 2)
+	assert.Equal(t, EncodeCursor("0x2"), next)
+	assert.Contains(t, fake.requests[0].Query, "first: 2)")
+	assert.NotContains(t, fake.requests[0].Query, "after")
+
+	accounts = nil
+	next, err = tx.Get(&accounts).Paginate(next, 2)
+	require.NoError(t, err)
+	assert.Len(t, accounts, 1)
+	assert.Empty(t, next)
+	assert.Contains(t, fake.requests[1].Query, "first: 2, after: 0x2)")
+
+	_, err = tx.Get(&accounts).OrderAsc("username").Paginate("", 2)
+	assert.Error(t, err)
+	_, err = tx.Get(&accounts).Paginate("invalid", 2)
+	assert.Equal(t, ErrInvalidCursor, err)
+}
@@ -18,6 +18,10 @@ package dgman
 
 import (
 	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/dgraph-io/dgo/v210/protos/api"
 	"github.com/pkg/errors"
@@ -73,23 +77,304 @@ func (d *DeleteEdge) writeTo(buffer *bytes.Buffer, uid string) {
 	}
 }
 
+// SetParams is a struct to pass set mutation parameters in a delete query request,
+// the set data can reference the query variables with uid funcs, e.g: {"uid": "uid(parents)"}
+type SetParams struct {
+	Cond string
+	// Data is marshaled as the set JSON of the mutation as is
+	Data interface{}
+}
+
+// DeleteQueryRequest builds a request of a query block, delete parameters and set parameters,
+// which are sent in a single request, so deletes and sets referencing the query variables are atomic
+type DeleteQueryRequest struct {
+	tx      *TxnContext
+	query   *QueryBlock
+	deletes []*DeleteParams
+	sets    []*SetParams
+}
+
+// DeleteQueryRequest prepares a request of a query block, delete and set parameters,
+// e.g: deleting edges to nodes by a query, and marking the nodes on the same request
+func (t *TxnContext) DeleteQueryRequest(query *QueryBlock) *DeleteQueryRequest {
+	return &DeleteQueryRequest{tx: t, query: query}
+}
+
+// Delete adds delete parameters to the request
+func (r *DeleteQueryRequest) Delete(params ...*DeleteParams) *DeleteQueryRequest {
+	r.deletes = append(r.deletes, params...)
+	return r
+}
+
+// Set adds set parameters to the request
+func (r *DeleteQueryRequest) Set(params ...*SetParams) *DeleteQueryRequest {
+	r.sets = append(r.sets, params...)
+	return r
+}
+
+// Do sends the request
+func (r *DeleteQueryRequest) Do() (DeleteQuery, error) {
+	if len(r.deletes) == 0 && len(r.sets) == 0 {
+		return DeleteQuery{}, errors.New("delete and set params cannot be empty")
+	}
+	return r.tx.deleteQueryWithSets(r.query, r.deletes, r.sets)
+}
+
+// DeleteWhereOptions specifies the edges to delete along with the matching nodes in DeleteWhere
+type DeleteWhereOptions struct {
+	// Cascade deletes the nodes of the edge predicates of the matching nodes, e.g: []string{"comments"}
+	Cascade []string
+}
+
+// DeleteWhere deletes the nodes of a model type matching a filter, optionally deleting the nodes
+// of edges as specified in the options, returning the deleted uids. The filter is validated against
+// the schema tags of the model, and nodes of soft delete types are soft deleted, as in DeleteNode.
+func (t *TxnContext) DeleteWhere(model interface{}, filter *Filter, opts ...DeleteWhereOptions) ([]string, error) {
+	if filter == nil {
+		return nil, errors.New("filter cannot be empty")
+	}
+	var options DeleteWhereOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+
+	var buffer strings.Builder
+	buffer.WriteString("{\n\t\tuid")
+	for _, predicate := range options.Cascade {
+		if !predicateRegex.MatchString(predicate) {
+			return nil, fmt.Errorf("invalid cascade predicate %q", predicate)
+		}
+		buffer.WriteString("\n\t\t")
+		buffer.WriteString(predicate)
+		buffer.WriteString(" { uid }")
+	}
+	buffer.WriteString("\n\t}")
+
+	uids, err := t.deleteResultNodes(t.Get(model).Where(filter).Query(buffer.String()))
+	if err != nil {
+		return nil, errors.Wrap(err, "delete where failed")
+	}
+	return uids, nil
+}
+
+// DeleteNodeCascade deletes nodes of a model type by their uids, along with the nodes of their owned edges,
+// i.e: edges tagged with dgraph:"owned", following the owned edges of the edge node types recursively,
+// returning the deleted uids. Nodes of soft delete types are soft deleted, as in DeleteNode.
+func (t *TxnContext) DeleteNodeCascade(model interface{}, uids ...string) ([]string, error) {
+	if len(uids) == 0 {
+		return nil, errors.New("uids cannot be empty")
+	}
+	if err := validateUIDs(uids...); err != nil {
+		return nil, err
+	}
+	modelType, err := reflectType(model)
+	if err != nil {
+		return nil, err
+	}
+	if modelType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("model \"%s\" is not a struct", modelType.Name())
+	}
+
+	var buffer strings.Builder
+	buffer.WriteString("{\n\t\tuid")
+	if err := writeOwnedEdges(&buffer, modelType, map[reflect.Type]bool{modelType: true}); err != nil {
+		return nil, err
+	}
+	buffer.WriteString("\n\t}")
+
+	deleted, err := t.deleteResultNodes(t.Get(model).UID(strings.Join(uids, ", ")).Query(buffer.String()))
+	if err != nil {
+		return nil, errors.Wrap(err, "delete node cascade failed")
+	}
+	return deleted, nil
+}
+
+// DeleteNodeIncoming deletes nodes of a model type by their uids, along with the incoming edges of other nodes
+// to the nodes, i.e: the edges of the reverse edge fields of the model, e.g: json:"~author", which predicates
+// must be defined with @reverse in the schema. Deleting a node only deletes its outgoing edges, so without
+// deleting the incoming edges, the edges of other nodes still point to the deleted uid.
+// Nodes of soft delete types are soft deleted, keeping their incoming edges, as in DeleteNode.
+func (t *TxnContext) DeleteNodeIncoming(model interface{}, uids ...string) error {
+	if len(uids) == 0 {
+		return errors.New("uids cannot be empty")
+	}
+	if err := validateUIDs(uids...); err != nil {
+		return err
+	}
+	modelType, err := reflectType(model)
+	if err != nil {
+		return err
+	}
+	if modelType.Kind() != reflect.Struct {
+		return fmt.Errorf("model \"%s\" is not a struct", modelType.Name())
+	}
+	if softDeletePredicate(modelType) != "" {
+		return t.deleteModelNode(model, uids...)
+	}
+
+	var (
+		selection strings.Builder
+		delNquads bytes.Buffer
+	)
+	reverseEdges := 0
+	for _, field := range modelFields(modelType) {
+		predicate, _ := getPredicate(&field)
+		if !strings.HasPrefix(predicate, "~") || isFacet(predicate) || !predicateRegex.MatchString(predicate) {
+			continue
+		}
+		// the nodes with edges to the deleted nodes
+		variable := fmt.Sprintf("r_%d", reverseEdges)
+		reverseEdges++
+		fmt.Fprintf(&selection, "\t\t%s as %s\n", variable, predicate)
+		for _, uid := range uids {
+			writeDeleteEdge(&delNquads, variable, predicate[1:], uid)
+		}
+	}
+	for _, uid := range uids {
+		writeDeleteNode(&delNquads, uid)
+	}
+
+	request := &api.Request{
+		Mutations: []*api.Mutation{{DelNquads: delNquads.Bytes()}},
+		CommitNow: t.commitNow,
+	}
+	if reverseEdges > 0 {
+		request.Query = FormatQuery(fmt.Sprintf("{\n\tvar(func: uid(%s)) {\n%s\t}\n}", strings.Join(uids, ", "), selection.String()))
+	}
+
+	if err := t.hooks.beforeDelete(t.ctx, uids); err != nil {
+		return err
+	}
+	if _, err := t.txn.Do(t.ctx, request); err != nil {
+		return errors.Wrap(err, "delete node incoming failed")
+	}
+	return t.hooks.afterDelete(t.ctx, uids)
+}
+
+// writeOwnedEdges writes the selection of the uids of the owned edges of a node type,
+// owned edges of node types already in the path are not followed
+func writeOwnedEdges(buffer *strings.Builder, modelType reflect.Type, path map[reflect.Type]bool) error {
+	for _, field := range modelFields(modelType) {
+		dgraphTag := field.Tag.Get(tagName)
+		if dgraphTag == "" {
+			continue
+		}
+		props, err := parseStructTag(dgraphTag)
+		if err != nil {
+			return errors.Wrapf(err, "parse dgraph tag failed on %s.%s", modelType.Name(), field.Name)
+		}
+		edgeType := edgeNodeType(field)
+		if !props.Owned || edgeType == nil {
+			continue
+		}
+
+		predicate, _ := getPredicate(&field)
+		if props.Predicate != "" {
+			predicate = props.Predicate
+		}
+		buffer.WriteString("\n\t\t")
+		buffer.WriteString(predicate)
+		buffer.WriteString(" {\n\t\tuid")
+		if !path[edgeType] {
+			path[edgeType] = true
+			if err := writeOwnedEdges(buffer, edgeType, path); err != nil {
+				return err
+			}
+			delete(path, edgeType)
+		}
+		buffer.WriteString("\n\t\t}")
+	}
+	return nil
+}
+
+// deleteResultNodes deletes the nodes and edge nodes returned by a query, returning the deleted uids
+func (t *TxnContext) deleteResultNodes(query *Query) ([]string, error) {
+	result, err := query.executeQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	uids, err := resultUIDs(result)
+	if err != nil {
+		return nil, err
+	}
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	if err := t.deleteModelNode(query.model, uids...); err != nil {
+		return nil, err
+	}
+	return uids, nil
+}
+
+// resultUIDs returns the sorted unique uids of the nodes and edge nodes in a query result
+func resultUIDs(result []byte) ([]string, error) {
+	var queryMap map[string]interface{}
+	if err := json.Unmarshal(result, &queryMap); err != nil {
+		return nil, errors.Wrap(err, "unmarshal query result failed")
+	}
+
+	uidSet := newSet()
+	var collect func(value interface{})
+	collect = func(value interface{}) {
+		switch value := value.(type) {
+		case []interface{}:
+			for _, item := range value {
+				collect(item)
+			}
+		case map[string]interface{}:
+			for key, item := range value {
+				if uid, ok := item.(string); ok && key == predicateUid {
+					uidSet.Add(uid)
+					continue
+				}
+				collect(item)
+			}
+		}
+	}
+	collect(queryMap)
+
+	uids := make([]string, 0, len(uidSet))
+	for uid := range uidSet {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+	return uids, nil
+}
+
 func (d *TxnContext) delete(params ...*DeleteParams) error {
 	_, err := d.deleteQuery(nil, params...)
 	return err
 }
 
 func (d *TxnContext) deleteQuery(query *QueryBlock, params ...*DeleteParams) (DeleteQuery, error) {
-	mutations := make([]*api.Mutation, len(params))
+	return d.deleteQueryWithSets(query, params, nil)
+}
+
+func (d *TxnContext) deleteQueryWithSets(query *QueryBlock, params []*DeleteParams, sets []*SetParams) (DeleteQuery, error) {
+	var uids []string
+	mutations := make([]*api.Mutation, len(params), len(params)+len(sets))
 	for i, param := range params {
 		var nQuads bytes.Buffer
 		for _, node := range param.Nodes {
 			node.writeTo(&nQuads)
+			uids = append(uids, node.UID)
 		}
 		mutations[i] = &api.Mutation{
 			DelNquads: nQuads.Bytes(),
 			Cond:      param.Cond,
 		}
 	}
+	for i, set := range sets {
+		setJSON, err := json.Marshal(set.Data)
+		if err != nil {
+			return DeleteQuery{}, errors.Wrapf(err, "marshal set params %d failed", i)
+		}
+		mutations = append(mutations, &api.Mutation{
+			SetJson: setJSON,
+			Cond:    set.Cond,
+		})
+	}
 	req := &api.Request{
 		Mutations: mutations,
 		CommitNow: d.commitNow,
@@ -97,10 +382,16 @@ func (d *TxnContext) deleteQuery(query *QueryBlock, params ...*DeleteParams) (De
 	if query != nil {
 		req.Query = query.String()
 	}
+	if err := d.hooks.beforeDelete(d.ctx, uids); err != nil {
+		return DeleteQuery{}, err
+	}
 	resp, err := d.txn.Do(d.ctx, req)
 	if err != nil {
 		return DeleteQuery{}, errors.Wrap(err, "request failed")
 	}
+	if err := d.hooks.afterDelete(d.ctx, uids); err != nil {
+		return DeleteQuery{}, err
+	}
 	return DeleteQuery{
 		query:  query,
 		result: resp.Json,
@@ -108,15 +399,23 @@ func (d *TxnContext) deleteQuery(query *QueryBlock, params ...*DeleteParams) (De
 }
 
 func (d *TxnContext) deleteNode(uids ...string) error {
+	return d.deleteModelNode(nil, uids...)
+}
+
+// deleteModelNode deletes nodes as in DeleteNode, also soft deleting the nodes of the soft delete type of a model
+func (d *TxnContext) deleteModelNode(model interface{}, uids ...string) error {
+	if types := d.softDeleteTypes(model); len(types) > 0 {
+		return d.softDeleteNode(types, uids...)
+	}
+	return d.hardDeleteNode(uids...)
+}
+
+func (d *TxnContext) hardDeleteNode(uids ...string) error {
 	var nQuads bytes.Buffer
 	for _, uid := range uids {
 		writeDeleteNodeRDF(&nQuads, uid)
 	}
-	_, err := d.txn.Mutate(d.ctx, &api.Mutation{
-		DelNquads: nQuads.Bytes(),
-		CommitNow: d.commitNow,
-	})
-	return err
+	return d.deleteNquads(uids, nQuads.Bytes())
 }
 
 func (d *TxnContext) deleteEdge(uid string, predicate string, edgeUIDs ...string) error {
@@ -128,11 +427,22 @@ func (d *TxnContext) deleteEdge(uid string, predicate string, edgeUIDs ...string
 	} else {
 		writeDeleteAllEdgesRDF(&nQuads, uid, predicate)
 	}
+	return d.deleteNquads([]string{uid}, nQuads.Bytes())
+}
+
+// deleteNquads sends delete n-quads of nodes, calling the delete hooks
+func (d *TxnContext) deleteNquads(uids []string, nQuads []byte) error {
+	if err := d.hooks.beforeDelete(d.ctx, uids); err != nil {
+		return err
+	}
 	_, err := d.txn.Mutate(d.ctx, &api.Mutation{
-		DelNquads: nQuads.Bytes(),
+		DelNquads: nQuads,
 		CommitNow: d.commitNow,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	return d.hooks.afterDelete(d.ctx, uids)
 }
 
 func writeDeleteNode(w *bytes.Buffer, uid string) {
@@ -20,7 +20,9 @@ import (
 	"log"
 	"testing"
 
+	"github.com/dgraph-io/dgo/v210/protos/api"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDelete(t *testing.T) {
@@ -418,3 +420,188 @@ func TestDeleteEdge(t *testing.T) {
 	assert.Len(t, updatedUser.Schools, 1)
 	assert.Equal(t, updatedUser.Schools[0].UID, user.Schools[1].UID)
 }
+
+func TestDeleteQueryRequest(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	query := NewQueryBlock(NewQuery().
+		Model(&TestUser{}).
+		UID("0x12").
+		Query(`{
+			schools @filter(eq(identifier, "harvard")) {
+				schoolId as uid
+			}
+		}`))
+	_, err := tx.DeleteQueryRequest(query).
+		Delete(&DeleteParams{
+			Nodes: []DeleteNode{
+				{
+					UID:   "0x12",
+					Edges: []DeleteEdge{{Pred: "schools", UIDs: []string{"schoolId"}}},
+				},
+			},
+		}).
+		Set(&SetParams{
+			Cond: "@if(gt(len(schoolId), 0))",
+			Data: map[string]interface{}{"uid": "uid(schoolId)", "orphaned": true},
+		}).
+		Do()
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+
+	req := fake.requests[0]
+	assert.Equal(t, query.String(), req.Query)
+	require.Len(t, req.Mutations, 2)
+	assert.Equal(t, "<0x12> <schools> uid(schoolId) .\n", string(req.Mutations[0].DelNquads))
+	assert.Equal(t, "@if(gt(len(schoolId), 0))", req.Mutations[1].Cond)
+	assert.JSONEq(t, `{"uid":"uid(schoolId)","orphaned":true}`, string(req.Mutations[1].SetJson))
+
+	_, err = tx.DeleteQueryRequest(query).Do()
+	assert.Error(t, err)
+}
+
+func TestDeleteWhere(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"data":[{"uid":"0x1","edges":[{"uid":"0x3"},{"uid":"0x4"}]},{"uid":"0x2","edges":[{"uid":"0x3"}]}]}`),
+	})
+
+	uids, err := tx.DeleteWhere(&TestModel{}, AllOfTerms("name", "wildan"), DeleteWhereOptions{
+		Cascade: []string{"edges"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0x1", "0x2", "0x3", "0x4"}, uids)
+
+	require.Len(t, fake.requests, 2)
+	assert.Equal(t, `{
+	data(func: type(TestModel)) @filter(has(dgraph.type) AND allofterms(name, "wildan")) {
+		uid
+		edges { uid }
+	}
+}`, fake.requests[0].Query)
+	require.Len(t, fake.requests[1].Mutations, 1)
+	assert.Equal(t, "<0x1> * * .\n<0x2> * * .\n<0x3> * * .\n<0x4> * * .\n",
+		string(fake.requests[1].Mutations[0].DelNquads))
+}
+
+func TestDeleteWhereNoMatch(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{Json: []byte(`{"data":[]}`)})
+
+	uids, err := tx.DeleteWhere(&TestModel{}, Eq("name", "wildan"))
+	require.NoError(t, err)
+	assert.Empty(t, uids)
+	assert.Len(t, fake.requests, 1)
+}
+
+func TestDeleteWhereInvalid(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	_, err := tx.DeleteWhere(&TestModel{}, nil)
+	assert.Error(t, err)
+	_, err = tx.DeleteWhere(&TestModel{}, Eq("email", "wildan"))
+	assert.Error(t, err)
+	_, err = tx.DeleteWhere(&TestModel{}, Has("name"), DeleteWhereOptions{Cascade: []string{"edges { password }"}})
+	assert.Error(t, err)
+	assert.Empty(t, fake.requests)
+}
+
+type OwnedDepartment struct {
+	UID     string        `json:"uid,omitempty"`
+	Name    string        `json:"name,omitempty"`
+	Courses []OwnedCourse `json:"courses,omitempty" dgraph:"owned"`
+	Head    *TestUser     `json:"head,omitempty"`
+	DType   []string      `json:"dgraph.type,omitempty"`
+}
+
+type OwnedCourse struct {
+	UID          string            `json:"uid,omitempty"`
+	Title        string            `json:"title,omitempty"`
+	Enrollments  []OwnedEnrollment `json:"enrollments,omitempty" dgraph:"owned"`
+	Prerequisite *OwnedCourse      `json:"prerequisite,omitempty"`
+	DType        []string          `json:"dgraph.type,omitempty"`
+}
+
+type OwnedEnrollment struct {
+	UID     string    `json:"uid,omitempty"`
+	Student *TestUser `json:"student,omitempty"`
+	DType   []string  `json:"dgraph.type,omitempty"`
+}
+
+type OwnedCategory struct {
+	UID           string          `json:"uid,omitempty"`
+	Subcategories []OwnedCategory `json:"subcategories,omitempty" dgraph:"owned"`
+	DType         []string        `json:"dgraph.type,omitempty"`
+}
+
+func TestDeleteNodeCascade(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"data":[{"uid":"0x1","courses":[{"uid":"0x2","enrollments":[{"uid":"0x4"}]},{"uid":"0x3"}]}]}`),
+	})
+
+	uids, err := tx.DeleteNodeCascade(&OwnedDepartment{}, "0x1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0x1", "0x2", "0x3", "0x4"}, uids)
+
+	require.Len(t, fake.requests, 2)
+	assert.Equal(t, `{
+	data(func: uid(0x1)) @filter(has(dgraph.type)) {
+		uid
+		courses {
+			uid
+			enrollments {
+				uid
+			}
+		}
+	}
+}`, fake.requests[0].Query)
+	assert.Equal(t, "<0x1> * * .\n<0x2> * * .\n<0x3> * * .\n<0x4> * * .\n",
+		string(fake.requests[1].Mutations[0].DelNquads))
+}
+
+func TestDeleteNodeCascadeRecursive(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{Json: []byte(`{"data":[]}`)})
+
+	uids, err := tx.DeleteNodeCascade(&OwnedCategory{}, "0x1", "0x2")
+	require.NoError(t, err)
+	assert.Empty(t, uids)
+
+	require.Len(t, fake.requests, 1)
+	assert.Equal(t, `{
+	data(func: uid(0x1, 0x2)) @filter(has(dgraph.type)) {
+		uid
+		subcategories {
+			uid
+		}
+	}
+}`, fake.requests[0].Query)
+
+	_, err = tx.DeleteNodeCascade(&OwnedCategory{})
+	assert.Error(t, err)
+	// uids are validated, as they are written into the query
+	_, err = tx.DeleteNodeCascade(&OwnedCategory{}, "0x1) { uid } }")
+	assert.Error(t, err)
+	assert.Len(t, fake.requests, 1)
+}
+
+func TestDeleteNodeIncoming(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	require.NoError(t, tx.DeleteNodeIncoming(&EdgeDepartment{}, "0x1", "0x2"))
+	require.Len(t, fake.requests, 1)
+	assert.Equal(t, `{
+	var(func: uid(0x1, 0x2)) {
+		r_0 as ~in_department
+	}
+}`, fake.requests[0].Query)
+	assert.Equal(t, "uid(r_0) <in_department> <0x1> .\nuid(r_0) <in_department> <0x2> .\n<0x1> * * .\n<0x2> * * .\n",
+		string(fake.requests[0].Mutations[0].DelNquads))
+
+	// without reverse edge fields, only the nodes are deleted
+	require.NoError(t, tx.DeleteNodeIncoming(&TestModel{}, "0x3"))
+	require.Len(t, fake.requests, 2)
+	assert.Empty(t, fake.requests[1].Query)
+	assert.Equal(t, "<0x3> * * .\n", string(fake.requests[1].Mutations[0].DelNquads))
+
+	assert.Error(t, tx.DeleteNodeIncoming(&EdgeDepartment{}, "r_0"))
+	assert.Error(t, tx.DeleteNodeIncoming(&EdgeDepartment{}, "0x1, uid(r_0)"))
+	assert.Len(t, fake.requests, 2)
+}
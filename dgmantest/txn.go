@@ -0,0 +1,434 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dgmantest provides an in-memory fake of dgman.Store, so stores depending on dgman.Store
+// can be unit tested without Dgraph
+package dgmantest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dolan-in/dgman/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	predicateUID        = "uid"
+	predicateDgraphType = "dgraph.type"
+	// edgeDepth is the depth of edges returned by queries, as in dgman.Query.All
+	edgeDepth = 2
+)
+
+var _ dgman.Store = (*Txn)(nil)
+
+// Txn is an in-memory fake of dgman.Store, assigning uids, injecting node types, and checking
+// unique predicates on mutations as dgman.TxnContext does. Find supports eq, uid_in, and has filters.
+// Mutations are committed immediately, and a failed mutation leaves the nodes unchanged.
+type Txn struct {
+	mu      sync.Mutex
+	nodes   map[string]map[string]interface{}
+	lastUID uint64
+}
+
+// NewTxn creates an empty in-memory fake
+func NewTxn() *Txn {
+	return &Txn{nodes: make(map[string]map[string]interface{})}
+}
+
+// Mutate creates or updates nodes, including edge nodes, returning the created uids,
+// returns a dgman.UniqueError when a unique predicate value already exists
+func (t *Txn) Mutate(data interface{}) ([]string, error) {
+	return t.mutate(data, nil, false)
+}
+
+// Upsert creates or updates nodes as in Mutate, updating the existing node with the same value
+// of the predicates, or of the unique predicates when no predicates are passed
+func (t *Txn) Upsert(data interface{}, predicates ...string) ([]string, error) {
+	return t.mutate(data, predicates, true)
+}
+
+// GetByUID gets the node with the uid into dst, returns dgman.ErrNodeNotFound when the node
+// does not exist, or is not of the node type of dst
+func (t *Txn) GetByUID(dst interface{}, uid string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	node, ok := t.nodes[uid]
+	if !ok || !hasNodeType(node, dgman.GetNodeType(dst)) {
+		return dgman.ErrNodeNotFound
+	}
+	return decode(t.expand(node, edgeDepth), dst)
+}
+
+// Find gets the nodes of the node type matching a filter into dst, a pointer to a slice of the node type,
+// ordered by uid, a nil filter gets all nodes of the node type
+func (t *Txn) Find(dst interface{}, filter *dgman.Filter) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	nodeType := dgman.GetNodeType(dst)
+	results := []interface{}{}
+	for _, uid := range t.sortedUIDs() {
+		node := t.nodes[uid]
+		if !hasNodeType(node, nodeType) {
+			continue
+		}
+		if filter != nil {
+			matched, err := filter.Match(node)
+			if err != nil {
+				return err
+			}
+			if !matched {
+				continue
+			}
+		}
+		results = append(results, t.expand(node, edgeDepth))
+	}
+	return decode(results, dst)
+}
+
+// DeleteNode deletes nodes by their uids
+func (t *Txn) DeleteNode(uids ...string) error {
+	if len(uids) == 0 {
+		return errors.New("uids cannot be empty")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, uid := range uids {
+		delete(t.nodes, uid)
+	}
+	return nil
+}
+
+// Nodes returns the stored nodes by uid, as decoded from json with edges as uid references,
+// e.g: for assertions in tests
+func (t *Txn) Nodes() map[string]map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	nodes := make(map[string]map[string]interface{}, len(t.nodes))
+	for uid, node := range t.nodes {
+		nodes[uid] = node
+	}
+	return nodes
+}
+
+func (t *Txn) mutate(data interface{}, predicates []string, upsert bool) ([]string, error) {
+	if err := dgman.SetTypes(data); err != nil {
+		return nil, errors.Wrap(err, "set types failed")
+	}
+	schema := dgman.NewTypeSchema()
+	schema.Marshal("", data)
+
+	var value interface{}
+	if err := decode(data, &value); err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m := &mutation{
+		txn:        t,
+		types:      schema.Types,
+		predicates: predicates,
+		upsert:     upsert,
+		nodes:      make(map[string]map[string]interface{}, len(t.nodes)),
+		blankUIDs:  make(map[string]string),
+		lastUID:    t.lastUID,
+	}
+	for uid, node := range t.nodes {
+		m.nodes[uid] = node
+	}
+	if err := m.walk(value); err != nil {
+		return nil, err
+	}
+
+	// the created uids are set on data, as the uids injected by dgman
+	if err := decode(value, data); err != nil {
+		return nil, err
+	}
+	t.nodes, t.lastUID = m.nodes, m.lastUID
+	return m.created, nil
+}
+
+// mutation stores the nodes of a mutation on a copy of the nodes, applied when the mutation succeeds
+type mutation struct {
+	txn        *Txn
+	types      dgman.TypeMap
+	predicates []string
+	upsert     bool
+	nodes      map[string]map[string]interface{}
+	blankUIDs  map[string]string
+	lastUID    uint64
+	created    []string
+}
+
+// walk stores the nodes in a json value, replacing their uids with the assigned uids
+func (m *mutation) walk(value interface{}) error {
+	switch value := value.(type) {
+	case []interface{}:
+		for _, elem := range value {
+			if err := m.walk(elem); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		_, err := m.store(value)
+		return err
+	}
+	return nil
+}
+
+// store stores a node and its edge nodes, returning the uid of the node
+func (m *mutation) store(node map[string]interface{}) (string, error) {
+	stored := make(map[string]interface{}, len(node))
+	for predicate, value := range node {
+		if predicate == predicateUID {
+			continue
+		}
+		ref, isEdge, err := m.storeEdges(value)
+		if err != nil {
+			return "", err
+		}
+		if isEdge {
+			stored[predicate] = ref
+			continue
+		}
+		stored[predicate] = value
+	}
+
+	uid, isNew := m.resolveUID(node)
+	nodeTypes := nodeTypes(stored)
+	for _, nodeType := range nodeTypes {
+		existing, err := m.checkUnique(nodeType, uid, stored)
+		if err != nil {
+			return "", err
+		}
+		if existing != "" && isNew && m.upsert {
+			uid, isNew = existing, false
+			m.bindBlankUID(node, uid)
+		}
+	}
+	if isNew {
+		m.lastUID++
+		uid = "0x" + strconv.FormatUint(m.lastUID, 16)
+		m.bindBlankUID(node, uid)
+		m.created = append(m.created, uid)
+	}
+
+	merged := make(map[string]interface{}, len(stored)+1)
+	for predicate, value := range m.nodes[uid] {
+		merged[predicate] = value
+	}
+	for predicate, value := range stored {
+		merged[predicate] = value
+	}
+	merged[predicateUID] = uid
+	m.nodes[uid] = merged
+	node[predicateUID] = uid
+	return uid, nil
+}
+
+// storeEdges stores the edge nodes of a value, returning their uid references
+func (m *mutation) storeEdges(value interface{}) (interface{}, bool, error) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		uid, err := m.store(value)
+		if err != nil {
+			return nil, true, err
+		}
+		return map[string]interface{}{predicateUID: uid}, true, nil
+	case []interface{}:
+		if len(value) == 0 {
+			return value, false, nil
+		}
+		if _, ok := value[0].(map[string]interface{}); !ok {
+			return value, false, nil
+		}
+		refs := make([]interface{}, len(value))
+		for i, elem := range value {
+			ref, _, err := m.storeEdges(elem)
+			if err != nil {
+				return nil, true, err
+			}
+			refs[i] = ref
+		}
+		return refs, true, nil
+	}
+	return value, false, nil
+}
+
+// resolveUID returns the uid of an existing node, or the uid assigned to a blank node
+func (m *mutation) resolveUID(node map[string]interface{}) (uid string, isNew bool) {
+	uid, _ = node[predicateUID].(string)
+	if uid == "" {
+		return "", true
+	}
+	if strings.HasPrefix(uid, "_:") {
+		if assigned, ok := m.blankUIDs[uid]; ok {
+			return assigned, false
+		}
+		return "", true
+	}
+	return uid, false
+}
+
+func (m *mutation) bindBlankUID(node map[string]interface{}, uid string) {
+	if blank, _ := node[predicateUID].(string); strings.HasPrefix(blank, "_:") {
+		m.blankUIDs[blank] = uid
+	}
+}
+
+// checkUnique checks the unique predicates of a node type, or the predicates passed to Upsert,
+// returning the uid of the existing node on upserts of new nodes, otherwise a dgman.UniqueError
+func (m *mutation) checkUnique(nodeType, uid string, node map[string]interface{}) (string, error) {
+	schemaMap := m.types[nodeType]
+	predicates := make([]string, 0, len(schemaMap))
+	for predicate := range schemaMap {
+		predicates = append(predicates, predicate)
+	}
+	sort.Strings(predicates)
+
+	for _, predicate := range predicates {
+		checked := schemaMap[predicate].Unique
+		if m.upsert && len(m.predicates) > 0 {
+			checked = contains(m.predicates, predicate)
+		}
+		value, ok := node[predicate]
+		if !checked || !ok || value == nil || value == "" {
+			continue
+		}
+		existing := m.find(nodeType, predicate, value, uid)
+		if existing == "" {
+			continue
+		}
+		if m.upsert && uid == "" {
+			return existing, nil
+		}
+		return "", &dgman.UniqueError{NodeType: nodeType, Field: predicate, Value: value, UID: existing}
+	}
+	return "", nil
+}
+
+// find returns the uid of another node of the node type with the predicate value
+func (m *mutation) find(nodeType, predicate string, value interface{}, uid string) string {
+	for existingUID, node := range m.nodes {
+		if existingUID != uid && hasNodeType(node, nodeType) && reflect.DeepEqual(node[predicate], value) {
+			return existingUID
+		}
+	}
+	return ""
+}
+
+// expand replaces the uid references of edges with their nodes, up to a depth
+func (t *Txn) expand(node map[string]interface{}, depth int) map[string]interface{} {
+	expanded := make(map[string]interface{}, len(node))
+	for predicate, value := range node {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if edge := t.expandEdge(value, depth); edge != nil {
+				expanded[predicate] = edge
+			}
+		case []interface{}:
+			if len(value) > 0 {
+				if _, ok := value[0].(map[string]interface{}); ok {
+					edges := []interface{}{}
+					for _, ref := range value {
+						if edge := t.expandEdge(ref.(map[string]interface{}), depth); edge != nil {
+							edges = append(edges, edge)
+						}
+					}
+					expanded[predicate] = edges
+					continue
+				}
+			}
+			expanded[predicate] = value
+		default:
+			expanded[predicate] = value
+		}
+	}
+	return expanded
+}
+
+func (t *Txn) expandEdge(ref map[string]interface{}, depth int) map[string]interface{} {
+	uid, _ := ref[predicateUID].(string)
+	node, ok := t.nodes[uid]
+	if !ok {
+		// edges to deleted nodes
+		return nil
+	}
+	if depth <= 0 {
+		return map[string]interface{}{predicateUID: uid}
+	}
+	return t.expand(node, depth-1)
+}
+
+func (t *Txn) sortedUIDs() []string {
+	uids := make([]string, 0, len(t.nodes))
+	for uid := range t.nodes {
+		uids = append(uids, uid)
+	}
+	sort.Slice(uids, func(i, j int) bool {
+		a, _ := strconv.ParseUint(strings.TrimPrefix(uids[i], "0x"), 16, 64)
+		b, _ := strconv.ParseUint(strings.TrimPrefix(uids[j], "0x"), 16, 64)
+		return a < b
+	})
+	return uids
+}
+
+func nodeTypes(node map[string]interface{}) []string {
+	var types []string
+	values, _ := node[predicateDgraphType].([]interface{})
+	for _, value := range values {
+		if nodeType, ok := value.(string); ok {
+			types = append(types, nodeType)
+		}
+	}
+	return types
+}
+
+func hasNodeType(node map[string]interface{}, nodeType string) bool {
+	return contains(nodeTypes(node), nodeType)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// decode converts a value through json, e.g: structs into json values and back
+func decode(value, dst interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return errors.Wrap(err, "marshal failed")
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("unmarshal into %T failed: %v", dst, err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgmantest
+
+import (
+	"testing"
+
+	"github.com/dolan-in/dgman/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type School struct {
+	UID   string   `json:"uid,omitempty"`
+	Name  string   `json:"name,omitempty" dgraph:"index=exact unique"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+type User struct {
+	UID    string   `json:"uid,omitempty"`
+	Name   string   `json:"name,omitempty" dgraph:"index=term"`
+	Email  string   `json:"email,omitempty" dgraph:"index=exact unique"`
+	School *School  `json:"school,omitempty"`
+	DType  []string `json:"dgraph.type,omitempty"`
+}
+
+func TestTxnMutate(t *testing.T) {
+	var store dgman.Store = NewTxn()
+
+	users := []User{
+		{Name: "wildan", Email: "wildan@mail.com", School: &School{Name: "harvard"}},
+		{Name: "moran", Email: "moran@mail.com", School: &School{UID: "_:harvard"}},
+	}
+	users[0].School.UID = "_:harvard"
+	uids, err := store.Mutate(&users)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0x1", "0x2", "0x3"}, uids)
+	assert.Equal(t, "0x2", users[0].UID)
+	assert.Equal(t, "0x1", users[0].School.UID)
+	assert.Equal(t, "0x1", users[1].School.UID)
+	assert.Equal(t, []string{"User"}, users[0].DType)
+
+	var user User
+	require.NoError(t, store.GetByUID(&user, users[0].UID))
+	assert.Equal(t, "wildan", user.Name)
+	require.NotNil(t, user.School)
+	assert.Equal(t, "harvard", user.School.Name)
+
+	// not a user
+	assert.Equal(t, dgman.ErrNodeNotFound, store.GetByUID(&user, users[0].School.UID))
+	assert.Equal(t, dgman.ErrNodeNotFound, store.GetByUID(&user, "0x99"))
+}
+
+func TestTxnUnique(t *testing.T) {
+	txn := NewTxn()
+
+	_, err := txn.Mutate(&User{Name: "wildan", Email: "wildan@mail.com"})
+	require.NoError(t, err)
+
+	_, err = txn.Mutate(&[]User{
+		{Name: "moran", Email: "moran@mail.com"},
+		{Name: "wildan2", Email: "wildan@mail.com"},
+	})
+	uniqueErr, ok := err.(*dgman.UniqueError)
+	require.True(t, ok, err)
+	assert.Equal(t, "email", uniqueErr.Field)
+	assert.Equal(t, "0x1", uniqueErr.UID)
+	// failed mutations are not stored
+	assert.Len(t, txn.Nodes(), 1)
+
+	user := User{UID: "0x1", Name: "wildan", Email: "wildan@mail.com"}
+	_, err = txn.Mutate(&user)
+	assert.NoError(t, err, "updating the same node")
+
+	user = User{Name: "wildan updated", Email: "wildan@mail.com"}
+	uids, err := txn.Upsert(&user)
+	require.NoError(t, err)
+	assert.Empty(t, uids)
+	assert.Equal(t, "0x1", user.UID)
+
+	user = User{Name: "wildan updated", Email: "other@mail.com"}
+	_, err = txn.Upsert(&user, "name")
+	require.NoError(t, err)
+	assert.Equal(t, "0x1", user.UID)
+	assert.Equal(t, "other@mail.com", txn.Nodes()["0x1"]["email"])
+}
+
+func TestTxnFind(t *testing.T) {
+	txn := NewTxn()
+
+	school := School{Name: "harvard"}
+	_, err := txn.Mutate(&school)
+	require.NoError(t, err)
+	_, err = txn.Mutate(&[]User{
+		{Name: "wildan", Email: "wildan@mail.com", School: &school},
+		{Name: "moran", Email: "moran@mail.com"},
+		{Name: "dolan", Email: "dolan@mail.com", School: &school},
+	})
+	require.NoError(t, err)
+
+	var users []User
+	require.NoError(t, txn.Find(&users, nil))
+	assert.Len(t, users, 3)
+
+	users = nil
+	require.NoError(t, txn.Find(&users, dgman.Eq("name", "moran", "dolan")))
+	require.Len(t, users, 2)
+	assert.Equal(t, "moran", users[0].Name)
+	assert.Equal(t, "dolan", users[1].Name)
+
+	users = nil
+	require.NoError(t, txn.Find(&users, dgman.UIDIn("school", school.UID).And(dgman.Not(dgman.Eq("name", "dolan")))))
+	require.Len(t, users, 1)
+	assert.Equal(t, "wildan", users[0].Name)
+
+	users = nil
+	require.NoError(t, txn.DeleteNode(school.UID))
+	require.NoError(t, txn.Find(&users, dgman.Has("school")))
+	require.Len(t, users, 2)
+	assert.Nil(t, users[0].School)
+
+	err = txn.Find(&users, dgman.AllOfTerms("name", "wildan"))
+	assert.EqualError(t, err, "allofterms filter cannot be matched in memory")
+}
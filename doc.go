@@ -17,4 +17,15 @@
 // Package dgman is a schema manager for Dgraph using the Go Dgraph client (dgo),
 // which manages Dgraph types, schema, and indexes from Go tags in struct definitions,
 // allowing ORM-like convenience for developing Dgraph clients in Go.
+//
+// Functions taking a client accept a DgraphClient, implemented by *dgo.Dgraph of
+// dgo v210 (github.com/dgraph-io/dgo/v210). Client holds the configuration of its transactions,
+// e.g: Client.SetOptions, Client.SetHooks, carried by the dgo client returned by Client.Dgraph.
+// Later dgo major versions, e.g: dgo v250 and its dgo.Client, are not supported, as they change
+// the import path of the client and the api protos used across the package, which would break
+// the API of this major version.
+//
+// The package is kept flat, without subpackages, as schema, query, mutation and delete share
+// unexported helpers, e.g: the struct walker, node type resolution, and json encoding, which
+// would otherwise need to be exported from a common package.
 package dgman
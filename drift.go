@@ -0,0 +1,200 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SchemaDriftKind is the kind of difference between the cluster schema and the models
+type SchemaDriftKind string
+
+const (
+	// SchemaDriftMissingPredicate is a predicate defined in the models, missing in the cluster
+	SchemaDriftMissingPredicate SchemaDriftKind = "missing predicate"
+	// SchemaDriftType is a predicate with a different type or list type
+	SchemaDriftType SchemaDriftKind = "type"
+	// SchemaDriftIndex is a predicate with different index tokenizers
+	SchemaDriftIndex SchemaDriftKind = "index"
+	// SchemaDriftReverse is a predicate with or without @reverse, different from the models
+	SchemaDriftReverse SchemaDriftKind = "reverse"
+	// SchemaDriftDirectives is a predicate with different @count, @upsert, @lang, or @noconflict directives
+	SchemaDriftDirectives SchemaDriftKind = "directives"
+	// SchemaDriftMissingType is a node type defined in the models, missing in the cluster
+	SchemaDriftMissingType SchemaDriftKind = "missing type"
+	// SchemaDriftTypeFields is a node type with different fields
+	SchemaDriftTypeFields SchemaDriftKind = "type fields"
+)
+
+// SchemaDrift is a difference between the cluster schema and the schema of the models
+type SchemaDrift struct {
+	Kind SchemaDriftKind
+	// Name is the predicate or the type name
+	Name string
+	// NodeType is the node type defining the predicate, in alphabetical order when defined by multiple types
+	NodeType string
+	// Existing is the cluster schema, empty when missing
+	Existing string
+	// Defined is the schema of the models
+	Defined string
+}
+
+func (d SchemaDrift) String() string {
+	if d.Existing == "" {
+		return fmt.Sprintf("%s %s: %s", d.Kind, d.Name, d.Defined)
+	}
+	return fmt.Sprintf("%s drift on %s: existing \"%s\", defined \"%s\"", d.Kind, d.Name, d.Existing, d.Defined)
+}
+
+// SchemaDriftError is returned by SchemaDiff.Err when the cluster schema drifted from the models
+type SchemaDriftError struct {
+	Drifts []SchemaDrift
+}
+
+func (e *SchemaDriftError) Error() string {
+	drifts := make([]string, len(e.Drifts))
+	for i, drift := range e.Drifts {
+		drifts[i] = drift.String()
+	}
+	return strings.Join(drifts, "; ")
+}
+
+// SchemaDiff reports the differences between the cluster schema and the schema of the models
+type SchemaDiff struct {
+	Drifts []SchemaDrift
+}
+
+// HasDrift returns whether the cluster schema differs from the models
+func (d *SchemaDiff) HasDrift() bool {
+	return len(d.Drifts) > 0
+}
+
+// Err returns a SchemaDriftError when the cluster schema differs from the models
+func (d *SchemaDiff) Err() error {
+	if !d.HasDrift() {
+		return nil
+	}
+	return &SchemaDriftError{Drifts: d.Drifts}
+}
+
+func (d *SchemaDiff) String() string {
+	var buffer strings.Builder
+	for _, drift := range d.Drifts {
+		buffer.WriteString(drift.String())
+		buffer.WriteByte('\n')
+	}
+	return buffer.String()
+}
+
+// DiffSchema compares the schema of the models against the cluster schema without altering it,
+// reporting predicates and types of the models which are missing or differ in the cluster,
+// e.g: index, type, and @reverse changes. Predicates in the cluster not defined in the models are not reported.
+func DiffSchema(c DgraphClient, models ...interface{}) (*SchemaDiff, error) {
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", models...)
+
+	existingSchema, err := fetchExistingSchema(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch existing schema failed")
+	}
+
+	existingTypes, err := fetchExistingTypes(c, typeSchema.Types)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch existing types failed")
+	}
+
+	return diffSchema(typeSchema, existingSchema, existingTypes), nil
+}
+
+func diffSchema(typeSchema *TypeSchema, existingSchema []*Schema, existingTypes TypeMap) *SchemaDiff {
+	diff := &SchemaDiff{}
+
+	existingMap := make(SchemaMap, len(existingSchema))
+	for _, schema := range existingSchema {
+		existingMap[schema.Predicate] = schema
+	}
+
+	predicates := make([]string, 0, len(typeSchema.Schema))
+	for predicate := range typeSchema.Schema {
+		predicates = append(predicates, predicate)
+	}
+	sort.Strings(predicates)
+
+	for _, predicate := range predicates {
+		schema := typeSchema.Schema[predicate]
+		drift := SchemaDrift{
+			Name:     predicate,
+			NodeType: predicateNodeType(typeSchema.Types, predicate),
+			Defined:  schema.String(),
+		}
+
+		existing, ok := existingMap[predicate]
+		if !ok {
+			drift.Kind = SchemaDriftMissingPredicate
+			diff.Drifts = append(diff.Drifts, drift)
+			continue
+		}
+		drift.Existing = existing.String()
+
+		normalizedExisting, normalized := normalizeSchema(existing), normalizeSchema(schema)
+		if normalizedExisting.Type != normalized.Type || normalizedExisting.List != normalized.List {
+			drift.Kind = SchemaDriftType
+			diff.Drifts = append(diff.Drifts, drift)
+		}
+		if strings.Join(normalizedExisting.Tokenizer, ",") != strings.Join(normalized.Tokenizer, ",") {
+			drift.Kind = SchemaDriftIndex
+			diff.Drifts = append(diff.Drifts, drift)
+		}
+		if normalizedExisting.Reverse != normalized.Reverse {
+			drift.Kind = SchemaDriftReverse
+			diff.Drifts = append(diff.Drifts, drift)
+		}
+		if normalizedExisting.Count != normalized.Count ||
+			normalizedExisting.Upsert != normalized.Upsert ||
+			normalizedExisting.Lang != normalized.Lang ||
+			normalizedExisting.Noconflict != normalized.Noconflict {
+			drift.Kind = SchemaDriftDirectives
+			diff.Drifts = append(diff.Drifts, drift)
+		}
+	}
+
+	types := make([]string, 0, len(typeSchema.Types))
+	for nodeType := range typeSchema.Types {
+		types = append(types, nodeType)
+	}
+	sort.Strings(types)
+
+	for _, nodeType := range types {
+		drift := SchemaDrift{Name: nodeType, NodeType: nodeType, Defined: typeFields(typeSchema.Types[nodeType])}
+		existing, ok := existingTypes[nodeType]
+		if !ok {
+			drift.Kind = SchemaDriftMissingType
+			diff.Drifts = append(diff.Drifts, drift)
+			continue
+		}
+		if drift.Existing = typeFields(existing); drift.Existing != drift.Defined {
+			drift.Kind = SchemaDriftTypeFields
+			diff.Drifts = append(diff.Drifts, drift)
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type DriftProduct struct {
+	UID    string       `json:"uid,omitempty"`
+	Name   string       `json:"name,omitempty" dgraph:"index=term"`
+	Price  int          `json:"price,omitempty" dgraph:"index=int"`
+	Sku    string       `json:"sku,omitempty" dgraph:"index=exact unique"`
+	Seller *DriftSeller `json:"seller,omitempty" dgraph:"reverse"`
+	DType  []string     `json:"dgraph.type"`
+}
+
+type DriftSeller struct {
+	UID   string   `json:"uid,omitempty"`
+	Title string   `json:"title,omitempty"`
+	DType []string `json:"dgraph.type"`
+}
+
+func TestDiffSchema(t *testing.T) {
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", DriftProduct{})
+
+	existingSchema := []*Schema{
+		{Predicate: "dgraph.type", Type: "string", List: true, Index: true, Tokenizer: []string{"exact"}},
+		{Predicate: "name", Type: "string", Index: true, Tokenizer: []string{"hash"}},
+		{Predicate: "price", Type: "float", Index: true, Tokenizer: []string{"float"}},
+		{Predicate: "sku", Type: "string", Index: true, Tokenizer: []string{"exact"}},
+		{Predicate: "seller", Type: "uid"},
+		{Predicate: "legacy", Type: "string"},
+	}
+	existingTypes := TypeMap{
+		"DriftProduct": SchemaMap{"name": nil, "price": nil, "sku": nil},
+	}
+
+	diff := diffSchema(typeSchema, existingSchema, existingTypes)
+	require.True(t, diff.HasDrift())
+	assert.Equal(t, []SchemaDrift{
+		{
+			Kind:     SchemaDriftIndex,
+			Name:     "name",
+			NodeType: "DriftProduct",
+			Existing: "name: string @index(hash) .",
+			Defined:  "name: string @index(term) .",
+		},
+		{
+			Kind:     SchemaDriftType,
+			Name:     "price",
+			NodeType: "DriftProduct",
+			Existing: "price: float @index(float) .",
+			Defined:  "price: int @index(int) .",
+		},
+		{
+			Kind:     SchemaDriftIndex,
+			Name:     "price",
+			NodeType: "DriftProduct",
+			Existing: "price: float @index(float) .",
+			Defined:  "price: int @index(int) .",
+		},
+		{
+			Kind:     SchemaDriftReverse,
+			Name:     "seller",
+			NodeType: "DriftProduct",
+			Existing: "seller: uid .",
+			Defined:  "seller: uid @reverse .",
+		},
+		{
+			Kind:     SchemaDriftDirectives,
+			Name:     "sku",
+			NodeType: "DriftProduct",
+			Existing: "sku: string @index(exact) .",
+			Defined:  "sku: string @index(exact) @upsert .",
+		},
+		{
+			Kind:     SchemaDriftMissingPredicate,
+			Name:     "title",
+			NodeType: "DriftSeller",
+			Defined:  "title: string .",
+		},
+		{
+			Kind:     SchemaDriftTypeFields,
+			Name:     "DriftProduct",
+			NodeType: "DriftProduct",
+			Existing: "{ name price sku }",
+			Defined:  "{ name price seller sku }",
+		},
+		{
+			Kind:     SchemaDriftMissingType,
+			Name:     "DriftSeller",
+			NodeType: "DriftSeller",
+			Defined:  "{ title }",
+		},
+	}, diff.Drifts)
+
+	err := diff.Err()
+	assert.IsType(t, &SchemaDriftError{}, err)
+	assert.Contains(t, err.Error(), `reverse drift on seller: existing "seller: uid .", defined "seller: uid @reverse ."`)
+	assert.Contains(t, err.Error(), "missing type DriftSeller: { title }")
+}
+
+func TestDiffSchemaNoDrift(t *testing.T) {
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", DriftSeller{})
+
+	existingSchema := []*Schema{
+		{Predicate: "title", Type: "string"},
+		{Predicate: "legacy", Type: "string"},
+	}
+	existingTypes := TypeMap{"DriftSeller": SchemaMap{"title": nil}}
+
+	diff := diffSchema(typeSchema, existingSchema, existingTypes)
+	assert.False(t, diff.HasDrift())
+	assert.NoError(t, diff.Err())
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"github.com/dgraph-io/dgo/v210/protos/api"
+)
+
+// MutationPlan is the request a mutation would send, as returned by DryRun
+type MutationPlan struct {
+	// Query is the query block of the upsert request, e.g: unique checks, empty when there are none
+	Query string
+	// Mutations are the mutations of the request
+	Mutations []PlannedMutation
+	CommitNow bool
+	// Request is the request as it would be sent
+	Request *api.Request
+}
+
+// PlannedMutation is a mutation of a mutation plan
+type PlannedMutation struct {
+	// Cond is the condition of the mutation, e.g: @if(eq(len(q_0), 0))
+	Cond string
+	// SetJSON is the JSON payload of the set mutation
+	SetJSON string
+	// SetNquads are the RDF n-quads of the set mutation, when mutating as n-quads
+	SetNquads string
+	// DelNquads are the RDF n-quads of the delete mutation, e.g: existing one-to-one edges
+	DelNquads string
+}
+
+// DryRun generates the request of a mutation with the optional mutate options, as in MutateWithOptions,
+// returning the mutation plan without sending the request. The nodes are validated, and BeforeMutate hooks
+// are called. The data is modified as on a mutation, i.e: node types and blank node uids are set.
+func (t *TxnContext) DryRun(data interface{}, opts ...MutateOptions) (*MutationPlan, error) {
+	var options MutateOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	mutation, err := newMutation(t, data, options)
+	if err != nil {
+		return nil, err
+	}
+	return mutation.plan()
+}
+
+func (m *mutation) plan() (*MutationPlan, error) {
+	if err := m.txn.hooks.beforeMutate(m.txn.ctx, m.opcode.hookOp(), m.data); err != nil {
+		return nil, err
+	}
+	if !m.skipValidate {
+		if err := Validate(m.data); err != nil {
+			return nil, err
+		}
+	}
+
+	request := &m.request
+	if m.opcode == mutationMutateBasic {
+		mu, err := m.generateBasicMutation()
+		if err != nil {
+			return nil, err
+		}
+		// as sent by the dgo transaction
+		request = &api.Request{Mutations: []*api.Mutation{mu}, CommitNow: mu.CommitNow}
+	} else if err := m.generateRequest(); err != nil {
+		return nil, err
+	}
+
+	plan := &MutationPlan{
+		Query:     request.Query,
+		CommitNow: request.CommitNow,
+		Request:   request,
+	}
+	for _, mu := range request.Mutations {
+		plan.Mutations = append(plan.Mutations, PlannedMutation{
+			Cond:      mu.Cond,
+			SetJSON:   string(mu.SetJson),
+			SetNquads: string(mu.SetNquads),
+			DelNquads: string(mu.DelNquads),
+		})
+	}
+	return plan, nil
+}
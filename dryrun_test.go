@@ -0,0 +1,85 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	updatedAt := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	user := ConditionalUser{UID: "0x1", Email: "bob@example.com", UpdatedAt: updatedAt}
+	plan, err := tx.DryRun(&user)
+	require.NoError(t, err)
+	assert.Empty(t, fake.requests)
+
+	assert.Equal(t, `{
+	q_0x1_1(func: type(ConditionalUser), first: 1) @filter(NOT uid(0x1) AND eq(email, "bob@example.com") AND type(ConditionalUser)) {
+		u_0x1_1 as uid
+	}
+	var(func: uid(0x1)) @filter(NOT (lt(updated_at, "2021-06-01T00:00:00Z"))) {
+		f_0x1 as uid
+	}
+}`, plan.Query)
+	require.Len(t, plan.Mutations, 1)
+	assert.Equal(t, "@if(eq(len(u_0x1_1), 0) AND eq(len(f_0x1), 0))", plan.Mutations[0].Cond)
+	assert.JSONEq(t, `{
+		"uid": "0x1",
+		"email": "bob@example.com",
+		"updated_at": "2021-06-01T00:00:00Z",
+		"dgraph.type": ["ConditionalUser"]
+	}`, plan.Mutations[0].SetJSON)
+	assert.Empty(t, plan.Mutations[0].DelNquads)
+
+	// the plan request is the request sent by the mutation
+	_, err = tx.Mutate(&ConditionalUser{UID: "0x1", Email: "bob@example.com", UpdatedAt: updatedAt})
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+	assert.Equal(t, fake.requests[0], plan.Request)
+}
+
+func TestDryRunBasic(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	plan, err := tx.DryRun(&ConditionalUser{UID: "0x1", Email: "bob@example.com"}, MutateOptions{
+		SkipUnique: true,
+		CommitNow:  true,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, fake.requests)
+
+	assert.Empty(t, plan.Query)
+	assert.True(t, plan.CommitNow)
+	require.Len(t, plan.Mutations, 1)
+	assert.Empty(t, plan.Mutations[0].Cond)
+	assert.JSONEq(t, `{"uid":"0x1","email":"bob@example.com","updated_at":"0001-01-01T00:00:00Z","dgraph.type":["ConditionalUser"]}`,
+		plan.Mutations[0].SetJSON)
+}
+
+func TestDryRunValidation(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	_, err := tx.DryRun(&ValidatedUser{})
+	assert.Error(t, err)
+	assert.Empty(t, fake.requests)
+}
@@ -0,0 +1,355 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EdgeQuery filters, orders, and paginates the nodes of an edge in the query expansion
+type EdgeQuery struct {
+	// Filter filters the edge nodes, validated against the schema tags of the edge node type
+	Filter *Filter
+	// First returns the first n edge nodes
+	First int
+	// Offset skips the first n edge nodes
+	Offset int
+	// After returns the edge nodes after a uid
+	After string
+	// OrderAsc orders the edge nodes ascending by a predicate
+	OrderAsc string
+	// OrderDesc orders the edge nodes descending by a predicate
+	OrderDesc string
+	// FacetFilter filters the edge nodes by the facets of the edge, validated against the facet fields of the edge node type
+	FacetFilter *Filter
+	// FacetOrderAsc orders the edge nodes ascending by a facet of the edge
+	FacetOrderAsc string
+	// FacetOrderDesc orders the edge nodes descending by a facet of the edge
+	FacetOrderDesc string
+}
+
+type edgeQuery struct {
+	EdgeQuery
+	filter      string
+	facetFilter string
+	// facets are the facets of the edge defined on the edge node type, returned with the edge nodes
+	facets []string
+}
+
+// hasFacets returns whether the edge query filters or orders by facets
+func (e *edgeQuery) hasFacets() bool {
+	return e.facetFilter != "" || e.FacetOrderAsc != "" || e.FacetOrderDesc != ""
+}
+
+// write writes the pagination arguments and filter of the edge
+func (e *edgeQuery) write(buffer *strings.Builder) {
+	var args []string
+	if e.First != 0 {
+		args = append(args, fmt.Sprintf("first: %d", e.First))
+	}
+	if e.Offset != 0 {
+		args = append(args, fmt.Sprintf("offset: %d", e.Offset))
+	}
+	if e.After != "" {
+		args = append(args, "after: "+e.After)
+	}
+	if e.OrderAsc != "" {
+		args = append(args, "orderasc: "+e.OrderAsc)
+	}
+	if e.OrderDesc != "" {
+		args = append(args, "orderdesc: "+e.OrderDesc)
+	}
+	if len(args) > 0 {
+		buffer.WriteString(" (")
+		buffer.WriteString(strings.Join(args, ", "))
+		buffer.WriteString(")")
+	}
+	if e.facetFilter != "" {
+		buffer.WriteString(" @facets(")
+		buffer.WriteString(e.facetFilter)
+		buffer.WriteString(")")
+	}
+	if e.hasFacets() {
+		e.writeFacets(buffer)
+	}
+	if e.filter != "" {
+		buffer.WriteString(" @filter(")
+		buffer.WriteString(e.filter)
+		buffer.WriteString(")")
+	}
+}
+
+// writeFacets writes the facets returned with the edge nodes, including the facet ordering
+func (e *edgeQuery) writeFacets(buffer *strings.Builder) {
+	var facets []string
+	switch {
+	case e.FacetOrderAsc != "":
+		facets = append(facets, "orderasc: "+e.FacetOrderAsc)
+	case e.FacetOrderDesc != "":
+		facets = append(facets, "orderdesc: "+e.FacetOrderDesc)
+	}
+	for _, facet := range e.facets {
+		if facet != e.FacetOrderAsc && facet != e.FacetOrderDesc {
+			facets = append(facets, facet)
+		}
+	}
+	buffer.WriteString(" @facets(")
+	buffer.WriteString(strings.Join(facets, ", "))
+	buffer.WriteString(")")
+}
+
+// Edge filters, orders, and paginates the nodes of an edge in the query expansion,
+// nested edges are specified by a dot separated path of predicates, e.g: "schools.teachers".
+// Edge nodes can be filtered and ordered by the facets of the edge, defined as "edge|facet" fields
+// of the edge node type, the facets are returned with the edge nodes.
+// The predicates of node types are listed from the model instead of using expand(_all_),
+// edges without an edge query are expanded as in All. Edge should be called before All.
+func (q *Query) Edge(path string, edge EdgeQuery) *Query {
+	if q.model == nil {
+		q.err = errors.New("edge queries require a model")
+		return q
+	}
+	edgeType, err := resolveEdgePath(reflect.TypeOf(q.model), path)
+	if err != nil {
+		q.err = err
+		return q
+	}
+
+	built := &edgeQuery{EdgeQuery: edge}
+	if edge.Filter != nil {
+		if err := edge.Filter.Validate(reflect.New(edgeType).Interface()); err != nil {
+			q.err = errors.Wrapf(err, "invalid filter on edge %s", path)
+			return q
+		}
+		if built.filter, err = edge.Filter.Build(); err != nil {
+			q.err = errors.Wrapf(err, "invalid filter on edge %s", path)
+			return q
+		}
+	}
+	if err := built.setFacets(path, edgeType); err != nil {
+		q.err = err
+		return q
+	}
+
+	if q.edges == nil {
+		q.edges = make(map[string]*edgeQuery)
+	}
+	q.edges[path] = built
+	return q
+}
+
+// ReverseEdge filters, orders, and paginates the nodes of a reverse edge in the query expansion, as in Edge,
+// e.g: q.ReverseEdge("~in_department", EdgeQuery{First: 10}), so large sets of child nodes are not pulled at once.
+// The reverse edge must be a field of the model, with the predicate defined with @reverse in the schema.
+func (q *Query) ReverseEdge(path string, edge EdgeQuery) *Query {
+	predicates := strings.Split(path, ".")
+	if predicate := predicates[len(predicates)-1]; !strings.HasPrefix(predicate, "~") {
+		q.err = fmt.Errorf("%s is not a reverse edge", predicate)
+		return q
+	}
+	return q.Edge(path, edge)
+}
+
+// setFacets validates and builds the facet filter and ordering of the edge,
+// against the facets of the edge defined on the edge node type
+func (e *edgeQuery) setFacets(path string, edgeType reflect.Type) error {
+	if e.FacetFilter == nil && e.FacetOrderAsc == "" && e.FacetOrderDesc == "" {
+		return nil
+	}
+	if e.FacetOrderAsc != "" && e.FacetOrderDesc != "" {
+		return fmt.Errorf("edge %s cannot be ordered both ascending and descending by facets", path)
+	}
+
+	predicates := strings.Split(path, ".")
+	e.facets = edgeFacets(edgeType, predicates[len(predicates)-1])
+	defined := newSet(e.facets...)
+	for _, facet := range []string{e.FacetOrderAsc, e.FacetOrderDesc} {
+		if facet != "" && !defined.Has(facet) {
+			return fmt.Errorf("%s is not a facet of edge %s", facet, path)
+		}
+	}
+	if e.FacetFilter != nil {
+		if err := e.FacetFilter.validateFacets(defined); err != nil {
+			return errors.Wrapf(err, "invalid facet filter on edge %s", path)
+		}
+		var err error
+		if e.facetFilter, err = e.FacetFilter.Build(); err != nil {
+			return errors.Wrapf(err, "invalid facet filter on edge %s", path)
+		}
+	}
+	return nil
+}
+
+// edgeFacets returns the facets of an edge predicate defined on the edge node type, as "edge|facet" fields
+func edgeFacets(edgeType reflect.Type, edge string) []string {
+	var facets []string
+	for _, field := range modelFields(edgeType) {
+		predicate, _ := getPredicate(&field)
+		if strings.HasPrefix(predicate, edge+"|") {
+			facets = append(facets, predicate[len(edge)+1:])
+		}
+	}
+	return facets
+}
+
+// modelFields returns the fields of a model type, including the fields of anonymous structs
+func modelFields(modelType reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && field.Anonymous {
+			fields = append(fields, modelFields(fieldType)...)
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// edgeNodeType returns the node type of an edge field, or nil when the field is not an edge
+func edgeNodeType(field reflect.StructField) reflect.Type {
+	fieldType := getElemType(field.Type)
+	if fieldType.Kind() != reflect.Struct || !isNodeType(fieldType) {
+		return nil
+	}
+	return fieldType
+}
+
+// resolveEdgePath returns the node type of the edge at a dot separated path of predicates
+func resolveEdgePath(modelType reflect.Type, path string) (reflect.Type, error) {
+	current := getElemType(modelType)
+	for _, predicate := range strings.Split(path, ".") {
+		var next reflect.Type
+		if current.Kind() == reflect.Struct {
+			for _, field := range modelFields(current) {
+				if fieldPredicate, _ := getPredicate(&field); fieldPredicate == predicate {
+					next = edgeNodeType(field)
+					break
+				}
+			}
+		}
+		if next == nil {
+			return nil, fmt.Errorf("%s is not an edge of %s", predicate, current.Name())
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// EdgeDepth overrides the expansion depth of an edge, see Depth
+type EdgeDepth struct {
+	Path  string
+	Depth int
+}
+
+// Depth overrides the expansion depth of an edge in AllDepth, nested edges are specified
+// by a dot separated path of predicates, e.g: "friends.friends". The depth counts from the edge,
+// so Depth("friends", 3) expands friends as All(3) would, and Depth("friends", 0) excludes it.
+func Depth(path string, depth int) EdgeDepth {
+	return EdgeDepth{Path: path, Depth: depth}
+}
+
+// hasNestedEdges returns whether there are edge queries or edge depths nested under the edge path
+func (q *Query) hasNestedEdges(path string) bool {
+	for edgePath := range q.edges {
+		if strings.HasPrefix(edgePath, path+".") {
+			return true
+		}
+	}
+	return q.hasNestedDepths(path)
+}
+
+// hasNestedDepths returns whether there are edge depths nested under the edge path
+func (q *Query) hasNestedDepths(path string) bool {
+	for edgePath := range q.depths {
+		if strings.HasPrefix(edgePath, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// expandEdges expands the predicates of a node type up to a depth, applying the edge queries and edge depths
+func (q *Query) expandEdges(buffer *strings.Builder, modelType reflect.Type, depth int, path string) {
+	buffer.WriteString("{\n\t\tuid\n\t\tdgraph.type")
+	for _, field := range modelFields(modelType) {
+		predicate, _ := getPredicate(&field)
+		if predicate == "" || predicate == "-" || predicate == predicateUid ||
+			predicate == predicateDgraphType || isFacet(predicate) {
+			continue
+		}
+
+		edgePath := predicate
+		if path != "" {
+			edgePath = path + "." + predicate
+		}
+		edgeDepth := depth
+		if d, ok := q.depths[edgePath]; ok {
+			edgeDepth = d
+		}
+
+		if getElemType(field.Type) == nodeRefType {
+			// polymorphic edges are expanded by the predicates of each node type
+			if edgeDepth > 0 {
+				buffer.WriteString("\n\t\t")
+				buffer.WriteString(predicate)
+				buffer.WriteString(" ")
+				buffer.WriteString(expandAll(edgeDepth - 1))
+			}
+			continue
+		}
+
+		fieldEdgeType := edgeNodeType(field)
+		if fieldEdgeType == nil {
+			buffer.WriteString("\n\t\t")
+			buffer.WriteString(predicate)
+			continue
+		}
+
+		edge, hasEdgeQuery := q.edges[edgePath]
+		if !hasEdgeQuery && edgeDepth <= 0 && !q.hasNestedDepths(edgePath) {
+			continue
+		}
+
+		buffer.WriteString("\n\t\t")
+		buffer.WriteString(predicate)
+		if reverse := reverseOf(field.Tag.Get(tagName)); reverse != "" {
+			// reverse edge fields are aliased reverse predicates, e.g: parent: ~children
+			buffer.WriteString(": ~")
+			buffer.WriteString(reverse)
+		}
+		if hasEdgeQuery {
+			edge.write(buffer)
+		}
+		buffer.WriteString(" ")
+		if q.hasNestedEdges(edgePath) {
+			q.expandEdges(buffer, fieldEdgeType, edgeDepth-1, edgePath)
+		} else {
+			buffer.WriteString(expandAll(edgeDepth - 1))
+		}
+	}
+	buffer.WriteString("\n\t}")
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// HasEdge checks whether a node has an edge to a target node, e.g: whether a user is a member of a group,
+// with a query counting the edge to the target instead of fetching the node.
+// Reverse edges can be checked with a ~predicate.
+func (t *TxnContext) HasEdge(uid, predicate, targetUID string) (bool, error) {
+	if err := validateUIDs(targetUID); err != nil {
+		return false, errors.Wrap(err, "invalid target")
+	}
+	count, err := t.countEdge(uid, predicate, fmt.Sprintf(" @filter(uid(%s))", targetUID))
+	return count > 0, err
+}
+
+// CountEdge counts the edges of a predicate of a node, with a query counting the edges
+// instead of fetching the node. Reverse edges can be counted with a ~predicate.
+func (t *TxnContext) CountEdge(uid, predicate string) (int, error) {
+	return t.countEdge(uid, predicate, "")
+}
+
+func (t *TxnContext) countEdge(uid, predicate, filter string) (int, error) {
+	if err := validateUIDs(uid); err != nil {
+		return 0, err
+	}
+	if !predicateRegex.MatchString(predicate) || isFacet(predicate) {
+		return 0, fmt.Errorf("invalid edge predicate %q", predicate)
+	}
+
+	query := fmt.Sprintf("{\n\tdata(func: uid(%s)) {\n\t\tcount: count(%s%s)\n\t}\n}", uid, predicate, filter)
+	resp, err := sendQuery(t.ctx, t.txn, t.hooks, query, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "count edge query failed")
+	}
+
+	var result struct {
+		Data []struct {
+			Count int `json:"count"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Json, &result); err != nil {
+		return 0, errors.Wrapf(err, `unmarshal queryResponse "%s"`, resp.Json)
+	}
+	if len(result.Data) == 0 {
+		return 0, nil
+	}
+	return result.Data[0].Count, nil
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasEdge(t *testing.T) {
+	tx, fake := newFakeTxnContext(
+		&api.Response{Json: []byte(`{"data":[{"count":1}]}`)},
+		&api.Response{Json: []byte(`{"data":[{"count":0}]}`)},
+	)
+
+	member, err := tx.HasEdge("0x1", "groups", "0x2")
+	require.NoError(t, err)
+	assert.True(t, member)
+	assert.Equal(t, `{
+	data(func: uid(0x1)) {
+		count: count(groups @filter(uid(0x2)))
+	}
+}`, fake.requests[0].Query)
+
+	member, err = tx.HasEdge("0x1", "~members", "0x3")
+	require.NoError(t, err)
+	assert.False(t, member)
+
+	_, err = tx.HasEdge("0x1", "groups", "uid(x)")
+	assert.Error(t, err)
+	_, err = tx.HasEdge("0x1", "groups", "0x2)) } }")
+	assert.Error(t, err)
+	_, err = tx.HasEdge("0x1", "groups|role", "0x2")
+	assert.Error(t, err)
+	assert.Len(t, fake.requests, 2)
+}
+
+func TestCountEdge(t *testing.T) {
+	tx, fake := newFakeTxnContext(
+		&api.Response{Json: []byte(`{"data":[{"count":3}]}`)},
+		&api.Response{Json: []byte(`{"data":[]}`)},
+	)
+
+	count, err := tx.CountEdge("0x1", "friends")
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Contains(t, fake.requests[0].Query, "count: count(friends)")
+
+	count, err = tx.CountEdge("0x9", "friends")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	_, err = tx.CountEdge("_:x", "friends")
+	assert.Error(t, err)
+	assert.Len(t, fake.requests, 2)
+}
@@ -0,0 +1,261 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type EdgeStudent struct {
+	UID     string        `json:"uid,omitempty"`
+	Name    string        `json:"name,omitempty" dgraph:"index=term"`
+	Schools []*EdgeSchool `json:"schools,omitempty"`
+	DType   []string      `json:"dgraph.type,omitempty"`
+}
+
+type EdgeSchool struct {
+	UID      string         `json:"uid,omitempty"`
+	Name     string         `json:"name,omitempty" dgraph:"index=term"`
+	Rank     int            `json:"rank,omitempty" dgraph:"index=int"`
+	Teachers []*EdgeTeacher `json:"teachers,omitempty"`
+	DType    []string       `json:"dgraph.type,omitempty"`
+}
+
+type EdgeTeacher struct {
+	UID   string   `json:"uid,omitempty"`
+	Name  string   `json:"name,omitempty"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+func TestQueryEdge(t *testing.T) {
+	tx, _ := newFakeTxnContext()
+
+	query := tx.Get(&[]EdgeStudent{}).
+		Edge("schools", EdgeQuery{Filter: Ge("rank", 5), First: 5, OrderAsc: "rank"}).
+		Edge("schools.teachers", EdgeQuery{First: 2}).
+		All(2)
+	require.NoError(t, query.err)
+	assert.Equal(t, `{
+	data(func: type(EdgeStudent)) @filter(has(dgraph.type)) {
+		uid
+		dgraph.type
+		name
+		schools (first: 5, orderasc: rank) @filter(ge(rank, 5)) {
+			uid
+			dgraph.type
+			name
+			rank
+			teachers (first: 2) {
+				uid
+				dgraph.type
+				expand(_all_)
+			}
+		}
+	}
+}`, query.String())
+}
+
+func TestQueryEdgeDefaultExpansion(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{Json: []byte(`{"data": []}`)})
+
+	var students []EdgeStudent
+	err := tx.Get(&students).
+		Edge("schools", EdgeQuery{Offset: 10}).
+		Nodes()
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+	assert.Contains(t, fake.requests[0].Query, "schools (offset: 10) {")
+}
+
+func TestQueryEdgeInvalid(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	var students []EdgeStudent
+	err := tx.Get(&students).Edge("name", EdgeQuery{First: 1}).Nodes()
+	assert.EqualError(t, err, "name is not an edge of EdgeStudent")
+
+	err = tx.Get(&students).Edge("schools.unknown", EdgeQuery{First: 1}).Nodes()
+	assert.EqualError(t, err, "unknown is not an edge of EdgeSchool")
+
+	// rank is not a predicate of teachers
+	err = tx.Get(&students).Edge("schools.teachers", EdgeQuery{Filter: Eq("rank", 1)}).Nodes()
+	assert.Error(t, err)
+	assert.Empty(t, fake.requests)
+}
+
+type DepthPerson struct {
+	UID     string         `json:"uid,omitempty"`
+	Name    string         `json:"name,omitempty"`
+	Friends []*DepthPerson `json:"friends,omitempty"`
+	Schools []*EdgeSchool  `json:"schools,omitempty"`
+	DType   []string       `json:"dgraph.type,omitempty"`
+}
+
+func TestQueryAllDepth(t *testing.T) {
+	tx, _ := newFakeTxnContext()
+
+	query := tx.Get(&[]DepthPerson{}).AllDepth(1, Depth("friends", 2), Depth("schools.teachers", 1))
+	require.NoError(t, query.err)
+	assert.Equal(t, `{
+	data(func: type(DepthPerson)) @filter(has(dgraph.type)) {
+		uid
+		dgraph.type
+		name
+		friends {
+			uid
+			dgraph.type
+			expand(_all_) {
+				uid
+				dgraph.type
+				expand(_all_)
+			}
+		}
+		schools {
+			uid
+			dgraph.type
+			name
+			rank
+			teachers {
+				uid
+				dgraph.type
+				expand(_all_)
+			}
+		}
+	}
+}`, query.String())
+
+	err := tx.Get(&[]DepthPerson{}).AllDepth(1, Depth("name", 2)).Nodes()
+	assert.EqualError(t, err, "name is not an edge of DepthPerson")
+
+	query = (&Query{}).AllDepth(1, Depth("friends", 2))
+	assert.EqualError(t, query.err, "edge depths require a model")
+}
+
+type EdgeDepartment struct {
+	UID       string          `json:"uid,omitempty"`
+	Name      string          `json:"name,omitempty" dgraph:"index=term"`
+	Employees []*EdgeEmployee `json:"~in_department,omitempty"`
+	DType     []string        `json:"dgraph.type,omitempty"`
+}
+
+type EdgeEmployee struct {
+	UID          string          `json:"uid,omitempty"`
+	Name         string          `json:"name,omitempty" dgraph:"index=term"`
+	Salary       int             `json:"salary,omitempty" dgraph:"index=int"`
+	InDepartment *EdgeDepartment `json:"in_department,omitempty" dgraph:"reverse"`
+	DType        []string        `json:"dgraph.type,omitempty"`
+}
+
+func TestQueryReverseEdge(t *testing.T) {
+	tx, _ := newFakeTxnContext()
+
+	query := tx.Get(&[]EdgeDepartment{}).
+		ReverseEdge("~in_department", EdgeQuery{Filter: Ge("salary", 5000), First: 10, Offset: 20, OrderDesc: "salary"})
+	require.NoError(t, query.err)
+	assert.Equal(t, `{
+	data(func: type(EdgeDepartment)) @filter(has(dgraph.type)) {
+		uid
+		dgraph.type
+		name
+		~in_department (first: 10, offset: 20, orderdesc: salary) @filter(ge(salary, 5000)) {
+			uid
+			dgraph.type
+			expand(_all_)
+		}
+	}
+}`, query.String())
+}
+
+func TestQueryReverseEdgeInvalid(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	var departments []EdgeDepartment
+	err := tx.Get(&departments).ReverseEdge("in_department", EdgeQuery{First: 1}).Nodes()
+	assert.EqualError(t, err, "in_department is not a reverse edge")
+
+	// reverse edges must be fields of the model
+	err = tx.Get(&departments).ReverseEdge("~schools", EdgeQuery{First: 1}).Nodes()
+	assert.EqualError(t, err, "~schools is not an edge of EdgeDepartment")
+
+	// rank is not a predicate of employees
+	err = tx.Get(&departments).ReverseEdge("~in_department", EdgeQuery{Filter: Eq("rank", 1)}).Nodes()
+	assert.Error(t, err)
+	assert.Empty(t, fake.requests)
+}
+
+type EdgePerson struct {
+	UID     string        `json:"uid,omitempty"`
+	Name    string        `json:"name,omitempty" dgraph:"index=term"`
+	Friends []*EdgePerson `json:"friends,omitempty"`
+	Weight  float64       `json:"friends|weight,omitempty"`
+	Close   bool          `json:"friends|close,omitempty"`
+	DType   []string      `json:"dgraph.type,omitempty"`
+}
+
+func TestQueryEdgeFacets(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"data":[{"uid":"0x1","name":"alice","friends":[{"uid":"0x2","name":"bob","friends|weight":0.8,"friends|close":true}]}]}`),
+	})
+
+	var people []EdgePerson
+	query := tx.Get(&people).
+		Edge("friends", EdgeQuery{FacetFilter: Eq("close", true), FacetOrderDesc: "weight", First: 3})
+	require.NoError(t, query.err)
+	assert.Equal(t, `{
+	data(func: type(EdgePerson)) @filter(has(dgraph.type)) {
+		uid
+		dgraph.type
+		name
+		friends (first: 3) @facets(eq(close, true)) @facets(orderdesc: weight, close) {
+			uid
+			dgraph.type
+			expand(_all_)
+		}
+	}
+}`, query.String())
+
+	require.NoError(t, query.Nodes())
+	require.Len(t, fake.requests, 1)
+	require.Len(t, people, 1)
+	require.Len(t, people[0].Friends, 1)
+	assert.Equal(t, 0.8, people[0].Friends[0].Weight)
+	assert.True(t, people[0].Friends[0].Close)
+}
+
+func TestQueryEdgeFacetsInvalid(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	var people []EdgePerson
+	err := tx.Get(&people).Edge("friends", EdgeQuery{FacetOrderAsc: "since"}).Nodes()
+	assert.EqualError(t, err, "since is not a facet of edge friends")
+
+	err = tx.Get(&people).Edge("friends", EdgeQuery{FacetFilter: Eq("name", "bob")}).Nodes()
+	assert.EqualError(t, err, "invalid facet filter on edge friends: facet name in eq filter is not defined")
+
+	err = tx.Get(&people).Edge("friends", EdgeQuery{FacetOrderAsc: "weight", FacetOrderDesc: "weight"}).Nodes()
+	assert.Error(t, err)
+
+	// schools have no facets defined
+	var students []EdgeStudent
+	err = tx.Get(&students).Edge("schools", EdgeQuery{FacetOrderAsc: "since"}).Nodes()
+	assert.EqualError(t, err, "since is not a facet of edge schools")
+	assert.Empty(t, fake.requests)
+}
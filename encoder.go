@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"unsafe"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// EncodeFunc encodes a field value into json, e.g: a time.Time into a unix timestamp
+type EncodeFunc func(value interface{}) ([]byte, error)
+
+// DecodeFunc decodes json into dst, a pointer to the field value
+type DecodeFunc func(data []byte, dst interface{}) error
+
+type fieldCodec struct {
+	encode EncodeFunc
+	decode DecodeFunc
+}
+
+var fieldCodecs sync.Map
+
+// RegisterEncoder registers a named json encoder and decoder, used by the fields tagged with encoder=name,
+// e.g: `dgraph:"encoder=unixtime type=int"`, so a field can be serialized differently without a global
+// jsoniter type encoder, which would affect every value of the type. Passing a nil encode removes the encoder.
+// The predicate type of an encoded field defaults to the type of the field, set type when the encoded type differs.
+func RegisterEncoder(name string, encode EncodeFunc, decode DecodeFunc) {
+	if encode == nil {
+		fieldCodecs.Delete(name)
+		return
+	}
+	fieldCodecs.Store(name, &fieldCodec{encode: encode, decode: decode})
+}
+
+func getFieldCodec(name string) (*fieldCodec, error) {
+	codec, ok := fieldCodecs.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("encoder %q is not registered", name)
+	}
+	return codec.(*fieldCodec), nil
+}
+
+// encoderExtension replaces the json encoders and decoders of the fields tagged with an encoder,
+// the registered encoder is looked up on each call, as jsoniter caches the field encoders
+type encoderExtension struct {
+	jsoniter.DummyExtension
+}
+
+func (*encoderExtension) UpdateStructDescriptor(structDescriptor *jsoniter.StructDescriptor) {
+	for _, binding := range structDescriptor.Fields {
+		dgraphTag := binding.Field.Tag().Get(tagName)
+		if dgraphTag == "" {
+			continue
+		}
+		props, err := parseStructTag(dgraphTag)
+		if err != nil || props.Encoder == "" {
+			continue
+		}
+		fieldType := binding.Field.Type().Type1()
+		binding.Encoder = &fieldEncoder{name: props.Encoder, fieldType: fieldType, encoder: binding.Encoder}
+		binding.Decoder = &fieldDecoder{name: props.Encoder, fieldType: fieldType}
+	}
+}
+
+type fieldEncoder struct {
+	name      string
+	fieldType reflect.Type
+	// encoder is the default encoder of the field, to check omitempty
+	encoder jsoniter.ValEncoder
+}
+
+func (e *fieldEncoder) IsEmpty(ptr unsafe.Pointer) bool {
+	return e.encoder.IsEmpty(ptr)
+}
+
+func (e *fieldEncoder) Encode(ptr unsafe.Pointer, stream *jsoniter.Stream) {
+	codec, err := getFieldCodec(e.name)
+	if err != nil {
+		stream.Error = err
+		return
+	}
+	value := reflect.NewAt(e.fieldType, ptr).Elem()
+	if value.Kind() == reflect.Ptr && value.IsNil() {
+		stream.WriteNil()
+		return
+	}
+	data, err := codec.encode(value.Interface())
+	if err != nil {
+		stream.Error = fmt.Errorf("encoder %s failed: %v", e.name, err)
+		return
+	}
+	stream.WriteRaw(string(data))
+}
+
+type fieldDecoder struct {
+	name      string
+	fieldType reflect.Type
+}
+
+func (d *fieldDecoder) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
+	data := iter.SkipAndReturnBytes()
+	if iter.Error != nil || string(data) == "null" {
+		return
+	}
+	codec, err := getFieldCodec(d.name)
+	if err != nil {
+		iter.ReportError("decode "+d.name, err.Error())
+		return
+	}
+	if codec.decode == nil {
+		iter.ReportError("decode "+d.name, "encoder has no decoder")
+		return
+	}
+	if err := codec.decode(data, reflect.NewAt(d.fieldType, ptr).Interface()); err != nil {
+		iter.ReportError("decode "+d.name, err.Error())
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type EncodedEvent struct {
+	UID       string     `json:"uid,omitempty"`
+	Name      string     `json:"name,omitempty"`
+	CreatedAt time.Time  `json:"created_at" dgraph:"encoder=unixtime type=int"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" dgraph:"encoder=unixtime type=int"`
+	UpdatedAt time.Time  `json:"updated_at,omitempty"`
+	DType     []string   `json:"dgraph.type,omitempty"`
+}
+
+func registerUnixTime() {
+	RegisterEncoder("unixtime", func(value interface{}) ([]byte, error) {
+		switch value := value.(type) {
+		case time.Time:
+			return []byte(strconv.FormatInt(value.Unix(), 10)), nil
+		case *time.Time:
+			return []byte(strconv.FormatInt(value.Unix(), 10)), nil
+		}
+		return nil, nil
+	}, func(data []byte, dst interface{}) error {
+		unix, err := strconv.ParseInt(string(data), 10, 64)
+		if err != nil {
+			return err
+		}
+		switch dst := dst.(type) {
+		case *time.Time:
+			*dst = time.Unix(unix, 0).UTC()
+		case **time.Time:
+			value := time.Unix(unix, 0).UTC()
+			*dst = &value
+		}
+		return nil
+	})
+}
+
+func TestEncoder(t *testing.T) {
+	registerUnixTime()
+	defer RegisterEncoder("unixtime", nil, nil)
+
+	createdAt := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	event := EncodedEvent{Name: "launch", CreatedAt: createdAt, UpdatedAt: createdAt}
+	data, err := json.Marshal(&event)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"launch","created_at":1609556645,"updated_at":"2021-01-02T03:04:05Z"}`, string(data))
+
+	tx, _ := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"data":[{"uid":"0x1","name":"launch","created_at":1609556645,"expires_at":1609556705}]}`),
+	})
+	var result EncodedEvent
+	require.NoError(t, tx.Get(&result).UID("0x1").Node())
+	assert.Equal(t, createdAt, result.CreatedAt)
+	require.NotNil(t, result.ExpiresAt)
+	assert.Equal(t, createdAt.Add(time.Minute), *result.ExpiresAt)
+}
+
+func TestEncoderNotRegistered(t *testing.T) {
+	_, err := json.Marshal(&EncodedEvent{Name: "launch"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `encoder "unixtime" is not registered`)
+}
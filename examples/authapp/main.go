@@ -23,7 +23,7 @@ func newDgraphClient() *dgo.Dgraph {
 
 func newApi(dgoClient *dgo.Dgraph) *userAPI {
 	return &userAPI{
-		store: &userStore{c: dgoClient},
+		store: newUserStore(dgoClient),
 	}
 }
 
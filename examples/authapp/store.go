@@ -40,11 +40,16 @@ type UserStore interface {
 }
 
 type userStore struct {
-	c *dgo.Dgraph
+	c     *dgo.Dgraph
+	users *dgman.Repository[User]
+}
+
+func newUserStore(c *dgo.Dgraph) *userStore {
+	return &userStore{c: c, users: dgman.NewRepository[User](c)}
 }
 
 func (s *userStore) Create(ctx context.Context, user *User) error {
-	_, err := dgman.NewTxnContext(ctx, s.c).SetCommitNow().Mutate(user)
+	err := s.users.Save(ctx, user)
 	if err != nil {
 		if uniqueErr, ok := err.(*dgman.UniqueError); ok {
 			if uniqueErr.Field == "email" {
@@ -62,10 +67,7 @@ func (s *userStore) CheckPassword(ctx context.Context, login *Login) (*CheckPass
 	tx := dgman.NewReadOnlyTxnContext(ctx, s.c)
 	err := tx.Get(&User{}).
 		Filter("eq(email, $1)", login.Email).
-		Query(`{ 
-			uid
-			valid: checkpwd(password, $1) 
-		}`, login.Password).
+		CheckPassword("password", login.Password).
 		Node(result)
 	if err != nil {
 		if err == dgman.ErrNodeNotFound {
@@ -77,11 +79,7 @@ func (s *userStore) CheckPassword(ctx context.Context, login *Login) (*CheckPass
 }
 
 func (s *userStore) Get(ctx context.Context, uid string) (*User, error) {
-	user := &User{}
-	err := dgman.NewReadOnlyTxnContext(ctx, s.c).
-		Get(user).
-		UID(uid).
-		Node()
+	user, err := s.users.GetByUID(ctx, uid)
 	if err != nil {
 		if err == dgman.ErrNodeNotFound {
 			return nil, ErrUserNotFound
@@ -0,0 +1,85 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"io"
+	"sort"
+	"strings"
+)
+
+// Export writes the schema and type definitions, sorted by predicate and node type,
+// so the output is stable, e.g: for reviewing schema changes in CI,
+// or loading the schema with the dgraph live loader
+func (t *TypeSchema) Export(w io.Writer) error {
+	var buffer strings.Builder
+
+	predicates := make([]string, 0, len(t.Schema))
+	for predicate := range t.Schema {
+		predicates = append(predicates, predicate)
+	}
+	sort.Strings(predicates)
+	for _, predicate := range predicates {
+		buffer.WriteString(t.Schema[predicate].String())
+		buffer.WriteString("\n")
+	}
+
+	nodeTypes := make([]string, 0, len(t.Types))
+	for nodeType := range t.Types {
+		nodeTypes = append(nodeTypes, nodeType)
+	}
+	sort.Strings(nodeTypes)
+	for _, nodeType := range nodeTypes {
+		typePredicates := make([]string, 0, len(t.Types[nodeType]))
+		for predicate := range t.Types[nodeType] {
+			typePredicates = append(typePredicates, predicate)
+		}
+		sort.Strings(typePredicates)
+
+		buffer.WriteString("\ntype ")
+		buffer.WriteString(nodeType)
+		buffer.WriteString(" {\n")
+		for _, predicate := range typePredicates {
+			buffer.WriteString("\t")
+			buffer.WriteString(predicate)
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString("}\n")
+	}
+
+	for _, extension := range t.Extensions {
+		buffer.WriteString("\n")
+		buffer.WriteString(extension)
+		buffer.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, buffer.String())
+	return err
+}
+
+// GenerateSchema generates the schema and type definitions of models, as written by TypeSchema.Export,
+// without connecting to a cluster. Returns a SchemaConflictError when there are schema conflicts.
+func GenerateSchema(models ...interface{}) (string, error) {
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", models...)
+
+	var buffer strings.Builder
+	if err := typeSchema.Export(&buffer); err != nil {
+		return "", err
+	}
+	return buffer.String(), typeSchema.Err()
+}
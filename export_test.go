@@ -0,0 +1,77 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ExportAuthor struct {
+	UID   string          `json:"uid,omitempty"`
+	Name  string          `json:"name,omitempty" dgraph:"index=term"`
+	Posts []ExportArticle `json:"posts,omitempty" dgraph:"reverse"`
+	DType []string        `json:"dgraph.type,omitempty"`
+}
+
+type ExportArticle struct {
+	UID   string   `json:"uid,omitempty"`
+	Title string   `json:"title,omitempty" dgraph:"index=exact unique"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+type ExportConflict struct {
+	UID   string   `json:"uid,omitempty"`
+	Title int      `json:"title,omitempty"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+func TestGenerateSchema(t *testing.T) {
+	schema, err := GenerateSchema(&ExportAuthor{})
+	require.NoError(t, err)
+	assert.Equal(t, `name: string @index(term) .
+posts: [uid] @reverse .
+title: string @index(exact) @upsert .
+
+type ExportArticle {
+	title
+}
+
+type ExportAuthor {
+	name
+	posts
+}
+`, schema)
+
+	// export output is stable
+	for i := 0; i < 10; i++ {
+		again, err := GenerateSchema(&ExportAuthor{})
+		require.NoError(t, err)
+		assert.Equal(t, schema, again)
+	}
+}
+
+func TestGenerateSchemaConflict(t *testing.T) {
+	schema, err := GenerateSchema(&ExportArticle{}, &ExportConflict{})
+	conflictErr, ok := err.(*SchemaConflictError)
+	require.True(t, ok)
+	assert.Len(t, conflictErr.Conflicts, 1)
+	assert.True(t, strings.HasPrefix(schema, "title: string @index(exact) @upsert ."))
+}
@@ -0,0 +1,470 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var predicateRegex = regexp.MustCompile(`^~?[^\s(){}\[\],"'<>@$]+(@[\w\-:.]+)?$`)
+
+// Filter is a composable filter expression, built from filter functions,
+// e.g: dgman.Eq("name", "Alice").And(dgman.AllOfTerms("bio", "golang")).
+// Function values are escaped as query parameters.
+type Filter struct {
+	function  string
+	predicate string
+	values    []interface{}
+	list      bool
+	operator  string
+	operands  []*Filter
+	negate    bool
+}
+
+func newFilterFunc(function, predicate string, values ...interface{}) *Filter {
+	return &Filter{function: function, predicate: predicate, values: values}
+}
+
+// Eq filters nodes with a predicate value equal to any of the values
+func Eq(predicate string, values ...interface{}) *Filter {
+	return newFilterFunc("eq", predicate, values...)
+}
+
+// Le filters nodes with a predicate value less than or equal to the value
+func Le(predicate string, value interface{}) *Filter {
+	return newFilterFunc("le", predicate, value)
+}
+
+// Lt filters nodes with a predicate value less than the value
+func Lt(predicate string, value interface{}) *Filter {
+	return newFilterFunc("lt", predicate, value)
+}
+
+// Ge filters nodes with a predicate value greater than or equal to the value
+func Ge(predicate string, value interface{}) *Filter {
+	return newFilterFunc("ge", predicate, value)
+}
+
+// Gt filters nodes with a predicate value greater than the value
+func Gt(predicate string, value interface{}) *Filter {
+	return newFilterFunc("gt", predicate, value)
+}
+
+// Between filters nodes with a predicate value between lo and hi, inclusive
+func Between(predicate string, lo, hi interface{}) *Filter {
+	return newFilterFunc("between", predicate, lo, hi)
+}
+
+// In filters nodes with a predicate value equal to any of the values, slice values are expanded,
+// e.g: dgman.In("age", []int{17, 18}) builds eq(age, [17, 18])
+func In(predicate string, values ...interface{}) *Filter {
+	filter := newFilterFunc("eq", predicate, expandValues(values)...)
+	filter.list = true
+	return filter
+}
+
+// expandValues flattens slice and array values, except byte slices
+func expandValues(values []interface{}) []interface{} {
+	expanded := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		v := reflect.ValueOf(value)
+		if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8 {
+			for i := 0; i < v.Len(); i++ {
+				expanded = append(expanded, v.Index(i).Interface())
+			}
+			continue
+		}
+		expanded = append(expanded, value)
+	}
+	return expanded
+}
+
+// AllOfTerms filters nodes with a predicate value containing all of the terms,
+// the predicate requires a term index
+func AllOfTerms(predicate, terms string) *Filter {
+	return newFilterFunc("allofterms", predicate, terms)
+}
+
+// AnyOfTerms filters nodes with a predicate value containing any of the terms,
+// the predicate requires a term index
+func AnyOfTerms(predicate, terms string) *Filter {
+	return newFilterFunc("anyofterms", predicate, terms)
+}
+
+// AllOfText filters nodes with a predicate value matching all of the text using full-text search,
+// the predicate requires a fulltext index
+func AllOfText(predicate, text string) *Filter {
+	return newFilterFunc("alloftext", predicate, text)
+}
+
+// AnyOfText filters nodes with a predicate value matching any of the text using full-text search,
+// the predicate requires a fulltext index
+func AnyOfText(predicate, text string) *Filter {
+	return newFilterFunc("anyoftext", predicate, text)
+}
+
+// Regexp filters nodes with a predicate value matching a regular expression, e.g: dgman.Regexp("name", "^Ste(ph|v)en")
+// builds regexp(name, /^Ste(ph|v)en/), the predicate requires a trigram index.
+// Slashes in the pattern are escaped, and matching is case insensitive with the (?i) flag.
+func Regexp(predicate, pattern string) *Filter {
+	return newFilterFunc("regexp", predicate, regexpPattern(pattern))
+}
+
+// regexpPattern is a regular expression formatted as a /pattern/ literal
+type regexpPattern string
+
+func (p regexpPattern) format() ([]byte, error) {
+	if _, err := regexp.Compile(string(p)); err != nil {
+		return nil, err
+	}
+
+	// case insensitive matching is written as the i flag of dgraph
+	pattern, flags := string(p), ""
+	if strings.HasPrefix(pattern, "(?i)") {
+		pattern, flags = pattern[len("(?i)"):], "i"
+	}
+
+	var buffer strings.Builder
+	buffer.WriteByte('/')
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			// keep escaped characters, including escaped slashes
+			buffer.WriteByte(pattern[i])
+			if i+1 < len(pattern) {
+				i++
+				buffer.WriteByte(pattern[i])
+			}
+		case '/':
+			buffer.WriteString(`\/`)
+		default:
+			buffer.WriteByte(pattern[i])
+		}
+	}
+	buffer.WriteByte('/')
+	buffer.WriteString(flags)
+	return []byte(buffer.String()), nil
+}
+
+// UIDIn filters nodes with an edge to any of the uids, e.g: a UID, UIDs, or a QueryVar,
+// string values are formatted as UID, and UIDs or string slices as a list of uids.
+// Reverse edges are filtered with reverse predicates, e.g: dgman.UIDIn("~author", posts)
+func UIDIn(predicate string, uids interface{}) *Filter {
+	var list []string
+	switch value := uids.(type) {
+	case string:
+		return newFilterFunc("uid_in", predicate, UID(value))
+	case UIDs:
+		list = value
+	case []string:
+		list = value
+	default:
+		return newFilterFunc("uid_in", predicate, uids)
+	}
+
+	filter := newFilterFunc("uid_in", predicate)
+	filter.list = true
+	for _, uid := range list {
+		filter.values = append(filter.values, UID(uid))
+	}
+	return filter
+}
+
+// Has filters nodes which have a value for a predicate
+func Has(predicate string) *Filter {
+	return newFilterFunc("has", predicate)
+}
+
+// NotHas filters nodes which do not have a value for a predicate
+func NotHas(predicate string) *Filter {
+	return Not(Has(predicate))
+}
+
+// TypeOf filters nodes of a node type, e.g: combined with other filters on queries with a custom root function
+func TypeOf(nodeType string) *Filter {
+	return newFilterFunc("type", nodeType)
+}
+
+// Not negates a filter
+func Not(filter *Filter) *Filter {
+	negated := *filter
+	negated.negate = !filter.negate
+	return &negated
+}
+
+// Not returns the negated filter, as in Not
+func (f *Filter) Not() *Filter {
+	return Not(f)
+}
+
+func (f *Filter) combine(operator string, others []*Filter) *Filter {
+	return &Filter{
+		operator: operator,
+		operands: append([]*Filter{f}, others...),
+	}
+}
+
+// And combines the filter with other filters, all of which must match
+func (f *Filter) And(others ...*Filter) *Filter {
+	return f.combine("AND", others)
+}
+
+// Or combines the filter with other filters, any of which must match
+func (f *Filter) Or(others ...*Filter) *Filter {
+	return f.combine("OR", others)
+}
+
+func (f *Filter) build(buffer *strings.Builder) error {
+	if f.negate {
+		buffer.WriteString("NOT ")
+	}
+
+	if f.operator != "" {
+		buffer.WriteByte('(')
+		for i, operand := range f.operands {
+			if i > 0 {
+				buffer.WriteByte(' ')
+				buffer.WriteString(f.operator)
+				buffer.WriteByte(' ')
+			}
+			if err := operand.build(buffer); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte(')')
+		return nil
+	}
+
+	if !predicateRegex.MatchString(f.predicate) {
+		return fmt.Errorf("invalid predicate %q in %s filter", f.predicate, f.function)
+	}
+
+	if f.list && len(f.values) == 0 {
+		return fmt.Errorf("empty values in %s filter on %s", f.function, f.predicate)
+	}
+
+	buffer.WriteString(f.function)
+	buffer.WriteByte('(')
+	buffer.WriteString(f.predicate)
+	if f.list {
+		buffer.WriteString(", [")
+	}
+	for i, value := range f.values {
+		param, err := formatParam(value)
+		if err != nil {
+			return fmt.Errorf("invalid value %v in %s filter: %v", value, f.function, err)
+		}
+		if i > 0 || !f.list {
+			buffer.WriteString(", ")
+		}
+		buffer.Write(param)
+	}
+	if f.list {
+		buffer.WriteByte(']')
+	}
+	buffer.WriteByte(')')
+	return nil
+}
+
+// Build returns the filter string, or an error when the filter has an invalid predicate or value
+func (f *Filter) Build() (string, error) {
+	var buffer strings.Builder
+	if err := f.build(&buffer); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func (f *Filter) String() string {
+	filter, _ := f.Build()
+	return filter
+}
+
+// filterTokenizers specifies the index tokenizer required by filter functions
+var filterTokenizers = map[string]string{
+	"allofterms": "term",
+	"anyofterms": "term",
+	"alloftext":  "fulltext",
+	"anyoftext":  "fulltext",
+	"regexp":     "trigram",
+	"near":       "geo",
+	"within":     "geo",
+	"contains":   "geo",
+}
+
+// Validate validates the filter predicates against the schema tags of a model,
+// making sure predicates are defined and indexed as required by the filter functions
+func (f *Filter) Validate(model interface{}) error {
+	modelType, err := reflectType(model)
+	if err != nil {
+		return err
+	}
+	if modelType.Kind() != reflect.Struct {
+		return nil
+	}
+	return f.validate(getModelSchema(modelType, make(SchemaMap)))
+}
+
+func (f *Filter) validate(schemaMap SchemaMap) error {
+	for _, operand := range f.operands {
+		if err := operand.validate(schemaMap); err != nil {
+			return err
+		}
+	}
+	if f.operator != "" || f.function == "type" {
+		return nil
+	}
+
+	predicate := strings.TrimPrefix(f.predicate, "~")
+	if langIndex := strings.Index(predicate, "@"); langIndex > 0 {
+		predicate = predicate[:langIndex]
+	}
+	if predicate == predicateUid || predicate == predicateDgraphType {
+		return nil
+	}
+
+	schema, ok := schemaMap[predicate]
+	if !ok {
+		if strings.HasPrefix(f.predicate, "~") {
+			// reverse edges of predicates defined by other node types
+			return nil
+		}
+		return fmt.Errorf("predicate %s in %s filter is not defined in model", predicate, f.function)
+	}
+
+	tokenizer, ok := filterTokenizers[f.function]
+	if !ok {
+		return nil
+	}
+	for _, t := range schema.Tokenizer {
+		if t == tokenizer {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s filter requires predicate %s to have a %s index", f.function, predicate, tokenizer)
+}
+
+// validateFacets validates the filter predicates against the facets of an edge
+func (f *Filter) validateFacets(facets set) error {
+	for _, operand := range f.operands {
+		if err := operand.validateFacets(facets); err != nil {
+			return err
+		}
+	}
+	if f.operator != "" || facets.Has(f.predicate) {
+		return nil
+	}
+	return fmt.Errorf("facet %s in %s filter is not defined", f.predicate, f.function)
+}
+
+// getModelSchema maps the predicates of a model type, including anonymous fields, to their schema
+func getModelSchema(modelType reflect.Type, schemaMap SchemaMap) SchemaMap {
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && field.Anonymous {
+			getModelSchema(fieldType, schemaMap)
+			continue
+		}
+
+		schema, err := parseDgraphTag(&field)
+		if err != nil || schema.Predicate == "" {
+			continue
+		}
+		schemaMap[strings.TrimPrefix(schema.Predicate, "~")] = schema
+	}
+	return schemaMap
+}
+
+// Match evaluates the filter in memory on a node decoded from json, e.g: in fakes for unit tests,
+// supporting eq, uid_in, and has filters, combined with And, Or, and Not
+func (f *Filter) Match(node map[string]interface{}) (bool, error) {
+	matched, err := f.match(node)
+	if err != nil {
+		return false, err
+	}
+	return matched != f.negate, nil
+}
+
+func (f *Filter) match(node map[string]interface{}) (bool, error) {
+	if f.operator != "" {
+		for _, operand := range f.operands {
+			matched, err := operand.Match(node)
+			if err != nil {
+				return false, err
+			}
+			if f.operator == "AND" && !matched {
+				return false, nil
+			}
+			if f.operator == "OR" && matched {
+				return true, nil
+			}
+		}
+		return f.operator == "AND", nil
+	}
+
+	value, exists := node[f.predicate]
+	switch f.function {
+	case "has":
+		return exists && value != nil, nil
+	case "type":
+		return matchValue(node[predicateDgraphType], f.predicate), nil
+	case "eq", "uid_in":
+		if !exists {
+			return false, nil
+		}
+		for _, filterValue := range f.values {
+			// compare the values as decoded from json, e.g: numbers as float64
+			data, err := json.Marshal(filterValue)
+			if err != nil {
+				return false, fmt.Errorf("invalid value %v in %s filter: %v", filterValue, f.function, err)
+			}
+			var expected interface{}
+			if err := json.Unmarshal(data, &expected); err != nil {
+				return false, fmt.Errorf("invalid value %v in %s filter: %v", filterValue, f.function, err)
+			}
+			if matchValue(value, expected) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("%s filter cannot be matched in memory", f.function)
+}
+
+// matchValue checks whether a node value, any value of a list, or the uid of an edge equals the expected value
+func matchValue(value, expected interface{}) bool {
+	switch value := value.(type) {
+	case []interface{}:
+		for _, elem := range value {
+			if matchValue(elem, expected) {
+				return true
+			}
+		}
+		return false
+	case map[string]interface{}:
+		return value[predicateUid] == expected
+	}
+	return reflect.DeepEqual(value, expected)
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterBuild(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *Filter
+		want   string
+	}{
+		{"eq", Eq("name", "wildan"), `eq(name, "wildan")`},
+		{"eq multiple", Eq("age", 17, 18), `eq(age, 17, 18)`},
+		{"escaped", Eq("name", `") OR has(password`), `eq(name, "\") OR has(password")`},
+		{"lang", Eq("name@en", "wildan"), `eq(name@en, "wildan")`},
+		{"uid param", Eq("uid", UID("0x1")), `eq(uid, 0x1)`},
+		{"between", Between("age", 17, 30), `between(age, 17, 30)`},
+		{"in", In("name", "wildan", "alice"), `eq(name, ["wildan", "alice"])`},
+		{"in slice", In("age", []int{17, 18}, 19), `eq(age, [17, 18, 19])`},
+		{"in uid params", In("uid", UID("0x1"), UID("0x2")), `eq(uid, [0x1, 0x2])`},
+		{"has", Has("address"), `has(address)`},
+		{"not", Not(Has("address")), `NOT has(address)`},
+		{"not method", Has("address").Not(), `NOT has(address)`},
+		{"not has", NotHas("address"), `NOT has(address)`},
+		{"type", TypeOf("User").And(Has("name")), `(type(User) AND has(name))`},
+		{"regexp", Regexp("name", "^Ste(ph|v)en"), `regexp(name, /^Ste(ph|v)en/)`},
+		{"regexp slashes", Regexp("path", `^/home/\w+\/`), `regexp(path, /^\/home\/\w+\//)`},
+		{"regexp ignore case", Regexp("name", "(?i)^wildan"), `regexp(name, /^wildan/i)`},
+		{"uid in", UIDIn("edges", "0x1"), `uid_in(edges, 0x1)`},
+		{"uid in list", UIDIn("edges", []string{"0x1", "0x2"}), `uid_in(edges, [0x1, 0x2])`},
+		{"uid in uids", UIDIn("edges", UIDs{"0x1", "0x2"}), `uid_in(edges, [0x1, 0x2])`},
+		{
+			"not uid in reverse var",
+			UIDIn("~BitsJobAddFileTask", QueryVar("v")).Not(),
+			`NOT uid_in(~BitsJobAddFileTask, uid(v))`,
+		},
+		{
+			"and",
+			Eq("name", "wildan").And(Ge("age", 17), Lt("age", 30)),
+			`(eq(name, "wildan") AND ge(age, 17) AND lt(age, 30))`,
+		},
+		{
+			"nested",
+			AllOfTerms("name", "wildan").Or(Not(Gt("age", 17).And(Le("age", 30)))),
+			`(allofterms(name, "wildan") OR NOT (gt(age, 17) AND le(age, 30)))`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.filter.Build()
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.want, got)
+			}
+		})
+	}
+}
+
+func TestFilterInvalidPredicate(t *testing.T) {
+	for _, predicate := range []string{"", "name)", "name, age", "na me", `name"`, "name{"} {
+		_, err := Eq(predicate, "wildan").Build()
+		assert.Error(t, err, predicate)
+	}
+}
+
+func TestFilterRegexp(t *testing.T) {
+	type Page struct {
+		UID  string `json:"uid"`
+		Path string `json:"path" dgraph:"index=trigram"`
+	}
+	assert.NoError(t, Regexp("path", "^/home").Validate(&Page{}))
+
+	_, err := Regexp("path", "^(home").Build()
+	assert.Error(t, err)
+}
+
+func TestFilterInEmpty(t *testing.T) {
+	_, err := In("age", []int{}).Build()
+	assert.Error(t, err)
+}
+
+func TestFilterValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  *Filter
+		wantErr bool
+	}{
+		{"defined", Eq("name", "wildan").And(Has("dgraph.type"), Eq("uid", UID("0x1"))), false},
+		{"term index", AnyOfTerms("name", "wildan"), false},
+		{"reverse edge", Has("~edges"), false},
+		{"type", TypeOf("TestUser"), false},
+		{"reverse edge of other node type", UIDIn("~owner", QueryVar("v")).Not(), false},
+		{"undefined", Eq("email", "wildan"), true},
+		{"undefined nested", Has("name").Or(Not(Has("email"))), true},
+		{"missing index", AllOfTerms("address", "beverly"), true},
+		{"missing fulltext index", AllOfText("name", "wildan"), true},
+		{"missing trigram index", Regexp("name", "^wil"), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.filter.Validate(&TestModel{})
+			assert.Equal(t, test.wantErr, err != nil, err)
+		})
+	}
+}
+
+func TestQueryWhere(t *testing.T) {
+	var model []TestModel
+	query := NewQuery().Model(&model).Where(Eq("name", "wildan").And(Gt("age", 17)))
+	assert.NoError(t, query.err)
+	assert.Equal(t, `(eq(name, "wildan") AND gt(age, 17))`, query.filter)
+
+	query = NewQuery().Model(&model).Where(Eq("email", "wildan"))
+	_, err := query.executeQuery()
+	assert.Error(t, err)
+}
+
+func TestPredicateFilter(t *testing.T) {
+	name, age := Predicate("name"), Predicate("age")
+	filter, err := name.Eq("wildan").And(age.Gt(17), Not(Predicate("email").Has())).Build()
+	assert.NoError(t, err)
+	assert.Equal(t, `(eq(name, "wildan") AND gt(age, 17) AND NOT has(email))`, filter)
+	assert.Equal(t, `NOT has(email)`, Predicate("email").NotHas().String())
+}
+
+func TestQueryWithoutTypeFilter(t *testing.T) {
+	query := NewQuery().Model(&[]TestModel{}).RootFunc("has(name)").WithoutTypeFilter()
+	assert.Contains(t, query.String(), "data(func: has(name)) {")
+
+	query = NewQuery().Model(&[]TestModel{}).RootFunc("has(name)").WithoutTypeFilter().Where(NotHas("address"))
+	assert.Contains(t, query.String(), "data(func: has(name)) @filter(NOT has(address)) {")
+}
+
+func TestFilterMatch(t *testing.T) {
+	node := map[string]interface{}{
+		"uid":         "0x1",
+		"name":        "wildan",
+		"age":         float64(17),
+		"tags":        []interface{}{"go", "dgraph"},
+		"school":      map[string]interface{}{"uid": "0x2"},
+		"dgraph.type": []interface{}{"User"},
+	}
+	tests := []struct {
+		name   string
+		filter *Filter
+		want   bool
+	}{
+		{name: "eq", filter: Eq("name", "wildan"), want: true},
+		{name: "eq number", filter: Eq("age", 17), want: true},
+		{name: "eq any value", filter: In("age", []int{16, 18}), want: false},
+		{name: "eq list", filter: Eq("tags", "dgraph"), want: true},
+		{name: "eq missing", filter: Eq("email", "wildan@mail.com"), want: false},
+		{name: "uid_in", filter: UIDIn("school", UIDs{"0x3", "0x2"}), want: true},
+		{name: "has", filter: Has("school"), want: true},
+		{name: "not has", filter: NotHas("email"), want: true},
+		{name: "type", filter: TypeOf("User"), want: true},
+		{name: "other type", filter: TypeOf("School"), want: false},
+		{name: "and", filter: Eq("name", "wildan").And(Not(Eq("age", 17))), want: false},
+		{name: "or", filter: Eq("name", "moran").Or(Has("tags")), want: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matched, err := test.filter.Match(node)
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, matched)
+		})
+	}
+
+	_, err := Ge("age", 17).Match(node)
+	assert.EqualError(t, err, "ge filter cannot be matched in memory")
+}
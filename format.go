@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"strings"
+)
+
+// FormatQuery formats a DQL query, indenting each line with tabs by its block depth
+func FormatQuery(query string) string {
+	return FormatQueryIndent(query, "\t")
+}
+
+// FormatQueryIndent formats a DQL query, indenting each line with the indent string by its block depth.
+// Empty lines are removed, and leading and trailing spaces of each line are trimmed.
+func FormatQueryIndent(query, indent string) string {
+	var buffer strings.Builder
+	depth := 0
+	for _, line := range strings.Split(query, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		// closing braces at the start of the line belong to the outer block
+		closing := len(line) - len(strings.TrimLeft(line, "}"))
+		depth -= closing
+		if depth < 0 {
+			depth = 0
+		}
+
+		if buffer.Len() > 0 {
+			buffer.WriteByte('\n')
+		}
+		buffer.WriteString(strings.Repeat(indent, depth))
+		buffer.WriteString(line)
+
+		depth += braceDelta(line[closing:])
+	}
+	return buffer.String()
+}
+
+// braceDelta returns the number of opened minus closed braces in a line,
+// ignoring braces in string literals
+func braceDelta(line string) int {
+	delta := 0
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if inString {
+				// skip escaped character
+				i++
+			}
+		case '"':
+			inString = !inString
+		case '{':
+			if !inString {
+				delta++
+			}
+		case '}':
+			if !inString {
+				delta--
+			}
+		}
+	}
+	return delta
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatQueryIndent(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		indent string
+		want   string
+	}{
+		{
+			name:   "should indent nested blocks",
+			query:  "{\ndata(func: type(User)) {\nuid\nfriends {\nuid\n}\n}\n}",
+			indent: "\t",
+			want:   "{\n\tdata(func: type(User)) {\n\t\tuid\n\t\tfriends {\n\t\t\tuid\n\t\t}\n\t}\n}",
+		},
+		{
+			name:   "should fix misaligned lines and remove empty lines",
+			query:  "{\n\t  data(func: type(User)) @filter(has(dgraph.type)) {\n\n\t\t\t\t uid\n\t }\n}",
+			indent: "  ",
+			want:   "{\n  data(func: type(User)) @filter(has(dgraph.type)) {\n    uid\n  }\n}",
+		},
+		{
+			name:   "should keep single line blocks",
+			query:  "{\npageInfo(func: uid(filtered)) { count(uid) }\n}",
+			indent: "\t",
+			want:   "{\n\tpageInfo(func: uid(filtered)) { count(uid) }\n}",
+		},
+		{
+			name:   "should ignore braces in string literals",
+			query:  "{\ndata(func: eq(name, \"{\\\"}\")) {\nuid\n}\n}",
+			indent: "\t",
+			want:   "{\n\tdata(func: eq(name, \"{\\\"}\")) {\n\t\tuid\n\t}\n}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatQueryIndent(tt.query, tt.indent))
+		})
+	}
+}
+
+func TestFormatQueryExpandAll(t *testing.T) {
+	query := NewQuery().Model(&TestModel{}).All(2).String()
+	expected := `{
+	data(func: type(TestModel)) @filter(has(dgraph.type)) {
+		uid
+		dgraph.type
+		expand(_all_) {
+			uid
+			dgraph.type
+			expand(_all_) {
+				uid
+				dgraph.type
+				expand(_all_)
+			}
+		}
+	}
+}`
+	assert.Equal(t, expected, query)
+}
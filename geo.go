@@ -0,0 +1,90 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"strconv"
+	"strings"
+)
+
+var (
+	_ ParamFormatter = GeoPoint{}
+	_ ParamFormatter = GeoPolygon{}
+)
+
+// GeoPoint is a geo coordinate in geo filters
+type GeoPoint struct {
+	Lat float64
+	Lng float64
+}
+
+func (p GeoPoint) write(buffer *strings.Builder) {
+	// geo coordinates are ordered by longitude, then latitude, as in GeoJSON
+	buffer.WriteByte('[')
+	buffer.WriteString(strconv.FormatFloat(p.Lng, 'f', -1, 64))
+	buffer.WriteString(", ")
+	buffer.WriteString(strconv.FormatFloat(p.Lat, 'f', -1, 64))
+	buffer.WriteByte(']')
+}
+
+// FormatParams implements the ParamFormatter interface
+func (p GeoPoint) FormatParams() []byte {
+	var buffer strings.Builder
+	p.write(&buffer)
+	return []byte(buffer.String())
+}
+
+// GeoPolygon is a polygon of geo coordinates in geo filters,
+// the polygon is closed when the last point is not the first point
+type GeoPolygon []GeoPoint
+
+// FormatParams implements the ParamFormatter interface
+func (p GeoPolygon) FormatParams() []byte {
+	points := p
+	if len(points) > 0 && points[0] != points[len(points)-1] {
+		points = append(points[:len(points):len(points)], points[0])
+	}
+
+	var buffer strings.Builder
+	buffer.WriteString("[[")
+	for i, point := range points {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+		point.write(&buffer)
+	}
+	buffer.WriteString("]]")
+	return []byte(buffer.String())
+}
+
+// Near filters nodes with a geo predicate within a distance in meters of a coordinate,
+// the predicate requires a geo index
+func Near(predicate string, lat, lng, meters float64) *Filter {
+	return newFilterFunc("near", predicate, GeoPoint{Lat: lat, Lng: lng}, meters)
+}
+
+// Within filters nodes with a geo predicate located within a polygon,
+// the predicate requires a geo index
+func Within(predicate string, polygon GeoPolygon) *Filter {
+	return newFilterFunc("within", predicate, polygon)
+}
+
+// Contains filters nodes with a geo predicate polygon containing a coordinate,
+// the predicate requires a geo index
+func Contains(predicate string, lat, lng float64) *Filter {
+	return newFilterFunc("contains", predicate, GeoPoint{Lat: lat, Lng: lng})
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type GeoPlace struct {
+	UID      string   `json:"uid,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Location *GeoLoc  `json:"location,omitempty" dgraph:"type=geo index=geo"`
+	Area     *GeoLoc  `json:"area,omitempty" dgraph:"type=geo"`
+	DType    []string `json:"dgraph.type,omitempty"`
+}
+
+func TestGeoFilterBuild(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter *Filter
+		want   string
+	}{
+		{"near", Near("location", -6.2, 106.8166, 1000), `near(location, [106.8166, -6.2], 1000)`},
+		{"contains", Contains("location", -6.2, 106.8166), `contains(location, [106.8166, -6.2])`},
+		{
+			"within closed",
+			Within("location", GeoPolygon{{Lat: 0, Lng: 0}, {Lat: 0, Lng: 1}, {Lat: 1, Lng: 1}, {Lat: 0, Lng: 0}}),
+			`within(location, [[[0, 0], [1, 0], [1, 1], [0, 0]]])`,
+		},
+		{
+			"within unclosed",
+			Within("location", GeoPolygon{{Lat: 0, Lng: 0}, {Lat: 0, Lng: 1}, {Lat: 1, Lng: 1}}),
+			`within(location, [[[0, 0], [1, 0], [1, 1], [0, 0]]])`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.filter.Build()
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.want, got)
+			}
+		})
+	}
+}
+
+func TestGeoPolygonNotModified(t *testing.T) {
+	polygon := make(GeoPolygon, 3, 4)
+	copy(polygon, GeoPolygon{{Lat: 0, Lng: 0}, {Lat: 0, Lng: 1}, {Lat: 1, Lng: 1}})
+	polygon.FormatParams()
+	assert.Len(t, polygon, 3)
+	assert.Equal(t, GeoPoint{}, polygon[:4][3])
+}
+
+func TestGeoFilterValidate(t *testing.T) {
+	assert.NoError(t, Near("location", 0, 0, 100).Validate(&GeoPlace{}))
+	assert.EqualError(t, Contains("area", 0, 0).Validate(&GeoPlace{}),
+		"contains filter requires predicate area to have a geo index")
+	assert.Error(t, Within("name", GeoPolygon{{Lat: 0, Lng: 0}}).Validate(&GeoPlace{}))
+}
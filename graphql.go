@@ -0,0 +1,278 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// graphQLTypes maps schema types to GraphQL scalar types
+var graphQLTypes = map[string]string{
+	"string":   "String",
+	"int":      "Int64",
+	"float":    "Float",
+	"bool":     "Boolean",
+	"datetime": "DateTime",
+	"geo":      "Point",
+}
+
+// graphQLSearch maps index tokenizers to the arguments of the @search directive,
+// empty for the default search of the type
+var graphQLSearch = map[string]string{
+	"exact":    "exact",
+	"hash":     "hash",
+	"term":     "term",
+	"fulltext": "fulltext",
+	"trigram":  "regexp",
+	"year":     "year",
+	"month":    "month",
+	"day":      "day",
+	"hour":     "hour",
+	"int":      "",
+	"float":    "",
+	"bool":     "",
+	"geo":      "",
+}
+
+var graphQLNameRegex = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// GenerateGraphQLSchema generates the Dgraph GraphQL schema of models, including the node types of edges,
+// mapping the fields to the predicates of the DQL schema with @dgraph, so DQL and GraphQL
+// share the same data. Index tags are mapped to @search, and unique or upsert string
+// predicates to @id. Facets and password predicates are not mapped.
+func GenerateGraphQLSchema(models ...interface{}) (string, error) {
+	nodeTypes := make(map[string]reflect.Type)
+	for _, model := range models {
+		modelType, err := reflectType(model)
+		if err != nil {
+			return "", err
+		}
+		collectGraphQLTypes(modelType, nodeTypes)
+	}
+
+	names := make([]string, 0, len(nodeTypes))
+	for name := range nodeTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buffer strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		if err := writeGraphQLType(&buffer, name, nodeTypes[name]); err != nil {
+			return "", err
+		}
+	}
+	return buffer.String(), nil
+}
+
+// collectGraphQLTypes collects the node type of a model and the node types of its edges
+func collectGraphQLTypes(modelType reflect.Type, nodeTypes map[string]reflect.Type) {
+	modelType = getElemType(modelType)
+	if modelType.Kind() != reflect.Struct {
+		return
+	}
+	nodeType := getNodeType(modelType)
+	if _, ok := nodeTypes[nodeType]; ok {
+		return
+	}
+	nodeTypes[nodeType] = modelType
+
+	for _, field := range modelFields(modelType) {
+		if edgeType := edgeNodeType(field); edgeType != nil {
+			collectGraphQLTypes(edgeType, nodeTypes)
+		}
+	}
+}
+
+// graphQLFieldName returns the GraphQL field name of a struct field, the json field name when valid
+func graphQLFieldName(field reflect.StructField, jsonName string) string {
+	if graphQLNameRegex.MatchString(jsonName) {
+		return jsonName
+	}
+	return strings.ToLower(field.Name[:1]) + field.Name[1:]
+}
+
+func writeGraphQLType(buffer *strings.Builder, nodeType string, modelType reflect.Type) error {
+	if !graphQLNameRegex.MatchString(nodeType) {
+		return fmt.Errorf("node type %s is not a valid GraphQL type name", nodeType)
+	}
+	fmt.Fprintf(buffer, "type %s @dgraph(type: %q) {\n", nodeType, nodeType)
+
+	for _, field := range modelFields(modelType) {
+		jsonName, _ := getPredicate(&field)
+		if jsonName == "" || jsonName == "-" || jsonName == predicateDgraphType || isFacet(jsonName) {
+			continue
+		}
+		if jsonName == predicateUid {
+			fmt.Fprintf(buffer, "\t%s: ID!\n", graphQLFieldName(field, "id"))
+			continue
+		}
+
+		schema, err := parseDgraphTag(&field)
+		if err != nil {
+			return errors.Wrapf(err, "parse dgraph tag failed on %s.%s", modelType.Name(), field.Name)
+		}
+		if schema.Type == "password" {
+			continue
+		}
+
+		fieldType, err := graphQLFieldType(field, schema)
+		if err != nil {
+			return errors.Wrapf(err, "invalid GraphQL field %s.%s", modelType.Name(), field.Name)
+		}
+		fmt.Fprintf(buffer, "\t%s: %s", graphQLFieldName(field, jsonName), fieldType)
+		if schema.Unique || schema.Upsert {
+			if fieldType == "String" || fieldType == "String!" {
+				buffer.WriteString(" @id")
+			}
+		}
+		if schema.Index {
+			buffer.WriteString(graphQLSearchDirective(schema.Tokenizer))
+		}
+		fmt.Fprintf(buffer, " @dgraph(pred: %q)\n", schema.Predicate)
+	}
+	buffer.WriteString("}\n")
+	return nil
+}
+
+// graphQLFieldType returns the GraphQL type of a field, edges are typed by their node type
+func graphQLFieldType(field reflect.StructField, schema *Schema) (string, error) {
+	schemaType := schema.Type
+	list := schema.List
+	if strings.HasPrefix(schemaType, "[") {
+		schemaType = strings.Trim(schemaType, "[]")
+		list = true
+	}
+
+	var fieldType string
+	if schemaType == schemaUid {
+		edgeType := edgeNodeType(field)
+		if edgeType == nil {
+			return "", errors.New("uid predicates must be edges of node types")
+		}
+		fieldType = getNodeType(edgeType)
+	} else {
+		var ok bool
+		if fieldType, ok = graphQLTypes[schemaType]; !ok {
+			return "", fmt.Errorf("unsupported GraphQL type of %s", schemaType)
+		}
+	}
+
+	if list {
+		fieldType = "[" + fieldType + "]"
+	}
+	if schema.Required {
+		fieldType += "!"
+	}
+	return fieldType, nil
+}
+
+func graphQLSearchDirective(tokenizers []string) string {
+	var by []string
+	for _, tokenizer := range tokenizers {
+		if search := graphQLSearch[strings.TrimSpace(tokenizer)]; search != "" {
+			by = append(by, search)
+		}
+	}
+	if len(by) == 0 {
+		return " @search"
+	}
+	return " @search(by: [" + strings.Join(by, ", ") + "])"
+}
+
+// GraphQLSchemaOptions are the options of pushing the GraphQL schema in UpdateGraphQLSchema
+type GraphQLSchemaOptions struct {
+	// Client sends the schema, defaults to http.DefaultClient
+	Client *http.Client
+	// Header is added to the request, e.g: X-Dgraph-AccessToken on Dgraph ACL
+	Header http.Header
+}
+
+type graphQLAdminResponse struct {
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// UpdateGraphQLSchema generates the GraphQL schema of models as in GenerateGraphQLSchema, and pushes it to the
+// /admin/schema endpoint of an alpha HTTP address, e.g: http://localhost:8080. Returns the pushed schema.
+func UpdateGraphQLSchema(ctx context.Context, alphaURL string, models []interface{}, opts ...GraphQLSchemaOptions) (string, error) {
+	schema, err := GenerateGraphQLSchema(models...)
+	if err != nil {
+		return "", err
+	}
+
+	var options GraphQLSchemaOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	client := options.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	url := strings.TrimSuffix(alphaURL, "/") + "/admin/schema"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBufferString(schema))
+	if err != nil {
+		return "", errors.Wrap(err, "create request failed")
+	}
+	for key, values := range options.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "push GraphQL schema failed")
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "read response failed")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("push GraphQL schema failed with status %d: %s", resp.StatusCode, body)
+	}
+
+	var adminResp graphQLAdminResponse
+	if err := json.Unmarshal(body, &adminResp); err != nil {
+		return "", errors.Wrapf(err, "unmarshal response %q", body)
+	}
+	if len(adminResp.Errors) > 0 {
+		messages := make([]string, len(adminResp.Errors))
+		for i, e := range adminResp.Errors {
+			messages[i] = e.Message
+		}
+		return "", fmt.Errorf("push GraphQL schema failed: %s", strings.Join(messages, "; "))
+	}
+	return schema, nil
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type GraphQLAuthor struct {
+	UID      string        `json:"uid,omitempty"`
+	Email    string        `json:"email,omitempty" dgraph:"index=exact unique required"`
+	Name     string        `json:"name,omitempty" dgraph:"predicate=author_name index=term,trigram"`
+	Age      int           `json:"age,omitempty" dgraph:"index=int"`
+	Born     time.Time     `json:"born,omitempty" dgraph:"index=year"`
+	Tags     []string      `json:"tags,omitempty"`
+	Password string        `json:"password,omitempty" dgraph:"type=password"`
+	Posts    []GraphQLPost `json:"posts,omitempty" dgraph:"reverse"`
+	DType    []string      `json:"dgraph.type,omitempty"`
+}
+
+type GraphQLPost struct {
+	UID    string          `json:"uid,omitempty"`
+	Title  string          `json:"title,omitempty" dgraph:"index=fulltext"`
+	Author []GraphQLAuthor `json:"~posts,omitempty"`
+	DType  []string        `json:"dgraph.type,omitempty" dgraph:"Post"`
+}
+
+func TestGenerateGraphQLSchema(t *testing.T) {
+	schema, err := GenerateGraphQLSchema(&GraphQLAuthor{})
+	require.NoError(t, err)
+	assert.Equal(t, `type GraphQLAuthor @dgraph(type: "GraphQLAuthor") {
+	id: ID!
+	email: String! @id @search(by: [exact]) @dgraph(pred: "email")
+	name: String @search(by: [term, regexp]) @dgraph(pred: "author_name")
+	age: Int64 @search @dgraph(pred: "age")
+	born: DateTime @search(by: [year]) @dgraph(pred: "born")
+	tags: [String] @dgraph(pred: "tags")
+	posts: [Post] @dgraph(pred: "posts")
+}
+
+type Post @dgraph(type: "Post") {
+	id: ID!
+	title: String @search(by: [fulltext]) @dgraph(pred: "title")
+	author: [GraphQLAuthor] @dgraph(pred: "~posts")
+}
+`, schema)
+}
+
+func TestUpdateGraphQLSchema(t *testing.T) {
+	var pushed string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/admin/schema", r.URL.Path)
+		assert.Equal(t, "token", r.Header.Get("X-Dgraph-AccessToken"))
+		body, _ := ioutil.ReadAll(r.Body)
+		pushed = string(body)
+		w.Write([]byte(`{"data":{"code":"Success","message":"Done"}}`))
+	}))
+	defer server.Close()
+
+	schema, err := UpdateGraphQLSchema(context.Background(), server.URL, []interface{}{&GraphQLPost{}},
+		GraphQLSchemaOptions{Header: http.Header{"X-Dgraph-AccessToken": []string{"token"}}})
+	require.NoError(t, err)
+	assert.Equal(t, schema, pushed)
+	assert.Contains(t, pushed, `type Post @dgraph(type: "Post")`)
+}
+
+func TestUpdateGraphQLSchemaError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors":[{"message":"input:3: invalid type"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := UpdateGraphQLSchema(context.Background(), server.URL, []interface{}{&GraphQLPost{}})
+	assert.EqualError(t, err, "push GraphQL schema failed: input:3: invalid type")
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	stdjson "encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// groupByKey is the key of the groups in a @groupby query result
+const groupByKey = "@groupby"
+
+// GroupByResult is the result of a query with GroupBy, with a row per group,
+// containing the group predicate value and the aggregate values, e.g: {"age": 17, "count": 2}
+type GroupByResult struct {
+	Rows []stdjson.RawMessage
+}
+
+// Len returns the number of groups
+func (r *GroupByResult) Len() int {
+	return len(r.Rows)
+}
+
+// Scan unmarshals the rows into dst, a pointer to a slice of structs or maps,
+// e.g: []struct{ Age int `json:"age"`; Count int `json:"count"` }
+func (r *GroupByResult) Scan(dst interface{}) error {
+	rows, err := json.Marshal(r.Rows)
+	if err != nil {
+		return errors.Wrap(err, "marshal groupby rows failed")
+	}
+	if err := json.Unmarshal(rows, dst); err != nil {
+		return errors.Wrap(err, "unmarshal groupby rows failed")
+	}
+	return nil
+}
+
+// parseGroupByResult parses the rows of a @groupby query result,
+// in the format {"<name>":[{"@groupby":[{ ... }]}]}
+func parseGroupByResult(jsonData []byte, name string) (*GroupByResult, error) {
+	var result map[string][]map[string][]stdjson.RawMessage
+	if err := json.Unmarshal(jsonData, &result); err != nil {
+		return nil, errors.Wrapf(err, "invalid json result for groupby: %s", jsonData)
+	}
+
+	groupBy := &GroupByResult{}
+	for _, node := range result[name] {
+		groupBy.Rows = append(groupBy.Rows, node[groupByKey]...)
+	}
+	return groupBy, nil
+}
+
+// GroupByResult returns the groups of a query with GroupBy, the query block defines
+// the aggregate values of each group, which defaults to the count of nodes in the group
+func (q *Query) GroupByResult() (*GroupByResult, error) {
+	if q.groupBy == "" {
+		return nil, errors.New("query has no groupby predicate")
+	}
+
+	result, err := q.executeQuery()
+	if err != nil {
+		return nil, err
+	}
+	return parseGroupByResult(result, q.name)
+}
+
+// Groups returns the groups of a query with GroupBy like GroupByResult, unmarshaled into dst
+func (q *Query) Groups(dst interface{}) error {
+	result, err := q.GroupByResult()
+	if err != nil {
+		return err
+	}
+	return result.Scan(dst)
+}
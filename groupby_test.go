@@ -0,0 +1,50 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type AgeGroup struct {
+	Age   int `json:"age"`
+	Count int `json:"count"`
+}
+
+func TestGroupBy(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{Json: []byte(`{"data":[{"@groupby":[{"age":17,"count":2},{"age":18,"count":1}]}]}`)})
+
+	var groups []AgeGroup
+	require.NoError(t, tx.Get(&TestModel{}).GroupBy("age").Groups(&groups))
+	assert.Equal(t, []AgeGroup{{Age: 17, Count: 2}, {Age: 18, Count: 1}}, groups)
+	assert.Contains(t, fake.requests[0].Query, "@groupby(age) {\n\t\tcount(uid)\n\t}")
+}
+
+func TestGroupByResult(t *testing.T) {
+	tx, _ := newFakeTxnContext(&api.Response{Json: []byte(`{"data":[]}`)})
+
+	result, err := tx.Get(&TestModel{}).GroupBy("age").GroupByResult()
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Len())
+
+	_, err = tx.Get(&TestModel{}).GroupByResult()
+	assert.Error(t, err)
+}
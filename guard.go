@@ -0,0 +1,212 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// AllTypes matches all node types in QueryGuard.PaginatedTypes
+const AllTypes = "*"
+
+// QueryGuard defines limits on queries, which are checked before the query is sent,
+// to protect against accidental full graph scans
+type QueryGuard struct {
+	// PaginatedTypes are node types which must be queried with First or After,
+	// AllTypes requires pagination on all node types. Queries by UID are not checked.
+	PaginatedTypes []string
+	// MaxFirst limits the number of results of paginated queries, 0 means no limit
+	MaxFirst int
+	// MaxDepth limits the depth of edges expanded in a query, 0 means no limit
+	MaxDepth int
+	// CascadeRequiresFilter rejects cascade queries without a filter or a uid,
+	// as cascade is applied after fetching all nodes of the root function
+	CascadeRequiresFilter bool
+	// StrictParams rejects queries with values interpolated into the strings of Query, Filter, and RootFunc,
+	// i.e: string or regex literals, and $ parameters without values, values must be passed as parameters
+	// or GraphQL vars instead, so values cannot inject into the query
+	StrictParams bool
+}
+
+// QueryGuardError is returned when a query is rejected by a query guard
+type QueryGuardError struct {
+	Query  string
+	Reason string
+}
+
+func (e *QueryGuardError) Error() string {
+	return fmt.Sprintf("query %s rejected: %s", e.Query, e.Reason)
+}
+
+// SetQueryGuard sets a query guard for transactions created from the client,
+// passing nil removes the query guard of the client
+func (c *Client) SetQueryGuard(guard *QueryGuard) *Client {
+	c.dg.config.guard = guard
+	return c
+}
+
+func (g *QueryGuard) requiresPagination(nodeType string) bool {
+	for _, paginatedType := range g.PaginatedTypes {
+		if paginatedType == AllTypes || paginatedType == nodeType {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns a QueryGuardError when a query exceeds the query guard limits
+func (g *QueryGuard) Check(q *Query) error {
+	if g == nil || q.isVar {
+		return nil
+	}
+
+	reject := func(format string, args ...interface{}) error {
+		return &QueryGuardError{Query: q.name, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	if q.uid == "" && q.model != nil {
+		nodeType := GetNodeType(q.model)
+		if q.first == 0 && q.after == "" && g.requiresPagination(nodeType) {
+			return reject("queries on type %s require pagination with first or after", nodeType)
+		}
+	}
+
+	if g.MaxFirst > 0 && q.first > g.MaxFirst {
+		return reject("first %d exceeds the limit of %d", q.first, g.MaxFirst)
+	}
+
+	if g.MaxDepth > 0 {
+		if depth := queryDepth(q.query) - 1; depth > g.MaxDepth {
+			return reject("edge depth %d exceeds the limit of %d", depth, g.MaxDepth)
+		}
+	}
+
+	if g.CascadeRequiresFilter && q.cascade != nil && q.filter == "" && q.uid == "" {
+		return reject("cascade requires a filter")
+	}
+
+	if g.StrictParams {
+		for _, part := range []queryPart{queryPartRootFunc, queryPartFilter, queryPartQuery} {
+			// parts replaced after parsing, e.g: by All, are not checked
+			if raw, ok := q.rawParams[part]; ok && raw.parsed == q.part(part) {
+				return reject("%s %s", part, raw.reason)
+			}
+		}
+	}
+
+	return nil
+}
+
+// queryDepth returns the maximum block depth of a query,
+// ignoring braces in string literals
+func queryDepth(query string) int {
+	depth, maxDepth := 0, 0
+	inString := false
+	for i := 0; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if inString {
+				// skip escaped character
+				i++
+			}
+		case '"':
+			inString = !inString
+		case '{':
+			if !inString {
+				depth++
+				if depth > maxDepth {
+					maxDepth = depth
+				}
+			}
+		case '}':
+			if !inString {
+				depth--
+			}
+		}
+	}
+	return maxDepth
+}
+
+// queryPart is a part of a query parsed with parameters
+type queryPart string
+
+const (
+	queryPartRootFunc queryPart = "root function"
+	queryPartFilter   queryPart = "filter"
+	queryPartQuery    queryPart = "query"
+)
+
+// rawParams is a query part with values not passed as parameters, rejected with StrictParams
+type rawParams struct {
+	parsed string
+	reason string
+}
+
+func (q *Query) part(part queryPart) string {
+	switch part {
+	case queryPartRootFunc:
+		return q.rootFunc
+	case queryPartFilter:
+		return q.filter
+	}
+	return q.query
+}
+
+// checkParams records a query part with values not passed as parameters, checked by StrictParams
+func (q *Query) checkParams(part queryPart, query string, params []interface{}, parsed string) {
+	delete(q.rawParams, part)
+	reason := rawParamsReason(part, query, len(params))
+	if reason == "" {
+		return
+	}
+	if q.rawParams == nil {
+		q.rawParams = make(map[queryPart]rawParams)
+	}
+	q.rawParams[part] = rawParams{parsed: parsed, reason: reason}
+}
+
+// rawParamsReason returns why a query string has values not passed as parameters,
+// empty if all values are parameters or GraphQL vars
+func rawParamsReason(part queryPart, query string, paramsLength int) string {
+	for pos := 0; pos < len(query); pos++ {
+		switch c := query[pos]; c {
+		case '"', '\'', '`':
+			return fmt.Sprintf("has a string literal at %d, pass values as parameters", pos)
+		case '/':
+			// division is only allowed in math of the query part
+			if part != queryPartQuery {
+				return fmt.Sprintf("has a regex literal at %d, pass values as parameters", pos)
+			}
+		case '$':
+			end := pos + 1
+			for end < len(query) && query[end] >= '0' && query[end] <= '9' {
+				end++
+			}
+			if end == pos+1 {
+				// GraphQL named var
+				continue
+			}
+			index, err := strconv.Atoi(query[pos+1 : end])
+			if err != nil || index < 1 || index > paramsLength {
+				return fmt.Sprintf("has parameter %s without a value", query[pos:end])
+			}
+			pos = end - 1
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryGuardCheck(t *testing.T) {
+	guard := &QueryGuard{
+		PaginatedTypes:        []string{"TestModel"},
+		MaxFirst:              100,
+		MaxDepth:              2,
+		CascadeRequiresFilter: true,
+	}
+
+	tests := []struct {
+		name    string
+		query   *Query
+		wantErr bool
+	}{
+		{"paginated", NewQuery().Model(&[]TestModel{}).First(10), false},
+		{"paginated after", NewQuery().Model(&[]TestModel{}).After("0x1"), false},
+		{"by uid", NewQuery().Model(&TestModel{}).UID("0x1").All(2), false},
+		{"unpaginated type", NewQuery().Model(&[]TestEdge{}), false},
+		{"unpaginated", NewQuery().Model(&[]TestModel{}), true},
+		{"unpaginated root func", NewQuery().Model(&[]TestModel{}).RootFunc("has(name)"), true},
+		{"first exceeded", NewQuery().Model(&[]TestModel{}).First(1000), true},
+		{"depth exceeded", NewQuery().Model(&TestModel{}).UID("0x1").All(3), true},
+		{"depth exceeded query", NewQuery().Model(&TestModel{}).UID("0x1").Query(`{ edges { edges { edges { uid } } } }`), true},
+		{"depth string literal", NewQuery().Model(&TestModel{}).UID("0x1").Query(`{ edges @filter(eq(level, "{{{")) { uid } }`), false},
+		{"cascade filter", NewQuery().Model(&[]TestModel{}).First(10).Filter("eq(name, $1)", "wildan").Cascade(), false},
+		{"cascade", NewQuery().Model(&[]TestModel{}).First(10).Cascade(), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := guard.Check(test.query)
+			if test.wantErr {
+				assert.IsType(t, &QueryGuardError{}, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestQueryGuardTxn(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+	tx.SetQueryGuard(&QueryGuard{PaginatedTypes: []string{AllTypes}})
+
+	var models []TestModel
+	err := tx.Get(&models).Nodes()
+	assert.IsType(t, &QueryGuardError{}, err)
+	assert.Empty(t, fake.requests)
+}
+
+func TestQueryGuardQueryBlock(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+	tx.SetQueryGuard(&QueryGuard{PaginatedTypes: []string{AllTypes}})
+
+	err := tx.Query(
+		NewQuery().Model(&[]TestModel{}).First(10),
+		NewQuery().Model(&[]TestEdge{}).Name("edges"),
+	).Scan()
+	assert.IsType(t, &QueryGuardError{}, err)
+	assert.Empty(t, fake.requests)
+}
+
+func TestQueryGuardStrictParams(t *testing.T) {
+	guard := &QueryGuard{StrictParams: true}
+
+	tests := []struct {
+		name    string
+		query   *Query
+		wantErr bool
+	}{
+		{"params", NewQuery().Filter("eq(name, $1) AND ge(age, $2)", "wildan", 17), false},
+		{"graphql vars", NewQuery().Vars("getUser($name: string)", map[string]string{"$name": "wildan"}).Filter("eq(name, $name)"), false},
+		{"root func params", NewQuery().RootFunc("eq(email, $1)", "wildan@dolan.in"), false},
+		{"where", NewQuery().Filter(`eq(name, "wildan")`).Where(Eq("name", "wildan")), false},
+		{"expansion", NewQuery().Query(`{ name total: math(price / count) }`), false},
+		{"string literal", NewQuery().Filter(`eq(name, "wildan")`), true},
+		{"regex literal", NewQuery().Filter(`regexp(name, /^wil/)`), true},
+		{"root func literal", NewQuery().RootFunc(`eq(email, "wildan@dolan.in")`), true},
+		{"query literal", NewQuery().Query(`{ friends @filter(eq(name, "wildan")) { uid } }`), true},
+		{"param without value", NewQuery().Filter("eq(name, $2)", "wildan"), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := guard.Check(test.query)
+			if test.wantErr {
+				assert.IsType(t, &QueryGuardError{}, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	err := guard.Check(NewQuery().Filter(`eq(name, "wildan")`))
+	assert.EqualError(t, err, "query data rejected: filter has a string literal at 9, pass values as parameters")
+	// not checked without strict params
+	assert.NoError(t, (&QueryGuard{}).Check(NewQuery().Filter(`eq(name, "wildan")`)))
+}
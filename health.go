@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+)
+
+const healthQuery = "schema(pred: [dgraph.type]) { type }"
+
+// Health is the result of a cluster health check
+type Health struct {
+	// Alpha is true when the alpha serves a best effort query, which does not require zero
+	Alpha bool
+	// Zero is true when the alpha serves a query, requiring a timestamp from zero
+	Zero bool
+	// Version is the alpha version, only checked when a DgraphClient is passed
+	Version string
+	// Latency is the round trip latency of the zero query
+	Latency time.Duration
+	// Err is the first error encountered by the health check
+	Err error
+}
+
+// Ready returns whether the cluster is ready to serve queries and mutations
+func (h *Health) Ready() bool {
+	return h.Alpha && h.Zero
+}
+
+// HealthCheck checks the health of the cluster the client connects to, usable for readiness probes.
+// Optionally, a DgraphClient can be passed to check the alpha version.
+//
+// The Dgraph gRPC API does not expose whether schema alters are in progress,
+// those are returned as errors by the alter call itself.
+func HealthCheck(ctx context.Context, c DgraphClient, dc ...api.DgraphClient) *Health {
+	health := &Health{}
+
+	if _, err := c.NewReadOnlyTxn().BestEffort().Query(ctx, healthQuery); err != nil {
+		health.Err = errors.Wrap(err, "alpha query failed")
+		return health
+	}
+	health.Alpha = true
+
+	start := time.Now()
+	if _, err := c.NewReadOnlyTxn().Query(ctx, healthQuery); err != nil {
+		health.Err = errors.Wrap(err, "zero query failed")
+		return health
+	}
+	health.Latency = time.Since(start)
+	health.Zero = true
+
+	if len(dc) > 0 {
+		version, err := dc[0].CheckVersion(ctx, &api.Check{})
+		if err != nil {
+			health.Err = errors.Wrap(err, "check version failed")
+			return health
+		}
+		health.Version = version.Tag
+	}
+
+	return health
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/dolan-in/reflectwalk"
+	"github.com/pkg/errors"
+)
+
+// HookOp is the mutation operation passed to mutation hooks
+type HookOp string
+
+const (
+	// HookMutate is passed on Mutate, MutateBasic and MutateMap
+	HookMutate HookOp = "mutate"
+	// HookMutateOrGet is passed on MutateOrGet
+	HookMutateOrGet HookOp = "mutate_or_get"
+	// HookUpsert is passed on Upsert and UpsertMap
+	HookUpsert HookOp = "upsert"
+)
+
+// MutateHook is called with the reflected value of a node struct in the mutation data,
+// or of a map node of MutateMap and UpsertMap
+type MutateHook func(ctx context.Context, op HookOp, node reflect.Value) error
+
+// Hooks are called on mutations, deletes and queries of a transaction,
+// for cross-cutting behavior such as audit timestamps, validation and tracing.
+// Nil hooks are skipped.
+type Hooks struct {
+	// BeforeMutate is called on each node struct in the mutation data, nested nodes included,
+	// before the mutation is generated. Node fields can be modified when the data is passed
+	// as a pointer. Returning an error aborts the mutation.
+	BeforeMutate MutateHook
+	// AfterMutate is called on each node struct in the mutation data after a successful mutation,
+	// with the created uids injected
+	AfterMutate MutateHook
+	// BeforeDelete is called with the uids of the deleted nodes, or the nodes of the deleted edges,
+	// before the delete is sent. Returning an error aborts the delete.
+	BeforeDelete func(ctx context.Context, uids []string) error
+	// AfterDelete is called with the uids of the deleted nodes after a successful delete
+	AfterDelete func(ctx context.Context, uids []string) error
+	// BeforeQuery is called with the query string before a query is sent.
+	// Returning an error aborts the query.
+	BeforeQuery func(ctx context.Context, query string) error
+	// AfterQuery is called with the query string and the query error after a query is sent
+	AfterQuery func(ctx context.Context, query string, err error)
+}
+
+// SetHooks sets the hooks for transactions created from the client,
+// passing nil removes the hooks of the client
+func (c *Client) SetHooks(hooks *Hooks) *Client {
+	c.dg.config.hooks = hooks
+	return c
+}
+
+func (o mutationOpCode) hookOp() HookOp {
+	switch o {
+	case mutationMutateOrGet:
+		return HookMutateOrGet
+	case mutationUpsert:
+		return HookUpsert
+	}
+	return HookMutate
+}
+
+// isNodeType checks whether a struct type has a uid field
+func isNodeType(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if predicate, _ := getPredicate(&field); predicate == predicateUid {
+			return true
+		}
+	}
+	return false
+}
+
+type mutateHookWalker struct {
+	ctx  context.Context
+	op   HookOp
+	hook MutateHook
+}
+
+func (w mutateHookWalker) Struct(v reflect.Value, level int) error {
+	if !v.CanInterface() || !isNodeType(v.Type()) {
+		return nil
+	}
+	return w.hook(w.ctx, w.op, v)
+}
+
+func (w mutateHookWalker) StructField(s reflect.Value, f reflect.StructField, v reflect.Value, level int) error {
+	return nil
+}
+
+func walkMutateHook(ctx context.Context, hook MutateHook, op HookOp, data interface{}) error {
+	if hook == nil {
+		return nil
+	}
+	if values, err := mapNodes(data); err == nil {
+		// map nodes of MutateMap and UpsertMap
+		for _, value := range values {
+			if err := hook(ctx, op, reflect.ValueOf(value)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return reflectwalk.Walk(data, mutateHookWalker{ctx: ctx, op: op, hook: hook})
+}
+
+func (h *Hooks) beforeMutate(ctx context.Context, op HookOp, data interface{}) error {
+	if h == nil {
+		return nil
+	}
+	return errors.Wrap(walkMutateHook(ctx, h.BeforeMutate, op, data), "before mutate hook failed")
+}
+
+func (h *Hooks) afterMutate(ctx context.Context, op HookOp, data interface{}) error {
+	if h == nil {
+		return nil
+	}
+	return errors.Wrap(walkMutateHook(ctx, h.AfterMutate, op, data), "after mutate hook failed")
+}
+
+func (h *Hooks) beforeDelete(ctx context.Context, uids []string) error {
+	if h == nil || h.BeforeDelete == nil {
+		return nil
+	}
+	return errors.Wrap(h.BeforeDelete(ctx, uids), "before delete hook failed")
+}
+
+func (h *Hooks) afterDelete(ctx context.Context, uids []string) error {
+	if h == nil || h.AfterDelete == nil {
+		return nil
+	}
+	return errors.Wrap(h.AfterDelete(ctx, uids), "after delete hook failed")
+}
+
+// sendQuery sends a query string with the query vars, calling the query hooks
+func sendQuery(ctx context.Context, tx transaction, hooks *Hooks, queryString string, vars map[string]string) (resp *api.Response, err error) {
+	if hooks != nil && hooks.BeforeQuery != nil {
+		if err := hooks.BeforeQuery(ctx, queryString); err != nil {
+			return nil, errors.Wrap(err, "before query hook failed")
+		}
+	}
+	if hooks != nil && hooks.AfterQuery != nil {
+		defer func() { hooks.AfterQuery(ctx, queryString, err) }()
+	}
+
+	if vars != nil {
+		return tx.QueryWithVars(ctx, queryString, vars)
+	}
+	return tx.Query(ctx, queryString)
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type HookPost struct {
+	UID       string      `json:"uid,omitempty"`
+	Title     string      `json:"title,omitempty"`
+	CreatedAt time.Time   `json:"created_at,omitempty"`
+	Author    *HookAuthor `json:"author,omitempty"`
+	DType     []string    `json:"dgraph.type,omitempty"`
+}
+
+type HookAuthor struct {
+	UID       string    `json:"uid,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	DType     []string  `json:"dgraph.type,omitempty"`
+}
+
+func setCreatedAt(ctx context.Context, op HookOp, node reflect.Value) error {
+	if field := node.FieldByName("CreatedAt"); field.IsValid() {
+		field.Set(reflect.ValueOf(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)))
+	}
+	return nil
+}
+
+func TestMutateHooks(t *testing.T) {
+	var ops []HookOp
+	tx, fake := newFakeTxnContext()
+	tx.SetHooks(&Hooks{
+		BeforeMutate: setCreatedAt,
+		AfterMutate: func(ctx context.Context, op HookOp, node reflect.Value) error {
+			ops = append(ops, op)
+			return nil
+		},
+	})
+
+	post := HookPost{Title: "hooks", Author: &HookAuthor{Name: "wildan"}}
+	_, err := tx.Upsert(&post)
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+
+	assert.False(t, post.CreatedAt.IsZero())
+	assert.False(t, post.Author.CreatedAt.IsZero())
+	assert.Contains(t, string(fake.requests[0].Mutations[0].SetJson), "2021-01-01T00:00:00Z")
+	assert.Equal(t, []HookOp{HookUpsert, HookUpsert}, ops)
+}
+
+func TestMutateHookAbort(t *testing.T) {
+	afterCalled := false
+	tx, fake := newFakeTxnContext()
+	tx.SetHooks(&Hooks{
+		BeforeMutate: func(ctx context.Context, op HookOp, node reflect.Value) error {
+			return errors.New("invalid node")
+		},
+		AfterMutate: func(ctx context.Context, op HookOp, node reflect.Value) error {
+			afterCalled = true
+			return nil
+		},
+	})
+
+	_, err := tx.MutateBasic(&HookPost{Title: "hooks"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid node")
+	assert.Empty(t, fake.requests)
+	assert.False(t, afterCalled)
+}
+
+func TestDeleteHooks(t *testing.T) {
+	var before, after [][]string
+	tx, fake := newFakeTxnContext()
+	tx.SetHooks(&Hooks{
+		BeforeDelete: func(ctx context.Context, uids []string) error {
+			before = append(before, uids)
+			return nil
+		},
+		AfterDelete: func(ctx context.Context, uids []string) error {
+			after = append(after, uids)
+			return nil
+		},
+	})
+
+	require.NoError(t, tx.DeleteNode("0x1", "0x2"))
+	require.NoError(t, tx.Delete(&DeleteParams{Nodes: []DeleteNode{{UID: "0x3"}}}))
+	require.NoError(t, tx.DeleteEdge("0x4", "author"))
+	assert.Len(t, fake.requests, 3)
+
+	expected := [][]string{{"0x1", "0x2"}, {"0x3"}, {"0x4"}}
+	assert.Equal(t, expected, before)
+	assert.Equal(t, expected, after)
+
+	tx.SetHooks(&Hooks{
+		BeforeDelete: func(ctx context.Context, uids []string) error {
+			return errors.New("protected node")
+		},
+	})
+	require.Error(t, tx.DeleteNode("0x1"))
+	assert.Len(t, fake.requests, 3)
+}
+
+func TestQueryHooks(t *testing.T) {
+	var queries []string
+	var queryErr error
+	tx, fake := newFakeTxnContext()
+	tx.SetHooks(&Hooks{
+		BeforeQuery: func(ctx context.Context, query string) error {
+			queries = append(queries, query)
+			return nil
+		},
+		AfterQuery: func(ctx context.Context, query string, err error) {
+			queryErr = err
+		},
+	})
+
+	var post HookPost
+	_ = tx.Get(&post).UID("0x1").Node()
+	require.Len(t, queries, 1)
+	assert.Equal(t, fake.requests[0].Query, queries[0])
+
+	fake.err = errors.New("query failed")
+	_ = tx.Query(tx.Get(&post).UID("0x1")).Scan()
+	require.Len(t, queries, 2)
+	assert.Equal(t, fake.err, queryErr)
+
+	tx.SetHooks(&Hooks{
+		BeforeQuery: func(ctx context.Context, query string) error {
+			return errors.New("query rejected")
+		},
+	})
+	err := tx.Get(&post).UID("0x1").Node()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "query rejected")
+	assert.Len(t, fake.requests, 2)
+}
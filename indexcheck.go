@@ -0,0 +1,190 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/dolan-in/reflectwalk"
+	"github.com/pkg/errors"
+)
+
+// IndexError is returned when a unique predicate of a mutated node is not indexed in the schema,
+// as unique checks query the existing nodes by the predicate values with eq
+type IndexError struct {
+	NodeType  string
+	Predicate string
+	// Missing is true when the predicate is not defined in the schema
+	Missing bool
+}
+
+func (e *IndexError) Error() string {
+	if e.Missing {
+		return fmt.Sprintf("unique predicate %s of %s is not defined in the schema", e.Predicate, e.NodeType)
+	}
+	return fmt.Sprintf("unique predicate %s of %s is not indexed, unique checks require an index, e.g: index=exact", e.Predicate, e.NodeType)
+}
+
+// IndexCheck checks that the unique predicates of mutated nodes, including unique together predicates,
+// are indexed in the schema before mutations with unique checks are sent, returning an IndexError
+// instead of failing at runtime with Dgraph errors
+type IndexCheck struct {
+	mu     sync.Mutex
+	schema SchemaMap
+}
+
+// NewIndexCheck returns an index check against a type schema, e.g: returned by CreateSchema or MutateSchema,
+// or against the cluster schema, fetched on the first check and cached, when the type schema is nil
+func NewIndexCheck(typeSchema *TypeSchema) *IndexCheck {
+	check := &IndexCheck{}
+	if typeSchema != nil {
+		check.schema = typeSchema.Schema
+	}
+	return check
+}
+
+// Refresh clears the cached schema, which is fetched from the cluster on the next check,
+// e.g: after altering the schema
+func (i *IndexCheck) Refresh() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.schema = nil
+}
+
+func (i *IndexCheck) getSchema(c DgraphClient) (SchemaMap, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.schema != nil {
+		return i.schema, nil
+	}
+	if c == nil {
+		return nil, errors.New("index check requires a client to fetch the schema")
+	}
+
+	existingSchema, err := fetchExistingSchema(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch schema for index check failed")
+	}
+	i.schema = make(SchemaMap, len(existingSchema))
+	for _, schema := range existingSchema {
+		i.schema[schema.Predicate] = schema
+	}
+	return i.schema, nil
+}
+
+func (i *IndexCheck) check(c DgraphClient, data interface{}) error {
+	walker := &indexCheckWalker{visited: make(map[reflect.Type]bool)}
+	if err := reflectwalk.Walk(data, walker); err != nil {
+		return err
+	}
+	return i.checkPredicates(c, walker.predicates)
+}
+
+// checkMap checks the unique predicates of the map nodes of MutateMap and UpsertMap
+func (i *IndexCheck) checkMap(c DgraphClient, opts MapOptions) error {
+	predicates := make([]IndexError, len(opts.Unique))
+	for j, predicate := range opts.Unique {
+		predicates[j] = IndexError{NodeType: opts.NodeType, Predicate: predicate}
+	}
+	return i.checkPredicates(c, predicates)
+}
+
+func (i *IndexCheck) checkPredicates(c DgraphClient, predicates []IndexError) error {
+	if len(predicates) == 0 {
+		return nil
+	}
+
+	schema, err := i.getSchema(c)
+	if err != nil {
+		return err
+	}
+	for _, unique := range predicates {
+		existing, ok := schema[unique.Predicate]
+		if !ok {
+			unique.Missing = true
+			return &unique
+		}
+		if !existing.Index {
+			return &unique
+		}
+	}
+	return nil
+}
+
+// indexCheckWalker collects the unique predicates of the node types of mutated nodes
+type indexCheckWalker struct {
+	visited    map[reflect.Type]bool
+	predicates []IndexError
+}
+
+func (w *indexCheckWalker) add(nodeType string, field reflect.StructField) error {
+	schema, err := parseDgraphTag(&field)
+	if err != nil {
+		return errors.Wrapf(err, "parse dgraph tag failed on %s.%s", nodeType, field.Name)
+	}
+	for _, unique := range w.predicates {
+		if unique.Predicate == schema.Predicate {
+			return nil
+		}
+	}
+	w.predicates = append(w.predicates, IndexError{NodeType: nodeType, Predicate: schema.Predicate})
+	return nil
+}
+
+func (w *indexCheckWalker) Struct(v reflect.Value, level int) error {
+	vType := v.Type()
+	if w.visited[vType] || !isNodeType(vType) {
+		return nil
+	}
+	w.visited[vType] = true
+
+	nodeType := getNodeType(vType)
+	for _, field := range modelFields(vType) {
+		schema, err := parseDgraphTag(&field)
+		if err != nil {
+			return errors.Wrapf(err, "parse dgraph tag failed on %s.%s", nodeType, field.Name)
+		}
+		if !schema.Unique {
+			continue
+		}
+		if err := w.add(nodeType, field); err != nil {
+			return err
+		}
+	}
+	for _, fields := range getUniqueTogether(vType) {
+		for _, index := range fields {
+			if err := w.add(nodeType, vType.Field(index)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *indexCheckWalker) StructField(s reflect.Value, f reflect.StructField, v reflect.Value, level int) error {
+	return nil
+}
+
+// SetIndexCheck sets the index check for mutations with unique checks of transactions created from the client,
+// i.e: Mutate, MutateOrGet, Upsert, MutateMap, UpsertMap, and MutateWithOptions without skipping unique checks,
+// passing nil removes the index check of the client
+func (c *Client) SetIndexCheck(check *IndexCheck) *Client {
+	c.dg.config.indexCheck = check
+	return c
+}
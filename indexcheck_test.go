@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type IndexCheckAccount struct {
+	UID   string   `json:"uid,omitempty"`
+	Email string   `json:"email,omitempty" dgraph:"unique"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+func TestIndexCheck(t *testing.T) {
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", &OverrideProduct{}, &IndexCheckAccount{})
+	check := NewIndexCheck(typeSchema)
+
+	tx, fake := newFakeTxnContext()
+	tx.SetIndexCheck(check)
+	_, err := tx.Mutate(&OverrideProduct{ExternalID: "x1", SKU: "s1"})
+	require.NoError(t, err)
+	assert.Len(t, fake.requests, 1)
+
+	tx, fake = newFakeTxnContext()
+	tx.SetIndexCheck(check)
+	_, err = tx.Upsert(&IndexCheckAccount{Email: "wildan@dolan.in"}, "email")
+	assert.Equal(t, &IndexError{NodeType: "IndexCheckAccount", Predicate: "email"}, err)
+	assert.EqualError(t, err, "unique predicate email of IndexCheckAccount is not indexed, unique checks require an index, e.g: index=exact")
+	assert.Empty(t, fake.requests)
+
+	// unique checks are skipped on MutateBasic
+	_, err = tx.MutateBasic(&IndexCheckAccount{Email: "wildan@dolan.in"})
+	require.NoError(t, err)
+	assert.Len(t, fake.requests, 1)
+}
+
+func TestIndexCheckUniqueTogether(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", &TogetherBooking{})
+	delete(typeSchema.Schema, "date")
+	tx.SetIndexCheck(NewIndexCheck(typeSchema))
+
+	_, err := tx.Mutate(&TogetherBooking{RoomID: "r1", Date: "2021-01-01"})
+	assert.Equal(t, &IndexError{NodeType: "TogetherBooking", Predicate: "date", Missing: true}, err)
+	assert.EqualError(t, err, "unique predicate date of TogetherBooking is not defined in the schema")
+	assert.Empty(t, fake.requests)
+}
+
+func TestIndexCheckClusterSchema(t *testing.T) {
+	dc := &responseClient{responses: []*api.Response{
+		{Json: []byte(`{"schema":[{"predicate":"ext_id","type":"string","index":true,"tokenizer":["exact"]},{"predicate":"sku","type":"string"}]}`)},
+		// sku is indexed after altering the schema
+		{Json: []byte(`{"schema":[{"predicate":"ext_id","type":"string","index":true,"tokenizer":["exact"]},{"predicate":"sku","type":"string","index":true,"tokenizer":["exact"]}]}`)},
+	}}
+	check := NewIndexCheck(nil)
+	c := NewClient(dc).SetIndexCheck(check).Dgraph()
+
+	_, err := NewTxn(c).Mutate(&OverrideProduct{ExternalID: "x1", SKU: "s1"})
+	assert.Equal(t, &IndexError{NodeType: "OverrideProduct", Predicate: "sku"}, err)
+	require.Len(t, dc.requests, 1)
+	assert.Contains(t, dc.requests[0].Query, "schema")
+
+	// the cluster schema is cached
+	_, err = NewTxn(c).Mutate(&OverrideProduct{ExternalID: "x2", SKU: "s2"})
+	assert.Error(t, err)
+	assert.Len(t, dc.requests, 1)
+
+	check.Refresh()
+	_, err = NewTxn(c).Mutate(&OverrideProduct{ExternalID: "x3", SKU: "s3"})
+	require.NoError(t, err)
+	// the schema is fetched again before the mutation
+	require.Len(t, dc.requests, 3)
+	assert.Contains(t, dc.requests[1].Query, "schema")
+}
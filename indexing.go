@@ -0,0 +1,90 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+)
+
+// SchemaOptions specifies the alter behavior of CreateSchemaWithOptions and MutateSchemaWithOptions
+type SchemaOptions struct {
+	// RunInBackground builds the indexes in the background, returning without waiting for
+	// the index rebuilds, which can be waited for with WaitForIndexing and the returned type schema
+	RunInBackground bool
+}
+
+// indexingPollInterval is the interval of polling the schema in WaitForIndexing
+var indexingPollInterval = time.Second
+
+func alterSchema(c DgraphClient, typeSchema *TypeSchema, opts SchemaOptions) error {
+	alterString := typeSchema.String()
+	if alterString == "" {
+		return nil
+	}
+	operation := &api.Operation{Schema: alterString, RunInBackground: opts.RunInBackground}
+	return c.Alter(context.Background(), operation)
+}
+
+// WaitForIndexing polls the schema until the predicates of a type schema altered in the background,
+// as returned by CreateSchemaWithOptions or MutateSchemaWithOptions, report their new indexes,
+// returning an error listing the pending predicates on timeout, e.g: to gate a deployment rollout on index readiness
+func WaitForIndexing(c DgraphClient, typeSchema *TypeSchema, timeout time.Duration) error {
+	pending := make(SchemaMap)
+	for predicate, schema := range typeSchema.Schema {
+		pending[predicate] = schema
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		existingSchema, err := fetchExistingSchema(c)
+		if err != nil {
+			return errors.Wrap(err, "fetch existing schema failed")
+		}
+		for _, existing := range existingSchema {
+			schema, ok := pending[existing.Predicate]
+			if ok && normalizeSchema(existing).String() == normalizeSchema(schema).String() {
+				delete(pending, existing.Predicate)
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("indexing not ready after %s: %s", timeout, strings.Join(pendingPredicates(pending), ", "))
+		}
+		time.Sleep(indexingPollInterval)
+	}
+}
+
+func pendingPredicates(pending SchemaMap) []string {
+	predicates := make([]string, 0, len(pending))
+	for predicate := range pending {
+		predicates = append(predicates, predicate)
+	}
+	sort.Strings(predicates)
+	return predicates
+}
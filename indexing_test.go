@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210"
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// alterClient records alter operations, responding to queries as responseClient,
+// or with the schema when there are no responses left
+type alterClient struct {
+	responseClient
+	schema     []byte
+	operations []*api.Operation
+}
+
+func (a *alterClient) Query(ctx context.Context, in *api.Request, opts ...grpc.CallOption) (*api.Response, error) {
+	if len(a.responses) == 0 && a.schema != nil {
+		return &api.Response{Json: a.schema}, nil
+	}
+	return a.responseClient.Query(ctx, in, opts...)
+}
+
+func (a *alterClient) Alter(ctx context.Context, in *api.Operation, opts ...grpc.CallOption) (*api.Payload, error) {
+	a.operations = append(a.operations, in)
+	return &api.Payload{}, nil
+}
+
+type IndexedNode struct {
+	UID   string   `json:"uid,omitempty"`
+	Name  string   `json:"name,omitempty" dgraph:"index=term"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+func TestWaitForIndexing(t *testing.T) {
+	indexingPollInterval = time.Millisecond
+	defer func() { indexingPollInterval = time.Second }()
+
+	dc := &alterClient{responseClient: responseClient{responses: []*api.Response{
+		// the index is still building
+		{Json: []byte(`{"schema":[{"predicate":"name","type":"string"}]}`)},
+		{Json: []byte(`{"schema":[{"predicate":"name","type":"string","index":true,"tokenizer":["term"]}]}`)},
+	}}}
+	c := dgo.NewDgraphClient(dc)
+
+	typeSchema, err := MutateSchemaWithOptions(c, SchemaOptions{RunInBackground: true}, &IndexedNode{})
+	require.NoError(t, err)
+	require.Len(t, dc.operations, 1)
+	assert.True(t, dc.operations[0].RunInBackground)
+
+	require.NoError(t, WaitForIndexing(c, typeSchema, time.Second))
+	assert.Len(t, dc.requests, 2)
+
+	// nothing pending
+	require.NoError(t, WaitForIndexing(c, NewTypeSchema(), time.Second))
+	assert.Len(t, dc.requests, 2)
+}
+
+func TestWaitForIndexingTimeout(t *testing.T) {
+	indexingPollInterval = time.Millisecond
+	defer func() { indexingPollInterval = time.Second }()
+
+	// the schema never reports the index
+	dc := &alterClient{schema: []byte(`{"schema":[]}`)}
+	c := dgo.NewDgraphClient(dc)
+
+	typeSchema, err := MutateSchemaWithOptions(c, SchemaOptions{RunInBackground: true}, &IndexedNode{})
+	require.NoError(t, err)
+
+	err = WaitForIndexing(c, typeSchema, 5*time.Millisecond)
+	assert.EqualError(t, err, "indexing not ready after 5ms: name")
+}
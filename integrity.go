@@ -0,0 +1,225 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// DanglingEdge is an edge to a node without a dgraph.type, e.g: a deleted node
+type DanglingEdge struct {
+	NodeType  string
+	UID       string
+	Predicate string
+	TargetUID string
+}
+
+// MissingPredicate is a node without a value for a predicate tagged as required
+type MissingPredicate struct {
+	NodeType  string
+	UID       string
+	Predicate string
+}
+
+// DuplicateValue is a value of a predicate tagged as unique, shared by multiple nodes of a type
+type DuplicateValue struct {
+	NodeType  string
+	Predicate string
+	Value     interface{}
+	UIDs      []string
+}
+
+// IntegrityReport reports the integrity issues found by CheckIntegrity
+type IntegrityReport struct {
+	DanglingEdges     []DanglingEdge
+	MissingPredicates []MissingPredicate
+	DuplicateValues   []DuplicateValue
+}
+
+// OK returns whether no integrity issues are found
+func (r *IntegrityReport) OK() bool {
+	return len(r.DanglingEdges) == 0 &&
+		len(r.MissingPredicates) == 0 &&
+		len(r.DuplicateValues) == 0
+}
+
+type integrityNode struct {
+	UID   string             `json:"uid"`
+	Edges stdjson.RawMessage `json:"edges"`
+	Value stdjson.RawMessage `json:"value"`
+}
+
+type integrityResult struct {
+	Nodes []integrityNode `json:"nodes"`
+}
+
+// CheckIntegrity scans the nodes of the types of the models, and their edges, for integrity issues:
+// edges to nodes without a dgraph.type, nodes missing predicates tagged as required,
+// and values of predicates tagged as unique shared by multiple nodes.
+func CheckIntegrity(c DgraphClient, models ...interface{}) (*IntegrityReport, error) {
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", models...)
+
+	// sort types and predicates for a stable report
+	nodeTypes := make([]string, 0, len(typeSchema.Types))
+	for nodeType := range typeSchema.Types {
+		nodeTypes = append(nodeTypes, nodeType)
+	}
+	sort.Strings(nodeTypes)
+
+	report := &IntegrityReport{}
+	for _, nodeType := range nodeTypes {
+		schemaMap := typeSchema.Types[nodeType]
+		predicates := make([]string, 0, len(schemaMap))
+		for predicate := range schemaMap {
+			predicates = append(predicates, predicate)
+		}
+		sort.Strings(predicates)
+
+		for _, predicate := range predicates {
+			schema := schemaMap[predicate]
+			if schema.Type == schemaUid || schema.Type == schemaUidList {
+				if err := checkDanglingEdges(c, report, nodeType, predicate); err != nil {
+					return nil, errors.Wrapf(err, "check dangling edges on %s.%s failed", nodeType, predicate)
+				}
+			}
+			if schema.Required {
+				if err := checkMissingPredicates(c, report, nodeType, predicate); err != nil {
+					return nil, errors.Wrapf(err, "check missing predicates on %s.%s failed", nodeType, predicate)
+				}
+			}
+			if schema.Unique {
+				if err := checkDuplicateValues(c, report, nodeType, predicate); err != nil {
+					return nil, errors.Wrapf(err, "check duplicate values on %s.%s failed", nodeType, predicate)
+				}
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func queryIntegrity(c DgraphClient, nodeType, filter, query string) ([]integrityNode, error) {
+	var result integrityResult
+	q := NewQuery().
+		Name("nodes").
+		RootFunc(fmt.Sprintf("type(%s)", nodeType)).
+		Filter(filter).
+		Query(query)
+
+	err := NewReadOnlyTxn(c).Query(q).Scan(&result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Nodes, nil
+}
+
+func checkDanglingEdges(c DgraphClient, report *IntegrityReport, nodeType, predicate string) error {
+	nodes, err := queryIntegrity(c, nodeType,
+		fmt.Sprintf("has(<%s>)", predicate),
+		fmt.Sprintf("{\n\t\tuid\n\t\tedges: <%s> @filter(NOT has(dgraph.type)) {\n\t\t\tuid\n\t\t}\n\t}", predicate))
+	if err != nil {
+		return err
+	}
+
+	for _, nodeResult := range nodes {
+		if len(nodeResult.Edges) == 0 {
+			continue
+		}
+		// single uid predicates return an object instead of a list
+		var edges []node
+		if nodeResult.Edges[0] == '[' {
+			if err := json.Unmarshal(nodeResult.Edges, &edges); err != nil {
+				return err
+			}
+		} else {
+			var edge node
+			if err := json.Unmarshal(nodeResult.Edges, &edge); err != nil {
+				return err
+			}
+			edges = append(edges, edge)
+		}
+
+		for _, edge := range edges {
+			report.DanglingEdges = append(report.DanglingEdges, DanglingEdge{
+				NodeType:  nodeType,
+				UID:       nodeResult.UID,
+				Predicate: predicate,
+				TargetUID: edge.UID,
+			})
+		}
+	}
+	return nil
+}
+
+func checkMissingPredicates(c DgraphClient, report *IntegrityReport, nodeType, predicate string) error {
+	nodes, err := queryIntegrity(c, nodeType, fmt.Sprintf("NOT has(<%s>)", predicate), "{ uid }")
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		report.MissingPredicates = append(report.MissingPredicates, MissingPredicate{
+			NodeType:  nodeType,
+			UID:       node.UID,
+			Predicate: predicate,
+		})
+	}
+	return nil
+}
+
+func checkDuplicateValues(c DgraphClient, report *IntegrityReport, nodeType, predicate string) error {
+	nodes, err := queryIntegrity(c, nodeType,
+		fmt.Sprintf("has(<%s>)", predicate),
+		fmt.Sprintf("{\n\t\tuid\n\t\tvalue: <%s>\n\t}", predicate))
+	if err != nil {
+		return err
+	}
+
+	var values []string
+	uidsByValue := make(map[string][]string)
+	for _, node := range nodes {
+		value := string(node.Value)
+		if _, ok := uidsByValue[value]; !ok {
+			values = append(values, value)
+		}
+		uidsByValue[value] = append(uidsByValue[value], node.UID)
+	}
+
+	for _, value := range values {
+		uids := uidsByValue[value]
+		if len(uids) < 2 {
+			continue
+		}
+
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			return err
+		}
+		report.DuplicateValues = append(report.DuplicateValues, DuplicateValue{
+			NodeType:  nodeType,
+			Predicate: predicate,
+			Value:     decoded,
+			UIDs:      uids,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type IntegrityAuthor struct {
+	UID   string           `json:"uid,omitempty"`
+	Email string           `json:"email,omitempty" dgraph:"index=exact unique"`
+	Name  string           `json:"name,omitempty" dgraph:"required"`
+	Books []*IntegrityBook `json:"books,omitempty"`
+	DType []string         `json:"dgraph.type,omitempty"`
+}
+
+type IntegrityBook struct {
+	UID   string   `json:"uid,omitempty"`
+	Title string   `json:"title,omitempty"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	c := newDgraphClient()
+	_, err := CreateSchema(c, &IntegrityAuthor{})
+	require.NoError(t, err)
+	defer dropAll(c)
+
+	authors := []*IntegrityAuthor{
+		{
+			Email: "wildan@gmail.com",
+			Name:  "wildan",
+			Books: []*IntegrityBook{{Title: "one"}, {Title: "two"}},
+		},
+		{
+			Email: "wildan@gmail.com",
+		},
+	}
+
+	report, err := CheckIntegrity(c, &IntegrityAuthor{})
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+
+	// skip unique checking and validation to create duplicates and missing predicates
+	_, err = NewTxn(c).MutateWithOptions(&authors, MutateOptions{SkipUnique: true, SkipValidation: true, CommitNow: true})
+	require.NoError(t, err)
+
+	deletedBook := authors[0].Books[0]
+	require.NoError(t, NewTxn(c).SetCommitNow().DeleteNode(deletedBook.UID))
+
+	report, err = CheckIntegrity(c, &IntegrityAuthor{})
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+
+	assert.Equal(t, []DanglingEdge{{
+		NodeType:  "IntegrityAuthor",
+		UID:       authors[0].UID,
+		Predicate: "books",
+		TargetUID: deletedBook.UID,
+	}}, report.DanglingEdges)
+
+	assert.Equal(t, []MissingPredicate{{
+		NodeType:  "IntegrityAuthor",
+		UID:       authors[1].UID,
+		Predicate: "name",
+	}}, report.MissingPredicates)
+
+	require.Len(t, report.DuplicateValues, 1)
+	assert.Equal(t, "email", report.DuplicateValues[0].Predicate)
+	assert.Equal(t, "wildan@gmail.com", report.DuplicateValues[0].Value)
+	assert.ElementsMatch(t, []string{authors[0].UID, authors[1].UID}, report.DuplicateValues[0].UIDs)
+}
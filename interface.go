@@ -29,11 +29,13 @@ type TxnInterface interface {
 	SetCommitNow() *TxnContext
 	BestEffort() *TxnContext
 	Txn() *dgo.Txn
+	Renew() *TxnContext
 	WithContext(context.Context)
 	Context() context.Context
 	Mutate(data interface{}) ([]string, error)
 	MutateOrGet(data interface{}, predicates ...string) ([]string, error)
 	Upsert(data interface{}, predicates ...string) ([]string, error)
+	MutateWithOptions(data interface{}, opts MutateOptions) ([]string, error)
 	Delete(params ...*DeleteParams) error
 	DeleteQuery(query *QueryBlock, params ...*DeleteParams) (DeleteQuery, error)
 	DeleteNode(uids ...string) error
@@ -41,11 +43,42 @@ type TxnInterface interface {
 	Get(model interface{}) *Query
 }
 
+// Store provides the basic mutations and queries of TxnContext, for stores to depend on instead of
+// TxnContext, so they can be unit tested without Dgraph using the in-memory fake of dgmantest
+type Store interface {
+	Mutate(data interface{}) ([]string, error)
+	Upsert(data interface{}, predicates ...string) ([]string, error)
+	GetByUID(dst interface{}, uid string) error
+	Find(dst interface{}, filter *Filter) error
+	DeleteNode(uids ...string) error
+}
+
 // SchemaType allows defining a custom type as a dgraph schema type
 type SchemaType interface {
 	SchemaType() string
 }
 
+// SchemaExtension allows a node type to append raw schema definitions to the generated schema,
+// e.g: for Dgraph features not supported by dgman
+type SchemaExtension interface {
+	SchemaExtension() string
+}
+
+// Computer allows a node to populate computed fields, e.g: age from date of birth,
+// Compute is called on each node, including nested nodes, after query results are scanned
+type Computer interface {
+	Compute()
+}
+
+// MutationConditioner allows a node to only be mutated when the existing node matches a filter,
+// e.g: only updating with newer data, with dgman.Lt("updated_at", user.UpdatedAt).
+// New nodes are always created. The condition is merged with the generated unique checks,
+// a node failing its condition is skipped along with its child nodes.
+type MutationConditioner interface {
+	MutationCondition() *Filter
+}
+
 var (
 	_ TxnInterface = (*TxnContext)(nil)
+	_ Store        = (*TxnContext)(nil)
 )
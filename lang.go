@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"reflect"
+	"strings"
+)
+
+// langBase returns the base predicate of a language tagged predicate, e.g: name of name@en,
+// returns an empty string when the predicate is not language tagged
+func langBase(predicate string) string {
+	if isFacet(predicate) || strings.HasPrefix(predicate, "~") {
+		return ""
+	}
+	if i := strings.IndexByte(predicate, '@'); i > 0 {
+		return predicate[:i]
+	}
+	return ""
+}
+
+// langPredicates returns the language tagged predicates of a model, e.g: name@en,
+// which are not returned by expand(_all_)
+func langPredicates(model interface{}) []string {
+	if model == nil {
+		return nil
+	}
+	modelType := getElemType(reflect.TypeOf(model))
+	if modelType.Kind() != reflect.Struct {
+		return nil
+	}
+	return langFields(modelType)
+}
+
+func langFields(modelType reflect.Type) []string {
+	var predicates []string
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && field.Anonymous {
+			predicates = append(predicates, langFields(fieldType)...)
+			continue
+		}
+
+		predicate, _ := getPredicate(&field)
+		if langBase(predicate) != "" {
+			predicates = append(predicates, predicate)
+		}
+	}
+	return predicates
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type LangArticle struct {
+	UID     string   `json:"uid,omitempty"`
+	Title   string   `json:"title,omitempty" dgraph:"index=term"`
+	TitleEn string   `json:"title@en,omitempty"`
+	TitleDe string   `json:"title@de,omitempty"`
+	BodyFr  string   `json:"body@fr,omitempty"`
+	DType   []string `json:"dgraph.type,omitempty"`
+}
+
+func TestLangSchema(t *testing.T) {
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", LangArticle{})
+
+	assert.Equal(t, "title: string @index(term) @lang .", typeSchema.Schema["title"].String())
+	assert.Equal(t, "body: string @lang .", typeSchema.Schema["body"].String())
+	assert.NotContains(t, typeSchema.Schema, "title@en")
+	assert.Contains(t, typeSchema.Types["LangArticle"], "body")
+}
+
+func TestLangQuery(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"data":[{"uid":"0x1","title":"Dgraph","title@en":"Graph","title@de":"Graph DE","body@fr":"Le graphe"}]}`),
+	})
+
+	var article LangArticle
+	require.NoError(t, tx.Get(&article).UID("0x1").Node())
+	assert.Equal(t, LangArticle{
+		UID:     "0x1",
+		Title:   "Dgraph",
+		TitleEn: "Graph",
+		TitleDe: "Graph DE",
+		BodyFr:  "Le graphe",
+	}, article)
+
+	require.Len(t, fake.requests, 1)
+	assert.Equal(t, `{
+	data(func: uid(0x1), first: 1) @filter(has(dgraph.type)) {
+		uid
+		dgraph.type
+		expand(_all_)
+		title@en
+		title@de
+		body@fr
+	}
+}`, fake.requests[0].Query)
+}
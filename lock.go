@@ -0,0 +1,190 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrLockHeld is returned when acquiring a lock that is held by another owner
+	ErrLockHeld = errors.New("lock is held by another owner")
+	// ErrLockNotHeld is returned when releasing or refreshing a lock that has expired and is acquired by another owner
+	ErrLockNotHeld = errors.New("lock is not held")
+)
+
+// Lock is a distributed lock stored as a node in Dgraph, identified by a unique name,
+// which expires after a TTL unless refreshed. Before using locks, the lock schema must
+// be created, e.g: CreateSchema(c, &Lock{}).
+//
+// Locks can be used for leader election, by acquiring a lock and refreshing it
+// periodically, with a refresh interval shorter than the TTL.
+type Lock struct {
+	UID     string    `json:"uid,omitempty"`
+	Name    string    `json:"dgman.lock.name,omitempty" dgraph:"index=exact upsert"`
+	Owner   string    `json:"dgman.lock.owner,omitempty" dgraph:"index=exact"`
+	Expires time.Time `json:"dgman.lock.expires,omitempty"`
+	DType   []string  `json:"dgraph.type,omitempty" dgraph:"DgmanLock"`
+
+	client DgraphClient
+	ttl    time.Duration
+}
+
+type lockResult struct {
+	Locks []*Lock `json:"locks"`
+}
+
+func newLockOwner() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+func doLockRequest(ctx context.Context, c DgraphClient, query *QueryBlock, mutations ...*api.Mutation) (*api.Response, *lockResult, error) {
+	resp, err := c.NewTxn().Do(ctx, &api.Request{
+		Query:     query.String(),
+		Mutations: mutations,
+		CommitNow: true,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result lockResult
+	if err := json.Unmarshal(resp.Json, &result); err != nil {
+		return nil, nil, errors.Wrap(err, "unmarshal lock result failed")
+	}
+	return resp, &result, nil
+}
+
+// AcquireLock acquires a lock by name, which expires after ttl. If the lock is held by
+// another owner and has not expired, ErrLockHeld is returned.
+// Concurrent acquires of the same lock will abort all but one, returning dgo.ErrAborted.
+func AcquireLock(ctx context.Context, c DgraphClient, name string, ttl time.Duration) (*Lock, error) {
+	owner, err := newLockOwner()
+	if err != nil {
+		return nil, errors.Wrap(err, "generate lock owner failed")
+	}
+
+	now := time.Now()
+	lock := &Lock{
+		UID:     "_:lock",
+		Name:    name,
+		Owner:   owner,
+		Expires: now.Add(ttl),
+		DType:   []string{"DgmanLock"},
+		client:  c,
+		ttl:     ttl,
+	}
+
+	createJSON, err := json.Marshal(lock)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal lock failed")
+	}
+	takeoverJSON, err := json.Marshal(&Lock{
+		UID:     "uid(expired)",
+		Owner:   lock.Owner,
+		Expires: lock.Expires,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal lock failed")
+	}
+
+	query := NewQueryBlock(
+		NewQuery().As("lock").Var().
+			RootFunc(parseQueryWithParams("eq(dgman.lock.name, $1)", []interface{}{name})).
+			Filter("type(DgmanLock)"),
+		NewQuery().As("expired").Var().UID("lock").
+			Filter("lt(dgman.lock.expires, $1)", now),
+		NewQuery().Name("locks").UID("lock").
+			Query("{ uid dgman.lock.expires }"),
+	)
+
+	resp, result, err := doLockRequest(ctx, c, query,
+		&api.Mutation{SetJson: createJSON, Cond: "@if(eq(len(lock), 0))"},
+		&api.Mutation{SetJson: takeoverJSON, Cond: "@if(eq(len(expired), 1))"},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "acquire lock failed")
+	}
+
+	if uid, created := resp.Uids["lock"]; created {
+		lock.UID = uid
+		return lock, nil
+	}
+
+	if len(result.Locks) > 0 && result.Locks[0].Expires.Before(now) {
+		lock.UID = result.Locks[0].UID
+		return lock, nil
+	}
+
+	return nil, ErrLockHeld
+}
+
+// Refresh extends the lock expiry by its TTL from now.
+// ErrLockNotHeld is returned if the lock has expired and is acquired by another owner.
+func (l *Lock) Refresh(ctx context.Context) error {
+	expires := time.Now().Add(l.ttl)
+	setJSON, err := json.Marshal(&Lock{UID: "uid(lock)", Expires: expires})
+	if err != nil {
+		return errors.Wrap(err, "marshal lock failed")
+	}
+
+	_, result, err := doLockRequest(ctx, l.client, l.ownedQuery(),
+		&api.Mutation{SetJson: setJSON, Cond: "@if(eq(len(lock), 1))"})
+	if err != nil {
+		return errors.Wrap(err, "refresh lock failed")
+	}
+	if len(result.Locks) == 0 {
+		return ErrLockNotHeld
+	}
+
+	l.Expires = expires
+	return nil
+}
+
+// Release releases the lock, deleting the lock node.
+// ErrLockNotHeld is returned if the lock has expired and is acquired by another owner.
+func (l *Lock) Release(ctx context.Context) error {
+	_, result, err := doLockRequest(ctx, l.client, l.ownedQuery(),
+		&api.Mutation{DelNquads: []byte("uid(lock) * * ."), Cond: "@if(eq(len(lock), 1))"})
+	if err != nil {
+		return errors.Wrap(err, "release lock failed")
+	}
+	if len(result.Locks) == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// ownedQuery queries the lock node only if it is still owned by this lock
+func (l *Lock) ownedQuery() *QueryBlock {
+	return NewQueryBlock(
+		NewQuery().As("lock").Var().UID(string(UID(l.UID).FormatParams())).
+			Filter("eq(dgman.lock.owner, $1)", l.Owner),
+		NewQuery().Name("locks").UID("lock").
+			Query("{ uid }"),
+	)
+}
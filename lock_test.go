@@ -0,0 +1,64 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock(t *testing.T) {
+	c := newDgraphClient()
+	_, err := CreateSchema(c, &Lock{})
+	require.NoError(t, err)
+	defer dropAll(c)
+
+	ctx := context.Background()
+
+	lock, err := AcquireLock(ctx, c, "leader", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, isUID(lock.UID))
+
+	_, err = AcquireLock(ctx, c, "leader", time.Minute)
+	assert.Equal(t, ErrLockHeld, err)
+
+	// other lock names are independent
+	other, err := AcquireLock(ctx, c, "other", time.Minute)
+	require.NoError(t, err)
+	assert.NotEqual(t, lock.UID, other.UID)
+
+	require.NoError(t, lock.Refresh(ctx))
+	require.NoError(t, lock.Release(ctx))
+
+	expiring, err := AcquireLock(ctx, c, "leader", time.Millisecond)
+	require.NoError(t, err)
+
+	time.Sleep(10 * time.Millisecond)
+
+	// expired lock can be taken over
+	takeover, err := AcquireLock(ctx, c, "leader", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, expiring.UID, takeover.UID)
+
+	assert.Equal(t, ErrLockNotHeld, expiring.Refresh(ctx))
+	assert.Equal(t, ErrLockNotHeld, expiring.Release(ctx))
+	assert.NoError(t, takeover.Release(ctx))
+}
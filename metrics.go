@@ -0,0 +1,196 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+)
+
+const (
+	// MetricsQuery is the operation of query requests
+	MetricsQuery = "query"
+	// MetricsMutation is the operation of mutation requests without a query
+	MetricsMutation = "mutation"
+	// MetricsUpsert is the operation of mutation requests with a query, e.g: unique checks
+	MetricsUpsert = "upsert"
+)
+
+// RequestMetrics are the latencies and metrics of a request returned by Dgraph,
+// tagged by the query name and the node type
+type RequestMetrics struct {
+	// Operation is the request operation, i.e: MetricsQuery, MetricsMutation, or MetricsUpsert
+	Operation string
+	// QueryName is the name of the query block, empty on mutations and queries sent without a query block
+	QueryName string
+	// NodeType is the node type of the query model, or of the mutated nodes
+	NodeType string
+
+	ParsingLatency         time.Duration
+	ProcessingLatency      time.Duration
+	EncodingLatency        time.Duration
+	AssignTimestampLatency time.Duration
+	TotalLatency           time.Duration
+	// NumUids are the number of uids touched by predicate, including the total as "_total"
+	NumUids map[string]uint64
+	// ResponseBytes is the size of the json response
+	ResponseBytes int
+	// Err is the error of the request, latencies and metrics are empty on errors
+	Err error
+}
+
+// MetricsCollector collects the metrics of the requests of transactions, e.g: into Prometheus
+type MetricsCollector interface {
+	Collect(ctx context.Context, metrics *RequestMetrics)
+}
+
+// MetricsCollectorFunc is a function implementing MetricsCollector
+type MetricsCollectorFunc func(ctx context.Context, metrics *RequestMetrics)
+
+// Collect implements the MetricsCollector interface
+func (f MetricsCollectorFunc) Collect(ctx context.Context, metrics *RequestMetrics) {
+	f(ctx, metrics)
+}
+
+// SetMetricsCollector sets the metrics collector for requests of transactions created from the client,
+// passing nil removes the metrics collector of the client. Queries returned from a query cache are not collected.
+func (c *Client) SetMetricsCollector(collector MetricsCollector) *Client {
+	c.dg.config.metrics = collector
+	return c
+}
+
+type metricsTagsKey struct{}
+
+// metricsTags are the tags of the metrics of a request, passed by the context
+type metricsTags struct {
+	queryName string
+	nodeType  string
+}
+
+// withMetricsTags returns a context tagging the metrics of requests with a query name and the node type of a model
+func withMetricsTags(ctx context.Context, queryName string, model interface{}) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	tags := metricsTags{queryName: queryName}
+	if model != nil {
+		if modelType := getElemType(reflect.TypeOf(model)); modelType.Kind() == reflect.Struct {
+			tags.nodeType = getNodeType(modelType)
+		}
+	}
+	return context.WithValue(ctx, metricsTagsKey{}, tags)
+}
+
+// withMetricsTags returns a context tagging the metrics of the query block with the names of its queries,
+// joined by commas, and the node type of the first query with a model
+func (q *QueryBlock) withMetricsTags(ctx context.Context) context.Context {
+	var names []string
+	var model interface{}
+	for _, block := range q.blocks {
+		if !block.isVar {
+			names = append(names, block.name)
+		}
+		if model == nil {
+			model = block.model
+		}
+	}
+	return withMetricsTags(ctx, strings.Join(names, ","), model)
+}
+
+// metricsTxn collects the metrics of the responses of a transaction
+type metricsTxn struct {
+	transaction
+	collector MetricsCollector
+}
+
+// withMetrics wraps a transaction to collect metrics, when the collector is set
+func withMetrics(txn transaction, collector MetricsCollector) transaction {
+	if collector == nil {
+		return txn
+	}
+	return &metricsTxn{transaction: txn, collector: collector}
+}
+
+// newRequestMetrics returns the metrics of a request, tagged by the metrics tags of the context
+func newRequestMetrics(ctx context.Context, operation string, resp *api.Response, err error) *RequestMetrics {
+	metrics := &RequestMetrics{Operation: operation, Err: err}
+	if tags, ok := ctx.Value(metricsTagsKey{}).(metricsTags); ok {
+		metrics.QueryName = tags.queryName
+		metrics.NodeType = tags.nodeType
+	}
+	if resp != nil {
+		metrics.ResponseBytes = len(resp.Json)
+		if latency := resp.Latency; latency != nil {
+			metrics.ParsingLatency = time.Duration(latency.ParsingNs)
+			metrics.ProcessingLatency = time.Duration(latency.ProcessingNs)
+			metrics.EncodingLatency = time.Duration(latency.EncodingNs)
+			metrics.AssignTimestampLatency = time.Duration(latency.AssignTimestampNs)
+			metrics.TotalLatency = time.Duration(latency.TotalNs)
+		}
+		if resp.Metrics != nil {
+			metrics.NumUids = resp.Metrics.NumUids
+		}
+	}
+	return metrics
+}
+
+// requestOperation returns the operation of a request
+func requestOperation(req *api.Request) string {
+	if len(req.Mutations) == 0 {
+		return MetricsQuery
+	}
+	if req.Query != "" {
+		return MetricsUpsert
+	}
+	return MetricsMutation
+}
+
+func (m *metricsTxn) unwrap() transaction {
+	return m.transaction
+}
+
+func (m *metricsTxn) collect(ctx context.Context, operation string, resp *api.Response, err error) {
+	m.collector.Collect(ctx, newRequestMetrics(ctx, operation, resp, err))
+}
+
+func (m *metricsTxn) Query(ctx context.Context, q string) (*api.Response, error) {
+	resp, err := m.transaction.Query(ctx, q)
+	m.collect(ctx, MetricsQuery, resp, err)
+	return resp, err
+}
+
+func (m *metricsTxn) QueryWithVars(ctx context.Context, q string, vars map[string]string) (*api.Response, error) {
+	resp, err := m.transaction.QueryWithVars(ctx, q, vars)
+	m.collect(ctx, MetricsQuery, resp, err)
+	return resp, err
+}
+
+func (m *metricsTxn) Mutate(ctx context.Context, mu *api.Mutation) (*api.Response, error) {
+	resp, err := m.transaction.Mutate(ctx, mu)
+	m.collect(ctx, MetricsMutation, resp, err)
+	return resp, err
+}
+
+func (m *metricsTxn) Do(ctx context.Context, req *api.Request) (*api.Response, error) {
+	resp, err := m.transaction.Do(ctx, req)
+	m.collect(ctx, requestOperation(req), resp, err)
+	return resp, err
+}
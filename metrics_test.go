@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	dc := &responseClient{responses: []*api.Response{
+		{
+			Json:    []byte(`{"users":[{"uid":"0x1","name":"wildan"}]}`),
+			Latency: &api.Latency{ParsingNs: 10, ProcessingNs: 20, EncodingNs: 30, TotalNs: 60},
+			Metrics: &api.Metrics{NumUids: map[string]uint64{"_total": 2, "name": 1}},
+		},
+		{
+			Uids:    map[string]string{"user": "0x2"},
+			Latency: &api.Latency{AssignTimestampNs: 5, TotalNs: 15},
+		},
+		{Json: []byte(`{"data":[]}`)},
+	}}
+	client := NewClient(dc)
+	c := client.Dgraph()
+
+	var collected []*RequestMetrics
+	client.SetMetricsCollector(MetricsCollectorFunc(func(ctx context.Context, metrics *RequestMetrics) {
+		collected = append(collected, metrics)
+	}))
+
+	var users []TestModel
+	require.NoError(t, NewReadOnlyTxn(c).Get(&users).Name("users").Nodes())
+	require.Len(t, collected, 1)
+	assert.Equal(t, &RequestMetrics{
+		Operation:         MetricsQuery,
+		QueryName:         "users",
+		NodeType:          "TestModel",
+		ParsingLatency:    10 * time.Nanosecond,
+		ProcessingLatency: 20 * time.Nanosecond,
+		EncodingLatency:   30 * time.Nanosecond,
+		TotalLatency:      60 * time.Nanosecond,
+		NumUids:           map[string]uint64{"_total": 2, "name": 1},
+		ResponseBytes:     len(`{"users":[{"uid":"0x1","name":"wildan"}]}`),
+	}, collected[0])
+
+	user := &TestModel{UID: "_:user", Name: "dolan"}
+	_, err := NewTxn(c).SetCommitNow().Mutate(user)
+	require.NoError(t, err)
+	require.Len(t, collected, 2)
+	assert.Equal(t, MetricsMutation, collected[1].Operation)
+	assert.Equal(t, "TestModel", collected[1].NodeType)
+	assert.Equal(t, 5*time.Nanosecond, collected[1].AssignTimestampLatency)
+	assert.Equal(t, 15*time.Nanosecond, collected[1].TotalLatency)
+
+	client.SetMetricsCollector(nil)
+	require.NoError(t, NewReadOnlyTxn(c).Get(&users).Nodes())
+	assert.Len(t, collected, 2)
+}
+
+func TestMetricsCollectorError(t *testing.T) {
+	queryErr := errors.New("query failed")
+	fake := &fakeTxn{err: queryErr}
+
+	var collected *RequestMetrics
+	txn := withMetrics(fake, MetricsCollectorFunc(func(ctx context.Context, metrics *RequestMetrics) {
+		collected = metrics
+	}))
+
+	ctx := withMetricsTags(context.Background(), "users", &[]TestModel{})
+	_, err := txn.Do(ctx, &api.Request{Query: "{ q() }", Mutations: []*api.Mutation{{}}})
+	assert.Equal(t, queryErr, err)
+	require.NotNil(t, collected)
+	assert.Equal(t, &RequestMetrics{Operation: MetricsUpsert, QueryName: "users", NodeType: "TestModel", Err: queryErr}, collected)
+}
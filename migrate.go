@@ -0,0 +1,303 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+)
+
+// MigrationOp is the operation of a migration change
+type MigrationOp string
+
+const (
+	MigrationAddPredicate   MigrationOp = "add predicate"
+	MigrationAlterPredicate MigrationOp = "alter predicate"
+	MigrationDropIndex      MigrationOp = "drop index"
+	MigrationChangeType     MigrationOp = "change type"
+	MigrationDropPredicate  MigrationOp = "drop predicate"
+	MigrationAddType        MigrationOp = "add type"
+	MigrationAlterType      MigrationOp = "alter type"
+)
+
+// MigrationChange is a schema change of a predicate or a type
+type MigrationChange struct {
+	Op MigrationOp
+	// Name is the predicate or the type name
+	Name string
+	// From is the existing schema, empty on additions
+	From string
+	// To is the new schema, empty on drops
+	To string
+	// Destructive changes may remove data or indexes, and are only applied when allowed by MigrateOptions
+	Destructive bool
+	// Skipped is true when a destructive change is not allowed by MigrateOptions
+	Skipped bool
+}
+
+func (c MigrationChange) String() string {
+	var change string
+	switch {
+	case c.From == "":
+		change = fmt.Sprintf("%s %s: %s", c.Op, c.Name, c.To)
+	case c.To == "":
+		change = fmt.Sprintf("%s %s: %s", c.Op, c.Name, c.From)
+	default:
+		change = fmt.Sprintf("%s %s: %s -> %s", c.Op, c.Name, c.From, c.To)
+	}
+	if c.Skipped {
+		change += " (skipped)"
+	}
+	return change
+}
+
+// MigrationPlan is the list of changes to migrate the existing schema to the schema of the models
+type MigrationPlan struct {
+	Changes []MigrationChange
+	// Applied is true when the plan is applied, false on dry run
+	Applied bool
+
+	typeSchema *TypeSchema
+}
+
+func (p *MigrationPlan) String() string {
+	var buffer strings.Builder
+	for _, change := range p.Changes {
+		buffer.WriteString(change.String())
+		buffer.WriteByte('\n')
+	}
+	return buffer.String()
+}
+
+// Schema returns the alter schema of the changes to be applied
+func (p *MigrationPlan) Schema() string {
+	var buffer strings.Builder
+	for _, change := range p.Changes {
+		if change.Skipped || change.Op == MigrationDropPredicate {
+			continue
+		}
+		switch change.Op {
+		case MigrationAddType, MigrationAlterType:
+			buffer.WriteString(TypeMap{change.Name: p.typeSchema.Types[change.Name]}.String())
+		default:
+			buffer.WriteString(change.To)
+			buffer.WriteByte('\n')
+		}
+	}
+	return buffer.String()
+}
+
+// MigrateOptions specifies the destructive operations allowed on migration
+type MigrateOptions struct {
+	// DryRun only returns the migration plan without applying it
+	DryRun bool
+	// DropPredicates drops existing predicates not defined in the models, including their data
+	DropPredicates bool
+	// DropIndexes allows removing indexes, e.g: tokenizers, reverse, and count indexes
+	DropIndexes bool
+	// ChangeTypes allows changing the type of existing predicates
+	ChangeTypes bool
+}
+
+func (o *MigrateOptions) allows(op MigrationOp) bool {
+	switch op {
+	case MigrationDropPredicate:
+		return o.DropPredicates
+	case MigrationDropIndex:
+		return o.DropIndexes
+	case MigrationChangeType:
+		return o.ChangeTypes
+	}
+	return true
+}
+
+// Migrate diffs the schema of the models against the existing schema, and applies
+// the non-destructive changes, i.e: new predicates, new indexes, and type definitions,
+// returning the migration plan
+func Migrate(c DgraphClient, models ...interface{}) (*MigrationPlan, error) {
+	return MigrateWithOptions(c, MigrateOptions{}, models...)
+}
+
+// MigrateWithOptions diffs the schema of the models against the existing schema,
+// and applies the changes allowed by the migrate options, returning the migration plan
+func MigrateWithOptions(c DgraphClient, opts MigrateOptions, models ...interface{}) (*MigrationPlan, error) {
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", models...)
+
+	existingSchema, err := fetchExistingSchema(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch existing schema failed")
+	}
+
+	existingTypes, err := fetchExistingTypes(c, typeSchema.Types)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch existing types failed")
+	}
+
+	plan := planMigration(typeSchema, existingSchema, existingTypes, opts)
+	if opts.DryRun {
+		return plan, nil
+	}
+
+	ctx := context.Background()
+	for _, change := range plan.Changes {
+		if change.Op != MigrationDropPredicate || change.Skipped {
+			continue
+		}
+		if err := c.Alter(ctx, &api.Operation{DropAttr: change.Name}); err != nil {
+			return plan, errors.Wrapf(err, "drop predicate %s failed", change.Name)
+		}
+	}
+
+	if alterSchema := plan.Schema(); alterSchema != "" {
+		if err := c.Alter(ctx, &api.Operation{Schema: alterSchema}); err != nil {
+			return plan, errors.Wrap(err, "alter schema failed")
+		}
+	}
+
+	plan.Applied = true
+	return plan, nil
+}
+
+// normalizeSchema normalizes a schema to be compared with the existing schema
+func normalizeSchema(s *Schema) *Schema {
+	normalized := &Schema{
+		Predicate:  s.Predicate,
+		Type:       s.Type,
+		List:       s.List,
+		Reverse:    s.Reverse,
+		Count:      s.Count,
+		Upsert:     s.Upsert || s.Unique,
+		Lang:       s.Lang,
+		Noconflict: s.Noconflict,
+	}
+	if strings.HasPrefix(normalized.Type, "[") {
+		normalized.Type = strings.Trim(normalized.Type, "[]")
+		normalized.List = true
+	}
+	if s.Index || len(s.Tokenizer) > 0 {
+		normalized.Tokenizer = append([]string(nil), s.Tokenizer...)
+		sort.Strings(normalized.Tokenizer)
+		normalized.Index = len(normalized.Tokenizer) > 0
+	}
+	return normalized
+}
+
+// dropsIndex checks whether migrating from the existing schema removes any index
+func dropsIndex(existing, schema *Schema) bool {
+	if (existing.Reverse && !schema.Reverse) ||
+		(existing.Count && !schema.Count) ||
+		(existing.Lang && !schema.Lang) {
+		return true
+	}
+	tokenizers := newSet(schema.Tokenizer...)
+	for _, tokenizer := range existing.Tokenizer {
+		if !tokenizers.Has(tokenizer) {
+			return true
+		}
+	}
+	return false
+}
+
+func planMigration(typeSchema *TypeSchema, existingSchema []*Schema, existingTypes TypeMap, opts MigrateOptions) *MigrationPlan {
+	plan := &MigrationPlan{typeSchema: typeSchema}
+	addChange := func(change MigrationChange) {
+		change.Destructive = !(&MigrateOptions{}).allows(change.Op)
+		change.Skipped = !opts.allows(change.Op)
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	existingMap := make(SchemaMap, len(existingSchema))
+	for _, schema := range existingSchema {
+		existingMap[schema.Predicate] = schema
+	}
+
+	predicates := make([]string, 0, len(typeSchema.Schema))
+	for predicate := range typeSchema.Schema {
+		predicates = append(predicates, predicate)
+	}
+	sort.Strings(predicates)
+
+	for _, predicate := range predicates {
+		schema := typeSchema.Schema[predicate]
+		existing, ok := existingMap[predicate]
+		if !ok {
+			addChange(MigrationChange{Op: MigrationAddPredicate, Name: predicate, To: schema.String()})
+			continue
+		}
+
+		normalizedExisting, normalized := normalizeSchema(existing), normalizeSchema(schema)
+		if normalizedExisting.String() == normalized.String() {
+			continue
+		}
+
+		op := MigrationAlterPredicate
+		if normalizedExisting.Type != normalized.Type || normalizedExisting.List != normalized.List {
+			op = MigrationChangeType
+		} else if dropsIndex(normalizedExisting, normalized) {
+			op = MigrationDropIndex
+		}
+		addChange(MigrationChange{Op: op, Name: predicate, From: existing.String(), To: schema.String()})
+	}
+
+	var removed []string
+	for _, existing := range existingSchema {
+		_, defined := typeSchema.Schema[existing.Predicate]
+		if !defined && !strings.HasPrefix(existing.Predicate, "dgraph.") {
+			removed = append(removed, existing.Predicate)
+		}
+	}
+	sort.Strings(removed)
+	for _, predicate := range removed {
+		addChange(MigrationChange{Op: MigrationDropPredicate, Name: predicate, From: existingMap[predicate].String()})
+	}
+
+	types := make([]string, 0, len(typeSchema.Types))
+	for nodeType := range typeSchema.Types {
+		types = append(types, nodeType)
+	}
+	sort.Strings(types)
+
+	for _, nodeType := range types {
+		fields := typeFields(typeSchema.Types[nodeType])
+		existing, ok := existingTypes[nodeType]
+		if !ok {
+			addChange(MigrationChange{Op: MigrationAddType, Name: nodeType, To: fields})
+			continue
+		}
+		if existingFields := typeFields(existing); existingFields != fields {
+			addChange(MigrationChange{Op: MigrationAlterType, Name: nodeType, From: existingFields, To: fields})
+		}
+	}
+
+	return plan
+}
+
+// typeFields returns the sorted fields of a type
+func typeFields(schemaMap SchemaMap) string {
+	fields := make([]string, 0, len(schemaMap))
+	for field := range schemaMap {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return "{ " + strings.Join(fields, " ") + " }"
+}
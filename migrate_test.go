@@ -0,0 +1,127 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MigrateProduct struct {
+	UID   string   `json:"uid,omitempty"`
+	Name  string   `json:"name,omitempty" dgraph:"index=term,exact"`
+	Price int      `json:"price,omitempty" dgraph:"index=int"`
+	Tags  []string `json:"tags,omitempty"`
+	DType []string `json:"dgraph.type"`
+}
+
+func TestPlanMigration(t *testing.T) {
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", MigrateProduct{})
+
+	existingSchema := []*Schema{
+		{Predicate: "dgraph.type", Type: "string", List: true, Index: true, Tokenizer: []string{"exact"}},
+		{Predicate: "name", Type: "string", Index: true, Tokenizer: []string{"exact", "hash"}},
+		{Predicate: "price", Type: "float", Index: true, Tokenizer: []string{"float"}},
+		{Predicate: "tags", Type: "string", List: true},
+		{Predicate: "sku", Type: "string", Index: true, Tokenizer: []string{"exact"}},
+	}
+	existingTypes := TypeMap{
+		"MigrateProduct": SchemaMap{"name": nil, "price": nil, "sku": nil},
+	}
+
+	tests := []struct {
+		name    string
+		opts    MigrateOptions
+		skipped []bool
+	}{
+		{
+			name:    "non-destructive",
+			skipped: []bool{true, true, true, false},
+		},
+		{
+			name:    "destructive",
+			opts:    MigrateOptions{DropPredicates: true, DropIndexes: true, ChangeTypes: true},
+			skipped: []bool{false, false, false, false},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan := planMigration(typeSchema, existingSchema, existingTypes, test.opts)
+
+			expected := []MigrationChange{
+				{
+					Op:          MigrationDropIndex,
+					Name:        "name",
+					From:        "name: string @index(exact,hash) .",
+					To:          "name: string @index(term,exact) .",
+					Destructive: true,
+				},
+				{
+					Op:          MigrationChangeType,
+					Name:        "price",
+					From:        "price: float @index(float) .",
+					To:          "price: int @index(int) .",
+					Destructive: true,
+				},
+				{
+					Op:          MigrationDropPredicate,
+					Name:        "sku",
+					From:        "sku: string @index(exact) .",
+					Destructive: true,
+				},
+				{
+					Op:   MigrationAlterType,
+					Name: "MigrateProduct",
+					From: "{ name price sku }",
+					To:   "{ name price tags }",
+				},
+			}
+			for i := range expected {
+				expected[i].Skipped = test.skipped[i]
+			}
+
+			assert.Equal(t, expected, plan.Changes)
+		})
+	}
+}
+
+func TestPlanMigrationAdd(t *testing.T) {
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", MigrateProduct{})
+
+	existingSchema := []*Schema{
+		{Predicate: "name", Type: "string", Index: true, Tokenizer: []string{"exact"}},
+		{Predicate: "price", Type: "int", Index: true, Tokenizer: []string{"int"}},
+	}
+
+	plan := planMigration(typeSchema, existingSchema, TypeMap{}, MigrateOptions{})
+
+	assert.Equal(t, []MigrationChange{
+		{Op: MigrationAlterPredicate, Name: "name", From: "name: string @index(exact) .", To: "name: string @index(term,exact) ."},
+		{Op: MigrationAddPredicate, Name: "tags", To: "tags: [string] ."},
+		{Op: MigrationAddType, Name: "MigrateProduct", To: "{ name price tags }"},
+	}, plan.Changes)
+
+	alterSchema := plan.Schema()
+	assert.Contains(t, alterSchema, "name: string @index(term,exact) .\n")
+	assert.Contains(t, alterSchema, "tags: [string] .\n")
+	assert.Contains(t, alterSchema, "type MigrateProduct {")
+	assert.NotContains(t, alterSchema, "price: int")
+}
@@ -17,6 +17,7 @@
 package dgman
 
 import (
+	"context"
 	stdjson "encoding/json"
 	"fmt"
 	"reflect"
@@ -37,15 +38,100 @@ const (
 	mutationUpsert
 )
 
+// UniqueConflict specifies how a mutation handles an existing node with the same unique predicate value
+type UniqueConflict uint8
+
+const (
+	// UniqueConflictError returns a UniqueError, as in Mutate
+	UniqueConflictError UniqueConflict = iota
+	// UniqueConflictGet gets the existing node and injects it into the struct values, as in MutateOrGet
+	UniqueConflictGet
+	// UniqueConflictUpdate updates the existing node and injects it into the struct values, as in Upsert
+	UniqueConflictUpdate
+)
+
+// MutateOptions specifies the behavior of a mutation
+type MutateOptions struct {
+	// SkipUnique skips unique checking on fields, as in MutateBasic,
+	// cannot be used with other unique conflict handling than UniqueConflictError
+	SkipUnique bool
+	// OnUniqueConflict specifies how to handle an existing node with the same unique predicate value
+	OnUniqueConflict UniqueConflict
+	// UpsertPredicates specifies the predicates to be unique checked for getting or updating existing nodes.
+	// A single node type can only have a single upsert predicate, resolved per node type when mixing node types,
+	// where the first specified predicate of the node type is used.
+	UpsertPredicates []string
+	// CommitNow commits the transaction on the mutation, as in TxnContext.SetCommitNow
+	CommitNow bool
+	// SkipValidation skips validating nodes against the validation rules in their dgraph tags
+	SkipValidation bool
+	// ReplaceEdges specifies the edge predicates which existing edges are replaced by the edges of the mutated nodes,
+	// instead of adding to the existing edges, cannot be used when skipping unique checks
+	ReplaceEdges []string
+	// AsNquads sends the set mutations as RDF n-quads instead of JSON, e.g: to be returned by DryRun for
+	// external tooling, nodes are written with their blank node aliases, and the facets of predicates and edges
+	AsNquads bool
+	// BlankUID names the blank nodes of new nodes without a uid, instead of the global counter, e.g: PathBlankUID
+	// for deterministic blank node names across runs
+	BlankUID BlankUIDFunc
+	// UpdateMask specifies the predicates to be set on the mutated nodes, other predicates are left untouched,
+	// except the uid, dgraph.type, and the upsert predicate of a node, cannot be used when skipping unique checks
+	UpdateMask []string
+}
+
+// MutateResult is the result of a mutation
+type MutateResult struct {
+	// UIDs are the uids of the created nodes
+	UIDs []string
+	// BlankUIDs maps the blank node names of the mutated nodes, without the "_:" prefix, to their uids,
+	// including existing nodes resolved on unique conflicts, as in MutateOrGet and Upsert
+	BlankUIDs map[string]string
+}
+
+func (o *MutateOptions) opcode() (mutationOpCode, error) {
+	if o.SkipUnique {
+		if o.OnUniqueConflict != UniqueConflictError {
+			return 0, errors.New("unique conflict handling cannot be used when skipping unique checks")
+		}
+		if len(o.ReplaceEdges) > 0 {
+			return 0, errors.New("replacing edges cannot be used when skipping unique checks")
+		}
+		if len(o.UpdateMask) > 0 {
+			return 0, errors.New("update mask cannot be used when skipping unique checks")
+		}
+		return mutationMutateBasic, nil
+	}
+
+	switch o.OnUniqueConflict {
+	case UniqueConflictError:
+		return mutationMutate, nil
+	case UniqueConflictGet:
+		return mutationMutateOrGet, nil
+	case UniqueConflictUpdate:
+		return mutationUpsert, nil
+	}
+	return 0, fmt.Errorf("unknown unique conflict handling %d", o.OnUniqueConflict)
+}
+
 // UniqueError returns the field and value that failed the unique node check
 type UniqueError struct {
 	NodeType string
 	Field    string
 	Value    interface{}
 	UID      string
+	// Fields are the predicates of a failed unique together constraint, joined by commas in Field,
+	// with their values in Value as []interface{}
+	Fields []string
 }
 
 func (u *UniqueError) Error() string {
+	if values, ok := u.Value.([]interface{}); ok && len(u.Fields) == len(values) {
+		fields := make([]string, len(u.Fields))
+		for i, field := range u.Fields {
+			fields[i] = fmt.Sprintf("%s=%v", field, values[i])
+		}
+		return fmt.Sprintf("%s with %s already exists at uid=%s", u.NodeType, strings.Join(fields, ", "), u.UID)
+	}
 	return fmt.Sprintf("%s with %s=%v already exists at uid=%s", u.NodeType, u.Field, u.Value, u.UID)
 }
 
@@ -61,6 +147,7 @@ type mutateType struct {
 	uidIndex    int
 	schema      []*Schema // maps struct index to dgraph schema
 	uidFuncPred string    // types with unique field must have a single predicate that determines the uid func
+	uidFuncRank int       // rank of the uid func predicate, see upsertRank
 	nodeType    string
 }
 
@@ -68,31 +155,140 @@ func isUIDAlias(uid string) bool {
 	return strings.HasPrefix(uid, "_:")
 }
 
-func (m *mutateType) getID(v reflect.Value) string {
-	id := v.Field(m.uidIndex).String()
-	if isUIDAlias(id) {
-		return v.Field(m.uidIndex).String()[2:]
+func (m *mutateType) hasPredicate(predicate string) bool {
+	for _, schema := range m.schema {
+		if schema.Predicate == predicate {
+			return true
+		}
 	}
-	return id
+	return false
 }
 
-func newMutateType(numFields int) *mutateType {
-	return &mutateType{
-		uidIndex: -1,
-		schema:   make([]*Schema, 0, numFields),
+// getID returns the id of a node, i.e: its uid, uid func, or blank uid name
+func (m *mutation) getID(v reflect.Value, mutateType *mutateType) string {
+	id := m.uidOf(v.Field(mutateType.uidIndex))
+	if isUIDAlias(id) {
+		return id[2:]
 	}
+	return id
 }
 
 type preparedMutation struct {
 	queries    []string
 	conditions []string
 	value      map[string]interface{}
+	oneEdges   []oneEdge
+	// replaceEdges are the edges which existing edges are replaced on mutation
+	replaceEdges []oneEdge
+	// reverseEdges are the forward edges of the parent nodes of the reverse edge fields
+	reverseEdges []reverseEdge
+}
+
+// oneEdge is a uid edge with cardinality=one, which existing edges are deleted on mutation
+type oneEdge struct {
+	id        string
+	nodeType  string
+	predicate string
+}
+
+// nodeRef returns the uid func and the n-quad term of a node uid
+func nodeRef(uid string) (uidFunc, term string) {
+	if isUIDFunc(uid) {
+		return uid, uid
+	}
+	return fmt.Sprintf("uid(%s)", uid), fmt.Sprintf("<%s>", uid)
+}
+
+// generateOneEdge generates the queries and n-quads for deleting the existing edge of a node,
+// and the edges of other nodes to the new edge target, enforcing one-to-one edges.
+// Generated after walking all nodes, as node and edge uids are resolved to uid funcs on upsert.
+func generateOneEdge(nodeValue map[string]interface{}, edge oneEdge) (queries, delNquads []string) {
+	nodeUID, _ := nodeValue[predicateUid].(string)
+	var targetUID string
+	if target, ok := nodeValue[edge.predicate].(map[string]interface{}); ok {
+		targetUID, _ = target[predicateUid].(string)
+	}
+	isExistingNode := isUID(nodeUID) || isUIDFunc(nodeUID)
+	isExistingTarget := isUID(targetUID) || isUIDFunc(targetUID)
+
+	if isExistingNode {
+		nodeFunc, nodeTerm := nodeRef(nodeUID)
+		variable := fmt.Sprintf("c_%s", edge.id)
+
+		var filter string
+		if isExistingTarget {
+			targetFunc, _ := nodeRef(targetUID)
+			filter = fmt.Sprintf(" @filter(NOT %s)", targetFunc)
+		}
+
+		queries = append(queries, fmt.Sprintf("\tvar(func: %s) {\n\t\t%s as %s%s\n\t}", nodeFunc, variable, edge.predicate, filter))
+		delNquads = append(delNquads, fmt.Sprintf("%s <%s> uid(%s) .", nodeTerm, edge.predicate, variable))
+	}
+
+	if isExistingTarget {
+		_, targetTerm := nodeRef(targetUID)
+		variable := fmt.Sprintf("r_%s", edge.id)
+
+		// uid_in takes either a uid literal or a uid func
+		filter := fmt.Sprintf("uid_in(%s, %s)", edge.predicate, targetUID)
+		if isExistingNode {
+			nodeFunc, _ := nodeRef(nodeUID)
+			filter = fmt.Sprintf("%s AND NOT %s", filter, nodeFunc)
+		}
+
+		queries = append(queries, fmt.Sprintf("\tvar(func: type(%s)) @filter(%s) {\n\t\t%s as uid\n\t}", edge.nodeType, filter, variable))
+		delNquads = append(delNquads, fmt.Sprintf("uid(%s) <%s> %s .", variable, edge.predicate, targetTerm))
+	}
+
+	return queries, delNquads
+}
+
+// generateReplaceEdges generates the queries and n-quads for deleting the existing edges of a node
+// which are not in the mutation, so the edges of the mutation replace the existing edges.
+// Generated after walking all nodes, as node and edge uids are resolved to uid funcs on upsert.
+func generateReplaceEdges(nodeValue map[string]interface{}, edge oneEdge) (queries, delNquads []string) {
+	nodeUID, _ := nodeValue[predicateUid].(string)
+	if !isUID(nodeUID) && !isUIDFunc(nodeUID) {
+		// new nodes have no existing edges
+		return nil, nil
+	}
+
+	var targets []map[string]interface{}
+	switch value := nodeValue[edge.predicate].(type) {
+	case []map[string]interface{}:
+		targets = value
+	case map[string]interface{}:
+		targets = append(targets, value)
+	}
+
+	// uid takes uid literals and uid variables
+	var keep []string
+	for _, target := range targets {
+		targetUID, _ := target[predicateUid].(string)
+		if isUID(targetUID) {
+			keep = append(keep, targetUID)
+		} else if isUIDFunc(targetUID) {
+			keep = append(keep, targetUID[len("uid("):len(targetUID)-1])
+		}
+	}
+
+	nodeFunc, nodeTerm := nodeRef(nodeUID)
+	variable := fmt.Sprintf("e_%s", edge.id)
+	var filter string
+	if len(keep) > 0 {
+		filter = fmt.Sprintf(" @filter(NOT uid(%s))", strings.Join(keep, ", "))
+	}
+
+	queries = append(queries, fmt.Sprintf("\tvar(func: %s) {\n\t\t%s as %s%s\n\t}", nodeFunc, variable, edge.predicate, filter))
+	delNquads = append(delNquads, fmt.Sprintf("%s <%s> uid(%s) .", nodeTerm, edge.predicate, variable))
+	return queries, delNquads
 }
 
 type mutation struct {
 	data         interface{}
 	txn          *TxnContext
 	mutations    []preparedMutation
+	nodes        []reflect.Value
 	request      api.Request
 	queries      []string
 	typeCache    map[string]*mutateType
@@ -100,9 +296,22 @@ type mutation struct {
 	refCache     map[string]map[string]interface{}
 	parentUids   map[string]string
 	conditions   map[string][]string
+	uniqueNodes  map[string]reflect.Value
+	mergedNodes  [][2]reflect.Value
+	numericUIDs  map[uintptr]string
 	opcode       mutationOpCode
-	upsertFields set
-	depth        int
+	upsertFields []string
+	upsertTypes  map[string]string
+	replaceEdges set
+	updateMask   set
+	commitNow    bool
+	skipValidate bool
+	asNquads     bool
+	blankUID     BlankUIDFunc
+	mapOptions   *MapOptions
+	// uniqueExclude is a uid variable of nodes excluded from unique checks, e.g: the previous versions of a versioned node
+	uniqueExclude string
+	depth         int
 }
 
 func getCreatedUIDs(uidsMap map[string]string) []string {
@@ -113,27 +322,102 @@ func getCreatedUIDs(uidsMap map[string]string) []string {
 	return uids
 }
 
-func (m *mutation) mutate() ([]string, error) {
+// generateBasicMutation generates the mutation without unique checking
+func (m *mutation) generateBasicMutation() (*api.Mutation, error) {
+	if err := m.assignBlankUIDs(); err != nil {
+		return nil, err
+	}
+
 	preHook := generateSchemaHook{mutation: m, skipTyping: true}
 	err := reflectwalk.Walk(m.data, preHook)
 	if err != nil {
 		return nil, errors.Wrap(err, "pre-mutation hook failed")
 	}
 
+	var (
+		reverseEdges  []reverseEdge
+		reverseFields []reflect.Value
+	)
+	if err := reflectwalk.Walk(m.data, reverseEdgeHook{mutation: m, edges: &reverseEdges, fields: &reverseFields}); err != nil {
+		return nil, errors.Wrap(err, "reverse edges hook failed")
+	}
+
+	// reverse edge fields are not predicates of the nodes, the fields are detached on marshaling
+	restore := detachFields(reverseFields)
+	setJSON, err := m.marshalBasicMutation(reverseEdges)
+	restore()
+	if err != nil {
+		return nil, err
+	}
+
+	mu := &api.Mutation{CommitNow: m.commitNow}
+	if err := m.setMutation(mu, setJSON); err != nil {
+		return nil, err
+	}
+	return mu, nil
+}
+
+// marshalBasicMutation marshals the nodes of a basic mutation, along with the parent nodes of the reverse edges
+// with their forward edges
+func (m *mutation) marshalBasicMutation(reverseEdges []reverseEdge) ([]byte, error) {
+	// numeric uid fields of new nodes are encoded with their blank uids
+	for addr, uid := range m.numericUIDs {
+		marshalingUIDs.Store(addr, uid)
+	}
+	defer func() {
+		for addr := range m.numericUIDs {
+			marshalingUIDs.Delete(addr)
+		}
+	}()
+
 	setJSON, err := json.Marshal(m.data)
 	if err != nil {
 		return nil, errors.Wrap(err, "marshal setJSON failed")
 	}
+	if len(reverseEdges) == 0 {
+		return setJSON, nil
+	}
+
+	edgeNodes, err := m.reverseEdgeNodes(reverseEdges)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]interface{}, 0, 2*len(reverseEdges))
+	for _, edge := range reverseEdges {
+		nodes = append(nodes, edge.parent)
+	}
+	if setJSON, err = appendJSONNodes(setJSON, append(nodes, edgeNodes...)); err != nil {
+		return nil, errors.Wrap(err, "marshal reverse edges failed")
+	}
+	return setJSON, nil
+}
+
+// setMutation sets the set json of a mutation, or the set n-quads when mutating as n-quads
+func (m *mutation) setMutation(mu *api.Mutation, setJSON []byte) error {
+	if !m.asNquads {
+		mu.SetJson = setJSON
+		return nil
+	}
+	setNquads, err := jsonToNquads(setJSON)
+	if err != nil {
+		return errors.Wrap(err, "convert setJSON to n-quads failed")
+	}
+	mu.SetNquads = setNquads
+	return nil
+}
+
+func (m *mutation) mutate() ([]string, error) {
+	mu, err := m.generateBasicMutation()
+	if err != nil {
+		return nil, err
+	}
 
-	resp, err := m.txn.txn.Mutate(m.txn.ctx, &api.Mutation{
-		SetJson:   setJSON,
-		CommitNow: m.txn.commitNow,
-	})
+	resp, err := m.txn.txn.Mutate(withMetricsTags(m.txn.ctx, "", m.data), mu)
 	if err != nil {
 		return nil, errors.Wrap(err, "txn mutate failed")
 	}
 
-	postHook := setUIDHook{resp: resp}
+	postHook := setUIDHook{resp: resp, numericUIDs: m.numericUIDs}
 	err = reflectwalk.Walk(m.data, postHook)
 	if err != nil {
 		return nil, errors.Wrap(err, "post-mutation hook failed")
@@ -148,9 +432,9 @@ func (m *mutation) do() ([]string, error) {
 		return nil, errors.Wrap(err, "generate request failed")
 	}
 
-	resp, err := m.txn.txn.Do(m.txn.ctx, &m.request)
+	resp, err := m.send(withMetricsTags(m.txn.ctx, "", m.data))
 	if err != nil {
-		return nil, errors.Wrap(err, "do request failed")
+		return nil, err
 	}
 
 	err = m.processResponse(resp)
@@ -162,6 +446,10 @@ func (m *mutation) do() ([]string, error) {
 }
 
 func (m *mutation) generateRequest() error {
+	if err := m.assignBlankUIDs(); err != nil {
+		return err
+	}
+
 	preMutationHooks := []reflectwalk.StructWalker{
 		generateSchemaHook{mutation: m},
 		generateMutationHook{m},
@@ -172,32 +460,79 @@ func (m *mutation) generateRequest() error {
 			return errors.Wrapf(err, "pre-mutation %d hook failed", i)
 		}
 	}
+	if err := m.validateUpdateMask(); err != nil {
+		return err
+	}
 
 	for i, mutation := range m.mutations {
 		setJSON, err := json.Marshal(mutation.value)
 		if err != nil {
 			return errors.Wrapf(err, "marshal mutation value %d failed", i)
 		}
+		if len(mutation.reverseEdges) > 0 {
+			// the forward edges are set in the same mutation as the node, sharing its condition
+			nodes, err := m.reverseEdgeNodes(mutation.reverseEdges)
+			if err != nil {
+				return err
+			}
+			if setJSON, err = appendJSONNodes(setJSON, nodes); err != nil {
+				return errors.Wrapf(err, "marshal reverse edges of mutation %d failed", i)
+			}
+		}
 
 		var condition string
 		if len(mutation.conditions) > 0 {
 			condition = fmt.Sprintf("@if(%s)", strings.Join(mutation.conditions, " AND "))
 		}
 
-		m.request.Mutations = append(m.request.Mutations, &api.Mutation{
-			SetJson: setJSON,
-			Cond:    condition,
-		})
+		var delNquads []string
+		for _, edge := range mutation.oneEdges {
+			queries, edgeNquads := generateOneEdge(mutation.value, edge)
+			m.queries = append(m.queries, queries...)
+			delNquads = append(delNquads, edgeNquads...)
+		}
+		for _, edge := range mutation.replaceEdges {
+			queries, edgeNquads := generateReplaceEdges(mutation.value, edge)
+			m.queries = append(m.queries, queries...)
+			delNquads = append(delNquads, edgeNquads...)
+		}
+
+		mu := &api.Mutation{Cond: condition}
+		if err := m.setMutation(mu, setJSON); err != nil {
+			return err
+		}
+		if len(delNquads) > 0 {
+			mu.DelNquads = []byte(strings.Join(delNquads, "\n"))
+		}
+		m.request.Mutations = append(m.request.Mutations, mu)
 	}
+	m.setQuery()
+
+	return nil
+}
+
+// setQuery sets the query of the request from the unique check queries of the mutation
+func (m *mutation) setQuery() {
 	queryString := strings.Join(m.queries, "\n")
 	if queryString != "" {
-		m.request.Query = fmt.Sprintf("{\n%s\n}", queryString)
+		m.request.Query = FormatQuery(fmt.Sprintf("{\n%s\n}", queryString))
 	}
+}
 
-	return nil
+// send sends the request of the mutation
+func (m *mutation) send(ctx context.Context) (*api.Response, error) {
+	resp, err := m.txn.txn.Do(ctx, &m.request)
+	if err != nil {
+		return nil, errors.Wrap(err, "do request failed")
+	}
+	return resp, nil
 }
 
 func getElemValue(value reflect.Value) reflect.Value {
+	if value.Type() == nodeRefType {
+		// the node of a polymorphic edge
+		value = value.Field(0)
+	}
 	if value.Kind() == reflect.Interface {
 		value = value.Elem()
 	}
@@ -211,7 +546,7 @@ func (m *mutation) setEdgeUID(target map[string]interface{}, edgeValue reflect.V
 	edgeValue = getElemValue(edgeValue)
 	edgeMutateType := m.typeCache[edgeValue.Type().String()]
 
-	target[predicateUid] = edgeValue.Field(edgeMutateType.uidIndex).String()
+	target[predicateUid] = m.uidOf(edgeValue.Field(edgeMutateType.uidIndex))
 }
 
 // addToRefMap adds a reference to an edge, for easier updating reference to edge uids on upsert
@@ -246,7 +581,7 @@ func (m *mutation) setEdge(nodeValue, edge map[string]interface{}, field reflect
 		return
 	}
 	edgeType := m.typeCache[fieldValue.Type().String()]
-	edgeID := edgeType.getID(fieldValue)
+	edgeID := m.getID(fieldValue, edgeType)
 	if isUID(edgeID) {
 		copyStructToMap(fieldValue, edge)
 	} else {
@@ -266,6 +601,14 @@ func copyStructToMap(structVal reflect.Value, target map[string]interface{}) {
 		if len(jsonTags) == 2 && jsonTags[1] == "omitempty" && isNull(field.Interface()) {
 			continue
 		}
+		if jsonTags[0] == predicateUid {
+			target[predicateUid] = uidValue(field)
+			continue
+		}
+		if reverseOf(structVal.Type().Field(i).Tag.Get(tagName)) != "" {
+			// reverse edges are not predicates of the node
+			continue
+		}
 		target[jsonTags[0]] = field.Interface()
 	}
 }
@@ -286,9 +629,32 @@ func (m *mutation) copyNodeValues(nodeValue map[string]interface{}, field reflec
 		m.setEdge(nodeValue, edge, field)
 		nodeValue[schema.Predicate] = edge
 	default:
-		if field.CanSet() {
-			nodeValue[schema.Predicate] = field.Interface()
+		if !field.CanSet() {
+			return
+		}
+		if schema.Set && field.Kind() == reflect.Slice {
+			nodeValue[schema.Predicate] = dedupeSlice(field).Interface()
+			return
 		}
+		nodeValue[schema.Predicate] = field.Interface()
+	}
+}
+
+// isFacet checks if a predicate is a facet, defined as "predicate|facet"
+func isFacet(predicate string) bool {
+	return strings.Contains(predicate, "|")
+}
+
+// setFacet sets a facet value, facets of the node predicates are set on the node,
+// otherwise it is an edge facet, which is set on the parent edge of the node
+func (m *mutation) setFacet(nodeValue map[string]interface{}, id string, mutateType *mutateType, facet string, value interface{}) {
+	predicate := facet[:strings.Index(facet, "|")]
+	if mutateType.hasPredicate(predicate) {
+		nodeValue[facet] = value
+		return
+	}
+	if edge, ok := m.refCache[id]; ok {
+		edge[facet] = value
 	}
 }
 
@@ -301,21 +667,41 @@ func generateFilter(id, nodeType, predicate string, jsonValue []byte) string {
 	return filter
 }
 
-// isUpsertField checks if the predicate is an upsert field specified by the user,
-// when upsertFields is empty, any unique field can be upserted
-func (m *mutation) isUpsertField(predicate string) bool {
-	return m.upsertFields.Has(predicate)
+// upsertRank ranks a unique predicate as the upsert predicate of a node type, resolved per node type
+// for data mixing node types: 2 or more for an upsert predicate specified by the user, where the first
+// specified predicate ranks highest, 1 for the default upsert predicate of the node type in the client options,
+// otherwise 0
+func (m *mutation) upsertRank(nodeType string, predicates ...string) int {
+	rank := 0
+	for _, predicate := range predicates {
+		for i, upsertField := range m.upsertFields {
+			if upsertField == predicate && len(m.upsertFields)-i+1 > rank {
+				rank = len(m.upsertFields) - i + 1
+			}
+		}
+		if m.upsertTypes[nodeType] == predicate && rank < 1 {
+			rank = 1
+		}
+	}
+	return rank
 }
 
 func (m *mutation) generateQuery(id string, mutateType *mutateType, uidListIndex string, schema *Schema, value interface{}, level int) (query string, err error) {
-	queryIndex := fmt.Sprintf("q%s", uidListIndex[1:])
-
 	jsonValue, err := json.Marshal(value)
 	if err != nil {
 		return "", errors.Wrapf(err, "marshal %v", value)
 	}
 
 	filter := generateFilter(id, mutateType.nodeType, schema.Predicate, jsonValue)
+	return m.generateUniqueQuery(mutateType, uidListIndex, filter, level), nil
+}
+
+// generateUniqueQuery generates the query of existing nodes matching a unique filter
+func (m *mutation) generateUniqueQuery(mutateType *mutateType, uidListIndex, filter string, level int) string {
+	queryIndex := fmt.Sprintf("q%s", uidListIndex[1:])
+	if m.uniqueExclude != "" {
+		filter = fmt.Sprintf("NOT uid(%s) AND %s", m.uniqueExclude, filter)
+	}
 
 	queryFields := fmt.Sprintf("%s as uid", uidListIndex)
 	if m.opcode == mutationMutateOrGet {
@@ -324,16 +710,14 @@ func (m *mutation) generateQuery(id string, mutateType *mutateType, uidListIndex
 		queryFields = fmt.Sprintf("%s\n\t\texpand(_all_)%s", queryFields, buffer.String())
 	}
 
-	query = fmt.Sprintf("\t%s(func: type(%s), first: 1) @filter(%s) {\n\t\t%s\n\t}", queryIndex, mutateType.nodeType, filter, queryFields)
-
-	return query, nil
+	return fmt.Sprintf("\t%s(func: type(%s), first: 1) @filter(%s) {\n\t\t%s\n\t}", queryIndex, mutateType.nodeType, filter, queryFields)
 }
 
 func (m *mutation) updateToUIDFunc(v reflect.Value, nodeValue map[string]interface{}, id, uidListIndex string, uidIndex int) string {
 	uidFunc := fmt.Sprintf("uid(%s)", uidListIndex)
 	// update uid value to uid func
 	nodeValue[predicateUid] = uidFunc
-	v.Field(uidIndex).SetString(uidFunc)
+	m.setUIDOf(v.Field(uidIndex), uidFunc)
 	// update node cache to use uid func instead of uid alias
 	m.nodeCache[uidFunc] = v
 	// update parent uid
@@ -344,10 +728,79 @@ func (m *mutation) updateToUIDFunc(v reflect.Value, nodeValue map[string]interfa
 	return uidFunc
 }
 
+// uniqueKey returns the key of a new node by the value of its uid func predicate,
+// to merge new nodes with equal unique values in the same request, empty if the node is not merged
+func (m *mutation) uniqueKey(v reflect.Value, mutateType *mutateType, id string) (string, error) {
+	if isUID(id) || mutateType.uidFuncPred == "" {
+		return "", nil
+	}
+	for schemaIndex, schema := range mutateType.schema {
+		if schema.Predicate != mutateType.uidFuncPred {
+			continue
+		}
+		field := v.Field(schemaIndex)
+		if !field.CanInterface() || isNull(field.Interface()) {
+			return "", nil
+		}
+		jsonValue, err := json.Marshal(field.Interface())
+		if err != nil {
+			return "", errors.Wrapf(err, "marshal %v", field.Interface())
+		}
+		return fmt.Sprintf("%s.%s=%s", mutateType.nodeType, schema.Predicate, jsonValue), nil
+	}
+	return "", nil
+}
+
+// mergeNode references the first node with an equal unique value instead of a duplicate node,
+// the duplicate node is set to the first node after the response is processed
+func (m *mutation) mergeNode(v reflect.Value, id string, first reflect.Value, mutateType *mutateType) {
+	uidFunc := m.uidOf(first.Field(mutateType.uidIndex))
+	m.setUIDOf(v.Field(mutateType.uidIndex), uidFunc)
+	m.setRefsToUIDFunc(id, uidFunc)
+	m.mergedNodes = append(m.mergedNodes, [2]reflect.Value{v, first})
+}
+
+// isInUpdateMask checks if a predicate of a node type is set on the mutation, as specified by the update mask
+func (m *mutation) isInUpdateMask(mutateType *mutateType, predicate string) bool {
+	if len(m.updateMask) == 0 || m.updateMask.Has(predicate) {
+		return true
+	}
+	switch predicate {
+	case predicateUid, predicateDgraphType, mutateType.uidFuncPred:
+		return true
+	}
+	if isFacet(predicate) {
+		base := predicate[:strings.Index(predicate, "|")]
+		// facets of edges to the node are set along with the edges of the parent node
+		return m.updateMask.Has(base) || !mutateType.hasPredicate(base)
+	}
+	return false
+}
+
+// validateUpdateMask checks that the update mask predicates are defined by the mutated node types
+func (m *mutation) validateUpdateMask() error {
+	for predicate := range m.updateMask {
+		defined := false
+		for _, mutateType := range m.typeCache {
+			if mutateType.hasPredicate(predicate) {
+				defined = true
+				break
+			}
+		}
+		if !defined {
+			return fmt.Errorf("update mask predicate %s is not defined in the mutated node types", predicate)
+		}
+	}
+	return nil
+}
+
 func (m *mutation) generateMutation(v reflect.Value, level int) error {
 	var (
-		queries    []string
-		conditions []string
+		queries      []string
+		conditions   []string
+		oneEdges     []oneEdge
+		replaceEdges []oneEdge
+		reverseEdges []int
 	)
 
 	vType := v.Type()
@@ -358,7 +811,7 @@ func (m *mutation) generateMutation(v reflect.Value, level int) error {
 		return nil
 	}
 
-	id := mutateType.getID(v)
+	id := m.getID(v, mutateType)
 	// use map[string]interface as nodeValue, to prevent including empty values on parent mutations
 	nodeValue := make(map[string]interface{}, vType.NumField())
 	idFunc := id
@@ -368,6 +821,19 @@ func (m *mutation) generateMutation(v reflect.Value, level int) error {
 		return nil
 	}
 
+	key, err := m.uniqueKey(v, mutateType, id)
+	if err != nil {
+		return err
+	}
+	if first, ok := m.uniqueNodes[key]; ok {
+		m.mergeNode(v, id, first, mutateType)
+		// the merged node is created once, from the first node
+		return reflectwalk.SkipEntry
+	}
+	if key != "" {
+		m.uniqueNodes[key] = v
+	}
+
 	for schemaIndex, schema := range mutateType.schema {
 		field := v.Field(schemaIndex)
 		if !field.CanInterface() {
@@ -375,15 +841,54 @@ func (m *mutation) generateMutation(v reflect.Value, level int) error {
 			continue
 		}
 
+		if schemaIndex == mutateType.uidIndex {
+			// uids are set as strings, e.g: numeric uid fields as "0x1"
+			if uid := m.uidOf(field); uid != "" {
+				nodeValue[predicateUid] = uid
+			}
+			continue
+		}
+
 		value := field.Interface()
 		if schema.OmitEmpty && isNull(value) {
 			// empty/null values don't need be to processed
 			continue
 		}
+		if !m.isInUpdateMask(mutateType, schema.Predicate) {
+			continue
+		}
+
+		if isFacet(schema.Predicate) {
+			m.setFacet(nodeValue, id, mutateType, schema.Predicate, value)
+			continue
+		}
+
+		if schema.ReverseOf != "" {
+			// set as the forward edges of the parent nodes, after the node uid is resolved
+			reverseEdges = append(reverseEdges, schemaIndex)
+			continue
+		}
 
 		// copy values to prevent mutating original data when setting edges
 		m.copyNodeValues(nodeValue, field, schema, schemaIndex)
 
+		if schema.Cardinality == CardinalityOne && !isNull(value) {
+			oneEdges = append(oneEdges, oneEdge{
+				id:        fmt.Sprintf("%s_%d", id, schemaIndex),
+				nodeType:  mutateType.nodeType,
+				predicate: schema.Predicate,
+			})
+		}
+
+		isEdge := schema.Type == schemaUid || schema.Type == schemaUidList
+		if isEdge && m.replaceEdges.Has(schema.Predicate) && !isNull(value) {
+			replaceEdges = append(replaceEdges, oneEdge{
+				id:        fmt.Sprintf("%s_%d", id, schemaIndex),
+				nodeType:  mutateType.nodeType,
+				predicate: schema.Predicate,
+			})
+		}
+
 		if schema.Unique {
 			uidListIndex := fmt.Sprintf("u_%s_%d", id, schemaIndex)
 
@@ -407,14 +912,48 @@ func (m *mutation) generateMutation(v reflect.Value, level int) error {
 		}
 	}
 
+	for i, fields := range getUniqueTogether(vType) {
+		query, condition, err := m.generateUniqueTogether(v, id, idFunc, mutateType, len(mutateType.schema)+i, fields, level)
+		if err != nil {
+			return errors.Wrapf(err, "generate unique together query on %s failed", mutateType.nodeType)
+		}
+		if query != "" {
+			queries = append(queries, query)
+			conditions = append(conditions, condition)
+		}
+	}
+
+	isExistingNode := isUID(idFunc) || isUIDFunc(idFunc)
+	if filter := getMutationCondition(v); filter != nil && isExistingNode {
+		query, condition, err := generateCondition(id, idFunc, filter)
+		if err != nil {
+			return err
+		}
+		queries = append(queries, query)
+		conditions = append(conditions, condition)
+	}
+
+	var parentEdges []reverseEdge
+	for _, schemaIndex := range reverseEdges {
+		edges, err := m.reverseEdges(v.Field(mutateType.uidIndex), v.Field(schemaIndex), mutateType.schema[schemaIndex].ReverseOf)
+		if err != nil {
+			return err
+		}
+		parentEdges = append(parentEdges, edges...)
+	}
+
 	// add parent conditions to prevent orphaned child nodes
 	parentConditions := m.conditions[m.parentUids[idFunc]]
 	conditions = append(parentConditions, conditions...)
 	m.conditions[idFunc] = conditions
 
+	m.nodes = append(m.nodes, v)
 	m.mutations = append([]preparedMutation{{
-		conditions: conditions,
-		value:      nodeValue,
+		conditions:   conditions,
+		value:        nodeValue,
+		oneEdges:     oneEdges,
+		replaceEdges: replaceEdges,
+		reverseEdges: parentEdges,
 	}}, m.mutations...)
 	m.queries = append(m.queries, queries...)
 
@@ -444,6 +983,24 @@ func parseQueryIndex(queryIndex string) (id string, schemaIndex int, err error)
 	return id, schemaIndex, nil
 }
 
+// uniqueError returns a UniqueError when the node found by a unique query is not the mutated node
+func (m *mutation) uniqueError(id string, schemaIndex int, msg []byte) (*UniqueError, error) {
+	var node node
+	if err := json.Unmarshal(msg, &node); err != nil {
+		return nil, err
+	}
+
+	nodeValue := m.nodeCache[id]
+	mutateType := m.typeCache[nodeValue.Type().String()]
+
+	// only return unique error if not updating the user specified node
+	// i.e: UID field is set
+	if m.uidOf(nodeValue.Field(mutateType.uidIndex)) == node.UID {
+		return nil, nil
+	}
+	return newUniqueError(mutateType, nodeValue, schemaIndex, node.UID), nil
+}
+
 func (m *mutation) processJSONResponse(resp []byte) error {
 	var mapNodes map[string][]stdjson.RawMessage
 	if err := json.Unmarshal(resp, &mapNodes); err != nil {
@@ -462,39 +1019,30 @@ func (m *mutation) processJSONResponse(resp []byte) error {
 
 		nodeValue := m.nodeCache[id]
 		mutateType := m.typeCache[nodeValue.Type().String()]
-		schema := mutateType.schema[schemaIndex]
 
 		switch m.opcode {
 		case mutationMutate:
-			var node node
-			if err := json.Unmarshal(msg[0], &node); err != nil {
+			uniqueErr, err := m.uniqueError(id, schemaIndex, msg[0])
+			if err != nil {
 				return errors.Wrapf(err, "unmarshal node %s", queryIndex)
 			}
-
-			queryUID := node.UID
-
-			// only return unique error if not updating the user specified node
-			// i.e: UID field is set
-			if nodeValue.Field(mutateType.uidIndex).String() != queryUID {
-				return &UniqueError{
-					NodeType: mutateType.nodeType,
-					Field:    schema.Predicate,
-					Value:    nodeValue.Field(schemaIndex).Interface(),
-					UID:      queryUID,
-				}
+			if uniqueErr != nil {
+				return uniqueErr
 			}
 		case mutationMutateOrGet:
 			parent := m.nodeCache[m.parentUids[id[2:]]]
 			if parent.IsValid() {
 				parentType := m.typeCache[parent.Type().String()]
-				parentID := parentType.getID(parent)
+				parentID := m.getID(parent, parentType)
 				if isUID(parentID) {
 					// if parent is already set from query, don't unmarshal this query
 					continue
 				}
 			}
 
-			if err := json.Unmarshal(msg[0], nodeValue.Addr().Interface()); err != nil {
+			// the existing node has the overridden predicates of fields, instead of the json field names
+			aliases := overrideAliases(nodeValue.Type())
+			if err := unmarshalNodes(msg[0], nodeValue.Addr().Interface(), aliases); err != nil {
 				return errors.Wrapf(err, "unmarshal query %s", queryIndex)
 			}
 		case mutationUpsert:
@@ -508,19 +1056,16 @@ func (m *mutation) processJSONResponse(resp []byte) error {
 			upsertNodeValue, ok := m.nodeCache[uidFunc]
 			if !ok {
 				// if not upsert field, return unique error
-				return &UniqueError{
-					NodeType: mutateType.nodeType,
-					Field:    schema.Predicate,
-					Value:    nodeValue.Field(schemaIndex).Interface(),
-					UID:      node.UID,
-				}
+				return newUniqueError(mutateType, nodeValue, schemaIndex, node.UID)
 			}
 
 			queryUID := node.UID
 
 			uidField := upsertNodeValue.Field(mutateType.uidIndex)
-			if uidFunc == uidField.String() {
-				uidField.SetString(queryUID)
+			if uidFunc == m.uidOf(uidField) {
+				if err := m.setUIDOf(uidField, queryUID); err != nil {
+					return errors.Wrapf(err, "set uid of node %s", queryIndex)
+				}
 			}
 		}
 	}
@@ -528,6 +1073,36 @@ func (m *mutation) processJSONResponse(resp []byte) error {
 	return nil
 }
 
+// uidsByType returns the uids of the mutated nodes by node type, after the response is processed
+func (m *mutation) uidsByType() map[string][]string {
+	uids := make(map[string][]string)
+	seen := make(set)
+	for _, v := range m.nodes {
+		uid := m.uidOf(v.Field(m.typeCache[v.Type().String()].uidIndex))
+		if !isUID(uid) || seen.Has(uid) {
+			continue
+		}
+		seen.Add(uid)
+		nodeType := getNodeType(v.Type())
+		uids[nodeType] = append(uids[nodeType], uid)
+	}
+	return uids
+}
+
+// blankUIDs maps the blank node names of the mutated nodes to their uids, after the response is processed
+func (m *mutation) blankUIDs() map[string]string {
+	uids := make(map[string]string)
+	for id, v := range m.nodeCache {
+		if !isUIDAlias(id) {
+			continue
+		}
+		if uid := nodeUID(v); isUID(uid) {
+			uids[id[2:]] = uid
+		}
+	}
+	return uids
+}
+
 func (m *mutation) processResponse(resp *api.Response) error {
 	if resp.Json != nil {
 		if err := m.processJSONResponse(resp.Json); err != nil {
@@ -535,12 +1110,16 @@ func (m *mutation) processResponse(resp *api.Response) error {
 		}
 	}
 
-	postHook := setUIDHook{resp: resp}
+	postHook := setUIDHook{resp: resp, numericUIDs: m.numericUIDs}
 	err := reflectwalk.Walk(m.data, postHook)
 	if err != nil {
 		return errors.Wrap(err, "post-mutation hook failed")
 	}
 
+	for _, merged := range m.mergedNodes {
+		merged[0].Set(merged[1])
+	}
+
 	return nil
 }
 
@@ -569,7 +1148,11 @@ type generateSchemaHook struct {
 }
 
 func (h generateSchemaHook) Struct(v reflect.Value, level int) error {
-	return nil
+	if h.skipTyping || !v.CanInterface() {
+		return nil
+	}
+	_, err := h.mutation.getMutateType(v.Type())
+	return err
 }
 
 func (h generateSchemaHook) StructField(p reflect.Value, field reflect.StructField, v reflect.Value, level int) error {
@@ -580,23 +1163,12 @@ func (h generateSchemaHook) StructField(p reflect.Value, field reflect.StructFie
 
 	pType := p.Type()
 	nodeType := pType.Name()
-	mutateType, ok := h.mutation.typeCache[pType.String()]
-	if !ok {
-		mutateType = newMutateType(p.NumField())
-	}
-	// schema typing is completed before on type
-	skipTyping := p.NumField() > 0 && len(mutateType.schema) == p.NumField()
-	if h.skipTyping {
-		skipTyping = true
-	}
-
-	i := field.Index[len(field.Index)-1]
 	fieldName := fmt.Sprintf("%s.%s", pType.Name(), field.Name)
 
 	predicate, _ := getPredicate(&field)
 	switch predicate {
 	case predicateUid:
-		uid, err := genUID(field, v)
+		uid, err := h.mutation.genUID(field, v)
 		if err != nil {
 			return errors.Wrap(err, "gen UID failed")
 		}
@@ -604,8 +1176,6 @@ func (h generateSchemaHook) StructField(p reflect.Value, field reflect.StructFie
 			// cache the struct value by its generated id
 			h.mutation.nodeCache[uid] = p
 		}
-		// for easier accessing the uid field
-		mutateType.uidIndex = i
 	case predicateDgraphType:
 		dgraphTag := field.Tag.Get(tagName)
 		if dgraphTag != "" {
@@ -614,7 +1184,6 @@ func (h generateSchemaHook) StructField(p reflect.Value, field reflect.StructFie
 		if err := setType(field, v, nodeType); err != nil {
 			return errors.Wrapf(err, "set type failed on %s", fieldName)
 		}
-		mutateType.nodeType = nodeType
 
 		// is a dgraph node, set max level as depth
 		if level > h.mutation.depth {
@@ -623,27 +1192,6 @@ func (h generateSchemaHook) StructField(p reflect.Value, field reflect.StructFie
 		}
 	}
 
-	if !skipTyping {
-		schema, err := parseDgraphTag(&field)
-		if err != nil {
-			return errors.Wrapf(err, "parse dgraph tag failed on %s", fieldName)
-		}
-		mutateType.schema = append(mutateType.schema, schema)
-		if schema.Unique {
-			if h.mutation.upsertFields.Has(predicate) {
-				mutateType.uidFuncPred = predicate
-			}
-
-			if mutateType.uidFuncPred == "" {
-				mutateType.uidFuncPred = predicate
-			}
-		}
-		// cache the parsed type
-		h.mutation.typeCache[pType.String()] = mutateType
-
-		return nil
-	}
-
 	return nil
 }
 
@@ -660,7 +1208,8 @@ func (h generateMutationHook) StructField(s reflect.Value, f reflect.StructField
 }
 
 type setUIDHook struct {
-	resp *api.Response
+	resp        *api.Response
+	numericUIDs map[uintptr]string
 }
 
 func (h setUIDHook) Struct(v reflect.Value, level int) error {
@@ -668,27 +1217,100 @@ func (h setUIDHook) Struct(v reflect.Value, level int) error {
 }
 
 func (h setUIDHook) StructField(s reflect.Value, f reflect.StructField, v reflect.Value, level int) error {
-	err := setUIDs(f, v, h.resp.Uids)
+	var err error
+	if isNumericUID(v.Kind()) {
+		err = setNumericUIDs(f, v, h.numericUIDs, h.resp.Uids)
+	} else {
+		err = setUIDs(f, v, h.resp.Uids)
+	}
 	if err != nil {
 		return errors.Wrap(err, "set UIDs failed")
 	}
 	return nil
 }
 
-func newMutation(txn *TxnContext, data interface{}) *mutation {
+func newMutation(txn *TxnContext, data interface{}, opts MutateOptions) (*mutation, error) {
+	opcode, err := opts.opcode()
+	if err != nil {
+		return nil, err
+	}
+
+	var upsertTypes map[string]string
+	if txn.opts != nil {
+		upsertTypes = txn.opts.UpsertPredicates
+	}
+
+	commitNow := txn.commitNow || opts.CommitNow
 	return &mutation{
 		data: data,
 		txn:  txn,
 		// TODO: optimize use of maps
-		nodeCache:  make(map[string]reflect.Value),
-		typeCache:  make(map[string]*mutateType),
-		refCache:   make(map[string]map[string]interface{}),
-		conditions: make(map[string][]string),
-		parentUids: make(map[string]string),
+		nodeCache:    make(map[string]reflect.Value),
+		typeCache:    make(map[string]*mutateType),
+		refCache:     make(map[string]map[string]interface{}),
+		conditions:   make(map[string][]string),
+		parentUids:   make(map[string]string),
+		uniqueNodes:  make(map[string]reflect.Value),
+		numericUIDs:  make(map[uintptr]string),
+		opcode:       opcode,
+		upsertFields: opts.UpsertPredicates,
+		upsertTypes:  upsertTypes,
+		replaceEdges: newSet(opts.ReplaceEdges...),
+		updateMask:   newSet(opts.UpdateMask...),
+		commitNow:    commitNow,
+		skipValidate: opts.SkipValidation,
+		asNquads:     opts.AsNquads,
+		blankUID:     opts.BlankUID,
 		request: api.Request{
-			CommitNow: txn.commitNow,
+			CommitNow: commitNow,
 		},
+	}, nil
+}
+
+// prepare runs the before mutate hooks, validation, and index check of the mutation data
+func (m *mutation) prepare() error {
+	if err := m.txn.hooks.beforeMutate(m.txn.ctx, m.opcode.hookOp(), m.data); err != nil {
+		return err
+	}
+	if !m.skipValidate {
+		if err := Validate(m.data); err != nil {
+			return err
+		}
+	}
+	if m.txn.indexCheck != nil && m.mapOptions != nil {
+		if err := m.txn.indexCheck.checkMap(m.txn.client, *m.mapOptions); err != nil {
+			return err
+		}
+	} else if m.txn.indexCheck != nil && m.opcode != mutationMutateBasic {
+		if err := m.txn.indexCheck.check(m.txn.client, m.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mutation) run() ([]string, error) {
+	if err := m.prepare(); err != nil {
+		return nil, err
+	}
+
+	var (
+		uids []string
+		err  error
+	)
+	if m.opcode == mutationMutateBasic {
+		uids, err = m.mutate()
+	} else {
+		uids, err = m.do()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.txn.hooks.afterMutate(m.txn.ctx, m.opcode.hookOp(), m.data); err != nil {
+		return uids, err
 	}
+	return uids, nil
 }
 
 // SetTypes recursively walks all structures in data and sets the value of the
@@ -0,0 +1,185 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+)
+
+// MutationBlock is a mutation of the data of a multi-block request, see MutateBlocks
+type MutationBlock struct {
+	// Data is the node or nodes to mutate, as in MutateWithOptions
+	Data interface{}
+	// Cond is the condition of the mutation on the variables of the shared query block,
+	// e.g: @if(eq(len(user), 1)), combined with the conditions of the unique checks
+	Cond string
+	// Options are the mutate options of the data
+	Options MutateOptions
+}
+
+// MutateBlocks mutates the data of multiple mutation blocks in a single request, with a shared query block
+// which variables can be referenced by the conditions and uid funcs of the mutations, so the mutations are atomic,
+// e.g: creating an order and decrementing the stock of a product only if the product is in stock.
+// Each block is mutated as in MutateWithOptions, with its own unique checks, and the query block can be nil.
+// Returns the created uids of the request.
+func (t *TxnContext) MutateBlocks(query *QueryBlock, blocks ...MutationBlock) ([]string, error) {
+	if len(blocks) == 0 {
+		return nil, errors.New("mutation blocks cannot be empty")
+	}
+
+	request := &api.Request{CommitNow: t.commitNow}
+	var queries []string
+	if query != nil {
+		for _, block := range query.blocks {
+			if block.err != nil {
+				return nil, block.err
+			}
+		}
+		request.Vars = query.vars
+	}
+
+	mutations := make([]*mutation, len(blocks))
+	for i, block := range blocks {
+		m, err := newMutation(t, block.Data, block.Options)
+		if err != nil {
+			return nil, err
+		}
+		if m.commitNow {
+			request.CommitNow = true
+		}
+		if err := m.prepare(); err != nil {
+			return nil, err
+		}
+
+		var blockMutations []*api.Mutation
+		if m.opcode == mutationMutateBasic {
+			mu, err := m.generateBasicMutation()
+			if err != nil {
+				return nil, errors.Wrapf(err, "generate mutation block %d failed", i)
+			}
+			blockMutations = []*api.Mutation{mu}
+		} else {
+			if err := m.generateRequest(); err != nil {
+				return nil, errors.Wrapf(err, "generate mutation block %d failed", i)
+			}
+			blockMutations = m.request.Mutations
+			queries = append(queries, m.queries...)
+		}
+
+		for _, mu := range blockMutations {
+			mu.CommitNow = false
+			if mu.Cond, err = combineConditions(block.Cond, mu.Cond); err != nil {
+				return nil, errors.Wrapf(err, "mutation block %d", i)
+			}
+		}
+		request.Mutations = append(request.Mutations, blockMutations...)
+		mutations[i] = m
+	}
+	request.Query = blocksQuery(query, queries)
+
+	resp, err := t.txn.Do(withMetricsTags(t.ctx, "", blocks[0].Data), request)
+	if err != nil {
+		return nil, errors.Wrap(err, "do request failed")
+	}
+
+	var results map[string]stdjson.RawMessage
+	if len(resp.Json) > 0 {
+		if err := json.Unmarshal(resp.Json, &results); err != nil {
+			return nil, errors.Wrapf(err, `unmarshal queryResponse "%s"`, resp.Json)
+		}
+	}
+	for i, m := range mutations {
+		// each mutation only processes the results of its unique queries
+		mutationResp := &api.Response{Uids: resp.Uids}
+		if mutationResp.Json, err = m.ownResults(results); err != nil {
+			return nil, errors.Wrapf(err, "mutation block %d", i)
+		}
+		if err := m.processResponse(mutationResp); err != nil {
+			return nil, err
+		}
+	}
+
+	uids := getCreatedUIDs(resp.Uids)
+	for _, m := range mutations {
+		if err := m.txn.hooks.afterMutate(m.txn.ctx, m.opcode.hookOp(), m.data); err != nil {
+			return uids, err
+		}
+	}
+	return uids, nil
+}
+
+// ownResults returns the results of the unique queries of the mutation, from the results of a multi-block request
+func (m *mutation) ownResults(results map[string]stdjson.RawMessage) ([]byte, error) {
+	own := make(map[string]stdjson.RawMessage)
+	for name, result := range results {
+		id, _, err := parseQueryIndex(name)
+		if err != nil {
+			continue
+		}
+		if _, ok := m.nodeCache[id]; ok {
+			own[name] = result
+		}
+	}
+	if len(own) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(own)
+}
+
+// combineConditions combines the @if conditions of mutations
+func combineConditions(conds ...string) (string, error) {
+	var filters []string
+	for _, cond := range conds {
+		cond = strings.TrimSpace(cond)
+		if cond == "" {
+			continue
+		}
+		if !strings.HasPrefix(cond, "@if(") || !strings.HasSuffix(cond, ")") {
+			return "", fmt.Errorf("invalid condition %q, expected @if(...)", cond)
+		}
+		filters = append(filters, cond[len("@if("):len(cond)-1])
+	}
+	switch len(filters) {
+	case 0:
+		return "", nil
+	case 1:
+		return fmt.Sprintf("@if(%s)", filters[0]), nil
+	}
+	return fmt.Sprintf("@if((%s))", strings.Join(filters, ") AND (")), nil
+}
+
+// blocksQuery adds the unique queries of the mutations to the shared query block
+func blocksQuery(query *QueryBlock, queries []string) string {
+	if query == nil {
+		if len(queries) == 0 {
+			return ""
+		}
+		return FormatQuery(fmt.Sprintf("{\n%s\n}", strings.Join(queries, "\n")))
+	}
+
+	queryString := query.String()
+	if len(queries) == 0 {
+		return queryString
+	}
+	end := strings.LastIndex(queryString, "}")
+	return FormatQuery(queryString[:end] + strings.Join(queries, "\n") + "\n}")
+}
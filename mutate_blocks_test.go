@@ -0,0 +1,60 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutateBlocks(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"q_u_2":[]}`),
+		Uids: map[string]string{"uid(u_u_2)": "0x5", "s": "0x6"},
+	})
+
+	query := NewQueryBlock(NewQuery().
+		As("harvard").Var().
+		Model(&TestSchool{}).
+		Filter("eq(identifier, $1)", "harvard"))
+	user := &TestUser{UID: "_:u", Username: "wildan"}
+	school := &TestSchool{UID: "_:s", Identifier: "mit"}
+	uids, err := tx.MutateBlocks(query,
+		MutationBlock{Data: user, Cond: "@if(eq(len(harvard), 1))"},
+		MutationBlock{Data: school, Cond: "@if(eq(len(harvard), 0))", Options: MutateOptions{SkipUnique: true}},
+	)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"0x5", "0x6"}, uids)
+	assert.Equal(t, "0x5", user.UID)
+	assert.Equal(t, "0x6", school.UID)
+
+	require.Len(t, fake.requests, 1)
+	req := fake.requests[0]
+	assert.Contains(t, req.Query, "harvard as var(func: type(TestSchool))")
+	assert.Contains(t, req.Query, "q_u_2(func: type(User), first: 1)")
+	require.Len(t, req.Mutations, 2)
+	assert.Equal(t, "@if((eq(len(harvard), 1)) AND (eq(len(u_u_2), 0)))", req.Mutations[0].Cond)
+	assert.Equal(t, "@if(eq(len(harvard), 0))", req.Mutations[1].Cond)
+
+	_, err = tx.MutateBlocks(nil, MutationBlock{Data: &TestSchool{}, Cond: "eq(len(harvard), 0)"})
+	assert.Error(t, err)
+	_, err = tx.MutateBlocks(query)
+	assert.Error(t, err)
+}
@@ -0,0 +1,259 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+)
+
+// MapOptions specifies the node type and unique predicates of map nodes, see MutateMap
+type MapOptions struct {
+	// NodeType is the node type of the nodes, set as their dgraph.type
+	NodeType string
+	// Unique are the predicates unique checked on the nodes of the node type,
+	// on upsert the first predicate is the upsert predicate
+	Unique []string
+}
+
+// MutateMap does a dgraph mutation of map[string]interface{} or []map[string]interface{} nodes
+// of a node type, without struct reflection, with unique checking on the unique predicates of the options.
+// Nodes without a uid are created with a blank node name, the created uids are injected into the "uid" key
+// of the maps, including nested nodes with blank node names, and returns the created uids.
+// It will return a UniqueError when unique checking fails on a predicate.
+// As with struct mutations, the mutate hooks are called with each map node, and the unique predicates
+// are checked by the index check of the transaction.
+func (t *TxnContext) MutateMap(data interface{}, opts MapOptions) ([]string, error) {
+	return t.mutateMap(data, opts, false)
+}
+
+// UpsertMap does a dgraph mutation like MutateMap, but instead of returning a UniqueError when a node already exists
+// for the value of the first unique predicate, it will update the existing node and inject its uid into the map.
+func (t *TxnContext) UpsertMap(data interface{}, opts MapOptions) ([]string, error) {
+	if len(opts.Unique) == 0 {
+		return nil, errors.New("upsert map requires a unique predicate")
+	}
+	return t.mutateMap(data, opts, true)
+}
+
+// mapNode is a map node to be mutated, with its id in the mutation and its unique queries
+type mapNode struct {
+	value map[string]interface{}
+	id    string
+	// queries are the unique predicates by query index
+	queries map[string]string
+}
+
+func mapNodes(data interface{}) ([]map[string]interface{}, error) {
+	switch data := data.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{data}, nil
+	case []map[string]interface{}:
+		return data, nil
+	case *map[string]interface{}:
+		return []map[string]interface{}{*data}, nil
+	case *[]map[string]interface{}:
+		return *data, nil
+	}
+	return nil, fmt.Errorf("data must be a map[string]interface{} or []map[string]interface{}, got %T", data)
+}
+
+func (t *TxnContext) mutateMap(data interface{}, opts MapOptions, upsert bool) ([]string, error) {
+	if !predicateRegex.MatchString(opts.NodeType) {
+		return nil, fmt.Errorf("invalid node type %q", opts.NodeType)
+	}
+	for _, predicate := range opts.Unique {
+		if !predicateRegex.MatchString(predicate) {
+			return nil, fmt.Errorf("invalid unique predicate %q", predicate)
+		}
+	}
+	values, err := mapNodes(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	opcode := mutationMutate
+	if upsert {
+		opcode = mutationUpsert
+	}
+	m := &mutation{
+		data:       data,
+		txn:        t,
+		opcode:     opcode,
+		mapOptions: &opts,
+		commitNow:  t.commitNow,
+		request: api.Request{
+			CommitNow: t.commitNow,
+		},
+	}
+	if err := m.prepare(); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*mapNode, len(values))
+	for i, value := range values {
+		node := &mapNode{value: value, queries: make(map[string]string)}
+		setValue := make(map[string]interface{}, len(value)+2)
+		for k, v := range value {
+			setValue[k] = v
+		}
+
+		uid, _ := value[predicateUid].(string)
+		if uid == "" {
+			uid = "_:" + PathBlankUID(opts.NodeType, strconv.Itoa(i))
+		}
+		node.id = uid
+		setValue[predicateUid] = uid
+		if _, ok := setValue[predicateDgraphType]; !ok {
+			setValue[predicateDgraphType] = opts.NodeType
+		}
+
+		var conditions []string
+		for j, predicate := range opts.Unique {
+			value, ok := value[predicate]
+			if !ok || value == nil {
+				continue
+			}
+			jsonValue, err := json.Marshal(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "marshal %s", predicate)
+			}
+
+			queryID := fmt.Sprintf("%d_%d", i, j)
+			queryIndex := "q_" + queryID
+			uidVar := "u_" + queryID
+			node.queries[queryIndex] = predicate
+			m.queries = append(m.queries, fmt.Sprintf("%s(func: type(%s), first: 1) @filter(%s) {\n%s as uid\n}",
+				queryIndex, opts.NodeType, generateFilter(uid, opts.NodeType, predicate, jsonValue), uidVar))
+
+			if upsert && j == 0 && !isUID(uid) {
+				// update the existing node of the upsert predicate value, if any
+				setValue[predicateUid] = fmt.Sprintf("uid(%s)", uidVar)
+				continue
+			}
+			conditions = append(conditions, fmt.Sprintf("eq(len(%s), 0)", uidVar))
+		}
+
+		setJSON, err := json.Marshal(setValue)
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshal map node %d", i)
+		}
+		mu := &api.Mutation{SetJson: setJSON}
+		if len(conditions) > 0 {
+			mu.Cond = fmt.Sprintf("@if(%s)", strings.Join(conditions, " AND "))
+		}
+		m.request.Mutations = append(m.request.Mutations, mu)
+		node.id, _ = setValue[predicateUid].(string)
+		nodes[i] = node
+	}
+	m.setQuery()
+
+	ctx := t.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	resp, err := m.send(context.WithValue(ctx, metricsTagsKey{}, metricsTags{nodeType: opts.NodeType}))
+	if err != nil {
+		return nil, err
+	}
+
+	var results map[string][]struct {
+		UID string `json:"uid"`
+	}
+	if len(resp.Json) > 0 {
+		if err := json.Unmarshal(resp.Json, &results); err != nil {
+			return nil, errors.Wrapf(err, `unmarshal queryResponse "%s"`, resp.Json)
+		}
+	}
+
+	for _, node := range nodes {
+		queryIndexes := make([]string, 0, len(node.queries))
+		for queryIndex := range node.queries {
+			queryIndexes = append(queryIndexes, queryIndex)
+		}
+		sort.Strings(queryIndexes)
+		for _, queryIndex := range queryIndexes {
+			existing := results[queryIndex]
+			if len(existing) == 0 {
+				continue
+			}
+			predicate := node.queries[queryIndex]
+			if upsert && predicate == opts.Unique[0] && isUIDFunc(node.id) {
+				node.id = existing[0].UID
+				continue
+			}
+			return nil, &UniqueError{
+				NodeType: opts.NodeType,
+				Field:    predicate,
+				Value:    node.value[predicate],
+				UID:      existing[0].UID,
+			}
+		}
+	}
+
+	for i, node := range nodes {
+		if uid, ok := createdUID(node.id, resp.Uids); ok {
+			node.id = uid
+		}
+		if !isUID(node.id) {
+			return nil, fmt.Errorf("uid of map node %d not created", i)
+		}
+		node.value[predicateUid] = node.id
+		setMapUIDs(node.value, resp.Uids)
+	}
+
+	uids := getCreatedUIDs(resp.Uids)
+	if err := t.hooks.afterMutate(t.ctx, opcode.hookOp(), data); err != nil {
+		return uids, err
+	}
+	return uids, nil
+}
+
+// setMapUIDs sets the created uids of nested map nodes with blank node names
+func setMapUIDs(value interface{}, uids map[string]string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for k, v := range value {
+			if k == predicateUid {
+				if uid, ok := v.(string); ok {
+					if created, ok := createdUID(uid, uids); ok {
+						value[k] = created
+					}
+				}
+				continue
+			}
+			setMapUIDs(v, uids)
+		}
+	case []map[string]interface{}:
+		for _, v := range value {
+			setMapUIDs(v, uids)
+		}
+	case []interface{}:
+		for _, v := range value {
+			setMapUIDs(v, uids)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutateMap(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Uids: map[string]string{"root0": "0x1", "p": "0x2"},
+	})
+
+	friend := map[string]interface{}{"uid": "_:p", "name": "friend"}
+	user := map[string]interface{}{
+		"email":   "wildan@dolan.in",
+		"friends": []interface{}{friend},
+	}
+	uids, err := tx.MutateMap(user, MapOptions{NodeType: "User", Unique: []string{"email"}})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"0x1", "0x2"}, uids)
+	assert.Equal(t, "0x1", user["uid"])
+	assert.Equal(t, "0x2", friend["uid"])
+
+	require.Len(t, fake.requests, 1)
+	request := fake.requests[0]
+	assert.Contains(t, request.Query, `q_0_0(func: type(User), first: 1) @filter(eq(email, "wildan@dolan.in") AND type(User))`)
+	require.Len(t, request.Mutations, 1)
+	assert.Equal(t, "@if(eq(len(u_0_0), 0))", request.Mutations[0].Cond)
+	assert.JSONEq(t, `{
+		"uid": "_:root0",
+		"dgraph.type": "User",
+		"email": "wildan@dolan.in",
+		"friends": [{"uid": "_:p", "name": "friend"}]
+	}`, string(request.Mutations[0].SetJson))
+
+	t.Run("unique error", func(t *testing.T) {
+		tx, _ := newFakeTxnContext(&api.Response{
+			Json: []byte(`{"q_1_0":[{"uid":"0x5"}]}`),
+		})
+		_, err := tx.MutateMap([]map[string]interface{}{
+			{"email": "new@dolan.in"},
+			{"email": "wildan@dolan.in"},
+		}, MapOptions{NodeType: "User", Unique: []string{"email"}})
+		uniqueErr, ok := err.(*UniqueError)
+		require.True(t, ok, err)
+		assert.Equal(t, &UniqueError{NodeType: "User", Field: "email", Value: "wildan@dolan.in", UID: "0x5"}, uniqueErr)
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		tx, fake := newFakeTxnContext()
+		_, err := tx.MutateMap(&TestUser{}, MapOptions{NodeType: "User"})
+		assert.Error(t, err)
+		_, err = tx.MutateMap(map[string]interface{}{}, MapOptions{})
+		assert.Error(t, err)
+		assert.Len(t, fake.requests, 0)
+	})
+}
+
+func TestUpsertMap(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		// the first user exists by email
+		Json: []byte(`{"q_0_0":[{"uid":"0x5"}]}`),
+		Uids: map[string]string{"uid(u_1_0)": "0x9"},
+	})
+
+	users := []map[string]interface{}{
+		{"email": "wildan@dolan.in", "username": "wildan"},
+		{"email": "new@dolan.in", "username": "new"},
+	}
+	uids, err := tx.UpsertMap(users, MapOptions{NodeType: "User", Unique: []string{"email", "username"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0x9"}, uids)
+	assert.Equal(t, "0x5", users[0]["uid"])
+	assert.Equal(t, "0x9", users[1]["uid"])
+
+	require.Len(t, fake.requests, 1)
+	mutations := fake.requests[0].Mutations
+	require.Len(t, mutations, 2)
+	// only conflicts on predicates other than the upsert predicate are checked
+	assert.Equal(t, "@if(eq(len(u_0_1), 0))", mutations[0].Cond)
+	assert.Contains(t, string(mutations[0].SetJson), `"uid":"uid(u_0_0)"`)
+
+	_, err = tx.UpsertMap(users, MapOptions{NodeType: "User"})
+	assert.Error(t, err)
+}
+
+func TestMutateMapHooks(t *testing.T) {
+	var ops []HookOp
+	tx, fake := newFakeTxnContext(&api.Response{
+		Uids: map[string]string{"root0": "0x1"},
+	})
+	tx.SetHooks(&Hooks{
+		BeforeMutate: func(ctx context.Context, op HookOp, node reflect.Value) error {
+			node.SetMapIndex(reflect.ValueOf("created_at"), reflect.ValueOf("2021-01-01T00:00:00Z"))
+			return nil
+		},
+		AfterMutate: func(ctx context.Context, op HookOp, node reflect.Value) error {
+			ops = append(ops, op)
+			assert.Equal(t, "0x1", node.MapIndex(reflect.ValueOf("uid")).Interface())
+			return nil
+		},
+	})
+
+	user := map[string]interface{}{"email": "wildan@dolan.in"}
+	_, err := tx.MutateMap(user, MapOptions{NodeType: "User", Unique: []string{"email"}})
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+	assert.Contains(t, string(fake.requests[0].Mutations[0].SetJson), `"created_at":"2021-01-01T00:00:00Z"`)
+	assert.Equal(t, FormatQuery(fake.requests[0].Query), fake.requests[0].Query)
+	assert.Equal(t, []HookOp{HookMutate}, ops)
+
+	t.Run("index check", func(t *testing.T) {
+		typeSchema := NewTypeSchema()
+		typeSchema.Marshal("", &IndexCheckAccount{})
+		tx, fake := newFakeTxnContext()
+		tx.SetIndexCheck(NewIndexCheck(typeSchema))
+		_, err := tx.UpsertMap(map[string]interface{}{"email": "wildan@dolan.in"},
+			MapOptions{NodeType: "IndexCheckAccount", Unique: []string{"email"}})
+		assert.Equal(t, &IndexError{NodeType: "IndexCheckAccount", Predicate: "email"}, err)
+		assert.Empty(t, fake.requests)
+	})
+}
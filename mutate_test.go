@@ -18,9 +18,12 @@ package dgman
 
 import (
 	"sort"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/dgraph-io/dgo/v210/protos/api"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -301,6 +304,147 @@ func TestMutationMutate_SetEdge(t *testing.T) {
 	assert.JSONEq(t, string(jsonSchool), string(jsonSchoolInterface))
 }
 
+type TestStudent struct {
+	UID    string      `json:"uid,omitempty"`
+	Name   string      `json:"name,omitempty"`
+	School *TestSchool `json:"mainSchool,omitempty" dgraph:"cardinality=one"`
+	DType  []string    `json:"dgraph.type,omitempty"`
+}
+
+func TestMutationMutate_CardinalityOne(t *testing.T) {
+	c := newDgraphClient()
+
+	_, err := CreateSchema(c, TestStudent{})
+	if err != nil {
+		t.Error(err)
+	}
+	defer dropAll(c)
+
+	student := TestStudent{
+		Name:   "wildan",
+		School: &TestSchool{Name: "Harvard University", Identifier: "harvard"},
+	}
+
+	tx := NewTxn(c).SetCommitNow()
+	if _, err := tx.Mutate(&student); err != nil {
+		t.Error(err)
+	}
+
+	// set a new school, the existing school edge should be replaced
+	updatedStudent := TestStudent{
+		UID:    student.UID,
+		School: &TestSchool{Name: "Stanford University", Identifier: "stanford"},
+	}
+
+	tx = NewTxn(c).SetCommitNow()
+	if _, err := tx.Mutate(&updatedStudent); err != nil {
+		t.Error(err)
+	}
+
+	var result TestStudent
+	tx = NewReadOnlyTxn(c)
+	err = tx.Get(&result).
+		UID(student.UID).
+		Query(`{ mainSchool { uid } }`).
+		Node()
+	if err != nil {
+		t.Error(err)
+	}
+
+	require.NotNil(t, result.School)
+	assert.Equal(t, updatedStudent.School.UID, result.School.UID)
+
+	// setting the same school should keep the edge
+	tx = NewTxn(c).SetCommitNow()
+	if _, err := tx.Mutate(&TestStudent{UID: student.UID, School: &TestSchool{UID: updatedStudent.School.UID}}); err != nil {
+		t.Error(err)
+	}
+
+	tx = NewReadOnlyTxn(c)
+	err = tx.Get(&result).
+		UID(student.UID).
+		Query(`{ mainSchool { uid } }`).
+		Node()
+	if err != nil {
+		t.Error(err)
+	}
+
+	require.NotNil(t, result.School)
+	assert.Equal(t, updatedStudent.School.UID, result.School.UID)
+
+	// setting the school on another student should remove the edge from the first student
+	otherStudent := TestStudent{
+		Name:   "dolan",
+		School: &TestSchool{UID: updatedStudent.School.UID},
+	}
+
+	tx = NewTxn(c).SetCommitNow()
+	if _, err := tx.Mutate(&otherStudent); err != nil {
+		t.Error(err)
+	}
+
+	result = TestStudent{}
+	tx = NewReadOnlyTxn(c)
+	err = tx.Get(&result).
+		UID(student.UID).
+		Query(`{ mainSchool { uid } }`).
+		Node()
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Nil(t, result.School)
+}
+
+type TestMember struct {
+	UID        string    `json:"uid,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	NameOrigin string    `json:"name|origin,omitempty"`
+	Club       *TestClub `json:"club,omitempty"`
+	DType      []string  `json:"dgraph.type,omitempty"`
+}
+
+type TestClub struct {
+	UID   string   `json:"uid,omitempty"`
+	Name  string   `json:"name,omitempty"`
+	Since int      `json:"club|since,omitempty"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+func TestMutationMutate_Facets(t *testing.T) {
+	c := newDgraphClient()
+
+	_, err := CreateSchema(c, TestMember{})
+	if err != nil {
+		t.Error(err)
+	}
+	defer dropAll(c)
+
+	member := TestMember{
+		Name:       "wildan",
+		NameOrigin: "indonesia",
+		Club: &TestClub{
+			Name:  "Persib",
+			Since: 2010,
+		},
+	}
+
+	tx := NewTxn(c).SetCommitNow()
+	if _, err := tx.Mutate(&member); err != nil {
+		t.Error(err)
+	}
+
+	var result TestMember
+	tx = NewReadOnlyTxn(c)
+	if err := tx.Get(&result).UID(member.UID).All(1).Node(); err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, "indonesia", result.NameOrigin)
+	require.NotNil(t, result.Club)
+	assert.Equal(t, 2010, result.Club.Since)
+}
+
 func TestMutationMutate_Nested(t *testing.T) {
 	c := newDgraphClient()
 
@@ -670,3 +814,321 @@ func TestSetTypes(t *testing.T) {
 	assert.Equal(t, "TestSchool", user.School.DType[0])
 	assert.Equal(t, "Location", user.School.Location.DType[0])
 }
+
+func TestMutateWithOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     MutateOptions
+		expected func(tx *TxnContext) error
+	}{
+		{
+			name: "should mutate like MutateBasic when skipping unique",
+			opts: MutateOptions{SkipUnique: true},
+			expected: func(tx *TxnContext) error {
+				_, err := tx.MutateBasic(newGoldenUser())
+				return err
+			},
+		},
+		{
+			name: "should mutate like MutateOrGet on unique conflict get",
+			opts: MutateOptions{OnUniqueConflict: UniqueConflictGet},
+			expected: func(tx *TxnContext) error {
+				_, err := tx.MutateOrGet(newGoldenUser())
+				return err
+			},
+		},
+		{
+			name: "should mutate like Upsert on unique conflict update",
+			opts: MutateOptions{OnUniqueConflict: UniqueConflictUpdate, UpsertPredicates: []string{"username"}},
+			expected: func(tx *TxnContext) error {
+				_, err := tx.Upsert(newGoldenUser(), "username")
+				return err
+			},
+		},
+		{
+			name: "should commit now",
+			opts: MutateOptions{CommitNow: true},
+			expected: func(tx *TxnContext) error {
+				_, err := tx.SetCommitNow().Mutate(newGoldenUser())
+				return err
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			atomic.StoreInt32(&blankuid, 0)
+			tx, expected := newFakeTxnContext()
+			require.NoError(t, test.expected(tx))
+
+			atomic.StoreInt32(&blankuid, 0)
+			tx, actual := newFakeTxnContext()
+			_, err := tx.MutateWithOptions(newGoldenUser(), test.opts)
+			require.NoError(t, err)
+
+			assert.Equal(t, formatRequests(expected.requests), formatRequests(actual.requests))
+		})
+	}
+
+	t.Run("should not skip unique with unique conflict handling", func(t *testing.T) {
+		tx, fake := newFakeTxnContext()
+		_, err := tx.MutateWithOptions(newGoldenUser(), MutateOptions{
+			SkipUnique:       true,
+			OnUniqueConflict: UniqueConflictUpdate,
+		})
+		assert.Error(t, err)
+		assert.Len(t, fake.requests, 0)
+	})
+}
+
+func TestUpsertByType(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		// the user exists by email, the first product by sku
+		Json: []byte(`{"q_u_3":[{"uid":"0x5"}],"q_p1_2":[{"uid":"0x7"}]}`),
+		Uids: map[string]string{"uid(u_p2_2)": "0x9"},
+	})
+	// the upsert predicate of users is resolved from the client options, of products from the passed predicates
+	tx.SetOptions(&ClientOptions{UpsertPredicates: map[string]string{"User": "email"}})
+
+	user := &TestUser{UID: "_:u", Username: "wildan", Email: "wildan@dolan.in"}
+	data := []interface{}{
+		user,
+		&OverrideProduct{UID: "_:p1", ExternalID: "x1", SKU: "s1"},
+		&OverrideProduct{UID: "_:p2", ExternalID: "x2", SKU: "s2"},
+	}
+	uids, err := tx.UpsertByType(data, "sku")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"User":            {"0x5"},
+		"OverrideProduct": {"0x7", "0x9"},
+	}, uids)
+	assert.Equal(t, "0x5", user.UID)
+
+	require.Len(t, fake.requests, 1)
+	for _, mu := range fake.requests[0].Mutations {
+		// only conflicts on predicates other than the upsert predicates are checked
+		assert.NotContains(t, mu.Cond, "u_u_3")
+		assert.NotContains(t, mu.Cond, "u_p1_2")
+		assert.NotContains(t, mu.Cond, "u_p2_2")
+	}
+}
+
+func TestUpsertNestedMixedTypes(t *testing.T) {
+	type Cart struct {
+		UID   string        `json:"uid,omitempty"`
+		Owner interface{}   `json:"owner,omitempty" dgraph:"type=uid"`
+		Items []interface{} `json:"items,omitempty" dgraph:"type=[uid]"`
+		DType []string      `json:"dgraph.type,omitempty"`
+	}
+
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"q_u_2":[{"uid":"0x5"}],"q_p_1":[{"uid":"0x7"}]}`),
+		Uids: map[string]string{"c": "0x1"},
+	})
+	cart := &Cart{
+		UID:   "_:c",
+		Owner: &TestUser{UID: "_:u", Username: "wildan", Email: "wildan@dolan.in"},
+		Items: []interface{}{&OverrideProduct{UID: "_:p", ExternalID: "x1", SKU: "s1"}},
+	}
+	uids, err := tx.UpsertByType(cart, "username", "ext_id")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"Cart":            {"0x1"},
+		"User":            {"0x5"},
+		"OverrideProduct": {"0x7"},
+	}, uids)
+
+	require.Len(t, fake.requests, 1)
+	setJSON := string(fake.requests[0].Mutations[len(fake.requests[0].Mutations)-1].SetJson)
+	assert.Contains(t, setJSON, `"owner":{"uid":"uid(u_u_2)"}`)
+	assert.Contains(t, setJSON, `"items":[{"uid":"uid(u_p_1)"}]`)
+}
+
+func TestMutateOrGetMergesDuplicates(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"q_s1_2":[{"uid":"0x9","name":"Harvard","identifier":"harvard"}]}`),
+	})
+	users := []*TestUser{
+		{UID: "_:u1", Username: "wildan", Schools: []TestSchool{{UID: "_:s1", Identifier: "harvard"}}},
+		{UID: "_:u2", Username: "dolan", School: &TestSchool{UID: "_:s2", Identifier: "harvard", Location: &TestLocation{UID: "_:l", LocationID: "boston"}}},
+	}
+	_, err := tx.MutateOrGet(&users)
+	require.NoError(t, err)
+
+	require.Len(t, fake.requests, 1)
+	request := fake.requests[0]
+	// the duplicate school and its edges are not queried or created
+	assert.Contains(t, request.Query, "q_s1_2")
+	assert.NotContains(t, request.Query, "q_s2_2")
+	assert.NotContains(t, request.Query, "q_l_1")
+	require.Len(t, request.Mutations, 3)
+	for _, mu := range request.Mutations {
+		assert.NotContains(t, string(mu.SetJson), "_:s2")
+	}
+	// the school node, and the edges of both users
+	assert.Equal(t, 3, strings.Count(formatRequests(fake.requests), `"uid": "uid(u_s1_2)"`))
+
+	assert.Equal(t, "0x9", users[0].Schools[0].UID)
+	assert.Equal(t, users[0].Schools[0], *users[1].School)
+	assert.Equal(t, "Harvard", users[1].School.Name)
+}
+
+func TestMutateUpdateMask(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"q_u_2":[{"uid":"0x5"}]}`),
+	})
+	user := &TestUser{UID: "_:u", Name: "wildan", Username: "wildan", Email: "wildan@dolan.in"}
+	_, err := tx.MutateWithOptions(user, MutateOptions{
+		OnUniqueConflict: UniqueConflictUpdate,
+		UpsertPredicates: []string{"username"},
+		UpdateMask:       []string{"name"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "0x5", user.UID)
+
+	require.Len(t, fake.requests, 1)
+	req := fake.requests[0]
+	// the email is not set, nor unique checked
+	assert.NotContains(t, req.Query, "q_u_3")
+	require.Len(t, req.Mutations, 1)
+	assert.JSONEq(t, `{"uid":"uid(u_u_2)","name":"wildan","username":"wildan","dgraph.type":["User"]}`, string(req.Mutations[0].SetJson))
+
+	_, err = tx.MutateWithOptions(&TestUser{Name: "wildan"}, MutateOptions{UpdateMask: []string{"nickname"}})
+	assert.EqualError(t, err, "generate request failed: update mask predicate nickname is not defined in the mutated node types")
+
+	_, err = tx.MutateWithOptions(&TestUser{Name: "wildan"}, MutateOptions{UpdateMask: []string{"name"}, SkipUnique: true})
+	assert.Error(t, err)
+}
+
+func TestMutatePathBlankUID(t *testing.T) {
+	newUsers := func() []*TestUser {
+		return []*TestUser{
+			{Username: "wildan", Schools: []TestSchool{{Identifier: "harvard"}, {UID: "_:mit", Identifier: "mit"}}},
+			{Username: "dolan", School: &TestSchool{Identifier: "stanford", Location: &TestLocation{}}},
+		}
+	}
+
+	var requests []string
+	for i := 0; i < 2; i++ {
+		tx, fake := newFakeTxnContext()
+		users := newUsers()
+		_, err := tx.MutateWithOptions(&users, MutateOptions{BlankUID: PathBlankUID, SkipUnique: true})
+		require.NoError(t, err)
+
+		assert.Equal(t, "_:root0", users[0].UID)
+		assert.Equal(t, "_:root0Schools0", users[0].Schools[0].UID)
+		assert.Equal(t, "_:mit", users[0].Schools[1].UID)
+		assert.Equal(t, "_:root1", users[1].UID)
+		assert.Equal(t, "_:root1School", users[1].School.UID)
+		assert.Equal(t, "_:root1SchoolLocation", users[1].School.Location.UID)
+		requests = append(requests, formatRequests(fake.requests))
+	}
+	// the same mutation is generated across runs
+	assert.Equal(t, requests[0], requests[1])
+}
+
+func TestMutateBlankUIDFunc(t *testing.T) {
+	blankUID := func(nodeType, path string) string {
+		return strings.ToLower(nodeType)
+	}
+
+	tx, _ := newFakeTxnContext()
+	schools := []TestSchool{{Identifier: "harvard"}, {Identifier: "mit"}, {Identifier: "stanford"}}
+	_, err := tx.MutateWithOptions(&schools, MutateOptions{BlankUID: blankUID, SkipUnique: true})
+	require.NoError(t, err)
+	// duplicate names are suffixed
+	assert.Equal(t, "_:testschool", schools[0].UID)
+	assert.Equal(t, "_:testschool2", schools[1].UID)
+	assert.Equal(t, "_:testschool3", schools[2].UID)
+
+	tx, fake := newFakeTxnContext()
+	_, err = tx.MutateWithOptions(&TestSchool{}, MutateOptions{SkipUnique: true, BlankUID: func(nodeType, path string) string {
+		return "school_1"
+	}})
+	assert.EqualError(t, err, `invalid blank uid "school_1" of TestSchool at "", must be alphanumeric`)
+	assert.Empty(t, fake.requests)
+}
+
+func TestMutateWithResult(t *testing.T) {
+	tx, _ := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"q_user_3":[{"uid":"0x5"}]}`),
+		Uids: map[string]string{"uid(u_harvard_2)": "0x6"},
+	})
+
+	user := &TestUser{
+		UID:      "_:user",
+		Username: "wildan",
+		Email:    "wildan@dolan.in",
+		School:   &TestSchool{UID: "_:harvard", Identifier: "harvard"},
+	}
+	result, err := tx.MutateWithResult(user, MutateOptions{
+		OnUniqueConflict: UniqueConflictUpdate,
+		UpsertPredicates: []string{"email"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"user": "0x5", "harvard": "0x6"}, result.BlankUIDs)
+	assert.Equal(t, []string{"0x6"}, result.UIDs)
+}
+
+type NumericUIDUser struct {
+	UID      uint64            `json:"uid,omitempty"`
+	Username string            `json:"username,omitempty" dgraph:"index=hash unique"`
+	Friends  []*NumericUIDUser `json:"friends,omitempty"`
+	DType    []string          `json:"dgraph.type,omitempty"`
+}
+
+type TypedUIDUser struct {
+	UID      UID      `json:"uid,omitempty"`
+	Username string   `json:"username,omitempty"`
+	DType    []string `json:"dgraph.type,omitempty"`
+}
+
+func TestMutateNumericUID(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Uids: map[string]string{"uid(u_root_1)": "0x1f", "uid(u_rootFriends1_1)": "0x20"},
+	})
+	user := &NumericUIDUser{
+		Username: "wildan",
+		Friends:  []*NumericUIDUser{{UID: 5}, {Username: "dolan"}},
+	}
+	_, err := tx.MutateWithOptions(user, MutateOptions{BlankUID: PathBlankUID})
+	require.NoError(t, err)
+
+	require.Len(t, fake.requests, 1)
+	request := formatRequests(fake.requests)
+	// new nodes are referenced by uid funcs of their unique queries
+	assert.Contains(t, request, `"uid": "uid(u_root_1)"`)
+	assert.Contains(t, request, `"uid": "0x5"`)
+	assert.Contains(t, request, `"uid": "uid(u_rootFriends1_1)"`)
+
+	assert.Equal(t, uint64(0x1f), user.UID)
+	assert.Equal(t, uint64(5), user.Friends[0].UID)
+	assert.Equal(t, uint64(0x20), user.Friends[1].UID)
+}
+
+func TestMutateNumericUIDUpsert(t *testing.T) {
+	tx, _ := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"q_root_1":[{"uid":"0x9"}]}`),
+	})
+	user := &NumericUIDUser{Username: "wildan"}
+	_, err := tx.MutateWithOptions(user, MutateOptions{BlankUID: PathBlankUID, OnUniqueConflict: UniqueConflictUpdate})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9), user.UID)
+}
+
+func TestMutateNumericUIDBasic(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{Uids: map[string]string{"root": "0x1"}})
+	user := &NumericUIDUser{Username: "wildan"}
+	_, err := tx.MutateWithOptions(user, MutateOptions{BlankUID: PathBlankUID, SkipUnique: true})
+	require.NoError(t, err)
+	assert.Contains(t, string(fake.requests[0].Mutations[0].SetJson), `"uid":"_:root"`)
+	assert.Equal(t, uint64(1), user.UID)
+}
+
+func TestMutateTypedUID(t *testing.T) {
+	tx, _ := newFakeTxnContext(&api.Response{Uids: map[string]string{"root": "0x1"}})
+	user := &TypedUIDUser{Username: "wildan"}
+	_, err := tx.MutateWithOptions(user, MutateOptions{BlankUID: PathBlankUID, SkipUnique: true})
+	require.NoError(t, err)
+	assert.Equal(t, UID("0x1"), user.UID)
+}
@@ -19,7 +19,11 @@ package dgman
 import (
 	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync/atomic"
+	"unicode"
 
 	"github.com/dolan-in/reflectwalk"
 )
@@ -32,6 +36,112 @@ func blankUID() string {
 	return fmt.Sprintf("_:%d", i)
 }
 
+// BlankUIDFunc returns the blank node name, without the "_:" prefix, of a new node without a uid in a mutation,
+// from its node type and its path in the mutation data, i.e: the json field names of edges and slice indexes
+// separated by dots, e.g: "0.schools.1" for the 2nd school of the 1st user in a slice, empty for the root node.
+// Blank node names must be alphanumeric, as they also name the unique check queries of the nodes.
+type BlankUIDFunc func(nodeType, path string) string
+
+var blankNameRegex = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// PathBlankUID is a BlankUIDFunc returning deterministic blank node names derived from the node paths,
+// e.g: "root0Schools1" for "0.schools.1", so generated mutations are the same across runs
+func PathBlankUID(nodeType, path string) string {
+	var buffer strings.Builder
+	buffer.WriteString("root")
+	for _, elem := range strings.Split(path, ".") {
+		upper := true
+		for _, r := range elem {
+			if !(r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))) {
+				continue
+			}
+			if upper {
+				r = unicode.ToUpper(r)
+				upper = false
+			}
+			buffer.WriteRune(r)
+		}
+	}
+	return buffer.String()
+}
+
+// assignBlankUIDs sets the uids of new nodes to the blank node names returned by the blank uid func
+// of the mutation, before the uids are generated, deduplicating names with a numeric suffix
+func (m *mutation) assignBlankUIDs() error {
+	if m.blankUID == nil {
+		return nil
+	}
+	return m.assignBlankUID(reflect.ValueOf(m.data), nil, newSet())
+}
+
+func (m *mutation) assignBlankUID(v reflect.Value, path []string, allocated set) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return m.assignBlankUID(v.Elem(), path, allocated)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := m.assignBlankUID(v.Index(i), append(path[:len(path):len(path)], strconv.Itoa(i)), allocated); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		vType := v.Type()
+		for i := 0; i < vType.NumField(); i++ {
+			field := vType.Field(i)
+			if field.PkgPath != "" {
+				// unexported field
+				continue
+			}
+			fieldValue := v.Field(i)
+			if field.Anonymous {
+				if err := m.assignBlankUID(fieldValue, path, allocated); err != nil {
+					return err
+				}
+				continue
+			}
+
+			predicate, _ := getPredicate(&field)
+			if predicate != predicateUid {
+				if err := m.assignBlankUID(fieldValue, append(path[:len(path):len(path)], predicate), allocated); err != nil {
+					return err
+				}
+				continue
+			}
+			if !isUIDField(fieldValue.Kind()) {
+				continue
+			}
+			if uid := m.uidOf(fieldValue); uid != "" {
+				if isUIDAlias(uid) {
+					allocated.Add(uid[2:])
+				}
+				continue
+			}
+
+			nodePath := strings.Join(path, ".")
+			name := m.blankUID(getNodeType(vType), nodePath)
+			if !blankNameRegex.MatchString(name) {
+				return fmt.Errorf("invalid blank uid %q of %s at %q, must be alphanumeric", name, vType.Name(), nodePath)
+			}
+			for n := 2; allocated.Has(name); n++ {
+				if candidate := name + strconv.Itoa(n); !allocated.Has(candidate) {
+					name = candidate
+				}
+			}
+			if !fieldValue.CanSet() {
+				return fmt.Errorf("cannot set uid")
+			}
+			allocated.Add(name)
+			if err := m.setUIDOf(fieldValue, "_:"+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func genUID(f reflect.StructField, v reflect.Value) (string, error) {
 	if v.Kind() != reflect.String {
 		return "", nil
@@ -49,12 +159,73 @@ func genUID(f reflect.StructField, v reflect.Value) (string, error) {
 			return "", fmt.Errorf("cannot set uid")
 		}
 		uid = blankUID()
-		v.Set(reflect.ValueOf(uid))
+		v.SetString(uid)
 		return uid, nil
 	}
 	return "", nil
 }
 
+// uidOf returns the uid of a uid field of a mutated node, the blank uids and uid funcs
+// of numeric uid fields are kept by the mutation, as the fields can only hold uids
+func (m *mutation) uidOf(v reflect.Value) string {
+	uid := uidValue(v)
+	if uid == "" && isNumericUID(v.Kind()) && v.CanAddr() {
+		return m.numericUIDs[v.UnsafeAddr()]
+	}
+	return uid
+}
+
+// setUIDOf sets a uid field of a mutated node to a uid, blank uid, or uid func
+func (m *mutation) setUIDOf(v reflect.Value, uid string) error {
+	if !isNumericUID(v.Kind()) || isUID(uid) {
+		return setUIDValue(v, uid)
+	}
+	if !v.CanAddr() {
+		return fmt.Errorf("cannot set uid")
+	}
+	m.numericUIDs[v.UnsafeAddr()] = uid
+	return nil
+}
+
+// genUID generates the blank uid of a new node, as in genUID, including numeric uid fields
+func (m *mutation) genUID(f reflect.StructField, v reflect.Value) (string, error) {
+	if predicate, _ := getPredicate(&f); predicate != predicateUid || !isNumericUID(v.Kind()) {
+		return genUID(f, v)
+	}
+	if uid := m.uidOf(v); uid != "" {
+		return uid, nil
+	}
+	uid := blankUID()
+	if err := m.setUIDOf(v, uid); err != nil {
+		return "", err
+	}
+	return uid, nil
+}
+
+// createdUID returns the uid created for a blank uid or uid func, from the uids of a mutation response
+func createdUID(setUID string, uids map[string]string) (string, bool) {
+	if isUIDAlias(setUID) {
+		uid, ok := uids[setUID[2:]]
+		return uid, ok
+	}
+	if isUIDFunc(setUID) {
+		uid, ok := uids[setUID]
+		return uid, ok
+	}
+	return "", false
+}
+
+// setNumericUIDs sets a numeric uid field of a new node to its uid created by the mutation
+func setNumericUIDs(f reflect.StructField, v reflect.Value, numericUIDs map[uintptr]string, uids map[string]string) error {
+	if predicate, _ := getPredicate(&f); predicate != predicateUid || !v.CanAddr() {
+		return nil
+	}
+	if uid, ok := createdUID(numericUIDs[v.UnsafeAddr()], uids); ok {
+		return setUIDValue(v, uid)
+	}
+	return nil
+}
+
 func setUIDs(f reflect.StructField, v reflect.Value, uids map[string]string) error {
 	if v.Kind() != reflect.String {
 		return nil
@@ -71,18 +242,9 @@ func setUIDs(f reflect.StructField, v reflect.Value, uids map[string]string) err
 		return fmt.Errorf("cannot set %s/%s", predicate, setUID)
 	}
 
-	if isUIDAlias(setUID) {
-		uid, ok := uids[setUID[2:]]
-		if ok {
-			v.SetString(uid)
-		}
-	} else if isUIDFunc(setUID) {
-		uid, ok := uids[setUID]
-		if ok {
-			v.SetString(uid)
-		}
+	if uid, ok := createdUID(setUID, uids); ok {
+		v.SetString(uid)
 	}
-
 	return nil
 }
 
@@ -0,0 +1,121 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+)
+
+// DefaultNamespace is the namespace of Dgraph without multi-tenancy
+const DefaultNamespace uint64 = 0
+
+// NamespaceClient manages a Client logged into each namespace of a multi-tenant Dgraph cluster,
+// sharing the same gRPC connections
+type NamespaceClient struct {
+	clients  []api.DgraphClient
+	user     string
+	password string
+	setup    func(c *Client)
+
+	mu         sync.Mutex
+	namespaces map[uint64]*Client
+}
+
+// NewNamespaceClient creates a namespace client, which logs into namespaces using the user credentials
+func NewNamespaceClient(user, password string, clients ...api.DgraphClient) *NamespaceClient {
+	return &NamespaceClient{
+		clients:    clients,
+		user:       user,
+		password:   password,
+		namespaces: make(map[uint64]*Client),
+	}
+}
+
+// OnLogin sets a function called on a new client logged into a namespace,
+// e.g: to set query guards or client options
+func (n *NamespaceClient) OnLogin(setup func(c *Client)) *NamespaceClient {
+	n.setup = setup
+	return n
+}
+
+// Client returns the client logged into a namespace, logging in on first use
+func (n *NamespaceClient) Client(ctx context.Context, namespace uint64) (*Client, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if c, ok := n.namespaces[namespace]; ok {
+		return c, nil
+	}
+
+	c := NewClient(n.clients...)
+	if err := c.LoginACL(ctx, n.user, n.password, namespace); err != nil {
+		return nil, errors.Wrapf(err, "login into namespace %d failed", namespace)
+	}
+	if n.setup != nil {
+		n.setup(c)
+	}
+	n.namespaces[namespace] = c
+	return c, nil
+}
+
+// NewTxn creates a new transaction within a namespace
+func (n *NamespaceClient) NewTxn(ctx context.Context, namespace uint64) (*TxnContext, error) {
+	c, err := n.Client(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return c.NewTxnContext(ctx), nil
+}
+
+// NewReadOnlyTxn creates a new read only transaction within a namespace
+func (n *NamespaceClient) NewReadOnlyTxn(ctx context.Context, namespace uint64) (*TxnContext, error) {
+	c, err := n.Client(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return c.NewReadOnlyTxnContext(ctx), nil
+}
+
+// CreateSchema creates the schema of the models within a namespace, as in CreateSchema
+func (n *NamespaceClient) CreateSchema(ctx context.Context, namespace uint64, models ...interface{}) (*TypeSchema, error) {
+	c, err := n.Client(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return c.CreateSchema(models...)
+}
+
+// MutateSchema alters the schema of the models within a namespace, as in MutateSchema
+func (n *NamespaceClient) MutateSchema(ctx context.Context, namespace uint64, models ...interface{}) (*TypeSchema, error) {
+	c, err := n.Client(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return c.MutateSchema(models...)
+}
+
+// Logout removes the client of a namespace, the next use of the namespace logs in again
+func (n *NamespaceClient) Logout(namespace uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.namespaces, namespace)
+}
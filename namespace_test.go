@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// loginClient records login requests, other DgraphClient methods are not implemented
+type loginClient struct {
+	api.DgraphClient
+	logins []*api.LoginRequest
+	err    error
+}
+
+func (l *loginClient) Login(ctx context.Context, in *api.LoginRequest, opts ...grpc.CallOption) (*api.Response, error) {
+	l.logins = append(l.logins, in)
+	if l.err != nil {
+		return nil, l.err
+	}
+	return &api.Response{}, nil
+}
+
+func TestNamespaceClient(t *testing.T) {
+	dc := &loginClient{}
+	var setupClients []*Client
+	nc := NewNamespaceClient("groot", "password", dc).
+		OnLogin(func(c *Client) {
+			setupClients = append(setupClients, c)
+		})
+
+	ctx := context.Background()
+	c1, err := nc.Client(ctx, 1)
+	require.NoError(t, err)
+	c2, err := nc.Client(ctx, 2)
+	require.NoError(t, err)
+	cached, err := nc.Client(ctx, 1)
+	require.NoError(t, err)
+
+	assert.True(t, c1 == cached, "namespace client should be cached")
+	assert.True(t, c1 != c2, "namespaces should have separate clients")
+	assert.Equal(t, []*Client{c1, c2}, setupClients)
+	require.Len(t, dc.logins, 2)
+	assert.Equal(t, &api.LoginRequest{Userid: "groot", Password: "password", Namespace: 1}, dc.logins[0])
+	assert.Equal(t, uint64(2), dc.logins[1].Namespace)
+
+	nc.Logout(1)
+	_, err = nc.NewTxn(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, dc.logins, 3)
+}
+
+func TestNamespaceClientLoginError(t *testing.T) {
+	dc := &loginClient{err: errors.New("invalid password")}
+	nc := NewNamespaceClient("groot", "wrong", dc)
+
+	_, err := nc.NewReadOnlyTxn(context.Background(), 1)
+	assert.EqualError(t, err, "login into namespace 1 failed: invalid password")
+}
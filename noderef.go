@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// NodeRef is a node of a polymorphic edge, e.g: []dgman.NodeRef of feed items pointing to posts, photos, and events.
+// On unmarshaling, the node is instantiated as the model registered with RegisterNodeType for its dgraph.type,
+// or as a map when no model is registered. On mutations, the node is mutated as an edge node.
+type NodeRef struct {
+	// Node is a pointer to the model of the node type, e.g: *Post
+	Node interface{}
+}
+
+var nodeRefType = reflect.TypeOf(NodeRef{})
+
+// nodeRefModels maps node types to the models of polymorphic edge nodes
+var nodeRefModels sync.Map
+
+// RegisterNodeType registers models as the Go types of their node types, to decode NodeRef edge nodes by their dgraph.type
+func RegisterNodeType(models ...interface{}) error {
+	for _, model := range models {
+		modelType, err := reflectType(model)
+		if err != nil {
+			return err
+		}
+		if modelType.Kind() != reflect.Struct {
+			return errors.Errorf("model %s is not a struct", modelType)
+		}
+		nodeRefModels.Store(getNodeType(modelType), modelType)
+	}
+	return nil
+}
+
+// MarshalJSON marshals the node
+func (n NodeRef) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.Node)
+}
+
+// UnmarshalJSON unmarshals the node into a new model registered for its dgraph.type
+func (n *NodeRef) UnmarshalJSON(data []byte) error {
+	var typed struct {
+		DType []string `json:"dgraph.type"`
+	}
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return errors.Wrap(err, "unmarshal dgraph.type failed")
+	}
+
+	for _, nodeType := range typed.DType {
+		model, ok := nodeRefModels.Load(nodeType)
+		if !ok {
+			continue
+		}
+		node := reflect.New(model.(reflect.Type)).Interface()
+		if err := json.Unmarshal(data, node); err != nil {
+			return errors.Wrapf(err, "unmarshal %s node failed", nodeType)
+		}
+		n.Node = node
+		return nil
+	}
+
+	var node map[string]interface{}
+	if err := json.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	n.Node = node
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type FeedPost struct {
+	UID   string   `json:"uid,omitempty"`
+	Title string   `json:"title,omitempty"`
+	DType []string `json:"dgraph.type,omitempty" dgraph:"Post"`
+}
+
+type FeedPhoto struct {
+	UID   string   `json:"uid,omitempty"`
+	URL   string   `json:"url,omitempty"`
+	DType []string `json:"dgraph.type,omitempty" dgraph:"Photo"`
+}
+
+type Feed struct {
+	UID   string    `json:"uid,omitempty"`
+	Items []NodeRef `json:"items,omitempty"`
+	DType []string  `json:"dgraph.type,omitempty"`
+}
+
+func TestNodeRefUnmarshal(t *testing.T) {
+	require.NoError(t, RegisterNodeType(&FeedPost{}, &FeedPhoto{}))
+
+	var feed Feed
+	err := json.Unmarshal([]byte(`{"uid":"0x1","items":[
+		{"uid":"0x2","dgraph.type":["Post"],"title":"hello"},
+		{"uid":"0x3","dgraph.type":["Photo"],"url":"a.jpg"},
+		{"uid":"0x4","dgraph.type":["Event"]}
+	]}`), &feed)
+	require.NoError(t, err)
+	require.Len(t, feed.Items, 3)
+	assert.Equal(t, &FeedPost{UID: "0x2", Title: "hello", DType: []string{"Post"}}, feed.Items[0].Node)
+	assert.Equal(t, &FeedPhoto{UID: "0x3", URL: "a.jpg", DType: []string{"Photo"}}, feed.Items[1].Node)
+	assert.Equal(t, map[string]interface{}{"uid": "0x4", "dgraph.type": []interface{}{"Event"}}, feed.Items[2].Node)
+}
+
+func TestNodeRefMutate(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Uids: map[string]string{"f": "0x1", "p": "0x2"},
+	})
+	post := &FeedPost{UID: "_:p", Title: "hello"}
+	feed := &Feed{UID: "_:f", Items: []NodeRef{{Node: post}, {Node: &FeedPhoto{UID: "0x3"}}}}
+	_, err := tx.Mutate(feed)
+	require.NoError(t, err)
+	assert.Equal(t, "0x2", post.UID)
+
+	require.Len(t, fake.requests, 1)
+	mutations := fake.requests[0].Mutations
+	require.Len(t, mutations, 2)
+	assert.JSONEq(t, `{"uid":"_:p","title":"hello","dgraph.type":["Post"]}`, string(mutations[0].SetJson))
+	assert.JSONEq(t, `{"uid":"_:f","items":[{"uid":"_:p"},{"uid":"0x3","dgraph.type":["Photo"]}],"dgraph.type":["Feed"]}`, string(mutations[1].SetJson))
+
+	schema := NewTypeSchema()
+	schema.Marshal("", &Feed{})
+	assert.Equal(t, "[uid]", schema.Schema["items"].Type)
+	assert.NotContains(t, schema.Types, "NodeRef")
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// aliasedField is a predicate, or a predicate of nested edges, returned as an alias
+type aliasedField struct {
+	// path are the predicates of the edges and the aliased predicate
+	path []string
+	// edgePaths are the json field names of the edges, to apply the edge queries
+	edgePaths []string
+	alias     string
+}
+
+// Normalize adds the @normalize directive, flattening the query results into a list of nodes
+// with only the aliased predicates, including the aliased predicates of nested edges, e.g:
+//
+//	tx.Get(&films).
+//		AliasField("title", "name").
+//		AliasField("director_name", "director.name").
+//		Normalize().
+//		Nodes(&rows)
+//
+// The flattened nodes can be scanned into flat structs with the alias json tags.
+func (q *Query) Normalize() *Query {
+	q.normalize = true
+	return q
+}
+
+// AliasField returns a predicate of the model as an alias in the query results, taking the alias first as Alias, e.g:
+// AliasField("actor_name", "name") returns "actor_name: name". Predicates of nested edges
+// are specified by a dot separated path of predicates, e.g: "director.name", which are
+// flattened with Normalize. Fields are predicates or json field names validated against the model.
+// When fields are aliased, the query returns only the aliased predicates, instead of expanding all
+// predicates as in All, edge queries set with Query.Edge are applied to the edges of the aliased fields.
+func (q *Query) AliasField(alias, field string) *Query {
+	if !aliasRegex.MatchString(alias) {
+		q.err = fmt.Errorf("invalid field alias %q", alias)
+		return q
+	}
+
+	aliased := aliasedField{alias: alias}
+	if q.model == nil {
+		for _, predicate := range strings.Split(field, ".") {
+			if !predicateRegex.MatchString(predicate) {
+				q.err = fmt.Errorf("invalid aliased field %q", field)
+				return q
+			}
+			aliased.path = append(aliased.path, predicate)
+			aliased.edgePaths = append(aliased.edgePaths, predicate)
+		}
+	} else if err := aliased.resolve(getElemType(reflect.TypeOf(q.model)), field); err != nil {
+		q.err = errors.Wrap(err, "invalid aliased field")
+		return q
+	}
+
+	q.aliasFields = append(q.aliasFields, aliased)
+	return q
+}
+
+// resolve resolves the predicates of the path of an aliased field against a node type
+func (f *aliasedField) resolve(modelType reflect.Type, field string) error {
+	names := strings.Split(field, ".")
+	for i, name := range names {
+		structField, predicate, err := selectField(modelType, name)
+		if err != nil {
+			return err
+		}
+		jsonName, _ := getPredicate(&structField)
+		f.path = append(f.path, predicate)
+		f.edgePaths = append(f.edgePaths, jsonName)
+
+		if i == len(names)-1 {
+			break
+		}
+		edgeType := edgeNodeType(structField)
+		if edgeType == nil {
+			return fmt.Errorf("%s is not an edge of %s", name, modelType.Name())
+		}
+		modelType = edgeType
+	}
+	return nil
+}
+
+// aliasedNode is a node of the tree of aliased fields, by the predicates of the paths
+type aliasedNode struct {
+	predicate string
+	edgePath  string
+	aliases   []string
+	edges     []*aliasedNode
+}
+
+func (n *aliasedNode) edge(predicate, edgePath string) *aliasedNode {
+	for _, edge := range n.edges {
+		if edge.predicate == predicate {
+			return edge
+		}
+	}
+	edge := &aliasedNode{predicate: predicate, edgePath: edgePath}
+	n.edges = append(n.edges, edge)
+	return edge
+}
+
+// aliasedPredicates returns the query of the aliased fields, with the edges of nested aliased fields
+func (q *Query) aliasedPredicates() string {
+	root := &aliasedNode{}
+	for _, field := range q.aliasFields {
+		node := root
+		last := len(field.path) - 1
+		for i := 0; i < last; i++ {
+			edgePath := strings.Join(field.edgePaths[:i+1], ".")
+			node = node.edge(field.path[i], edgePath)
+		}
+		node.aliases = append(node.aliases, field.alias+": "+field.path[last])
+	}
+
+	var buffer strings.Builder
+	q.writeAliasedNode(&buffer, root)
+	return buffer.String()
+}
+
+func (q *Query) writeAliasedNode(buffer *strings.Builder, node *aliasedNode) {
+	buffer.WriteString("{")
+	for _, alias := range node.aliases {
+		buffer.WriteString("\n\t\t")
+		buffer.WriteString(alias)
+	}
+	for _, edge := range node.edges {
+		buffer.WriteString("\n\t\t")
+		buffer.WriteString(edge.predicate)
+		if edgeQuery, ok := q.edges[edge.edgePath]; ok {
+			edgeQuery.write(buffer)
+		}
+		buffer.WriteString(" ")
+		q.writeAliasedNode(buffer, edge)
+	}
+	buffer.WriteString("\n\t}")
+}
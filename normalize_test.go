@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type NormalizeDirector struct {
+	UID   string   `json:"uid,omitempty"`
+	Name  string   `json:"name,omitempty"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+type NormalizeFilm struct {
+	UID       string               `json:"uid,omitempty"`
+	Name      string               `json:"name,omitempty" dgraph:"index=term"`
+	Released  int                  `json:"released,omitempty" dgraph:"predicate=release_year"`
+	Directors []*NormalizeDirector `json:"directors,omitempty"`
+	DType     []string             `json:"dgraph.type,omitempty"`
+}
+
+type NormalizeFilmRow struct {
+	Title        string `json:"title"`
+	Year         int    `json:"year"`
+	DirectorName string `json:"director_name"`
+}
+
+func TestQueryNormalize(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{Json: []byte(`{"data":[
+		{"title":"Alien","year":1979,"director_name":"Ridley Scott"},
+		{"title":"Heat","year":1995,"director_name":"Michael Mann"}
+	]}`)})
+
+	query := tx.Get(&[]NormalizeFilm{}).
+		AliasField("title", "name").
+		AliasField("year", "released").
+		AliasField("director_name", "directors.name").
+		Edge("directors", EdgeQuery{First: 1}).
+		Normalize()
+	require.NoError(t, query.err)
+	assert.Equal(t, `{
+	data(func: type(NormalizeFilm)) @filter(has(dgraph.type)) @normalize {
+		title: name
+		year: release_year
+		directors (first: 1) {
+			director_name: name
+		}
+	}
+}`, query.String())
+
+	var rows []NormalizeFilmRow
+	require.NoError(t, query.Nodes(&rows))
+	assert.Equal(t, []NormalizeFilmRow{
+		{Title: "Alien", Year: 1979, DirectorName: "Ridley Scott"},
+		{Title: "Heat", Year: 1995, DirectorName: "Michael Mann"},
+	}, rows)
+	require.Len(t, fake.requests, 1)
+}
+
+func TestQueryNormalizeNodesAndCount(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{Json: []byte(`{
+		"result":[{"title":"Alien","director_name":"Ridley Scott"}],
+		"pageInfo":[{"count":2}]
+	}`)})
+
+	var rows []NormalizeFilmRow
+	count, err := tx.Get(&[]NormalizeFilm{}).
+		AliasField("title", "name").
+		AliasField("director_name", "directors.name").
+		Normalize().
+		First(1).
+		NodesAndCount(&rows)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []NormalizeFilmRow{{Title: "Alien", DirectorName: "Ridley Scott"}}, rows)
+
+	require.Len(t, fake.requests, 1)
+	// the aliased fields are returned in the paged result block
+	assert.Contains(t, fake.requests[0].Query, `result(func: uid(filtered), first: 1) @filter(has(dgraph.type)) @normalize {
+		title: name
+		directors {
+			director_name: name
+		}
+	}`)
+}
+
+func TestQueryAliasFieldWithoutModel(t *testing.T) {
+	query := NewQuery().
+		RootFunc("eq(name, $1)", "Alien").
+		AliasField("actor_name", "starring.performance.actor.name").
+		AliasField("title", "name").
+		Normalize()
+	require.NoError(t, query.err)
+	assert.Equal(t, `{
+	data(func: eq(name, "Alien")) @filter(has(dgraph.type)) @normalize {
+		title: name
+		starring {
+			performance {
+				actor {
+					actor_name: name
+				}
+			}
+		}
+	}
+}`, query.String())
+}
+
+func TestQueryAliasFieldInvalid(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	var films []NormalizeFilm
+	err := tx.Get(&films).AliasField("invalid alias", "name").Nodes()
+	assert.EqualError(t, err, `invalid field alias "invalid alias"`)
+
+	err = tx.Get(&films).AliasField("r", "rating").Nodes()
+	assert.EqualError(t, err, "invalid aliased field: rating is not a predicate of NormalizeFilm")
+
+	err = tx.Get(&films).AliasField("n", "name.first").Nodes()
+	assert.EqualError(t, err, "invalid aliased field: name is not an edge of NormalizeFilm")
+
+	err = NewQuery().AliasField("n", "name{").Nodes()
+	assert.EqualError(t, err, `invalid aliased field "name{"`)
+	assert.Empty(t, fake.requests)
+}
@@ -0,0 +1,233 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// nquadWriter writes the set n-quads of the nodes of a set json mutation
+type nquadWriter struct {
+	buffer *bytes.Buffer
+	blank  int
+}
+
+// jsonToNquads converts the set json of a mutation into set n-quads, nodes are written with
+// their uids, blank node aliases, or uid functions as subjects, including the facets of predicates and edges
+func jsonToNquads(setJSON []byte) ([]byte, error) {
+	decoder := stdjson.NewDecoder(bytes.NewReader(setJSON))
+	decoder.UseNumber()
+
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, errors.Wrap(err, "unmarshal set json failed")
+	}
+
+	w := nquadWriter{buffer: &bytes.Buffer{}}
+	switch data := data.(type) {
+	case []interface{}:
+		for _, node := range data {
+			if node, ok := node.(map[string]interface{}); ok {
+				if _, err := w.writeNode(node); err != nil {
+					return nil, err
+				}
+			}
+		}
+	case map[string]interface{}:
+		if _, err := w.writeNode(data); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid set json %s", setJSON)
+	}
+	return w.buffer.Bytes(), nil
+}
+
+// subject returns the subject term of a node, a new blank node when the node has no uid
+func (w *nquadWriter) subject(node map[string]interface{}) string {
+	uid, _ := node[predicateUid].(string)
+	switch {
+	case uid == "":
+		w.blank++
+		return fmt.Sprintf("_:nquad%d", w.blank)
+	case isUIDAlias(uid) || isUIDFunc(uid):
+		return uid
+	}
+	return "<" + uid + ">"
+}
+
+// writeNode writes the n-quads of a node and its edge nodes, returning the subject term of the node
+func (w *nquadWriter) writeNode(node map[string]interface{}) (string, error) {
+	subject := w.subject(node)
+
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if key == predicateUid || isFacet(key) {
+			continue
+		}
+		predicate, lang := key, ""
+		if i := strings.Index(key, "@"); i > 0 {
+			predicate, lang = key[:i], key[i+1:]
+		}
+		facets := nodeFacets(node, predicate)
+
+		values, isList := node[key].([]interface{})
+		if !isList {
+			values = []interface{}{node[key]}
+		}
+		for _, value := range values {
+			if err := w.writeValue(subject, predicate, lang, facets, value); err != nil {
+				return "", errors.Wrapf(err, "write %s failed", key)
+			}
+		}
+	}
+	return subject, nil
+}
+
+func (w *nquadWriter) writeValue(subject, predicate, lang string, facets map[string]interface{}, value interface{}) error {
+	var object string
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		if isGeoJSON(value) {
+			geoJSON, err := stdjson.Marshal(value)
+			if err != nil {
+				return err
+			}
+			object = quoteNquad(string(geoJSON)) + "^^<geo:geojson>"
+			break
+		}
+		edge, err := w.writeNode(value)
+		if err != nil {
+			return err
+		}
+		object = edge
+		// edge facets are set on the edge nodes
+		facets = nodeFacets(value, predicate)
+	default:
+		literal, err := nquadLiteral(value)
+		if err != nil {
+			return err
+		}
+		object = literal
+		if lang != "" {
+			object += "@" + lang
+		}
+	}
+
+	fmt.Fprintf(w.buffer, "%s <%s> %s", subject, predicate, object)
+	if len(facets) > 0 {
+		if err := writeNquadFacets(w.buffer, facets); err != nil {
+			return err
+		}
+	}
+	w.buffer.WriteString(" .\n")
+	return nil
+}
+
+// nodeFacets returns the facets of a predicate defined on a node, as predicate|facet
+func nodeFacets(node map[string]interface{}, predicate string) map[string]interface{} {
+	var facets map[string]interface{}
+	for key, value := range node {
+		if !strings.HasPrefix(key, predicate+"|") || value == nil {
+			continue
+		}
+		if facets == nil {
+			facets = make(map[string]interface{})
+		}
+		facets[key[len(predicate)+1:]] = value
+	}
+	return facets
+}
+
+func writeNquadFacets(buffer *bytes.Buffer, facets map[string]interface{}) error {
+	keys := make([]string, 0, len(facets))
+	for key := range facets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buffer.WriteString(" (")
+	for i, key := range keys {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+		var value string
+		switch facet := facets[key].(type) {
+		case string:
+			value = quoteNquad(facet)
+		case stdjson.Number:
+			value = facet.String()
+		case bool:
+			value = fmt.Sprint(facet)
+		default:
+			return fmt.Errorf("unsupported facet value %v of %s", facet, key)
+		}
+		buffer.WriteString(key)
+		buffer.WriteByte('=')
+		buffer.WriteString(value)
+	}
+	buffer.WriteByte(')')
+	return nil
+}
+
+// nquadLiteral returns the literal of a scalar value, typed as in json mutations
+func nquadLiteral(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return quoteNquad(value), nil
+	case bool:
+		return fmt.Sprintf("\"%t\"^^<xs:boolean>", value), nil
+	case stdjson.Number:
+		if strings.ContainsAny(value.String(), ".eE") {
+			return quoteNquad(value.String()) + "^^<xs:float>", nil
+		}
+		return quoteNquad(value.String()) + "^^<xs:int>", nil
+	}
+	return "", fmt.Errorf("unsupported value %v", value)
+}
+
+// isGeoJSON returns whether a json object is a geojson value, instead of a node
+func isGeoJSON(value map[string]interface{}) bool {
+	_, hasType := value["type"].(string)
+	_, hasCoordinates := value["coordinates"]
+	return hasType && hasCoordinates
+}
+
+var nquadEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+)
+
+func quoteNquad(value string) string {
+	return `"` + nquadEscaper.Replace(value) + `"`
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONToNquads(t *testing.T) {
+	setJSON := `[{
+		"uid": "0x1",
+		"name": "Wildan \"wildan\"\nMS",
+		"name@id": "Wildan",
+		"age": 17,
+		"score": 9.5,
+		"dead": false,
+		"tags": ["a", "b"],
+		"address": null,
+		"location": {"type": "Point", "coordinates": [106.8, -6.2]},
+		"friends": [{"uid": "_:friend", "name": "Dolan", "friends|close": true}]
+	}, {"name": "Other"}]`
+
+	nquads, err := jsonToNquads([]byte(setJSON))
+	require.NoError(t, err)
+	assert.Equal(t, `<0x1> <age> "17"^^<xs:int> .
+<0x1> <dead> "false"^^<xs:boolean> .
+_:friend <name> "Dolan" .
+<0x1> <friends> _:friend (close=true) .
+<0x1> <location> "{\"coordinates\":[106.8,-6.2],\"type\":\"Point\"}"^^<geo:geojson> .
+<0x1> <name> "Wildan \"wildan\"\nMS" .
+<0x1> <name> "Wildan"@id .
+<0x1> <score> "9.5"^^<xs:float> .
+<0x1> <tags> "a" .
+<0x1> <tags> "b" .
+_:nquad1 <name> "Other" .
+`, string(nquads))
+}
+
+func TestJSONToNquadsInvalid(t *testing.T) {
+	_, err := jsonToNquads([]byte(`"value"`))
+	assert.Error(t, err)
+}
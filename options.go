@@ -0,0 +1,161 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210"
+	"github.com/dgraph-io/dgo/v210/protos/api"
+)
+
+// Logger logs the requests sent by transactions, *log.Logger implements Logger
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// ClientOptions are the default options of transactions created from a client,
+// which can be overridden per call, e.g: Query.All with a depth parameter, or
+// passing upsert predicates to MutateOrGet and Upsert
+type ClientOptions struct {
+	// Depth is the default depth of expanded edges in queries without a query block
+	Depth int
+	// CommitNow commits mutations of non read-only transactions, as in TxnContext.SetCommitNow
+	CommitNow bool
+	// UpsertPredicates maps a node type to its default upsert predicate,
+	// used for node types without an upsert predicate passed on a mutation
+	UpsertPredicates map[string]string
+	// Logger logs the requests sent by transactions, nil disables logging
+	Logger Logger
+	// Verbose logs responses along with requests
+	Verbose bool
+	// PredicateAliases renames predicates in query results to the predicates of the struct fields
+	// by node type, when scanning results, e.g: for legacy data with different predicate names
+	PredicateAliases PredicateAliases
+	// StrictSchema returns a SchemaConflictError on CreateSchema and MutateSchema
+	// when models define a predicate with different schemas
+	StrictSchema bool
+	// Timeout is the default timeout of each request sent by transactions,
+	// applied when the context of the request has no deadline, e.g: from Query.Timeout
+	Timeout time.Duration
+}
+
+// SetOptions sets the default options for transactions created from the client,
+// passing nil resets the default options
+func (c *Client) SetOptions(opts *ClientOptions) *Client {
+	if opts == nil {
+		opts = &ClientOptions{}
+	}
+	c.dg.config.opts = opts
+	return c
+}
+
+// loggingTxn logs the requests sent by a transaction
+type loggingTxn struct {
+	transaction
+	logger  Logger
+	verbose bool
+}
+
+func (l *loggingTxn) unwrap() transaction {
+	return l.transaction
+}
+
+func (l *loggingTxn) logResponse(resp *api.Response, err error) {
+	if err != nil {
+		l.logger.Printf("dgman: error: %v", err)
+		return
+	}
+	if l.verbose {
+		l.logger.Printf("dgman: response: %s uids: %v", resp.Json, resp.Uids)
+	}
+}
+
+func (l *loggingTxn) Query(ctx context.Context, q string) (*api.Response, error) {
+	l.logger.Printf("dgman: query: %s", q)
+	resp, err := l.transaction.Query(ctx, q)
+	l.logResponse(resp, err)
+	return resp, err
+}
+
+func (l *loggingTxn) QueryWithVars(ctx context.Context, q string, vars map[string]string) (*api.Response, error) {
+	l.logger.Printf("dgman: query: %s vars: %v", q, vars)
+	resp, err := l.transaction.QueryWithVars(ctx, q, vars)
+	l.logResponse(resp, err)
+	return resp, err
+}
+
+func (l *loggingTxn) Mutate(ctx context.Context, mu *api.Mutation) (*api.Response, error) {
+	l.logger.Printf("dgman: mutation: set: %s delete: %s", mu.SetJson, mu.DeleteJson)
+	resp, err := l.transaction.Mutate(ctx, mu)
+	l.logResponse(resp, err)
+	return resp, err
+}
+
+func (l *loggingTxn) Do(ctx context.Context, req *api.Request) (*api.Response, error) {
+	l.logger.Printf("dgman: request: query: %s", req.Query)
+	for _, mu := range req.Mutations {
+		l.logger.Printf("dgman: request: cond: %s set: %s delete: %s set nquads: %s delete nquads: %s",
+			mu.Cond, mu.SetJson, mu.DeleteJson, mu.SetNquads, mu.DelNquads)
+	}
+	resp, err := l.transaction.Do(ctx, req)
+	l.logResponse(resp, err)
+	return resp, err
+}
+
+// unwrapOptions returns the transaction without the wrappers of the client options
+func unwrapOptions(txn transaction) transaction {
+	if logged, ok := txn.(*loggingTxn); ok {
+		txn = logged.transaction
+	}
+	if timed, ok := txn.(*timeoutTxn); ok {
+		txn = timed.transaction
+	}
+	return txn
+}
+
+// withOptions wraps a transaction to apply the default timeout, and to log requests when a logger is set
+func withOptions(txn transaction, opts *ClientOptions) transaction {
+	txn = unwrapOptions(txn)
+	if opts.Timeout > 0 {
+		txn = &timeoutTxn{transaction: txn, timeout: opts.Timeout}
+	}
+	if opts.Logger == nil {
+		return txn
+	}
+	return &loggingTxn{transaction: txn, logger: opts.Logger, verbose: opts.Verbose}
+}
+
+// wrappedTxn is a transaction wrapping another transaction, e.g: to log requests or apply a timeout
+type wrappedTxn interface {
+	unwrap() transaction
+}
+
+// unwrapTxn returns the dgo transaction of a transaction, unwrapping every wrapper,
+// nil when the transaction does not wrap a dgo transaction
+func unwrapTxn(txn transaction) *dgo.Txn {
+	for {
+		wrapped, ok := txn.(wrappedTxn)
+		if !ok {
+			break
+		}
+		txn = wrapped.unwrap()
+	}
+	dgoTxn, _ := txn.(*dgo.Txn)
+	return dgoTxn
+}
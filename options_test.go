@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type OptionsAccount struct {
+	UID      string   `json:"uid,omitempty"`
+	Username string   `json:"username,omitempty" dgraph:"index=hash unique"`
+	Email    string   `json:"email,omitempty" dgraph:"index=hash unique"`
+	DType    []string `json:"dgraph.type,omitempty"`
+}
+
+func TestClientOptionsDepth(t *testing.T) {
+	tx, _ := newFakeTxnContext()
+	tx.SetOptions(&ClientOptions{Depth: 2})
+
+	assert.Equal(t, NewQuery().Model(&OptionsAccount{}).All(2).String(), tx.Get(&OptionsAccount{}).String())
+	// per call override
+	assert.Equal(t, NewQuery().Model(&OptionsAccount{}).All(1).String(), tx.Get(&OptionsAccount{}).All(1).String())
+}
+
+func TestClientOptionsCommitNow(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+	tx.SetOptions(&ClientOptions{CommitNow: true})
+
+	_, err := tx.MutateBasic(&OptionsAccount{Username: "wildan"})
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+	assert.True(t, fake.requests[0].CommitNow)
+}
+
+// upsertUIDVar returns the uid variable of the unique query on a predicate
+func upsertUIDVar(t *testing.T, query, predicate string) string {
+	matches := regexp.MustCompile(`eq\(` + predicate + `,[^\n]*\{\s*(u_\d+_\d+) as uid`).FindStringSubmatch(query)
+	require.Len(t, matches, 2)
+	return matches[1]
+}
+
+func TestClientOptionsUpsertPredicates(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+	tx.SetOptions(&ClientOptions{UpsertPredicates: map[string]string{"OptionsAccount": "email"}})
+
+	_, err := tx.Upsert(&OptionsAccount{Username: "wildan", Email: "wildan@dolan.in"})
+	require.NoError(t, err)
+	require.NotEmpty(t, fake.requests)
+	req := fake.requests[0]
+	assert.Contains(t, string(req.Mutations[0].SetJson), "uid("+upsertUIDVar(t, req.Query, "email")+")")
+
+	// per call override
+	tx, fake = newFakeTxnContext()
+	tx.SetOptions(&ClientOptions{UpsertPredicates: map[string]string{"OptionsAccount": "email"}})
+
+	_, err = tx.Upsert(&OptionsAccount{Username: "wildan", Email: "wildan@dolan.in"}, "username")
+	require.NoError(t, err)
+	require.NotEmpty(t, fake.requests)
+	req = fake.requests[0]
+	assert.Contains(t, string(req.Mutations[0].SetJson), "uid("+upsertUIDVar(t, req.Query, "username")+")")
+}
+
+func TestClientOptionsLogger(t *testing.T) {
+	var buf bytes.Buffer
+	tx, _ := newFakeTxnContext()
+	tx.SetOptions(&ClientOptions{Logger: log.New(&buf, "", 0)})
+
+	var account OptionsAccount
+	_ = tx.Get(&account).UID("0x1").Node()
+	assert.Contains(t, buf.String(), "dgman: query: ")
+	assert.NotContains(t, buf.String(), "dgman: response: ")
+
+	buf.Reset()
+	tx.SetOptions(&ClientOptions{Logger: log.New(&buf, "", 0), Verbose: true})
+
+	_ = tx.Get(&account).UID("0x1").Node()
+	assert.Contains(t, buf.String(), "dgman: response: ")
+}
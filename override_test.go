@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type OverrideProduct struct {
+	UID        string   `json:"uid,omitempty"`
+	ExternalID string   `json:"externalId,omitempty" dgraph:"predicate=ext_id index=exact unique"`
+	SKU        string   `json:"sku,omitempty" dgraph:"index=exact unique"`
+	Name       string   `json:"name,omitempty" dgraph:"predicate=product_name"`
+	DType      []string `json:"dgraph.type,omitempty"`
+}
+
+func TestUpsertPredicateOverride(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	_, err := tx.Upsert(&OverrideProduct{ExternalID: "x1", SKU: "s1"})
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+	req := fake.requests[0]
+	assert.Contains(t, req.Query, `@filter(eq(ext_id, "x1") AND type(OverrideProduct))`)
+	assert.Regexp(t, `"uid":"uid\(u_\d+_1\)"`, string(req.Mutations[0].SetJson))
+	assert.Contains(t, string(req.Mutations[0].SetJson), `"ext_id":"x1"`)
+
+	// upsert predicates can be specified by the json field name, where the first specified predicate is used
+	for _, upsertPredicate := range []string{"ext_id", "externalId"} {
+		tx, fake = newFakeTxnContext()
+		_, err = tx.Upsert(&OverrideProduct{ExternalID: "x1", SKU: "s1"}, upsertPredicate, "sku")
+		require.NoError(t, err)
+		assert.Regexp(t, `"uid":"uid\(u_\d+_1\)"`, string(fake.requests[0].Mutations[0].SetJson))
+
+		tx, fake = newFakeTxnContext()
+		_, err = tx.Upsert(&OverrideProduct{ExternalID: "x1", SKU: "s1"}, "sku", upsertPredicate)
+		require.NoError(t, err)
+		assert.Regexp(t, `"uid":"uid\(u_\d+_2\)"`, string(fake.requests[0].Mutations[0].SetJson))
+	}
+}
+
+func TestMutateOrGetPredicateOverride(t *testing.T) {
+	tx, _ := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"q_p1_1":[{"uid":"0x5","ext_id":"x1","sku":"s0","product_name":"existing"}]}`),
+	})
+
+	product := OverrideProduct{UID: "_:p1", ExternalID: "x1", SKU: "s1"}
+	_, err := tx.MutateOrGet(&product)
+	require.NoError(t, err)
+	assert.Equal(t, "0x5", product.UID)
+	assert.Equal(t, "x1", product.ExternalID)
+	assert.Equal(t, "s0", product.SKU)
+	assert.Equal(t, "existing", product.Name)
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"reflect"
+	"sync"
+)
+
+// ParamFormatterFunc formats a query parameter value of a registered type
+type ParamFormatterFunc func(value interface{}) []byte
+
+var paramFormatters sync.Map
+
+// RegisterParamFormatter sets the query parameter formatter of a type, for types which cannot
+// implement ParamFormatter, e.g: third party uuid or decimal types. Passing nil removes the formatter.
+// Values implementing ParamFormatter use their own formatting.
+func RegisterParamFormatter(paramType reflect.Type, formatter ParamFormatterFunc) {
+	if formatter == nil {
+		paramFormatters.Delete(paramType)
+		return
+	}
+	paramFormatters.Store(paramType, formatter)
+}
+
+func getParamFormatter(paramType reflect.Type) ParamFormatterFunc {
+	formatter, ok := paramFormatters.Load(paramType)
+	if !ok {
+		return nil
+	}
+	return formatter.(ParamFormatterFunc)
+}
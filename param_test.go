@@ -0,0 +1,53 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ParamUUID [4]byte
+
+type ParamStatus int
+
+func TestRegisterParamFormatter(t *testing.T) {
+	RegisterParamFormatter(reflect.TypeOf(ParamUUID{}), func(value interface{}) []byte {
+		return []byte(fmt.Sprintf(`"%x"`, value.(ParamUUID)))
+	})
+	RegisterParamFormatter(reflect.TypeOf(ParamStatus(0)), func(value interface{}) []byte {
+		return []byte(fmt.Sprintf(`"status_%d"`, value.(ParamStatus)))
+	})
+	defer RegisterParamFormatter(reflect.TypeOf(ParamUUID{}), nil)
+	defer RegisterParamFormatter(reflect.TypeOf(ParamStatus(0)), nil)
+
+	query := NewQuery().Model(&TestModel{}).
+		Filter("eq(id, $1) AND eq(status, $2) AND eq(name, $3)", ParamUUID{0xde, 0xad, 0xbe, 0xef}, ParamStatus(2), "wildan")
+	assert.Contains(t, query.String(), `eq(id, "deadbeef") AND eq(status, "status_2") AND eq(name, "wildan")`)
+
+	filter, err := Eq("status", ParamStatus(1)).Build()
+	assert.NoError(t, err)
+	assert.Equal(t, `eq(status, "status_1")`, filter)
+
+	RegisterParamFormatter(reflect.TypeOf(ParamStatus(0)), nil)
+	filter, err = Eq("status", ParamStatus(1)).Build()
+	assert.NoError(t, err)
+	assert.Equal(t, `eq(status, 1)`, filter)
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	checkPasswordVar   = "$checkpwd"
+	checkPasswordAlias = "valid"
+)
+
+// PasswordCheck is the result of a query with CheckPassword
+type PasswordCheck struct {
+	UID   string `json:"uid"`
+	Valid bool   `json:"valid"`
+}
+
+// CheckPassword verifies a password predicate of the query nodes against a plaintext password,
+// returned as "valid", e.g: scanned into PasswordCheck. The password is passed as a query variable
+// instead of being formatted into the query. When no query is set, only the uid is queried.
+// Vars should be called before CheckPassword, as it adds the password to the query variables.
+func (q *Query) CheckPassword(predicate, password string) *Query {
+	if !aliasRegex.MatchString(predicate) {
+		q.err = fmt.Errorf("invalid password predicate %q", predicate)
+		return q
+	}
+	if q.query == "" {
+		q.query = "{\n\t\tuid\n\t}"
+	}
+	q.addVar(checkPasswordVar, "string", password)
+	q.computed = append(q.computed, checkPasswordAlias+": checkpwd("+predicate+", "+checkPasswordVar+")")
+	return q
+}
+
+// addVar adds a variable to the query variables and their function definition,
+// e.g: query($name: string)
+func (q *Query) addVar(name, varType, value string) {
+	vars := make(map[string]string, len(q.vars)+1)
+	for k, v := range q.vars {
+		vars[k] = v
+	}
+	vars[name] = value
+	q.vars = vars
+
+	definition := name + ": " + varType
+	start, end := strings.Index(q.paramString, "("), strings.LastIndex(q.paramString, ")")
+	switch {
+	case start < 0 || end < start:
+		q.paramString = "q(" + definition + ")"
+	case strings.TrimSpace(q.paramString[start+1:end]) == "":
+		q.paramString = q.paramString[:start+1] + definition + q.paramString[end:]
+	default:
+		q.paramString = q.paramString[:end] + ", " + definition + q.paramString[end:]
+	}
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPassword(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{Json: []byte(`{"data":[{"uid":"0x1","valid":true}]}`)})
+
+	var result PasswordCheck
+	err := tx.Get(&TestModel{}).
+		Filter("eq(name, $1)", "wildan").
+		CheckPassword("password", `secret") } evil { q(func: has(password`).
+		Node(&result)
+	require.NoError(t, err)
+	assert.Equal(t, PasswordCheck{UID: "0x1", Valid: true}, result)
+
+	require.Len(t, fake.requests, 1)
+	assert.Equal(t, map[string]string{"$checkpwd": `secret") } evil { q(func: has(password`}, fake.requests[0].Vars)
+	assert.Equal(t, `query q($checkpwd: string){
+	data(func: type(TestModel), first: 1) @filter(has(dgraph.type) AND eq(name, "wildan")) {
+		uid
+		valid: checkpwd(password, $checkpwd)
+	}
+}`, fake.requests[0].Query)
+}
+
+func TestCheckPasswordVars(t *testing.T) {
+	vars := map[string]string{"$name": "wildan"}
+	query := NewQuery().Model(&TestModel{}).
+		Filter("eq(name, $name)").
+		Vars("getUser($name: string)", vars).
+		CheckPassword("password", "secret")
+
+	assert.Equal(t, `query getUser($name: string, $checkpwd: string){
+	data(func: type(TestModel)) @filter(has(dgraph.type) AND eq(name, $name)) {
+		uid
+		valid: checkpwd(password, $checkpwd)
+	}
+}`, query.String())
+	assert.Equal(t, map[string]string{"$name": "wildan", "$checkpwd": "secret"}, query.vars)
+	assert.Len(t, vars, 1)
+}
+
+func TestCheckPasswordInvalidPredicate(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	err := tx.Get(&TestModel{}).CheckPassword("password, $1) } {", "secret").Node()
+	assert.Error(t, err)
+	assert.Empty(t, fake.requests)
+}
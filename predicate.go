@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+// Predicate is a typed predicate name, as generated by the dgman gen command,
+// which builds filters on the predicate, e.g: UserFields.Email.Eq("alice@example.com")
+type Predicate string
+
+func (p Predicate) String() string {
+	return string(p)
+}
+
+// Eq filters nodes with a predicate value equal to any of the values
+func (p Predicate) Eq(values ...interface{}) *Filter {
+	return Eq(string(p), values...)
+}
+
+// Le filters nodes with a predicate value less than or equal to the value
+func (p Predicate) Le(value interface{}) *Filter {
+	return Le(string(p), value)
+}
+
+// Lt filters nodes with a predicate value less than the value
+func (p Predicate) Lt(value interface{}) *Filter {
+	return Lt(string(p), value)
+}
+
+// Ge filters nodes with a predicate value greater than or equal to the value
+func (p Predicate) Ge(value interface{}) *Filter {
+	return Ge(string(p), value)
+}
+
+// Gt filters nodes with a predicate value greater than the value
+func (p Predicate) Gt(value interface{}) *Filter {
+	return Gt(string(p), value)
+}
+
+// Between filters nodes with a predicate value between lo and hi, inclusive
+func (p Predicate) Between(lo, hi interface{}) *Filter {
+	return Between(string(p), lo, hi)
+}
+
+// In filters nodes with a predicate value equal to any of the values, slice values are expanded
+func (p Predicate) In(values ...interface{}) *Filter {
+	return In(string(p), values...)
+}
+
+// AllOfTerms filters nodes with a predicate value containing all of the terms
+func (p Predicate) AllOfTerms(terms string) *Filter {
+	return AllOfTerms(string(p), terms)
+}
+
+// AnyOfTerms filters nodes with a predicate value containing any of the terms
+func (p Predicate) AnyOfTerms(terms string) *Filter {
+	return AnyOfTerms(string(p), terms)
+}
+
+// AllOfText filters nodes with a predicate value matching all of the text using full-text search
+func (p Predicate) AllOfText(text string) *Filter {
+	return AllOfText(string(p), text)
+}
+
+// AnyOfText filters nodes with a predicate value matching any of the text using full-text search
+func (p Predicate) AnyOfText(text string) *Filter {
+	return AnyOfText(string(p), text)
+}
+
+// Regexp filters nodes with a predicate value matching a regular expression
+func (p Predicate) Regexp(pattern string) *Filter {
+	return Regexp(string(p), pattern)
+}
+
+// UIDIn filters nodes with an edge to any of the uids
+func (p Predicate) UIDIn(uids interface{}) *Filter {
+	return UIDIn(string(p), uids)
+}
+
+// Has filters nodes which have a value for the predicate
+func (p Predicate) Has() *Filter {
+	return Has(string(p))
+}
+
+// NotHas filters nodes which do not have a value for the predicate
+func (p Predicate) NotHas() *Filter {
+	return NotHas(string(p))
+}
@@ -20,15 +20,16 @@ import (
 	"context"
 	stdjson "encoding/json"
 	"reflect"
+	"sort"
 
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dgraph-io/dgo/v210/protos/api"
 	"github.com/pkg/errors"
-
-	"github.com/dgraph-io/dgo/v210"
 )
 
 var (
@@ -43,7 +44,12 @@ type ParamFormatter interface {
 
 type QueryBlock struct {
 	ctx         context.Context
-	tx          *dgo.Txn
+	tx          transaction
+	guard       *QueryGuard
+	hooks       *Hooks
+	depth       int
+	aliases     PredicateAliases
+	queryTxn    queryTxnFunc
 	paramString string
 	vars        map[string]string
 	blocks      []*Query
@@ -78,10 +84,10 @@ func (q *QueryBlock) scan(result []byte, dst ...interface{}) error {
 		}
 		return nil
 	}
-	if err := json.Unmarshal(result, dst[0]); err != nil {
+	if err := unmarshalNodes(result, dst[0], q.aliases); err != nil {
 		return errors.Wrap(err, "unmarshal query result failed")
 	}
-	return nil
+	return compute(dst[0])
 }
 
 func (q *QueryBlock) scanModel(result []byte) error {
@@ -112,18 +118,23 @@ func (q *QueryBlock) scanModel(result []byte) error {
 			modelSliceRef := reflect.MakeSlice(reflect.SliceOf(reflect.PtrTo(modelType)), 1, 1)
 			modelSlice := reflect.New(modelSliceRef.Type())
 			modelSlice.Elem().Set(modelSliceRef)
-			if err := json.Unmarshal(blockResult, modelSlice.Interface()); err != nil {
+			if err := unmarshalNodes(blockResult, modelSlice.Interface(), q.aliases); err != nil {
 				return errors.Wrapf(err, "queryMap %s unmarshal failed", block.name)
 			}
-			if modelSlice.Elem().Len() > 0 {
-				// set the model value to the query result value
-				reflect.ValueOf(block.model).Elem().Set(modelSlice.Elem().Index(0).Elem())
+			if modelSlice.Elem().Len() == 0 {
+				continue
 			}
+			// set the model value to the query result value
+			reflect.ValueOf(block.model).Elem().Set(modelSlice.Elem().Index(0).Elem())
 		case reflect.Slice:
-			if err := json.Unmarshal(blockResult, block.model); err != nil {
+			if err := unmarshalNodes(blockResult, block.model, q.aliases); err != nil {
 				return errors.Wrapf(err, "queryMap %s unmarshal failed", block.name)
 			}
 		}
+
+		if err := compute(block.model); err != nil {
+			return errors.Wrapf(err, "queryMap %s compute failed", block.name)
+		}
 	}
 	return nil
 }
@@ -156,25 +167,40 @@ func (q *QueryBlock) String() string {
 
 	queryBuf.WriteString("}")
 
-	return queryBuf.String()
+	return FormatQuery(queryBuf.String())
 }
 
 func (q *QueryBlock) executeQuery() (result []byte, err error) {
-	queryString := q.String()
-
-	var resp *api.Response
-	if q.vars != nil {
-		resp, err = q.tx.QueryWithVars(q.ctx, queryString, q.vars)
-	} else {
-		resp, err = q.tx.Query(q.ctx, queryString)
-	}
+	resp, err := q.execute()
 	if err != nil {
 		return nil, err
 	}
-
 	return resp.Json, nil
 }
 
+func (q *QueryBlock) execute() (resp *api.Response, err error) {
+	for _, block := range q.blocks {
+		if block.err != nil {
+			return nil, block.err
+		}
+		if block.depth == 0 {
+			block.depth = q.depth
+		}
+		if err := q.guard.Check(block); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := withTimeout(q.ctx, q.timeout())
+	defer cancel()
+	return sendQuery(q.withMetricsTags(ctx), q.sendTxn(), q.hooks, q.String(), q.vars)
+}
+
+type recurse struct {
+	depth int
+	loop  bool
+}
+
 type order struct {
 	descending bool
 	clause     string
@@ -182,7 +208,11 @@ type order struct {
 
 type Query struct {
 	ctx         context.Context
-	tx          *dgo.Txn
+	tx          transaction
+	guard       *QueryGuard
+	hooks       *Hooks
+	depth       int
+	aliases     PredicateAliases
 	model       interface{}
 	name        string
 	as          string
@@ -196,9 +226,23 @@ type Query struct {
 	order       []order
 	groupBy     string
 	cascade     []string
+	recurse     *recurse
 	uid         string
 	filter      string
 	query       string
+	withDeleted bool
+	untyped     bool
+	edges       map[string]*edgeQuery
+	depths      map[string]int
+	rawParams   map[queryPart]rawParams
+	computed    []string
+	aliasFields []aliasedField
+	normalize   bool
+	timeout     time.Duration
+	readOnly    bool
+	bestEffort  bool
+	queryTxn    queryTxnFunc
+	err         error
 }
 
 type PagedResults struct {
@@ -229,6 +273,14 @@ func (q *Query) As(varName string) *Query {
 	return q
 }
 
+// AsVar defines a query variable name like As, returning a reference to the variable
+// which can be passed as a query parameter to other query blocks
+func (q *Query) AsVar(varName string) QueryVar {
+	v := QueryVar(varName)
+	q.as = v.Name()
+	return v
+}
+
 // Var defines whether a query block is a var, which are not returned in query results
 func (q *Query) Var() *Query {
 	q.isVar = true
@@ -238,12 +290,29 @@ func (q *Query) Var() *Query {
 // Query defines the query portion other than the root function
 func (q *Query) Query(query string, params ...interface{}) *Query {
 	q.query = parseQueryWithParams(query, params)
+	q.checkParams(queryPartQuery, query, params, q.query)
 	return q
 }
 
 // Filter defines a query filter, return predicates at the first depth
 func (q *Query) Filter(filter string, params ...interface{}) *Query {
 	q.filter = parseQueryWithParams(filter, params)
+	q.checkParams(queryPartFilter, filter, params, q.filter)
+	return q
+}
+
+// Where defines a query filter using a filter expression, which is validated against
+// the model schema tags when a model is defined, returning any error on query execution
+func (q *Query) Where(filter *Filter) *Query {
+	if q.model != nil {
+		if err := filter.Validate(q.model); err != nil {
+			q.err = err
+			return q
+		}
+	}
+
+	q.filter, q.err = filter.Build()
+	delete(q.rawParams, queryPartFilter)
 	return q
 }
 
@@ -272,28 +341,61 @@ func expandPredicate(buffer *strings.Builder, depth int) {
 	}
 }
 
-func expandAll(depth int) string {
+func expandAll(depth int, predicates ...string) string {
 	var buffer strings.Builder
 
 	buffer.WriteString("{\n\t\tuid\n\t\tdgraph.type\n\t\texpand(_all_)")
 	expandPredicate(&buffer, depth)
+	// predicates not expanded by expand(_all_), e.g: language tagged predicates
+	for _, predicate := range predicates {
+		buffer.WriteString("\n\t\t")
+		buffer.WriteString(predicate)
+	}
 	buffer.WriteString("\n\t}")
 
 	return buffer.String()
 }
 
 // All returns expands all predicates, with a depth parameter that specifies
-// how deep should edges be expanded
+// how deep should edges be expanded, defaults to the depth of the client options.
+// Language tagged predicates of the model, e.g: name@en, are included at the first depth.
 func (q *Query) All(depthParam ...int) *Query {
-	depth := 0
+	depth := q.depth
 	if len(depthParam) > 0 {
 		depth = depthParam[0]
 	}
 
-	q.query = expandAll(depth)
+	if len(q.edges) > 0 || len(q.depths) > 0 {
+		var buffer strings.Builder
+		q.expandEdges(&buffer, getElemType(reflect.TypeOf(q.model)), depth, "")
+		q.query = buffer.String()
+		return q
+	}
+
+	q.query = expandAll(depth, langPredicates(q.model)...)
 	return q
 }
 
+// AllDepth expands all predicates as in All, with depth overrides of edges of the model,
+// e.g: q.AllDepth(1, dgman.Depth("friends", 3)) expands only the friends edge deeper.
+func (q *Query) AllDepth(depth int, edgeDepths ...EdgeDepth) *Query {
+	if len(edgeDepths) > 0 && q.model == nil {
+		q.err = errors.New("edge depths require a model")
+		return q
+	}
+	for _, edgeDepth := range edgeDepths {
+		if _, err := resolveEdgePath(reflect.TypeOf(q.model), edgeDepth.Path); err != nil {
+			q.err = err
+			return q
+		}
+		if q.depths == nil {
+			q.depths = make(map[string]int)
+		}
+		q.depths[edgeDepth.Path] = edgeDepth.Depth
+	}
+	return q.All(depth)
+}
+
 // Vars specify the GraphQL variables to be passed on the query,
 // by specifying the function definition of vars, and variable map.
 // Example funcDef: getUserByEmail($email: string)
@@ -304,9 +406,36 @@ func (q *Query) Vars(funcDef string, vars map[string]string) *Query {
 }
 
 // RootFunc modifies the dgraph query root function, if not set,
-// the default is "type(NodeType)"
-func (q *Query) RootFunc(rootFunc string) *Query {
+// the default is "type(NodeType)", with optional query parameters.
+// Literal values in Query, Filter, and RootFunc are rejected by a query guard with StrictParams.
+func (q *Query) RootFunc(rootFunc string, params ...interface{}) *Query {
+	q.rootFunc = parseQueryWithParams(rootFunc, params)
+	q.checkParams(queryPartRootFunc, rootFunc, params, q.rootFunc)
+	return q
+}
+
+// Root sets the root function from a filter function, e.g: dgman.Eq("email", email) or dgman.SimilarTo,
+// with the values escaped as query parameters. The function is validated against the model schema tags
+// as in Where, combined and negated filters cannot be root functions.
+func (q *Query) Root(function *Filter) *Query {
+	if function.operator != "" || function.negate {
+		q.err = fmt.Errorf("root function must be a single filter function, got %s", function)
+		return q
+	}
+	if q.model != nil {
+		if err := function.Validate(q.model); err != nil {
+			q.err = err
+			return q
+		}
+	}
+
+	rootFunc, err := function.Build()
+	if err != nil {
+		q.err = err
+		return q
+	}
 	q.rootFunc = rootFunc
+	delete(q.rawParams, queryPartRootFunc)
 	return q
 }
 
@@ -340,7 +469,18 @@ func (q *Query) OrderDesc(clause string) *Query {
 	return q
 }
 
-// GroupBy defines the predicate to group the query by
+// OrderAscVal adds an ascending order clause by a value variable, e.g: orderasc: val(score)
+func (q *Query) OrderAscVal(v ValueVar) *Query {
+	return q.OrderAsc(v.String())
+}
+
+// OrderDescVal adds an descending order clause by a value variable, e.g: orderdesc: val(score)
+func (q *Query) OrderDescVal(v ValueVar) *Query {
+	return q.OrderDesc(v.String())
+}
+
+// GroupBy defines the predicate to group the query by,
+// the groups are returned by GroupByResult and Groups
 func (q *Query) GroupBy(predicate string) *Query {
 	q.groupBy = predicate
 	return q
@@ -356,6 +496,47 @@ func (q *Query) Cascade(predicates ...string) *Query {
 	return q
 }
 
+// WithDeleted includes soft deleted nodes in the query results
+func (q *Query) WithDeleted() *Query {
+	q.withDeleted = true
+	return q
+}
+
+// WithoutTypeFilter removes the has(dgraph.type) filter added to the root of queries,
+// e.g: to query untyped legacy data with a custom root function
+func (q *Query) WithoutTypeFilter() *Query {
+	q.untyped = true
+	return q
+}
+
+// Recurse adds the recurse directive to traverse edges recursively until the depth,
+// or until no new edges are found when depth is 0, with loop allowing revisiting nodes.
+// If no query is defined, the query is a flat list of the predicates of the model and its edges.
+func (q *Query) Recurse(depth int, loop bool) *Query {
+	q.recurse = &recurse{depth: depth, loop: loop}
+	return q
+}
+
+// recursePredicates generates a flat query of the predicates of the model
+// and its edges, as recurse queries apply the query on each level
+func (q *Query) recursePredicates() string {
+	predicates := []string{predicateUid, predicateDgraphType}
+	if q.model != nil {
+		if _, err := reflectType(q.model); err == nil {
+			typeSchema := NewTypeSchema()
+			typeSchema.Marshal("", q.model)
+
+			schemaPredicates := make([]string, 0, len(typeSchema.Schema))
+			for predicate := range typeSchema.Schema {
+				schemaPredicates = append(schemaPredicates, predicate)
+			}
+			sort.Strings(schemaPredicates)
+			predicates = append(predicates, schemaPredicates...)
+		}
+	}
+	return fmt.Sprintf("{\n\t\t%s\n\t}", strings.Join(predicates, "\n\t\t"))
+}
+
 // Node returns the first single node from the query,
 // optional destination can be passed, otherwise bind to model
 func (q *Query) Node(dst ...interface{}) (err error) {
@@ -391,7 +572,10 @@ func (q *Query) node(jsonData []byte, dst interface{}) error {
 		return ErrNodeNotFound
 	}
 
-	return json.Unmarshal(dataBytes, dst)
+	if err := unmarshalNodes(dataBytes, dst, q.aliases); err != nil {
+		return err
+	}
+	return compute(dst)
 }
 
 // Nodes returns all results from the query,
@@ -419,17 +603,37 @@ func (q *Query) nodes(jsonData []byte, dst interface{}) error {
 
 	dataBytes := jsonData[dataPrefixLen : dataLen-1]
 
-	return json.Unmarshal(dataBytes, dst)
+	if err := unmarshalNodes(dataBytes, dst, q.aliases); err != nil {
+		return err
+	}
+	return compute(dst)
 }
 
 // NodesAndCount return paged nodes result with the total count of the query,
 // optional destination can be passed, otherwise bind to model.
 func (q *Query) NodesAndCount(dst ...interface{}) (count int, err error) {
-	tx := TxnContext{txn: q.tx, ctx: q.ctx}
 	model := q.model
 	if len(dst) > 0 {
 		model = dst[0]
 	}
+
+	result, err := q.nodesAndCount(model)
+	if err != nil {
+		return 0, err
+	}
+	return result.Count, nil
+}
+
+// nodesAndCount executes a query block of the paged query with the total count
+func (q *Query) nodesAndCount(model interface{}) (*Result, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	if err := q.guard.Check(q); err != nil {
+		return nil, err
+	}
+
+	tx := TxnContext{txn: q.sendTxn(), ctx: q.ctx, hooks: q.hooks, opts: &ClientOptions{Depth: q.depth}}
 	var qr string
 	// only apply the query if the result will be cascaded
 	if q.cascade != nil {
@@ -439,23 +643,36 @@ func (q *Query) NodesAndCount(dst ...interface{}) (count int, err error) {
 	pagedResult := PagedResults{}
 	query := tx.Query(
 		&Query{
-			as:       "filtered",
-			isVar:    true,
-			uid:      q.uid,
-			rootFunc: q.rootFunc,
-			model:    q.model,
-			filter:   q.filter,
-			query:    qr,
-			cascade:  q.cascade,
+			as:          "filtered",
+			isVar:       true,
+			uid:         q.uid,
+			rootFunc:    q.rootFunc,
+			model:       q.model,
+			filter:      q.filter,
+			query:       qr,
+			cascade:     q.cascade,
+			withDeleted: q.withDeleted,
+			untyped:     q.untyped,
 		},
 		&Query{
-			name:   "result",
-			uid:    "filtered",
-			first:  q.first,
-			after:  q.after,
-			offset: q.offset,
-			order:  q.order,
-			query:  q.query,
+			name:    "result",
+			uid:     "filtered",
+			first:   q.first,
+			after:   q.after,
+			offset:  q.offset,
+			order:   q.order,
+			recurse: q.recurse,
+			// cascade the result like the filtered block, so nested edges are pruned consistently
+			cascade:     q.cascade,
+			query:       q.query,
+			model:       q.model,
+			edges:       q.edges,
+			computed:    q.computed,
+			aliasFields: q.aliasFields,
+			normalize:   q.normalize,
+			withDeleted: q.withDeleted,
+			untyped:     q.untyped,
+			timeout:     q.timeout,
 		},
 		&Query{
 			name:  "pageInfo",
@@ -464,20 +681,29 @@ func (q *Query) NodesAndCount(dst ...interface{}) (count int, err error) {
 		},
 	).Vars(q.paramString, q.vars)
 
-	err = query.Scan(&pagedResult)
+	resp, err := query.execute()
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	result := newResult(model, query.String(), resp)
+	if err = query.scan(resp.Json, &pagedResult); err != nil {
+		return nil, errors.Wrap(err, "scan failed")
 	}
 
 	if pagedResult.Result == nil {
-		return 0, nil
+		return result, nil
 	}
 
-	if err := json.Unmarshal(pagedResult.Result, model); err != nil {
-		return 0, err
+	if err := unmarshalNodes(pagedResult.Result, model, q.aliases); err != nil {
+		return nil, err
+	}
+	if err := compute(model); err != nil {
+		return nil, err
 	}
 
-	return pagedResult.PageInfo[0].Count, nil
+	result.Count = pagedResult.PageInfo[0].Count
+	return result, nil
 }
 
 func isUID(str string) bool {
@@ -548,16 +774,19 @@ func (q *Query) generateQuery(queryBuf *strings.Builder) {
 	// END ROOT FUNCTION
 
 	// make sure deleted nodes are not returned
-	typeIsNotNull := "has(dgraph.type)"
+	var filters []string
+	if !q.untyped {
+		filters = append(filters, "has(dgraph.type)")
+	}
+	if predicate := modelSoftDeletePredicate(q.model); predicate != "" && !q.withDeleted {
+		filters = append(filters, "NOT has("+predicate+")")
+	}
 	if q.filter != "" {
+		filters = append(filters, q.filter)
+	}
+	if len(filters) > 0 {
 		queryBuf.WriteString("@filter(")
-		queryBuf.WriteString(typeIsNotNull)
-		queryBuf.WriteString(" AND ")
-		queryBuf.WriteString(q.filter)
-		queryBuf.WriteString(") ")
-	} else {
-		queryBuf.WriteString("@filter(")
-		queryBuf.WriteString(typeIsNotNull)
+		queryBuf.WriteString(strings.Join(filters, " AND "))
 		queryBuf.WriteString(") ")
 	}
 
@@ -567,6 +796,22 @@ func (q *Query) generateQuery(queryBuf *strings.Builder) {
 		queryBuf.WriteString(") ")
 	}
 
+	if q.recurse != nil {
+		queryBuf.WriteString("@recurse(")
+		if q.recurse.depth > 0 {
+			queryBuf.WriteString("depth: ")
+			queryBuf.Write(intToBytes(q.recurse.depth))
+			queryBuf.WriteString(", ")
+		}
+		queryBuf.WriteString("loop: ")
+		queryBuf.WriteString(strconv.FormatBool(q.recurse.loop))
+		queryBuf.WriteString(") ")
+	}
+
+	if q.normalize {
+		queryBuf.WriteString("@normalize ")
+	}
+
 	if q.cascade != nil {
 		queryBuf.WriteString("@cascade")
 		if len(q.cascade) > 0 {
@@ -579,12 +824,19 @@ func (q *Query) generateQuery(queryBuf *strings.Builder) {
 
 	// allow var to have empty query block
 	if !q.isVar {
-		if q.query == "" {
+		if q.query == "" && q.recurse != nil {
+			q.query = q.recursePredicates()
+		} else if q.query == "" && q.groupBy != "" {
+			// groups only return aggregate values
+			q.query = "{\n\t\tcount(uid)\n\t}"
+		} else if q.query == "" && len(q.aliasFields) > 0 {
+			q.query = q.aliasedPredicates()
+		} else if q.query == "" {
 			q.All()
 		}
 	}
 
-	queryBuf.WriteString(q.query)
+	queryBuf.WriteString(q.withComputed(q.query))
 	queryBuf.WriteString("\n")
 }
 
@@ -601,23 +853,33 @@ func (q *Query) String() string {
 
 	queryBuf.WriteString("}")
 
-	return queryBuf.String()
+	return FormatQuery(queryBuf.String())
 }
 
 func (q *Query) executeQuery() (result []byte, err error) {
-	queryString := q.String()
+	resp, err := q.execute()
+	if err != nil {
+		return nil, err
+	}
+	return resp.Json, nil
+}
 
-	var resp *api.Response
-	if q.vars != nil {
-		resp, err = q.tx.QueryWithVars(q.ctx, queryString, q.vars)
-	} else {
-		resp, err = q.tx.Query(q.ctx, queryString)
+func (q *Query) execute() (resp *api.Response, err error) {
+	if q.err != nil {
+		return nil, q.err
 	}
-	if err != nil {
+	if err := q.guard.Check(q); err != nil {
 		return nil, err
 	}
 
-	return resp.Json, nil
+	return q.send(q.String())
+}
+
+// send sends a query string with the query vars
+func (q *Query) send(queryString string) (*api.Response, error) {
+	ctx, cancel := withTimeout(q.ctx, q.timeout)
+	defer cancel()
+	return sendQuery(withMetricsTags(ctx, q.name, q.model), q.sendTxn(), q.hooks, queryString, q.vars)
 }
 
 // NewQueryBlock returns a new empty query block
@@ -632,6 +894,22 @@ func NewQuery() *Query {
 	}
 }
 
+func formatParam(param interface{}) ([]byte, error) {
+	switch param := param.(type) {
+	case ParamFormatter:
+		return param.FormatParams(), nil
+	case *big.Float:
+		// big.Float marshals into a quoted string
+		return formatBigFloat(param), nil
+	case regexpPattern:
+		return param.format()
+	}
+	if formatter := getParamFormatter(reflect.TypeOf(param)); formatter != nil {
+		return formatter(param), nil
+	}
+	return json.Marshal(param)
+}
+
 func parseQueryWithParams(query string, params []interface{}) string {
 	var buffer strings.Builder
 	queryLength := len(query)
@@ -646,7 +924,7 @@ func parseQueryWithParams(query string, params []interface{}) string {
 			}
 
 			var numStr []byte
-			for ; query[pos] >= '0' && query[pos] <= '9'; pos++ {
+			for ; pos < queryLength && query[pos] >= '0' && query[pos] <= '9'; pos++ {
 				numStr = append(numStr, query[pos])
 			}
 
@@ -666,21 +944,18 @@ func parseQueryWithParams(query string, params []interface{}) string {
 				goto write
 			}
 
-			var paramString []byte
-			param := params[paramIndex-1]
-			if formatter, ok := param.(ParamFormatter); ok {
-				paramString = formatter.FormatParams()
-			} else {
-				paramString, err = json.Marshal(param)
-				if err != nil {
-					goto write
-				}
+			paramString, err := formatParam(params[paramIndex-1])
+			if err != nil {
+				goto write
 			}
 
 			buffer.Write(paramString)
 		}
 	write:
-		buffer.WriteByte(query[pos])
+		// params may end the query
+		if pos < queryLength {
+			buffer.WriteByte(query[pos])
+		}
 	}
 	return buffer.String()
 }
@@ -20,9 +20,12 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/dgraph-io/dgo/v210/protos/api"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type TestModel struct {
@@ -686,6 +689,14 @@ func Test_parseQueryWithParams(t *testing.T) {
 			},
 			want: `{ valid: checkpwd(password, ) }`,
 		},
+		{
+			name: "should parse a param at the end of the query",
+			args: args{
+				query:  "a + b * $1",
+				params: []interface{}{2},
+			},
+			want: `a + b * 2`,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -693,3 +704,63 @@ func Test_parseQueryWithParams(t *testing.T) {
 		})
 	}
 }
+
+func TestQueryRecurse(t *testing.T) {
+	query := NewQuery().
+		Model(&TestModel{}).
+		UID("0x1").
+		Recurse(3, false)
+
+	assert.Equal(t, `{
+	data(func: uid(0x1)) @filter(has(dgraph.type)) @recurse(depth: 3, loop: false) {
+		uid
+		dgraph.type
+		address
+		age
+		dead
+		edges
+		level
+		name
+	}
+}`, query.String())
+
+	query = NewQuery().
+		Model(&TestModel{}).
+		UID("0x1").
+		Recurse(0, true).
+		Query(`{ name edges }`)
+
+	assert.Equal(t, `{
+	data(func: uid(0x1)) @filter(has(dgraph.type)) @recurse(loop: true) { name edges }
+}`, query.String())
+}
+
+func TestNodesAndCountNestedFilters(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"result":[{"uid":"0x1","name":"wildan"}],"pageInfo":[{"count":1}]}`),
+	})
+
+	var result []*TestModel
+	count, err := tx.Get(&result).
+		Filter(`anyofterms(name, "wildan")`).
+		First(3).
+		Query(`{
+			uid
+			name
+			~edges @facets(eq(since, "2021")) @filter(eq(level, "1")) {
+				uid
+			}
+		}`).
+		Cascade("~edges").
+		NodesAndCount()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Len(t, result, 1)
+
+	require.Len(t, fake.requests, 1)
+	query := fake.requests[0].Query
+	nested := `~edges @facets(eq(since, "2021")) @filter(eq(level, "1"))`
+	// the nested constraints apply to both the counted and the returned nodes
+	assert.Equal(t, 2, strings.Count(query, nested))
+	assert.Equal(t, 2, strings.Count(query, "@cascade(~edges)"))
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"regexp"
+)
+
+var (
+	varCleanerRegex = regexp.MustCompile(`[^\w.]+`)
+
+	_ ParamFormatter = (*QueryVar)(nil)
+	_ ParamFormatter = (*ValueVar)(nil)
+)
+
+// QueryVar is a reference to a query variable, which allows passing
+// uid variables as query parameters to other query blocks, formatted as uid(var)
+type QueryVar string
+
+// Name returns the variable name
+func (v QueryVar) Name() string {
+	return varCleanerRegex.ReplaceAllString(string(v), "")
+}
+
+// Val returns a reference to the value of the variable
+func (v QueryVar) Val() ValueVar {
+	return ValueVar(v)
+}
+
+func (v QueryVar) String() string {
+	return "uid(" + v.Name() + ")"
+}
+
+// FormatParams implements the ParamFormatter interface
+func (v QueryVar) FormatParams() []byte {
+	return []byte(v.String())
+}
+
+// ValueVar is a reference to a value variable, which allows passing
+// value variables as query parameters to other query blocks, formatted as val(var)
+type ValueVar string
+
+// Name returns the variable name
+func (v ValueVar) Name() string {
+	return QueryVar(v).Name()
+}
+
+func (v ValueVar) String() string {
+	return "val(" + v.Name() + ")"
+}
+
+// FormatParams implements the ParamFormatter interface
+func (v ValueVar) FormatParams() []byte {
+	return []byte(v.String())
+}
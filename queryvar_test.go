@@ -0,0 +1,74 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryVar_FormatParams(t *testing.T) {
+	tests := []struct {
+		name string
+		v    ParamFormatter
+		want []byte
+	}{
+		{
+			name: "should format uid variable",
+			v:    QueryVar("friends"),
+			want: []byte("uid(friends)"),
+		},
+		{
+			name: "should format value variable",
+			v:    QueryVar("score").Val(),
+			want: []byte("val(score)"),
+		},
+		{
+			name: "should remove all unknown characters",
+			v:    QueryVar("friends), has(password"),
+			want: []byte("uid(friendshaspassword)"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.v.FormatParams(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FormatParams() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryVarBlocks(t *testing.T) {
+	schoolQuery := NewQuery().Model(&[]TestEdge{}).Var().Filter("eq(level, $1)", "high")
+	schools := schoolQuery.AsVar("schools")
+
+	query := NewQueryBlock(
+		schoolQuery,
+		NewQuery().
+			Model(&[]TestModel{}).
+			RootFunc("uid_in(edges, $1)", schools).
+			Where(UIDIn("edges", schools)).
+			Query(`{ name }`),
+	)
+
+	assert.Equal(t, `{
+	schools as var(func: type(TestEdge)) @filter(has(dgraph.type) AND eq(level, "high"))
+	data(func: uid_in(edges, uid(schools))) @filter(has(dgraph.type) AND uid_in(edges, uid(schools))) { name }
+}`, query.String())
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+// queryTxnFunc returns the transaction to send a query with the read mode of the query
+type queryTxnFunc func(readOnly, bestEffort bool) transaction
+
+// ReadOnly sends the query in a separate read only transaction, when the transaction of the query is not read only,
+// so the query does not add to the conflict keys of the transaction, without seeing its uncommitted mutations.
+// On a query block, the block is sent read only when any of its queries is read only.
+func (q *Query) ReadOnly() *Query {
+	q.readOnly = true
+	return q
+}
+
+// BestEffort sends the query in a separate read only best effort transaction, when the transaction of
+// the query is not best effort, for cheap reads which may not see the latest committed data.
+// On a query block, the block is sent best effort when any of its queries is best effort.
+func (q *Query) BestEffort() *Query {
+	q.bestEffort = true
+	return q
+}
+
+// sendTxn returns the transaction to send the query with
+func (q *Query) sendTxn() transaction {
+	if q.queryTxn == nil {
+		return q.tx
+	}
+	return q.queryTxn(q.readOnly, q.bestEffort)
+}
+
+// sendTxn returns the transaction to send the query block with, using the strongest read mode of the queries
+func (q *QueryBlock) sendTxn() transaction {
+	if q.queryTxn == nil {
+		return q.tx
+	}
+	var readOnly, bestEffort bool
+	for _, block := range q.blocks {
+		readOnly = readOnly || block.readOnly
+		bestEffort = bestEffort || block.bestEffort
+	}
+	return q.queryTxn(readOnly, bestEffort)
+}
+
+// queryTxn returns the transaction to send queries with a read mode, a new read only
+// transaction of the client when the read mode is not satisfied by the transaction
+func (t *TxnContext) queryTxn(readOnly, bestEffort bool) transaction {
+	if t.client == nil || (!readOnly && !bestEffort) || (t.readOnly && (t.bestEffort || !bestEffort)) {
+		return t.txn
+	}
+	txn := newTransaction(t.client, true, t.config, t.cache, t.opts)
+	if dgoTxn := unwrapTxn(txn); bestEffort && dgoTxn != nil {
+		dgoTxn.BestEffort()
+	}
+	return txn
+}
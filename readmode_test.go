@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210"
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// queryClient records query requests, other DgraphClient methods are not implemented
+type queryClient struct {
+	api.DgraphClient
+	requests []*api.Request
+}
+
+func (q *queryClient) Query(ctx context.Context, in *api.Request, opts ...grpc.CallOption) (*api.Response, error) {
+	q.requests = append(q.requests, in)
+	result := `{"data":[]}`
+	if strings.Contains(in.Query, "pageInfo") {
+		result = `{"result":[],"pageInfo":[{"count":0}]}`
+	}
+	return &api.Response{Json: []byte(result), Txn: &api.TxnContext{StartTs: 1}}, nil
+}
+
+func TestQueryReadMode(t *testing.T) {
+	dc := &queryClient{}
+	c := dgo.NewDgraphClient(dc)
+
+	tests := []struct {
+		name       string
+		tx         *TxnContext
+		query      func(q *Query) *Query
+		readOnly   bool
+		bestEffort bool
+	}{
+		{"default", NewTxn(c), func(q *Query) *Query { return q }, false, false},
+		{"read only", NewTxn(c), (*Query).ReadOnly, true, false},
+		{"best effort", NewTxn(c), (*Query).BestEffort, true, true},
+		{"read only txn", NewReadOnlyTxn(c), func(q *Query) *Query { return q }, true, false},
+		{"read only txn best effort", NewReadOnlyTxn(c), (*Query).BestEffort, true, true},
+		{"best effort txn", NewReadOnlyTxn(c).BestEffort(), (*Query).ReadOnly, true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dc.requests = nil
+			var models []TestModel
+			require.NoError(t, test.query(test.tx.Get(&models)).Nodes())
+			require.NoError(t, test.tx.Query(test.query(NewQuery().Model(&models))).Scan())
+			_, err := test.query(test.tx.Get(&models)).First(10).NodesAndCount()
+			require.NoError(t, err)
+
+			require.Len(t, dc.requests, 3)
+			for _, req := range dc.requests {
+				assert.Equal(t, test.readOnly, req.ReadOnly)
+				assert.Equal(t, test.bestEffort, req.BestEffort)
+			}
+		})
+	}
+}
+
+func TestQueryReadModeSeparateTxn(t *testing.T) {
+	dc := &queryClient{}
+	tx := NewTxn(dgo.NewDgraphClient(dc))
+
+	var models []TestModel
+	require.NoError(t, tx.Get(&models).BestEffort().Nodes())
+	require.NoError(t, tx.Get(&models).Nodes())
+	require.Len(t, dc.requests, 2)
+	// the start ts of the best effort query is not merged into the transaction
+	assert.Equal(t, uint64(0), dc.requests[1].StartTs)
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Recommendation builds a "friends of friends" recommendation query, which returns
+// the top nodes reachable within 2 hops of a predicate that are not already
+// connected to the source node, ranked by score.
+//
+// By default the score is the number of mutual connections, which can be changed
+// to a facet value of the second hop edges with RankByFacet.
+// The score is returned in the "score" field of each result node.
+type Recommendation struct {
+	txn       *TxnContext
+	model     interface{}
+	name      string
+	uid       string
+	predicate string
+	facet     string
+	first     int
+}
+
+// Name defines the query block name, which also prefixes the query variables,
+// allowing multiple recommendations in a single query block
+func (r *Recommendation) Name(name string) *Recommendation {
+	r.name = name
+	return r
+}
+
+// First returns the top n recommendations
+func (r *Recommendation) First(n int) *Recommendation {
+	r.first = n
+	return r
+}
+
+// RankByFacet ranks recommendations by a facet value of the second hop edges, instead of the number of mutual connections
+func (r *Recommendation) RankByFacet(facet string) *Recommendation {
+	r.facet = facet
+	return r
+}
+
+func (r *Recommendation) varName(name string) string {
+	return fmt.Sprintf("%s_%s", r.name, name)
+}
+
+// Queries returns the query blocks of the recommendation,
+// to be composed with other queries in a QueryBlock
+func (r *Recommendation) Queries() []*Query {
+	source, connected, candidates, score := r.varName("source"), r.varName("connected"),
+		r.varName("candidates"), r.varName("score")
+
+	queries := []*Query{
+		{
+			isVar: true,
+			uid:   string(UID(r.uid).FormatParams()),
+			query: fmt.Sprintf("{\n\t\t%s as uid\n\t\t%s as %s\n\t}", source, connected, r.predicate),
+		},
+	}
+	if r.facet != "" {
+		queries = append(queries, &Query{
+			isVar: true,
+			uid:   connected,
+			query: fmt.Sprintf("{\n\t\t%s as %s @filter(NOT uid(%s, %s)) @facets(%s as %s)\n\t}",
+				candidates, r.predicate, source, connected, score, r.facet),
+		})
+	} else {
+		// the path count variable propagates to the candidates, summing
+		// the number of paths from the source node, i.e: mutual connections
+		paths := r.varName("paths")
+		queries = append(queries, &Query{
+			isVar: true,
+			uid:   source,
+			query: fmt.Sprintf("{\n\t\t%s as math(1)\n\t\t%s {\n\t\t\t%s as %s @filter(NOT uid(%s, %s)) {\n\t\t\t\t%s as math(%s)\n\t\t\t}\n\t\t}\n\t}",
+				paths, r.predicate, candidates, r.predicate, source, connected, score, paths),
+		})
+	}
+
+	result := &Query{model: r.model}
+	if r.txn != nil {
+		result = r.txn.Get(r.model)
+	}
+	result.name = r.name
+	result.uid = candidates
+	result.first = r.first
+	result.order = []order{{descending: true, clause: fmt.Sprintf("val(%s)", score)}}
+	result.query = fmt.Sprintf("{\n\t\tuid\n\t\tdgraph.type\n\t\texpand(_all_)\n\t\tscore: val(%s)\n\t}", score)
+	return append(queries, result)
+}
+
+func (r *Recommendation) String() string {
+	return NewQueryBlock(r.Queries()...).String()
+}
+
+// Nodes returns the recommended nodes ordered by score,
+// optional destination can be passed, otherwise bind to model
+func (r *Recommendation) Nodes(dst ...interface{}) error {
+	if r.txn == nil {
+		return errors.New("recommendation has no transaction, use TxnContext.Recommend")
+	}
+	queries := r.Queries()
+	result := queries[len(queries)-1]
+	if len(dst) > 0 {
+		result.model = dst[0]
+	}
+
+	return r.txn.Query(queries...).Scan()
+}
+
+// Recommend prepares a recommendation query for nodes of a model type that are connected
+// to the node uid within 2 hops of predicate, but not directly connected to it.
+// e.g: friends of friends of a user who are not yet friends of the user.
+func (t *TxnContext) Recommend(model interface{}, uid, predicate string) *Recommendation {
+	return &Recommendation{
+		txn:       t,
+		model:     model,
+		name:      "recommendations",
+		uid:       uid,
+		predicate: predicate,
+		first:     10,
+	}
+}
+
+// NewRecommendation returns a new recommendation query, for composing
+// with other queries using Queries
+func NewRecommendation(uid, predicate string) *Recommendation {
+	return &Recommendation{
+		name:      "recommendations",
+		uid:       uid,
+		predicate: predicate,
+		first:     10,
+	}
+}
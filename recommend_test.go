@@ -0,0 +1,132 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecommendationString(t *testing.T) {
+	expected := `{
+	var(func: uid(0x1)) @filter(has(dgraph.type)) {
+		recommendations_source as uid
+		recommendations_connected as friends
+	}
+	var(func: uid(recommendations_source)) @filter(has(dgraph.type)) {
+		recommendations_paths as math(1)
+		friends {
+			recommendations_candidates as friends @filter(NOT uid(recommendations_source, recommendations_connected)) {
+				recommendations_score as math(recommendations_paths)
+			}
+		}
+	}
+	recommendations(func: uid(recommendations_candidates), first: 5, orderdesc: val(recommendations_score)) @filter(has(dgraph.type)) {
+		uid
+		dgraph.type
+		expand(_all_)
+		score: val(recommendations_score)
+	}
+}`
+	assert.Equal(t, expected, NewRecommendation("0x1", "friends").First(5).String())
+
+	expectedFacet := `{
+	var(func: uid(0x1)) @filter(has(dgraph.type)) {
+		feed_source as uid
+		feed_connected as follows
+	}
+	var(func: uid(feed_connected)) @filter(has(dgraph.type)) {
+		feed_candidates as follows @filter(NOT uid(feed_source, feed_connected)) @facets(feed_score as weight)
+	}
+	feed(func: uid(feed_candidates), first: 10, orderdesc: val(feed_score)) @filter(has(dgraph.type)) {
+		uid
+		dgraph.type
+		expand(_all_)
+		score: val(feed_score)
+	}
+}`
+	assert.Equal(t, expectedFacet, NewRecommendation("0x1", "follows").Name("feed").RankByFacet("weight").String())
+}
+
+func TestRecommendTxn(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"recommendations":[{"uid":"0x2","name":"carol","score":2}]}`),
+	})
+	var queries []string
+	tx.SetHooks(&Hooks{
+		BeforeQuery: func(ctx context.Context, query string) error {
+			queries = append(queries, query)
+			return nil
+		},
+	})
+
+	var recommended []*TestUser
+	require.NoError(t, tx.Recommend(&recommended, "0x1", "friends").Nodes())
+	require.Len(t, recommended, 1)
+	assert.Equal(t, "0x2", recommended[0].UID)
+	require.Len(t, fake.requests, 1)
+	assert.Equal(t, []string{fake.requests[0].Query}, queries)
+
+	tx.SetQueryGuard(&QueryGuard{MaxFirst: 5})
+	assert.Error(t, tx.Recommend(&recommended, "0x1", "friends").First(10).Nodes())
+	assert.Len(t, fake.requests, 1)
+
+	assert.Error(t, NewRecommendation("0x1", "friends").Nodes(&recommended))
+}
+
+func TestRecommend(t *testing.T) {
+	type Person struct {
+		UID     string    `json:"uid,omitempty"`
+		Name    string    `json:"name,omitempty" dgraph:"index=exact"`
+		Friends []*Person `json:"friends,omitempty"`
+		Score   int       `json:"score,omitempty"`
+		DType   []string  `json:"dgraph.type,omitempty"`
+	}
+
+	c := newDgraphClient()
+	if _, err := CreateSchema(c, &Person{}); err != nil {
+		t.Error(err)
+	}
+	defer dropAll(c)
+
+	carol := &Person{Name: "carol"}
+	dave := &Person{Name: "dave"}
+	bob := &Person{Name: "bob", Friends: []*Person{carol, dave}}
+	erin := &Person{Name: "erin", Friends: []*Person{carol}}
+	alice := &Person{Name: "alice", Friends: []*Person{bob, erin}}
+
+	tx := NewTxn(c).SetCommitNow()
+	if _, err := tx.Mutate(alice); err != nil {
+		t.Error(err)
+		return
+	}
+
+	var recommended []*Person
+	if err := NewReadOnlyTxn(c).Recommend(&recommended, alice.UID, "friends").Nodes(); err != nil {
+		t.Error(err)
+	}
+
+	assert.Len(t, recommended, 2)
+	assert.Equal(t, "carol", recommended[0].Name)
+	assert.Equal(t, 2, recommended[0].Score)
+	assert.Equal(t, "dave", recommended[1].Name)
+	assert.Equal(t, 1, recommended[1].Score)
+}
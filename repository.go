@@ -0,0 +1,77 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+)
+
+// Repository provides the common queries and mutations of a node type T, e.g: a User struct,
+// removing the Get and Mutate boilerplate of stores, each call runs in its own transaction
+type Repository[T any] struct {
+	c DgraphClient
+}
+
+// NewRepository creates a repository of the node type T on a client
+func NewRepository[T any](c DgraphClient) *Repository[T] {
+	return &Repository[T]{c: c}
+}
+
+// GetByUID returns the node with the uid, returns ErrNodeNotFound when the node does not exist
+func (r *Repository[T]) GetByUID(ctx context.Context, uid string) (*T, error) {
+	node := new(T)
+	if err := NewReadOnlyTxnContext(ctx, r.c).Get(node).UID(uid).Node(); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// Find returns the nodes matching a filter, validated against the schema tags of T,
+// passing a nil filter returns all nodes of the node type
+func (r *Repository[T]) Find(ctx context.Context, filter *Filter) ([]T, error) {
+	var nodes []T
+	query := NewReadOnlyTxnContext(ctx, r.c).Get(&nodes)
+	if filter != nil {
+		query.Where(filter)
+	}
+	if err := query.Nodes(); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// Stream streams the nodes matching a filter to fn one node at a time, in pages of pageSize nodes, as in Stream,
+// passing a nil filter streams all nodes of the node type
+func (r *Repository[T]) Stream(ctx context.Context, filter *Filter, pageSize int, fn func(node *T) error) error {
+	query := NewReadOnlyTxnContext(ctx, r.c).Get(new(T))
+	if filter != nil {
+		query.Where(filter)
+	}
+	return Stream(ctx, query, pageSize, fn)
+}
+
+// Save creates the node, or updates it when the uid is set, with unique checking as in Mutate,
+// the uid of a created node is set on the node
+func (r *Repository[T]) Save(ctx context.Context, node *T) error {
+	_, err := NewTxnContext(ctx, r.c).SetCommitNow().Mutate(node)
+	return err
+}
+
+// DeleteByUID deletes the node with the uid, as in DeleteNode
+func (r *Repository[T]) DeleteByUID(ctx context.Context, uid string) error {
+	return NewTxnContext(ctx, r.c).SetCommitNow().DeleteNode(uid)
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210"
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// responseClient records requests, responding with the responses in order,
+// other DgraphClient methods are not implemented
+type responseClient struct {
+	api.DgraphClient
+	requests  []*api.Request
+	responses []*api.Response
+}
+
+func (r *responseClient) Query(ctx context.Context, in *api.Request, opts ...grpc.CallOption) (*api.Response, error) {
+	r.requests = append(r.requests, in)
+	if len(r.responses) == 0 {
+		return &api.Response{}, nil
+	}
+	resp := r.responses[0]
+	r.responses = r.responses[1:]
+	return resp, nil
+}
+
+func TestRepository(t *testing.T) {
+	dc := &responseClient{responses: []*api.Response{
+		{Json: []byte(`{"data":[{"uid":"0x1","name":"wildan","age":17}]}`)},
+		{Json: []byte(`{"data":[{"uid":"0x1","name":"wildan"},{"uid":"0x2","name":"dolan"}]}`)},
+		{Json: []byte(`{"data":[]}`)},
+	}}
+	repo := NewRepository[TestModel](dgo.NewDgraphClient(dc))
+	ctx := context.Background()
+
+	user, err := repo.GetByUID(ctx, "0x1")
+	require.NoError(t, err)
+	assert.Equal(t, &TestModel{UID: "0x1", Name: "wildan", Age: 17}, user)
+	assert.True(t, dc.requests[0].ReadOnly)
+	assert.Contains(t, dc.requests[0].Query, "data(func: uid(0x1)")
+
+	users, err := repo.Find(ctx, Eq("name", "wildan").Or(Eq("name", "dolan")))
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Contains(t, dc.requests[1].Query, `eq(name, "wildan") OR eq(name, "dolan")`)
+
+	_, err = repo.GetByUID(ctx, "0x3")
+	assert.Equal(t, ErrNodeNotFound, err)
+}
+
+func TestRepositorySave(t *testing.T) {
+	dc := &responseClient{responses: []*api.Response{{Uids: map[string]string{"user": "0x1"}}}}
+	repo := NewRepository[TestModel](dgo.NewDgraphClient(dc))
+
+	user := &TestModel{UID: "_:user", Name: "wildan"}
+	require.NoError(t, repo.Save(context.Background(), user))
+	assert.Equal(t, "0x1", user.UID)
+	require.Len(t, dc.requests, 1)
+	assert.True(t, dc.requests[0].CommitNow)
+	assert.Contains(t, string(dc.requests[0].Mutations[0].SetJson), `"name":"wildan"`)
+
+	require.NoError(t, repo.DeleteByUID(context.Background(), "0x1"))
+	require.Len(t, dc.requests, 2)
+	assert.True(t, dc.requests[1].CommitNow)
+}
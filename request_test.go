@@ -0,0 +1,361 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"bytes"
+	"context"
+	stdjson "encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+// fakeTxn implements transaction, recording every request sent
+// instead of sending it to Dgraph
+type fakeTxn struct {
+	requests  []*api.Request
+	responses []*api.Response
+	err       error
+	committed bool
+	discarded bool
+}
+
+func (f *fakeTxn) respond(req *api.Request) (*api.Response, error) {
+	f.requests = append(f.requests, req)
+	if f.err != nil {
+		return nil, f.err
+	}
+	if len(f.responses) == 0 {
+		return &api.Response{}, nil
+	}
+	resp := f.responses[0]
+	f.responses = f.responses[1:]
+	return resp, nil
+}
+
+func (f *fakeTxn) Query(ctx context.Context, q string) (*api.Response, error) {
+	return f.respond(&api.Request{Query: q})
+}
+
+func (f *fakeTxn) QueryWithVars(ctx context.Context, q string, vars map[string]string) (*api.Response, error) {
+	return f.respond(&api.Request{Query: q, Vars: vars})
+}
+
+func (f *fakeTxn) Mutate(ctx context.Context, mu *api.Mutation) (*api.Response, error) {
+	return f.respond(&api.Request{Mutations: []*api.Mutation{mu}, CommitNow: mu.CommitNow})
+}
+
+func (f *fakeTxn) Do(ctx context.Context, req *api.Request) (*api.Response, error) {
+	return f.respond(req)
+}
+
+func (f *fakeTxn) Commit(ctx context.Context) error {
+	f.committed = true
+	return nil
+}
+
+func (f *fakeTxn) Discard(ctx context.Context) error {
+	f.discarded = true
+	return nil
+}
+
+func newFakeTxnContext(responses ...*api.Response) (*TxnContext, *fakeTxn) {
+	fake := &fakeTxn{responses: responses}
+	return &TxnContext{txn: fake, ctx: context.Background(), opts: &ClientOptions{}}, fake
+}
+
+func indentJSON(data []byte) string {
+	var buf bytes.Buffer
+	if err := stdjson.Indent(&buf, data, "", "  "); err != nil {
+		return string(data)
+	}
+	return buf.String()
+}
+
+// formatRequests renders recorded requests in a readable form for golden files
+func formatRequests(requests []*api.Request) string {
+	var buf bytes.Buffer
+	for i, req := range requests {
+		fmt.Fprintf(&buf, "### request %d (commit_now: %t)\n", i, req.CommitNow)
+		if req.Query != "" {
+			fmt.Fprintf(&buf, "query:\n%s\n", req.Query)
+		}
+		for j, mu := range req.Mutations {
+			fmt.Fprintf(&buf, "mutation %d:\n", j)
+			if mu.Cond != "" {
+				fmt.Fprintf(&buf, "cond: %s\n", mu.Cond)
+			}
+			if len(mu.SetJson) > 0 {
+				fmt.Fprintf(&buf, "set_json:\n%s\n", indentJSON(mu.SetJson))
+			}
+			if len(mu.SetNquads) > 0 {
+				fmt.Fprintf(&buf, "set_nquads:\n%s", mu.SetNquads)
+			}
+			if len(mu.DeleteJson) > 0 {
+				fmt.Fprintf(&buf, "delete_json:\n%s\n", indentJSON(mu.DeleteJson))
+			}
+			if len(mu.DelNquads) > 0 {
+				fmt.Fprintf(&buf, "del_nquads:\n%s", mu.DelNquads)
+			}
+		}
+	}
+	return buf.String()
+}
+
+func assertGolden(t *testing.T, name string, actual string) {
+	path := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		require.NoError(t, ioutil.WriteFile(path, []byte(actual), 0644))
+	}
+	expected, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), actual)
+}
+
+type GoldenUser struct {
+	UID        string        `json:"uid,omitempty"`
+	Username   string        `json:"username,omitempty" dgraph:"index=hash unique"`
+	Name       string        `json:"name,omitempty"`
+	NameOrigin string        `json:"name|origin,omitempty"`
+	School     *GoldenSchool `json:"school,omitempty"`
+	DType      []string      `json:"dgraph.type,omitempty"`
+}
+
+type GoldenSchool struct {
+	UID        string   `json:"uid,omitempty"`
+	Identifier string   `json:"identifier,omitempty" dgraph:"index=hash unique"`
+	Since      int      `json:"school|since,omitempty"`
+	DType      []string `json:"dgraph.type,omitempty"`
+}
+
+type GoldenPassport struct {
+	UID    string      `json:"uid,omitempty"`
+	Number string      `json:"number,omitempty" dgraph:"index=hash unique"`
+	Owner  *GoldenUser `json:"owner,omitempty" dgraph:"cardinality=one"`
+	DType  []string    `json:"dgraph.type,omitempty"`
+}
+
+type GoldenClass struct {
+	UID      string        `json:"uid,omitempty"`
+	Code     string        `json:"code,omitempty" dgraph:"index=hash unique"`
+	Students []*GoldenUser `json:"students,omitempty"`
+	DType    []string      `json:"dgraph.type,omitempty"`
+}
+
+func newGoldenUser() *GoldenUser {
+	return &GoldenUser{
+		Username: "wildan",
+		Name:     "Wildan",
+		School: &GoldenSchool{
+			Identifier: "bss",
+		},
+	}
+}
+
+func TestRequestGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		do   func(tx *TxnContext) error
+	}{
+		{
+			name: "mutate",
+			do: func(tx *TxnContext) error {
+				_, err := tx.Mutate(newGoldenUser())
+				return err
+			},
+		},
+		{
+			name: "mutate_basic",
+			do: func(tx *TxnContext) error {
+				_, err := tx.MutateBasic(newGoldenUser())
+				return err
+			},
+		},
+		{
+			name: "mutate_or_get",
+			do: func(tx *TxnContext) error {
+				_, err := tx.MutateOrGet(newGoldenUser())
+				return err
+			},
+		},
+		{
+			name: "upsert",
+			do: func(tx *TxnContext) error {
+				_, err := tx.Upsert(newGoldenUser(), "username")
+				return err
+			},
+		},
+		{
+			name: "update",
+			do: func(tx *TxnContext) error {
+				user := newGoldenUser()
+				user.UID = "0x1"
+				user.School.UID = "0x2"
+				_, err := tx.SetCommitNow().Mutate(user)
+				return err
+			},
+		},
+		{
+			name: "mutate_facets",
+			do: func(tx *TxnContext) error {
+				user := newGoldenUser()
+				user.NameOrigin = "indonesia"
+				user.School.Since = 2010
+				_, err := tx.Mutate(user)
+				return err
+			},
+		},
+		{
+			name: "mutate_nquads",
+			do: func(tx *TxnContext) error {
+				user := newGoldenUser()
+				user.NameOrigin = "indonesia"
+				user.School.Since = 2010
+				_, err := tx.MutateWithOptions(user, MutateOptions{AsNquads: true})
+				return err
+			},
+		},
+		{
+			name: "mutate_basic_nquads",
+			do: func(tx *TxnContext) error {
+				user := newGoldenUser()
+				user.School.Since = 2010
+				_, err := tx.MutateWithOptions(user, MutateOptions{SkipUnique: true, AsNquads: true})
+				return err
+			},
+		},
+		{
+			name: "update_facets",
+			do: func(tx *TxnContext) error {
+				user := newGoldenUser()
+				user.UID = "0x1"
+				user.School = &GoldenSchool{UID: "0x2", Since: 2010}
+				_, err := tx.Mutate(user)
+				return err
+			},
+		},
+		{
+			name: "cardinality_one_update",
+			do: func(tx *TxnContext) error {
+				owner := newGoldenUser()
+				owner.UID = "0x1"
+				_, err := tx.Mutate(&GoldenPassport{UID: "0x3", Owner: owner})
+				return err
+			},
+		},
+		{
+			name: "cardinality_one_upsert",
+			do: func(tx *TxnContext) error {
+				_, err := tx.Upsert(&GoldenPassport{Number: "A123", Owner: newGoldenUser()})
+				return err
+			},
+		},
+		{
+			name: "upsert_replace_edges",
+			do: func(tx *TxnContext) error {
+				class := &GoldenClass{Code: "c1", Students: []*GoldenUser{{UID: "0x1"}, newGoldenUser()}}
+				_, err := tx.MutateWithOptions(class, MutateOptions{
+					OnUniqueConflict: UniqueConflictUpdate,
+					ReplaceEdges:     []string{"students"},
+				})
+				return err
+			},
+		},
+		{
+			name: "update_replace_edges",
+			do: func(tx *TxnContext) error {
+				class := &GoldenClass{UID: "0x5", Students: []*GoldenUser{{UID: "0x1"}}}
+				_, err := tx.MutateWithOptions(class, MutateOptions{ReplaceEdges: []string{"students"}})
+				return err
+			},
+		},
+		{
+			name: "delete",
+			do: func(tx *TxnContext) error {
+				return tx.Delete(&DeleteParams{
+					Nodes: []DeleteNode{
+						{
+							UID: "0x1",
+							Edges: []DeleteEdge{
+								{Pred: "school", UIDs: []string{"0x2"}},
+								{Pred: "friends"},
+							},
+						},
+						{UID: "0x2"},
+					},
+				})
+			},
+		},
+		{
+			name: "delete_query",
+			do: func(tx *TxnContext) error {
+				query := NewQueryBlock(NewQuery().
+					As("user").Var().
+					Model(&GoldenUser{}).
+					Filter("eq(username, $1)", "wildan"))
+				_, err := tx.DeleteQuery(query, &DeleteParams{
+					Cond:  "@if(gt(len(user), 0))",
+					Nodes: []DeleteNode{{UID: "user"}},
+				})
+				return err
+			},
+		},
+		{
+			name: "delete_node",
+			do: func(tx *TxnContext) error {
+				return tx.DeleteNode("0x1", "0x2")
+			},
+		},
+		{
+			name: "delete_edge",
+			do: func(tx *TxnContext) error {
+				return tx.DeleteEdge("0x1", "school", "0x2")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// make generated blank node uids deterministic
+			atomic.StoreInt32(&blankuid, 0)
+
+			tx, fake := newFakeTxnContext()
+			require.NoError(t, test.do(tx))
+			assertGolden(t, test.name, formatRequests(fake.requests))
+		})
+	}
+}
+
+func TestReplaceEdgesSkipUnique(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+	_, err := tx.MutateWithOptions(&GoldenClass{UID: "0x5"}, MutateOptions{
+		SkipUnique:   true,
+		ReplaceEdges: []string{"students"},
+	})
+	assert.Error(t, err)
+	assert.Empty(t, fake.requests)
+}
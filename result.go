@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+)
+
+// Result is a query result with metadata, useful for debugging and building wrappers
+type Result struct {
+	// Data is the destination the result is decoded into
+	Data interface{}
+	// Count is the total count of the query, only set on ResultAndCount
+	Count int
+	// JSON is the raw JSON result
+	JSON []byte
+	// Query is the generated query
+	Query string
+	// Latency is the query latency reported by Dgraph
+	Latency *api.Latency
+	// Metrics is the query metrics reported by Dgraph, e.g: number of uids processed per predicate
+	Metrics *api.Metrics
+}
+
+func newResult(data interface{}, query string, resp *api.Response) *Result {
+	return &Result{
+		Data:    data,
+		JSON:    resp.Json,
+		Query:   query,
+		Latency: resp.Latency,
+		Metrics: resp.Metrics,
+	}
+}
+
+// Result returns all results from the query like Nodes, with the query metadata,
+// optional destination can be passed, otherwise bind to model
+func (q *Query) Result(dst ...interface{}) (*Result, error) {
+	model := q.model
+	if len(dst) > 0 {
+		model = dst[0]
+	}
+
+	resp, err := q.execute()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.nodes(resp.Json, model); err != nil {
+		return nil, err
+	}
+	return newResult(model, q.String(), resp), nil
+}
+
+// ResultAndCount returns paged results from the query like NodesAndCount, with the total count
+// and the query metadata, optional destination can be passed, otherwise bind to model
+func (q *Query) ResultAndCount(dst ...interface{}) (*Result, error) {
+	model := q.model
+	if len(dst) > 0 {
+		model = dst[0]
+	}
+	return q.nodesAndCount(model)
+}
+
+// Result unmarshals the query result like Scan, with the query metadata,
+// if no destination is passed, it will be unmarshaled to the individual query models.
+func (q *QueryBlock) Result(dst ...interface{}) (*Result, error) {
+	resp, err := q.execute()
+	if err != nil {
+		return nil, err
+	}
+	if err = q.scan(resp.Json, dst...); err != nil {
+		return nil, errors.Wrap(err, "scan failed")
+	}
+
+	var data interface{}
+	if len(dst) > 0 {
+		data = dst[0]
+	}
+	return newResult(data, q.String(), resp), nil
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryResult(t *testing.T) {
+	data := []byte(`{"data":[{"uid":"0x1","name":"wildan"}]}`)
+	latency := &api.Latency{TotalNs: 1000}
+	tx, fake := newFakeTxnContext(&api.Response{Json: data, Latency: latency})
+
+	var models []TestModel
+	result, err := tx.Get(&models).Filter("eq(name, $1)", "wildan").Result()
+	require.NoError(t, err)
+
+	assert.Equal(t, &models, result.Data)
+	assert.Equal(t, data, result.JSON)
+	assert.Equal(t, fake.requests[0].Query, result.Query)
+	assert.Equal(t, latency, result.Latency)
+	assert.Len(t, models, 1)
+}
+
+func TestQueryResultAndCount(t *testing.T) {
+	data := []byte(`{"result":[{"uid":"0x1","name":"wildan"}],"pageInfo":[{"count":5}]}`)
+	tx, fake := newFakeTxnContext(&api.Response{Json: data})
+
+	var models []TestModel
+	result, err := tx.Get(&models).First(1).ResultAndCount()
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, result.Count)
+	assert.Equal(t, fake.requests[0].Query, result.Query)
+	assert.Len(t, models, 1)
+}
+
+func TestQueryBlockResult(t *testing.T) {
+	data := []byte(`{"users":[{"uid":"0x1","name":"wildan"}]}`)
+	tx, fake := newFakeTxnContext(&api.Response{Json: data})
+
+	var models []TestModel
+	result, err := tx.Query(NewQuery().Name("users").Model(&models)).Result()
+	require.NoError(t, err)
+
+	assert.Nil(t, result.Data)
+	assert.Equal(t, fake.requests[0].Query, result.Query)
+	assert.Len(t, models, 1)
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultRetryMaxAttempts    = 5
+	defaultRetryInitialBackoff = 10 * time.Millisecond
+	defaultRetryMaxBackoff     = time.Second
+	defaultRetryMultiplier     = 2
+)
+
+// RetryOptions specifies the retries of aborted transactions in RunInTxn
+type RetryOptions struct {
+	// MaxAttempts is the maximum number of attempts, including the first attempt, defaults to 5
+	MaxAttempts int
+	// InitialBackoff is the backoff before the first retry, defaults to 10ms
+	InitialBackoff time.Duration
+	// MaxBackoff caps the backoff between retries, defaults to 1s
+	MaxBackoff time.Duration
+	// Multiplier is the backoff growth factor after each retry, defaults to 2
+	Multiplier float64
+}
+
+func (o RetryOptions) withDefaults() RetryOptions {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = defaultRetryMaxBackoff
+	}
+	if o.Multiplier < 1 {
+		o.Multiplier = defaultRetryMultiplier
+	}
+	return o
+}
+
+// backoff returns the backoff before a retry, with jitter to spread out conflicting retries
+func (o RetryOptions) backoff(retry int) time.Duration {
+	backoff := float64(o.InitialBackoff)
+	for i := 0; i < retry && backoff < float64(o.MaxBackoff); i++ {
+		backoff *= o.Multiplier
+	}
+	if backoff > float64(o.MaxBackoff) {
+		backoff = float64(o.MaxBackoff)
+	}
+	half := time.Duration(backoff / 2)
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retrySleep waits for the backoff or until the context is done, replaceable in tests
+var retrySleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunInTxn runs fn in a new transaction and commits it, retrying with a renewed transaction, as in TxnContext.Renew,
+// when the transaction is aborted, i.e: dgo.ErrAborted, on conflicting concurrent transactions.
+// Retries back off exponentially as specified by the optional retry options.
+// The transaction is discarded when fn returns an error, and the error is returned as is,
+// fn should be safe to be called multiple times, and mutations in fn should not commit now.
+func RunInTxn(ctx context.Context, c DgraphClient, fn func(tx *TxnContext) error, opts ...RetryOptions) error {
+	return runInTxn(NewTxnContext(ctx, c), (*TxnContext).Renew, fn, opts...)
+}
+
+func runInTxn(tx *TxnContext, renew func(tx *TxnContext) *TxnContext, fn func(tx *TxnContext) error, opts ...RetryOptions) error {
+	var retryOpts RetryOptions
+	if len(opts) > 0 {
+		retryOpts = opts[0]
+	}
+	retryOpts = retryOpts.withDefaults()
+
+	// commit at the end, instead of on each mutation, renewed transactions keep it
+	tx.commitNow = false
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			tx = renew(tx)
+		}
+
+		err := fn(tx)
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err == nil {
+			return nil
+		}
+		// discarding a committed or aborted transaction is a no-op
+		_ = tx.Discard()
+
+		if errors.Cause(err) != dgo.ErrAborted {
+			return err
+		}
+		if attempt >= retryOpts.MaxAttempts {
+			return errors.Wrapf(err, "transaction aborted after %d attempts", attempt)
+		}
+		if err := retrySleep(tx.ctx, retryOpts.backoff(attempt-1)); err != nil {
+			return err
+		}
+	}
+}
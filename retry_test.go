@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubRetrySleep(t *testing.T) *[]time.Duration {
+	var sleeps []time.Duration
+	sleep := retrySleep
+	retrySleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	t.Cleanup(func() { retrySleep = sleep })
+	return &sleeps
+}
+
+// renewFakeTxn renews a fake transaction as in TxnContext.Renew, with a new fake transaction
+func renewFakeTxn(fakes *[]*fakeTxn) func(tx *TxnContext) *TxnContext {
+	return func(tx *TxnContext) *TxnContext {
+		renewed, fake := newFakeTxnContext()
+		renewed.ctx = tx.ctx
+		renewed.commitNow = tx.commitNow
+		renewed.hooks = tx.hooks
+		*fakes = append(*fakes, fake)
+		return renewed
+	}
+}
+
+func TestRunInTxnRetry(t *testing.T) {
+	sleeps := stubRetrySleep(t)
+
+	tx, fake := newFakeTxnContext()
+	tx.commitNow = true
+	tx.hooks = &Hooks{}
+	fakes := []*fakeTxn{fake}
+
+	attempts := 0
+	err := runInTxn(tx, renewFakeTxn(&fakes), func(attemptTx *TxnContext) error {
+		attempts++
+		assert.False(t, attemptTx.commitNow)
+		assert.Same(t, tx.hooks, attemptTx.hooks)
+		if attempts < 3 {
+			return errors.Wrap(dgo.ErrAborted, "mutate failed")
+		}
+		return nil
+	}, RetryOptions{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 15 * time.Millisecond})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, attempts)
+	require.Len(t, fakes, 3)
+	assert.True(t, fakes[0].discarded)
+	assert.True(t, fakes[1].discarded)
+	assert.True(t, fakes[2].committed)
+
+	require.Len(t, *sleeps, 2)
+	// backoff with jitter between half and the full backoff
+	assert.True(t, (*sleeps)[0] >= 5*time.Millisecond && (*sleeps)[0] <= 10*time.Millisecond)
+	assert.True(t, (*sleeps)[1] >= 7*time.Millisecond && (*sleeps)[1] <= 15*time.Millisecond)
+}
+
+func TestRunInTxnMaxAttempts(t *testing.T) {
+	sleeps := stubRetrySleep(t)
+
+	tx, _ := newFakeTxnContext()
+	var fakes []*fakeTxn
+	attempts := 0
+	err := runInTxn(tx, renewFakeTxn(&fakes), func(tx *TxnContext) error {
+		attempts++
+		return dgo.ErrAborted
+	}, RetryOptions{MaxAttempts: 3})
+	assert.Equal(t, dgo.ErrAborted, errors.Cause(err))
+	assert.Equal(t, 3, attempts)
+	assert.Len(t, fakes, 2)
+	assert.Len(t, *sleeps, 2)
+}
+
+func TestRunInTxnError(t *testing.T) {
+	stubRetrySleep(t)
+
+	tx, fake := newFakeTxnContext()
+	var fakes []*fakeTxn
+	fnErr := errors.New("failed")
+	attempts := 0
+	err := runInTxn(tx, renewFakeTxn(&fakes), func(tx *TxnContext) error {
+		attempts++
+		return fnErr
+	})
+	assert.Equal(t, fnErr, err)
+	assert.Equal(t, 1, attempts)
+	assert.Empty(t, fakes)
+	assert.True(t, fake.discarded)
+	assert.False(t, fake.committed)
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// reverseEdge is a forward edge of a parent node to a child node, set by a reverse edge field of the child
+type reverseEdge struct {
+	// parent is the parent node, set in basic mutations along with the forward edge
+	parent    interface{}
+	parentUID reflect.Value
+	childUID  reflect.Value
+	predicate string
+}
+
+// reverseEdges returns the forward edges of the parent nodes of a reverse edge field, tagged with reverse_of=predicate
+func (m *mutation) reverseEdges(childUID, field reflect.Value, predicate string) ([]reverseEdge, error) {
+	parents := []reflect.Value{field}
+	if field.Kind() == reflect.Slice {
+		parents = make([]reflect.Value, field.Len())
+		for i := range parents {
+			parents[i] = field.Index(i)
+		}
+	}
+
+	edges := make([]reverseEdge, 0, len(parents))
+	for _, parent := range parents {
+		parent = getElemValue(parent)
+		if !parent.IsValid() || parent.Kind() != reflect.Struct {
+			continue
+		}
+		parentType, err := m.getMutateType(parent.Type())
+		if err != nil {
+			return nil, err
+		}
+		if parentType.uidIndex == -1 {
+			continue
+		}
+		// parents of single node fields are copied, as the fields are detached on marshaling basic mutations
+		parentNode := reflect.New(parent.Type())
+		if field.Kind() != reflect.Struct && parent.CanAddr() {
+			parentNode = parent.Addr()
+		} else {
+			parentNode.Elem().Set(parent)
+		}
+		edges = append(edges, reverseEdge{
+			parent:    parentNode.Interface(),
+			parentUID: parent.Field(parentType.uidIndex),
+			childUID:  childUID,
+			predicate: predicate,
+		})
+	}
+	return edges, nil
+}
+
+// reverseEdgeNodes returns the parent nodes of reverse edges with their forward edges,
+// the uids are resolved on marshaling, as upserted nodes are set to their uid funcs after they are traversed
+func (m *mutation) reverseEdgeNodes(edges []reverseEdge) ([]interface{}, error) {
+	nodes := make([]interface{}, len(edges))
+	for i, edge := range edges {
+		parentUID := m.uidOf(edge.parentUID)
+		if parentUID == "" {
+			return nil, errors.Errorf("parent node of reverse edge %s has no uid", edge.predicate)
+		}
+		nodes[i] = map[string]interface{}{
+			predicateUid:   parentUID,
+			edge.predicate: []map[string]interface{}{{predicateUid: m.uidOf(edge.childUID)}},
+		}
+	}
+	return nodes, nil
+}
+
+// reverseEdgeHook collects the reverse edge fields of basic mutations, with the forward edges of their parent nodes
+type reverseEdgeHook struct {
+	mutation *mutation
+	edges    *[]reverseEdge
+	fields   *[]reflect.Value
+}
+
+func (h reverseEdgeHook) Struct(v reflect.Value, level int) error {
+	return nil
+}
+
+func (h reverseEdgeHook) StructField(p reflect.Value, field reflect.StructField, v reflect.Value, level int) error {
+	predicate := reverseOf(field.Tag.Get(tagName))
+	if predicate == "" || !v.CanInterface() {
+		return nil
+	}
+	if !v.CanSet() {
+		return errors.Errorf("reverse edge field %s.%s is not settable, pass a pointer", p.Type().Name(), field.Name)
+	}
+	childType, err := h.mutation.getMutateType(p.Type())
+	if err != nil {
+		return err
+	}
+	if childType.uidIndex == -1 {
+		return nil
+	}
+	edges, err := h.mutation.reverseEdges(p.Field(childType.uidIndex), v, predicate)
+	if err != nil {
+		return err
+	}
+	*h.edges = append(*h.edges, edges...)
+	*h.fields = append(*h.fields, v)
+	return nil
+}
+
+// detachFields sets fields to their zero values, returning a func restoring the fields,
+// e.g: to omit reverse edge fields when marshaling basic mutations
+func detachFields(fields []reflect.Value) func() {
+	values := make([]reflect.Value, len(fields))
+	for i, field := range fields {
+		values[i] = reflect.ValueOf(field.Interface())
+		field.Set(reflect.Zero(field.Type()))
+	}
+	return func() {
+		for i, field := range fields {
+			field.Set(values[i])
+		}
+	}
+}
+
+// reverseOf returns the forward predicate of the dgraph tag of a reverse edge field, tagged with reverse_of=predicate
+func reverseOf(dgraphTag string) string {
+	if !strings.Contains(dgraphTag, "reverse_of=") {
+		return ""
+	}
+	props, err := parseStructTag(dgraphTag)
+	if err != nil {
+		return ""
+	}
+	return props.ReverseOf
+}
+
+// singleReverseEdgeCache caches whether model types have reverse edge fields of single nodes
+var singleReverseEdgeCache sync.Map
+
+// hasSingleReverseEdges checks whether a model type or its edge types have reverse edge fields of single nodes,
+// which are decoded from the first node of the reverse edges, as reverse edges are returned as lists
+func hasSingleReverseEdges(modelType reflect.Type) bool {
+	modelType = getElemType(modelType)
+	if has, ok := singleReverseEdgeCache.Load(modelType); ok {
+		return has.(bool)
+	}
+	has := findSingleReverseEdges(modelType, make(map[reflect.Type]bool))
+	singleReverseEdgeCache.Store(modelType, has)
+	return has
+}
+
+func findSingleReverseEdges(modelType reflect.Type, visited map[reflect.Type]bool) bool {
+	if modelType.Kind() != reflect.Struct || visited[modelType] {
+		return false
+	}
+	visited[modelType] = true
+
+	for _, field := range modelFields(modelType) {
+		if reverseOf(field.Tag.Get(tagName)) != "" && field.Type.Kind() != reflect.Slice {
+			return true
+		}
+		if edgeType := edgeNodeType(field); edgeType != nil && findSingleReverseEdges(edgeType, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendJSONNodes appends nodes to the json of a node or a list of nodes
+func appendJSONNodes(setJSON []byte, nodes []interface{}) ([]byte, error) {
+	nodesJSON, err := json.Marshal(nodes)
+	if err != nil {
+		return nil, err
+	}
+	set := bytes.TrimSpace(setJSON)
+	if len(set) > 0 && set[0] == '[' {
+		set = bytes.TrimSpace(set[1 : len(set)-1])
+	}
+	if len(set) == 0 || bytes.Equal(set, []byte("null")) {
+		return nodesJSON, nil
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteByte('[')
+	buffer.Write(set)
+	buffer.WriteByte(',')
+	buffer.Write(nodesJSON[1:])
+	return buffer.Bytes(), nil
+}
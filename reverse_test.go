@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ReverseParent struct {
+	UID      string          `json:"uid,omitempty"`
+	Name     string          `json:"name,omitempty"`
+	Children []*ReverseChild `json:"children,omitempty" dgraph:"reverse"`
+	DType    []string        `json:"dgraph.type,omitempty"`
+}
+
+type ReverseChild struct {
+	UID    string         `json:"uid,omitempty"`
+	Name   string         `json:"name,omitempty"`
+	Parent *ReverseParent `json:"parent,omitempty" dgraph:"reverse_of=children"`
+	DType  []string       `json:"dgraph.type,omitempty"`
+}
+
+func TestParseReverseOf(t *testing.T) {
+	field := reflect.StructField{Type: reflect.TypeOf(&ReverseParent{}), Tag: `json:"parent,omitempty" dgraph:"reverse_of=children"`}
+	schema, err := parseDgraphTag(&field)
+	require.NoError(t, err)
+	assert.Equal(t, "~children", schema.Predicate)
+	assert.Equal(t, "children", schema.ReverseOf)
+
+	invalid := []reflect.StructField{
+		{Type: reflect.TypeOf(""), Tag: `json:"parent" dgraph:"reverse_of=children"`},
+		{Type: reflect.TypeOf(&ReverseParent{}), Tag: `json:"parent" dgraph:"reverse_of=~children"`},
+		{Type: reflect.TypeOf(&ReverseParent{}), Tag: `json:"parent" dgraph:"reverse_of=children|since"`},
+	}
+	for _, field := range invalid {
+		_, err := parseDgraphTag(&field)
+		assert.Error(t, err, field.Tag)
+	}
+
+	// the reverse flag is not a reverse edge field
+	field = reflect.StructField{Type: reflect.TypeOf([]*ReverseChild{}), Tag: `json:"children,omitempty" dgraph:"reverse=true"`}
+	schema, err = parseDgraphTag(&field)
+	require.NoError(t, err)
+	assert.Equal(t, "children", schema.Predicate)
+	assert.True(t, schema.Reverse)
+	assert.Empty(t, schema.ReverseOf)
+
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", &ReverseParent{}, &ReverseChild{})
+	assert.NotContains(t, typeSchema.Schema, "parent")
+	assert.True(t, typeSchema.Schema["children"].Reverse)
+}
+
+func TestMutateReverseEdge(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{}, &api.Response{})
+
+	child := &ReverseChild{Name: "child", Parent: &ReverseParent{UID: "0x1"}}
+	_, err := tx.Mutate(child)
+	require.NoError(t, err)
+
+	require.Len(t, fake.requests, 1)
+	var nodes []map[string]interface{}
+	require.NoError(t, json.Unmarshal(fake.requests[0].Mutations[0].SetJson, &nodes))
+	require.Len(t, nodes, 2)
+	assert.NotContains(t, nodes[0], "parent")
+	assert.NotContains(t, nodes[0], "~children")
+	assert.Equal(t, map[string]interface{}{
+		"uid":      "0x1",
+		"children": []interface{}{map[string]interface{}{"uid": nodes[0]["uid"]}},
+	}, nodes[1])
+
+	basicChild := &ReverseChild{UID: "_:child", Parent: &ReverseParent{UID: "0x1", Name: "parent"}}
+	_, err = tx.MutateBasic(basicChild)
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 2)
+	nodes = nil
+	require.NoError(t, json.Unmarshal(fake.requests[1].Mutations[0].SetJson, &nodes))
+	require.Len(t, nodes, 3)
+	assert.NotContains(t, nodes[0], "parent")
+	assert.Equal(t, map[string]interface{}{"uid": "0x1", "name": "parent", "dgraph.type": []interface{}{"ReverseParent"}}, nodes[1])
+	assert.Equal(t, map[string]interface{}{
+		"uid":      "0x1",
+		"children": []interface{}{map[string]interface{}{"uid": "_:child"}},
+	}, nodes[2])
+	// the detached reverse edge field is restored
+	assert.Equal(t, "0x1", basicChild.Parent.UID)
+
+	// new parent nodes are created along with their forward edges
+	tx, fake = newFakeTxnContext(&api.Response{})
+	_, err = tx.Mutate(&ReverseChild{Name: "child", Parent: &ReverseParent{Name: "parent"}})
+	require.NoError(t, err)
+	mutations := fake.requests[0].Mutations
+	require.Len(t, mutations, 2)
+	var parent map[string]interface{}
+	require.NoError(t, json.Unmarshal(mutations[0].SetJson, &parent))
+	require.NoError(t, json.Unmarshal(mutations[1].SetJson, &nodes))
+	require.Len(t, nodes, 2)
+	assert.Equal(t, parent["uid"], nodes[1]["uid"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"uid": nodes[0]["uid"]}}, nodes[1]["children"])
+}
+
+func TestQueryReverseEdgeField(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"data":[{"uid":"0x2","parent":[{"uid":"0x1","name":"parent"},{"uid":"0x3"}]}]}`),
+	})
+
+	var child ReverseChild
+	err := tx.Get(&child).UID("0x2").Edge("parent", EdgeQuery{}).All(1).Node()
+	require.NoError(t, err)
+	// the reverse edge field is queried from the aliased reverse predicate
+	assert.Contains(t, fake.requests[0].Query, "parent: ~children {")
+	// the first node of the reverse edge is decoded into the single node field
+	require.NotNil(t, child.Parent)
+	assert.Equal(t, "0x1", child.Parent.UID)
+	assert.Equal(t, "parent", child.Parent.Name)
+}
@@ -24,10 +24,7 @@ import (
 	"reflect"
 	"strings"
 
-	"github.com/dgraph-io/dgo/v210/protos/api"
 	"github.com/kr/logfmt"
-
-	"github.com/dgraph-io/dgo/v210"
 )
 
 const (
@@ -37,35 +34,58 @@ const (
 
 	schemaUid     = "uid"
 	schemaUidList = "[uid]"
+
+	// CardinalityOne enforces a single edge on a uid predicate, replacing the existing edge on mutation
+	CardinalityOne = "one"
 )
 
 type rawSchema struct {
-	Predicate  string
-	Index      string
-	Constraint string
-	Reverse    bool
-	Count      bool
-	List       bool
-	Upsert     bool
-	Lang       bool
-	Type       string
-	Noconflict bool
-	Unique     bool
+	Predicate   string
+	Index       string
+	Constraint  string
+	Reverse     bool
+	Count       bool
+	List        bool
+	Upsert      bool
+	Lang        bool
+	Type        string
+	Noconflict  bool
+	Unique      bool
+	Required    bool
+	Cardinality string
+	Directive   string
+	SoftDelete  bool
+	Set         bool
+	Min         string
+	Max         string
+	Pattern     string
+	Owned       bool
+	Encoder     string
+	TTL         bool
+	// ReverseOf is the forward predicate of a reverse edge field, from reverse_of=predicate
+	ReverseOf string `logfmt:"reverse_of"`
 }
 
 type Schema struct {
-	Predicate  string
-	Type       string
-	Index      bool
-	Tokenizer  []string
-	Reverse    bool
-	Count      bool
-	List       bool
-	Upsert     bool
-	Lang       bool
-	Noconflict bool `json:"no_conflict"`
-	Unique     bool
-	OmitEmpty  bool
+	Predicate   string
+	Type        string
+	Index       bool
+	Tokenizer   []string
+	Reverse     bool
+	Count       bool
+	List        bool
+	Upsert      bool
+	Lang        bool
+	Noconflict  bool `json:"no_conflict"`
+	Unique      bool
+	Required    bool
+	Cardinality string
+	Directive   string
+	OmitEmpty   bool
+	Set         bool
+	// ReverseOf is the forward predicate of the parent nodes of a reverse edge field,
+	// the field is mutated as the forward edges of its nodes to the node of the field
+	ReverseOf string
 }
 
 func (s Schema) String() string {
@@ -92,6 +112,10 @@ func (s Schema) String() string {
 	if s.Noconflict {
 		schema += "@noconflict "
 	}
+	if s.Directive != "" {
+		// raw directives not supported by dgman
+		schema += s.Directive + " "
+	}
 	return schema + "."
 }
 
@@ -126,13 +150,62 @@ func (s SchemaMap) String() string {
 	return buffer.String()
 }
 
+// SchemaConflict is a predicate defined with different schemas
+type SchemaConflict struct {
+	Predicate string
+	// NodeType is the node type of the conflicting definition
+	NodeType string
+	// Defined is the schema already defined by another model, or in the cluster
+	Defined string
+	// Conflicting is the schema conflicting with the defined schema, which is not installed
+	Conflicting string
+	// Existing is true when the defined schema is the existing cluster schema
+	Existing bool
+}
+
+func (c SchemaConflict) String() string {
+	definedBy := "already defined"
+	if c.Existing {
+		definedBy = "existing schema"
+	}
+	return fmt.Sprintf("conflicting schema %s on %s, %s as \"%s\", trying to define \"%s\"",
+		c.Predicate, c.NodeType, definedBy, c.Defined, c.Conflicting)
+}
+
+// SchemaConflictError is returned on schema conflicts when StrictSchema is set in the client options
+type SchemaConflictError struct {
+	Conflicts []SchemaConflict
+}
+
+func (e *SchemaConflictError) Error() string {
+	conflicts := make([]string, len(e.Conflicts))
+	for i, conflict := range e.Conflicts {
+		conflicts[i] = conflict.String()
+	}
+	return strings.Join(conflicts, "; ")
+}
+
 type TypeSchema struct {
 	Types  TypeMap
 	Schema SchemaMap
+	// Extensions are raw schema definitions from node types implementing SchemaExtension
+	Extensions []string
+	// Conflicts are predicates defined with different schemas, where the first definition is kept
+	Conflicts []SchemaConflict
+	// softDeletes maps the soft delete node types of the models to their soft delete predicate
+	softDeletes map[string]string
+}
+
+// Err returns a SchemaConflictError when there are schema conflicts
+func (t *TypeSchema) Err() error {
+	if len(t.Conflicts) == 0 {
+		return nil
+	}
+	return &SchemaConflictError{Conflicts: t.Conflicts}
 }
 
 func (t *TypeSchema) String() string {
-	return strings.Join([]string{t.Schema.String(), t.Types.String()}, "\n")
+	return strings.Join(append([]string{t.Schema.String(), t.Types.String()}, t.Extensions...), "\n")
 }
 
 // Marshal marshals passed models into type and schema definitions
@@ -144,8 +217,9 @@ func (t *TypeSchema) Marshal(parentType string, models ...interface{}) {
 			continue
 		}
 
-		if current.Kind() == reflect.Interface {
-			// don't parse raw interfaces or it will panic
+		if current.Kind() == reflect.Interface || current == nodeRefType {
+			// don't parse raw interfaces or it will panic,
+			// the node types of polymorphic edges are defined by their models
 			continue
 		}
 
@@ -153,13 +227,25 @@ func (t *TypeSchema) Marshal(parentType string, models ...interface{}) {
 		if _, ok := t.Types[nodeType]; ok {
 			continue
 		}
+		if predicate := softDeletePredicate(current); predicate != "" {
+			if t.softDeletes == nil {
+				t.softDeletes = make(map[string]string)
+			}
+			t.softDeletes[nodeType] = predicate
+		}
 		if parentType == "" {
 			t.Types[nodeType] = make(SchemaMap)
+			if extension, ok := reflect.New(current).Interface().(SchemaExtension); ok {
+				t.Extensions = append(t.Extensions, extension.SchemaExtension())
+			}
 		} else {
 			// allow anonymous fields to be parsed into parent type
 			nodeType = parentType
 		}
 
+		// base predicates of language tagged predicates, e.g: name of name@en
+		var langBases []string
+
 		numFields := current.NumField()
 		for i := 0; i < numFields; i++ {
 			field := current.Field(i)
@@ -181,11 +267,16 @@ func (t *TypeSchema) Marshal(parentType string, models ...interface{}) {
 				continue
 			}
 
+			if base := langBase(s.Predicate); base != "" {
+				langBases = append(langBases, base)
+				continue
+			}
+
 			schema, exists := t.Schema[s.Predicate]
 			parse := s.Predicate != "" &&
 				s.Predicate != "uid" && // don't parse uid
 				s.Predicate != predicateDgraphType && // don't parse dgraph.type
-				!strings.Contains(s.Predicate, "|") && // don't parse facet
+				!isFacet(s.Predicate) && // don't parse facet
 				s.Predicate[0] != '~' && // don't parse reverse edge
 				!strings.Contains(s.Predicate, "@") // don't parse non-primary lang predicate
 
@@ -203,11 +294,27 @@ func (t *TypeSchema) Marshal(parentType string, models ...interface{}) {
 			// each type should uniquely specify a predicate, that's why use a map on predicate
 			t.Types[nodeType][s.Predicate] = s
 			if exists && schema.String() != s.String() {
-				log.Printf("conflicting schema %s, already defined as \"%s\", trying to define \"%s\"\n", s.Predicate, schema.String(), s.String())
+				t.Conflicts = append(t.Conflicts, SchemaConflict{
+					Predicate:   s.Predicate,
+					NodeType:    nodeType,
+					Defined:     schema.String(),
+					Conflicting: s.String(),
+				})
 			} else {
 				t.Schema[s.Predicate] = s
 			}
 		}
+
+		// language tagged predicates require @lang on the base predicate
+		for _, base := range langBases {
+			schema, exists := t.Schema[base]
+			if !exists {
+				schema = &Schema{Predicate: base, Type: "string"}
+				t.Schema[base] = schema
+			}
+			schema.Lang = true
+			t.Types[nodeType][base] = schema
+		}
 	}
 }
 
@@ -231,6 +338,10 @@ func getSchemaType(fieldType reflect.Type) string {
 		return schemaTyper.SchemaType()
 	}
 
+	if fieldType == bigFloatType {
+		return "bigfloat"
+	}
+
 	switch fieldType.Kind() {
 	case reflect.Interface:
 		return "uid"
@@ -287,8 +398,11 @@ func parseDgraphTag(field *reflect.StructField) (*Schema, error) {
 		schema.Count = dgraphProps.Count
 		schema.Reverse = dgraphProps.Reverse
 		schema.Unique = dgraphProps.Unique
+		schema.Required = dgraphProps.Required
 		schema.Noconflict = dgraphProps.Noconflict
 		schema.Lang = dgraphProps.Lang
+		schema.Directive = dgraphProps.Directive
+		schema.Set = dgraphProps.Set
 
 		if dgraphProps.Predicate != "" {
 			schema.Predicate = dgraphProps.Predicate
@@ -301,6 +415,39 @@ func parseDgraphTag(field *reflect.StructField) (*Schema, error) {
 		if schema.Index {
 			schema.Tokenizer = strings.Split(dgraphProps.Index, ",")
 		}
+
+		if dgraphProps.ReverseOf != "" {
+			if schema.Type != schemaUid && schema.Type != schemaUidList {
+				return nil, fmt.Errorf("reverse_of=%s is only supported on uid edges, got %s", dgraphProps.ReverseOf, schema.Type)
+			}
+			if strings.HasPrefix(dgraphProps.ReverseOf, "~") || isFacet(dgraphProps.ReverseOf) {
+				return nil, fmt.Errorf("reverse_of=%s must be a forward predicate", dgraphProps.ReverseOf)
+			}
+			// the field is the reverse edge of the forward predicate, without a predicate of its own
+			schema.Predicate = "~" + dgraphProps.ReverseOf
+			schema.ReverseOf = dgraphProps.ReverseOf
+		}
+
+		if dgraphProps.TTL {
+			if schema.Type != "datetime" {
+				return nil, fmt.Errorf("ttl is only supported on datetime predicates, got %s", schema.Type)
+			}
+			if !schema.Index {
+				// expired nodes are queried with le(), which requires an index on datetime predicates
+				schema.Index = true
+				schema.Tokenizer = []string{"hour"}
+			}
+		}
+
+		if dgraphProps.Cardinality != "" {
+			if dgraphProps.Cardinality != CardinalityOne {
+				return nil, fmt.Errorf("unsupported cardinality %q", dgraphProps.Cardinality)
+			}
+			if schema.Type != schemaUid || schema.List {
+				return nil, fmt.Errorf("cardinality=%s is only supported on uid edges", CardinalityOne)
+			}
+			schema.Cardinality = dgraphProps.Cardinality
+		}
 	}
 	return schema, nil
 }
@@ -332,7 +479,7 @@ func parseStructTag(tag string) (*rawSchema, error) {
 	return &schema, nil
 }
 
-func fetchExistingSchema(c *dgo.Dgraph) ([]*Schema, error) {
+func fetchExistingSchema(c DgraphClient) ([]*Schema, error) {
 	schemaQuery := `
 		schema {
 			type
@@ -374,7 +521,7 @@ type typeQueryResponse struct {
 	} `json:"types"`
 }
 
-func fetchExistingTypes(c *dgo.Dgraph, typeMap TypeMap) (TypeMap, error) {
+func fetchExistingTypes(c DgraphClient, typeMap TypeMap) (TypeMap, error) {
 	// get keys of typeMap
 	keys := make([]string, 0, len(typeMap))
 	for key := range typeMap {
@@ -406,60 +553,179 @@ func fetchExistingTypes(c *dgo.Dgraph, typeMap TypeMap) (TypeMap, error) {
 	return types, nil
 }
 
-func cleanExistingSchema(c *dgo.Dgraph, schemaMap SchemaMap) error {
+func cleanExistingSchema(c DgraphClient, typeSchema *TypeSchema) error {
 	existingSchema, err := fetchExistingSchema(c)
 	if err != nil {
 		return err
 	}
 
 	for _, schema := range existingSchema {
-		if s, exists := schemaMap[schema.Predicate]; exists {
+		if s, exists := typeSchema.Schema[schema.Predicate]; exists {
 			if s.String() != schema.String() {
-				log.Printf("existing schema %s, already defined as \"%s\", trying to install \"%s\"\n", schema.Predicate, schema.String(), s.String())
+				typeSchema.Conflicts = append(typeSchema.Conflicts, SchemaConflict{
+					Predicate:   schema.Predicate,
+					NodeType:    predicateNodeType(typeSchema.Types, schema.Predicate),
+					Defined:     schema.String(),
+					Conflicting: s.String(),
+					Existing:    true,
+				})
 			}
 
-			delete(schemaMap, schema.Predicate)
+			delete(typeSchema.Schema, schema.Predicate)
 		}
 	}
 
 	return nil
 }
 
+// predicateNodeType returns the first node type, in alphabetical order, defining a predicate
+func predicateNodeType(types TypeMap, predicate string) string {
+	nodeType := ""
+	for name, schemaMap := range types {
+		if _, ok := schemaMap[predicate]; ok && (nodeType == "" || name < nodeType) {
+			nodeType = name
+		}
+	}
+	return nodeType
+}
+
 // CreateSchema generate indexes, schema, and types from struct models,
 // returns the created schema map and types, does not update duplicate/conflict predicates.
-func CreateSchema(c *dgo.Dgraph, models ...interface{}) (*TypeSchema, error) {
+// Conflicting predicates are reported in TypeSchema.Conflicts, or returned as a SchemaConflictError
+// without altering the schema when StrictSchema is set in the client options.
+// When passed the dgo client of Client.Dgraph, the soft delete types of the models are registered on the client,
+// as in Client.RegisterSoftDelete.
+func CreateSchema(c DgraphClient, models ...interface{}) (*TypeSchema, error) {
+	return CreateSchemaWithOptions(c, SchemaOptions{}, models...)
+}
+
+// CreateSchemaWithOptions creates the schema of the models as in CreateSchema,
+// with the alter behavior specified by the schema options
+func CreateSchemaWithOptions(c DgraphClient, opts SchemaOptions, models ...interface{}) (*TypeSchema, error) {
 	typeSchema := NewTypeSchema()
 	typeSchema.Marshal("", models...)
 
-	err := cleanExistingSchema(c, typeSchema.Schema)
+	err := cleanExistingSchema(c, typeSchema)
 	if err != nil {
 		return nil, err
 	}
 
-	alterString := typeSchema.String()
-	if alterString != "" {
-		if err = c.Alter(context.Background(), &api.Operation{Schema: alterString}); err != nil {
-			return nil, err
+	if getClientConfig(c).opts.StrictSchema {
+		if err := typeSchema.Err(); err != nil {
+			return typeSchema, err
 		}
 	}
+
+	if err := alterSchema(c, typeSchema, opts); err != nil {
+		return nil, err
+	}
+	getClientConfig(c).registerSoftDeletes(typeSchema.softDeletes)
 	return typeSchema, nil
 }
 
 // MutateSchema generate indexes and schema from struct models,
 // attempt updates for type, schema, and indexes.
-func MutateSchema(c *dgo.Dgraph, models ...interface{}) (*TypeSchema, error) {
+// Conflicting predicates between models and soft delete types are handled as in CreateSchema.
+func MutateSchema(c DgraphClient, models ...interface{}) (*TypeSchema, error) {
+	return MutateSchemaWithOptions(c, SchemaOptions{}, models...)
+}
+
+// MutateSchemaWithOptions updates the schema of the models as in MutateSchema,
+// with the alter behavior specified by the schema options
+func MutateSchemaWithOptions(c DgraphClient, opts SchemaOptions, models ...interface{}) (*TypeSchema, error) {
 	typeSchema := NewTypeSchema()
 	typeSchema.Marshal("", models...)
 
-	alterString := typeSchema.String()
-	if alterString != "" {
-		if err := c.Alter(context.Background(), &api.Operation{Schema: alterString}); err != nil {
-			return nil, err
+	if getClientConfig(c).opts.StrictSchema {
+		if err := typeSchema.Err(); err != nil {
+			return typeSchema, err
 		}
 	}
+
+	if err := alterSchema(c, typeSchema, opts); err != nil {
+		return nil, err
+	}
+	getClientConfig(c).registerSoftDeletes(typeSchema.softDeletes)
 	return typeSchema, nil
 }
 
+// ReverseEdge reports a reverse edge field defined in a model
+type ReverseEdge struct {
+	// Field is the struct field name
+	Field string
+	// Predicate is the reverse predicate, e.g: ~school
+	Predicate string
+	// Available is true when the predicate is defined with @reverse in the cluster schema
+	Available bool
+}
+
+func getReverseEdgeFields(modelType reflect.Type) []ReverseEdge {
+	var edges []ReverseEdge
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() == reflect.Struct && field.Anonymous {
+			edges = append(edges, getReverseEdgeFields(fieldType)...)
+			continue
+		}
+
+		s, err := parseDgraphTag(&field)
+		if err != nil {
+			log.Println("unmarshal dgraph tag: ", err)
+			continue
+		}
+
+		isReverse := s.Predicate != "" &&
+			s.Predicate[0] == '~' &&
+			!isFacet(s.Predicate) // don't parse facet
+		if isReverse {
+			edges = append(edges, ReverseEdge{Field: field.Name, Predicate: s.Predicate})
+		}
+	}
+	return edges
+}
+
+// AvailableReverseEdges reports the reverse edge fields (~predicate) of a model,
+// and whether they are available in the cluster schema, i.e: the predicate is defined with @reverse.
+// Reverse edges without @reverse silently return empty results when queried.
+func AvailableReverseEdges(c DgraphClient, model interface{}) ([]ReverseEdge, error) {
+	modelType, err := reflectType(model)
+	if err != nil {
+		return nil, err
+	}
+	if modelType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("model \"%s\" is not a struct", modelType.Name())
+	}
+
+	edges := getReverseEdgeFields(modelType)
+	if len(edges) == 0 {
+		return edges, nil
+	}
+
+	existingSchema, err := fetchExistingSchema(c)
+	if err != nil {
+		return nil, err
+	}
+
+	reversePredicates := newSet()
+	for _, schema := range existingSchema {
+		if schema.Reverse {
+			reversePredicates.Add(schema.Predicate)
+		}
+	}
+
+	for i := range edges {
+		edges[i].Available = reversePredicates.Has(edges[i].Predicate[1:])
+	}
+
+	return edges, nil
+}
+
 func getNodeType(dataType reflect.Type) string {
 	// get node type from struct name
 	nodeType := ""
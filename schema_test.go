@@ -17,6 +17,7 @@
 package dgman
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -131,6 +132,87 @@ func TestMarshalSchema(t *testing.T) {
 	assert.Contains(t, types["User"], "field_2")
 }
 
+func TestParseCardinality(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   reflect.StructField
+		want    string
+		wantErr bool
+	}{
+		{"one", reflect.StructField{Type: reflect.TypeOf(&School{}), Tag: `json:"school" dgraph:"cardinality=one"`}, CardinalityOne, false},
+		{"list", reflect.StructField{Type: reflect.TypeOf([]School{}), Tag: `json:"schools" dgraph:"cardinality=one"`}, "", true},
+		{"scalar", reflect.StructField{Type: reflect.TypeOf(""), Tag: `json:"name" dgraph:"cardinality=one"`}, "", true},
+		{"unsupported", reflect.StructField{Type: reflect.TypeOf(&School{}), Tag: `json:"school" dgraph:"cardinality=many"`}, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schema, err := parseDgraphTag(&test.field)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, test.want, schema.Cardinality)
+			}
+		})
+	}
+}
+
+type ExtendedNode struct {
+	UID     string   `json:"uid,omitempty"`
+	Name    string   `json:"name,omitempty" dgraph:"index=term directive=@future"`
+	Summary string   `json:"summary,omitempty" dgraph:"directive=\"@embedding @future(a: 1)\""`
+	DType   []string `json:"dgraph.type,omitempty"`
+}
+
+func (n *ExtendedNode) SchemaExtension() string {
+	return "vector: float32vector @index(hnsw) ."
+}
+
+func TestSchemaExtension(t *testing.T) {
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", ExtendedNode{})
+
+	assert.Equal(t, "name: string @index(term) @future .", typeSchema.Schema["name"].String())
+	assert.Equal(t, "summary: string @embedding @future(a: 1) .", typeSchema.Schema["summary"].String())
+	assert.Equal(t, []string{"vector: float32vector @index(hnsw) ."}, typeSchema.Extensions)
+	assert.Contains(t, typeSchema.String(), "vector: float32vector @index(hnsw) .")
+}
+
+type ConflictingUser struct {
+	UID   string   `json:"uid,omitempty"`
+	Name  string   `json:"name,omitempty" dgraph:"index=hash"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+type ConflictingAdmin struct {
+	UID   string   `json:"uid,omitempty"`
+	Name  string   `json:"name,omitempty" dgraph:"index=term"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+func TestSchemaConflicts(t *testing.T) {
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", ConflictingAdmin{}, ConflictingUser{})
+
+	assert.Equal(t, "name: string @index(term) .", typeSchema.Schema["name"].String())
+	assert.Equal(t, []SchemaConflict{
+		{
+			Predicate:   "name",
+			NodeType:    "ConflictingUser",
+			Defined:     "name: string @index(term) .",
+			Conflicting: "name: string @index(hash) .",
+		},
+	}, typeSchema.Conflicts)
+	assert.EqualError(t, typeSchema.Err(), `conflicting schema name on ConflictingUser, already defined as "name: string @index(term) .", trying to define "name: string @index(hash) ."`)
+
+	typeSchema = NewTypeSchema()
+	typeSchema.Marshal("", ConflictingAdmin{})
+	assert.Empty(t, typeSchema.Conflicts)
+	assert.NoError(t, typeSchema.Err())
+}
+
 func TestGetNodeType(t *testing.T) {
 	nodeTypeStruct := GetNodeType(User{})
 	nodeTypePtr := GetNodeType(&User{})
@@ -250,3 +332,30 @@ func Test_fetchExistingTypes(t *testing.T) {
 
 	assert.Len(t, types, 2)
 }
+
+type ReverseSchool struct {
+	UID      string   `json:"uid,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Students []User   `json:"~school,omitempty"`
+	Alumni   []User   `json:"~schools,omitempty"`
+	DType    []string `json:"dgraph.type"`
+}
+
+func TestAvailableReverseEdges(t *testing.T) {
+	c := newDgraphClient()
+	defer dropAll(c)
+
+	if _, err := CreateSchema(c, &User{}); err != nil {
+		t.Error(err)
+	}
+
+	edges, err := AvailableReverseEdges(c, &ReverseSchool{})
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, []ReverseEdge{
+		{Field: "Students", Predicate: "~school", Available: true},
+		{Field: "Alumni", Predicate: "~schools", Available: false},
+	}, edges)
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EdgeSelection selects the predicates of the nodes of an edge in Query.Select
+type EdgeSelection struct {
+	predicate string
+	fields    []interface{}
+}
+
+// Edge selects the predicates of the nodes of an edge in Query.Select, fields are predicates
+// or nested edge selections, only the uid and dgraph.type are selected when no fields are passed
+func Edge(predicate string, fields ...interface{}) EdgeSelection {
+	return EdgeSelection{predicate: predicate, fields: fields}
+}
+
+// Select selects the predicates of the model to query, instead of expanding all predicates as in All, e.g:
+//
+//	Select("name", "email", dgman.Edge("schools", "name"))
+//
+// Fields are predicates or json field names of the model, or edges selected with Edge,
+// validated against the model. Overridden predicates are aliased to the json field names.
+// The uid and dgraph.type are always selected,
+// and edge queries set with Query.Edge are applied to the selected edges,
+// as such Query.Edge should be called before Select.
+func (q *Query) Select(fields ...interface{}) *Query {
+	if q.model == nil {
+		q.err = errors.New("select requires a model")
+		return q
+	}
+
+	var buffer strings.Builder
+	if err := q.writeSelection(&buffer, getElemType(reflect.TypeOf(q.model)), fields, ""); err != nil {
+		q.err = errors.Wrap(err, "invalid select")
+		return q
+	}
+	q.query = buffer.String()
+	return q
+}
+
+// selectField returns the field of a node type by its predicate or json field name
+func selectField(modelType reflect.Type, name string) (reflect.StructField, string, error) {
+	for _, field := range modelFields(modelType) {
+		schema, err := parseDgraphTag(&field)
+		if err != nil {
+			return field, "", errors.Wrapf(err, "parse dgraph tag failed on %s.%s", modelType.Name(), field.Name)
+		}
+		if jsonName, _ := getPredicate(&field); jsonName == name || schema.Predicate == name {
+			return field, schema.Predicate, nil
+		}
+	}
+	return reflect.StructField{}, "", fmt.Errorf("%s is not a predicate of %s", name, modelType.Name())
+}
+
+// writeSelection writes the selected predicates of a node type, with the edge queries of selected edges
+func (q *Query) writeSelection(buffer *strings.Builder, modelType reflect.Type, fields []interface{}, path string) error {
+	buffer.WriteString("{\n\t\tuid\n\t\tdgraph.type")
+	for _, field := range fields {
+		var (
+			name       string
+			edgeFields []interface{}
+			isEdge     bool
+		)
+		switch field := field.(type) {
+		case string:
+			name = field
+		case EdgeSelection:
+			name, edgeFields, isEdge = field.predicate, field.fields, true
+		default:
+			return fmt.Errorf("unsupported select field %v of type %T", field, field)
+		}
+		if name == predicateUid || name == predicateDgraphType {
+			continue
+		}
+
+		structField, predicate, err := selectField(modelType, name)
+		if err != nil {
+			return err
+		}
+		// overridden predicates are aliased to the json field names, to be scanned into the fields
+		jsonName, _ := getPredicate(&structField)
+		buffer.WriteString("\n\t\t")
+		if jsonName != predicate {
+			buffer.WriteString(jsonName)
+			buffer.WriteString(": ")
+		}
+		buffer.WriteString(predicate)
+
+		fieldEdgeType := edgeNodeType(structField)
+		if fieldEdgeType == nil {
+			if isEdge {
+				return fmt.Errorf("%s is not an edge of %s", name, modelType.Name())
+			}
+			continue
+		}
+
+		// edge queries are set by the json field names of edges
+		edgePath := jsonName
+		if path != "" {
+			edgePath = path + "." + edgePath
+		}
+		if edge, ok := q.edges[edgePath]; ok {
+			edge.write(buffer)
+		}
+		buffer.WriteString(" ")
+		if err := q.writeSelection(buffer, fieldEdgeType, edgeFields, edgePath); err != nil {
+			return err
+		}
+	}
+	buffer.WriteString("\n\t}")
+	return nil
+}
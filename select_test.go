@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuerySelect(t *testing.T) {
+	tx, _ := newFakeTxnContext()
+
+	query := tx.Get(&[]EdgeStudent{}).
+		Edge("schools", EdgeQuery{OrderAsc: "rank"}).
+		Select("name", Edge("schools", "name", Edge("teachers", "name")))
+	require.NoError(t, query.err)
+	assert.Equal(t, `{
+	data(func: type(EdgeStudent)) @filter(has(dgraph.type)) {
+		uid
+		dgraph.type
+		name
+		schools (orderasc: rank) {
+			uid
+			dgraph.type
+			name
+			teachers {
+				uid
+				dgraph.type
+				name
+			}
+		}
+	}
+}`, query.String())
+}
+
+func TestQuerySelectOverriddenPredicate(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"data":[{"uid":"0x1","externalId":"x1","name":"book"}]}`),
+	})
+
+	var products []OverrideProduct
+	// predicates can be selected by the predicate or the json field name
+	err := tx.Get(&products).Select("externalId", "product_name").Nodes()
+	require.NoError(t, err)
+	// overridden predicates are aliased to the json field names
+	assert.Contains(t, fake.requests[0].Query, "externalId: ext_id\n")
+	assert.Contains(t, fake.requests[0].Query, "name: product_name\n")
+	assert.NotContains(t, fake.requests[0].Query, "expand(_all_)")
+	require.Len(t, products, 1)
+	assert.Equal(t, "x1", products[0].ExternalID)
+	assert.Equal(t, "book", products[0].Name)
+}
+
+func TestQuerySelectInvalid(t *testing.T) {
+	tx, _ := newFakeTxnContext()
+
+	for _, fields := range [][]interface{}{
+		{"unknown"},
+		{Edge("name", "uid")},
+		{Edge("schools", "unknown")},
+		{1},
+	} {
+		query := tx.Get(&[]EdgeStudent{}).Select(fields...)
+		assert.Error(t, query.err, fields)
+	}
+
+	query := (&Query{}).Select("name")
+	assert.Error(t, query.err)
+}
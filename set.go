@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+)
+
+// dedupeSlice returns a copy of a slice without duplicate values, keeping the first occurrence,
+// slices of non-comparable values are returned as is
+func dedupeSlice(slice reflect.Value) reflect.Value {
+	if !slice.Type().Elem().Comparable() {
+		return slice
+	}
+	seen := make(map[interface{}]bool, slice.Len())
+	deduped := reflect.MakeSlice(slice.Type(), 0, slice.Len())
+	for i := 0; i < slice.Len(); i++ {
+		value := slice.Index(i)
+		key := value.Interface()
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			// non-comparable value in an interface slice
+			deduped = reflect.Append(deduped, value)
+			continue
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		deduped = reflect.Append(deduped, value)
+	}
+	return deduped
+}
+
+// AddToSet adds values to a list predicate of a node, treating the list as a set.
+// Each value is only added when the node does not have it yet, using a conditional upsert,
+// so repeated ingestion of the same values does not grow the list.
+func (t *TxnContext) AddToSet(uid, predicate string, values ...interface{}) error {
+	if len(values) == 0 {
+		return errors.New("values cannot be empty")
+	}
+	values = dedupeSlice(reflect.ValueOf(values)).Interface().([]interface{})
+
+	var queryBuf strings.Builder
+	nodes := make([]map[string]interface{}, len(values))
+	queryBuf.WriteString("{\n")
+	for i, value := range values {
+		jsonValue, err := json.Marshal(value)
+		if err != nil {
+			return errors.Wrapf(err, "marshal %v", value)
+		}
+
+		variable := fmt.Sprintf("s_%d", i)
+		fmt.Fprintf(&queryBuf, "\t%s as var(func: uid(%s)) @filter(NOT eq(%s, %s))\n", variable, uid, predicate, jsonValue)
+		nodes[i] = map[string]interface{}{
+			predicateUid: fmt.Sprintf("uid(%s)", variable),
+			predicate:    value,
+		}
+	}
+	queryBuf.WriteString("}")
+
+	setJSON, err := json.Marshal(nodes)
+	if err != nil {
+		return errors.Wrap(err, "marshal setJSON failed")
+	}
+
+	_, err = t.txn.Do(t.ctx, &api.Request{
+		Query:     FormatQuery(queryBuf.String()),
+		Mutations: []*api.Mutation{{SetJson: setJSON}},
+		CommitNow: t.commitNow,
+	})
+	if err != nil {
+		return errors.Wrap(err, "add to set request failed")
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type SetArticle struct {
+	UID   string   `json:"uid,omitempty"`
+	Title string   `json:"title,omitempty"`
+	Tags  []string `json:"tags,omitempty" dgraph:"list set"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+func TestMutateSetDedupe(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	article := SetArticle{Title: "sets", Tags: []string{"go", "dgraph", "go"}}
+	_, err := tx.Mutate(&article)
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+	assert.Contains(t, string(fake.requests[0].Mutations[0].SetJson), `"tags":["go","dgraph"]`)
+	// mutation data is not modified
+	assert.Equal(t, []string{"go", "dgraph", "go"}, article.Tags)
+}
+
+func TestAddToSet(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	require.NoError(t, tx.AddToSet("0x1", "tags", "go", "dgraph", "go"))
+	require.Len(t, fake.requests, 1)
+	req := fake.requests[0]
+	assert.Equal(t, "{\n\ts_0 as var(func: uid(0x1)) @filter(NOT eq(tags, \"go\"))\n\ts_1 as var(func: uid(0x1)) @filter(NOT eq(tags, \"dgraph\"))\n}", req.Query)
+	assert.JSONEq(t, `[{"uid":"uid(s_0)","tags":"go"},{"uid":"uid(s_1)","tags":"dgraph"}]`, string(req.Mutations[0].SetJson))
+
+	assert.Error(t, tx.AddToSet("0x1", "tags"))
+}
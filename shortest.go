@@ -0,0 +1,180 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// predicatePath is the result key of shortest path query blocks
+const predicatePath = "_path_"
+
+// Path is a path between two nodes returned by a shortest path query
+type Path struct {
+	// UIDs are the uids of the nodes along the path, from the source to the destination node
+	UIDs []string
+	// Edges are the edge predicates traversed between the nodes along the path
+	Edges []string
+	// Weight is the total weight of the path, i.e: the number of edges,
+	// or the sum of the weight facets of the edges
+	Weight float64
+}
+
+type shortestEdge struct {
+	predicate string
+	facet     string
+}
+
+// ShortestPath builds a shortest path query, which finds the shortest paths
+// between two nodes along the specified edge predicates
+type ShortestPath struct {
+	ctx      context.Context
+	tx       transaction
+	hooks    *Hooks
+	from     string
+	to       string
+	numPaths int
+	depth    int
+	edges    []shortestEdge
+}
+
+// ShortestPath prepares a shortest path query from a node to another node,
+// using the transaction of the query block
+func (q *QueryBlock) ShortestPath(from, to string) *ShortestPath {
+	return &ShortestPath{ctx: q.ctx, tx: q.tx, hooks: q.hooks, from: from, to: to}
+}
+
+// NumPaths returns the n shortest paths, instead of only the shortest path
+func (p *ShortestPath) NumPaths(n int) *ShortestPath {
+	p.numPaths = n
+	return p
+}
+
+// Depth limits the number of edges traversed by the paths
+func (p *ShortestPath) Depth(n int) *ShortestPath {
+	p.depth = n
+	return p
+}
+
+// Edge adds an edge predicate to traverse, with an optional facet as the edge weight,
+// otherwise each edge has a weight of 1
+func (p *ShortestPath) Edge(predicate string, weightFacet ...string) *ShortestPath {
+	edge := shortestEdge{predicate: predicate}
+	if len(weightFacet) > 0 {
+		edge.facet = weightFacet[0]
+	}
+	p.edges = append(p.edges, edge)
+	return p
+}
+
+func (p *ShortestPath) validate() error {
+	if p.from == "" || p.to == "" {
+		return errors.New("shortest path requires from and to nodes")
+	}
+	if len(p.edges) == 0 {
+		return errors.New("shortest path requires at least an edge predicate")
+	}
+	for _, edge := range p.edges {
+		if !predicateRegex.MatchString(edge.predicate) {
+			return fmt.Errorf("invalid shortest path edge predicate %q", edge.predicate)
+		}
+	}
+	return nil
+}
+
+func (p *ShortestPath) String() string {
+	var queryBuf strings.Builder
+	fmt.Fprintf(&queryBuf, "{\n\tshortest(from: %s, to: %s", p.from, p.to)
+	if p.numPaths > 0 {
+		fmt.Fprintf(&queryBuf, ", numpaths: %d", p.numPaths)
+	}
+	if p.depth > 0 {
+		fmt.Fprintf(&queryBuf, ", depth: %d", p.depth)
+	}
+	queryBuf.WriteString(") {\n")
+	for _, edge := range p.edges {
+		queryBuf.WriteString("\t\t")
+		queryBuf.WriteString(edge.predicate)
+		if edge.facet != "" {
+			fmt.Fprintf(&queryBuf, " @facets(%s)", edge.facet)
+		}
+		queryBuf.WriteString("\n")
+	}
+	queryBuf.WriteString("\t}\n}")
+	return FormatQuery(queryBuf.String())
+}
+
+// Paths returns the shortest paths, ordered from the shortest,
+// returns an empty slice when the nodes are not connected
+func (p *ShortestPath) Paths() ([]Path, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	resp, err := sendQuery(p.ctx, p.tx, p.hooks, p.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return p.parsePaths(resp.Json)
+}
+
+func (p *ShortestPath) parsePaths(result []byte) ([]Path, error) {
+	var pathResult map[string][]map[string]interface{}
+	if err := json.Unmarshal(result, &pathResult); err != nil {
+		return nil, errors.Wrap(err, "unmarshal shortest path result failed")
+	}
+
+	pathNodes := pathResult[predicatePath]
+	paths := make([]Path, 0, len(pathNodes))
+	for _, node := range pathNodes {
+		paths = append(paths, p.parsePath(node))
+	}
+	return paths, nil
+}
+
+// parsePath flattens a path, which is returned as nested nodes along the traversed edges
+func (p *ShortestPath) parsePath(node map[string]interface{}) Path {
+	var path Path
+	path.Weight, _ = node["_weight_"].(float64)
+
+	for node != nil {
+		uid, _ := node[predicateUid].(string)
+		path.UIDs = append(path.UIDs, uid)
+
+		var next map[string]interface{}
+		for _, edge := range p.edges {
+			switch edgeValue := node[edge.predicate].(type) {
+			case map[string]interface{}:
+				next = edgeValue
+			case []interface{}:
+				if len(edgeValue) > 0 {
+					next, _ = edgeValue[0].(map[string]interface{})
+				}
+			}
+			if next != nil {
+				path.Edges = append(path.Edges, edge.predicate)
+				break
+			}
+		}
+		node = next
+	}
+	return path
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortestPath(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{Json: []byte(`{"_path_": [
+		{"uid": "0x1", "friend": {"uid": "0x2", "follows": [{"uid": "0x3"}]}, "_weight_": 3.5},
+		{"uid": "0x1", "friend": {"uid": "0x4", "friend": {"uid": "0x3"}}, "_weight_": 4}
+	]}`)})
+
+	paths, err := tx.Query().ShortestPath("0x1", "0x3").
+		NumPaths(2).
+		Depth(3).
+		Edge("friend", "weight").
+		Edge("follows").
+		Paths()
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+	assert.Equal(t, "{\n\tshortest(from: 0x1, to: 0x3, numpaths: 2, depth: 3) {\n\t\tfriend @facets(weight)\n\t\tfollows\n\t}\n}", fake.requests[0].Query)
+
+	assert.Equal(t, []Path{
+		{UIDs: []string{"0x1", "0x2", "0x3"}, Edges: []string{"friend", "follows"}, Weight: 3.5},
+		{UIDs: []string{"0x1", "0x4", "0x3"}, Edges: []string{"friend", "friend"}, Weight: 4},
+	}, paths)
+}
+
+func TestShortestPathNotConnected(t *testing.T) {
+	tx, _ := newFakeTxnContext(&api.Response{Json: []byte(`{}`)})
+
+	paths, err := tx.Query().ShortestPath("0x1", "0x3").Edge("friend").Paths()
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+}
+
+func TestShortestPathInvalid(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	_, err := tx.Query().ShortestPath("0x1", "0x3").Paths()
+	assert.Error(t, err)
+	_, err = tx.Query().ShortestPath("0x1", "").Edge("friend").Paths()
+	assert.Error(t, err)
+	_, err = tx.Query().ShortestPath("0x1", "0x3").Edge("friend {").Paths()
+	assert.Error(t, err)
+	assert.Empty(t, fake.requests)
+}
@@ -0,0 +1,222 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+)
+
+// softDeleteModels caches the soft delete predicate of model types
+var softDeleteModels sync.Map
+
+// softDeleteNow returns the soft delete timestamp, replaceable in tests
+var softDeleteNow = time.Now
+
+// softDeletePredicate returns the soft delete predicate of a model type,
+// i.e: the predicate of the field tagged with dgraph:"softdelete",
+// returns an empty string when the model type does not support soft deletes
+func softDeletePredicate(modelType reflect.Type) string {
+	modelType = getElemType(modelType)
+	if modelType.Kind() != reflect.Struct {
+		return ""
+	}
+	if predicate, ok := softDeleteModels.Load(modelType); ok {
+		return predicate.(string)
+	}
+
+	predicate := softDeleteField(modelType)
+	softDeleteModels.Store(modelType, predicate)
+	return predicate
+}
+
+func softDeleteField(modelType reflect.Type) string {
+	return taggedField(modelType, func(props *rawSchema) bool { return props.SoftDelete })
+}
+
+// taggedField returns the predicate of the first field of a model type, including the fields
+// of anonymous structs, with dgraph tag properties matching a condition
+func taggedField(modelType reflect.Type, match func(props *rawSchema) bool) string {
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && field.Anonymous {
+			if predicate := taggedField(fieldType, match); predicate != "" {
+				return predicate
+			}
+			continue
+		}
+
+		dgraphTag := field.Tag.Get(tagName)
+		if dgraphTag == "" {
+			continue
+		}
+		props, err := parseStructTag(dgraphTag)
+		if err != nil || !match(props) {
+			continue
+		}
+		predicate, _ := getPredicate(&field)
+		if props.Predicate != "" {
+			predicate = props.Predicate
+		}
+		return predicate
+	}
+	return ""
+}
+
+// modelSoftDeletePredicate returns the soft delete predicate of a model
+func modelSoftDeletePredicate(model interface{}) string {
+	if model == nil {
+		return ""
+	}
+	return softDeletePredicate(reflect.TypeOf(model))
+}
+
+type softDeleteType struct {
+	nodeType  string
+	predicate string
+}
+
+// RegisterSoftDelete registers the soft delete node types of models for DeleteNode of transactions created
+// from the client, as done by CreateSchema and MutateSchema with the client, models without soft deletes are ignored
+func (c *Client) RegisterSoftDelete(models ...interface{}) *Client {
+	softDeletes := make(map[string]string)
+	for _, model := range models {
+		if predicate := modelSoftDeletePredicate(model); predicate != "" {
+			softDeletes[GetNodeType(model)] = predicate
+		}
+	}
+	c.dg.config.registerSoftDeletes(softDeletes)
+	return c
+}
+
+// registerSoftDeletes registers soft delete node types by their soft delete predicate
+func (c *clientConfig) registerSoftDeletes(softDeletes map[string]string) {
+	if len(softDeletes) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.softDeletes == nil {
+		c.softDeletes = make(map[string]string)
+	}
+	for nodeType, predicate := range softDeletes {
+		c.softDeletes[nodeType] = predicate
+	}
+}
+
+// getSoftDeleteTypes returns the registered soft delete node types of a client, or the soft delete node types
+// of the models parsed by the process, e.g: by CreateSchema or queries, for dgo clients without a Client
+func (c *clientConfig) getSoftDeleteTypes() map[string]string {
+	softDeletes := make(map[string]string)
+	if c == nil {
+		return softDeletes
+	}
+	if c.parsedSoftDeletes {
+		softDeleteModels.Range(func(modelType, predicate interface{}) bool {
+			if predicate := predicate.(string); predicate != "" {
+				softDeletes[getNodeType(modelType.(reflect.Type))] = predicate
+			}
+			return true
+		})
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for nodeType, predicate := range c.softDeletes {
+		softDeletes[nodeType] = predicate
+	}
+	return softDeletes
+}
+
+// softDeleteTypes returns the soft delete node types registered on the client of the transaction,
+// along with the soft delete node types of the passed models, sorted by node type
+func (d *TxnContext) softDeleteTypes(models ...interface{}) []softDeleteType {
+	softDeletes := d.config.getSoftDeleteTypes()
+	for _, model := range models {
+		if predicate := modelSoftDeletePredicate(model); predicate != "" {
+			softDeletes[GetNodeType(model)] = predicate
+		}
+	}
+
+	types := make([]softDeleteType, 0, len(softDeletes))
+	for nodeType, predicate := range softDeletes {
+		types = append(types, softDeleteType{nodeType: nodeType, predicate: predicate})
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].nodeType < types[j].nodeType
+	})
+	return types
+}
+
+// softDeleteNode sets the soft delete predicate of nodes with a soft delete type to the current time,
+// and deletes the other nodes, in a single upsert request
+func (d *TxnContext) softDeleteNode(types []softDeleteType, uids ...string) error {
+	uidList := strings.Join(uids, ", ")
+	timestamp, err := json.Marshal(softDeleteNow())
+	if err != nil {
+		return errors.Wrap(err, "marshal soft delete timestamp failed")
+	}
+
+	var (
+		queryBuf  strings.Builder
+		setNquads bytes.Buffer
+		delNquads bytes.Buffer
+		typeFuncs []string
+	)
+	queryBuf.WriteString("{\n")
+	for i, softDelete := range types {
+		variable := fmt.Sprintf("s_%d", i)
+		typeFunc := fmt.Sprintf("type(%s)", softDelete.nodeType)
+		typeFuncs = append(typeFuncs, typeFunc)
+
+		fmt.Fprintf(&queryBuf, "\t%s as var(func: uid(%s)) @filter(%s)\n", variable, uidList, typeFunc)
+		fmt.Fprintf(&setNquads, "uid(%s) <%s> %s^^<xs:dateTime> .\n", variable, softDelete.predicate, timestamp)
+	}
+	fmt.Fprintf(&queryBuf, "\th as var(func: uid(%s)) @filter(NOT (%s))\n", uidList, strings.Join(typeFuncs, " OR "))
+	queryBuf.WriteString("}")
+	writeDeleteNode(&delNquads, "h")
+
+	if err := d.hooks.beforeDelete(d.ctx, uids); err != nil {
+		return err
+	}
+	_, err = d.txn.Do(d.ctx, &api.Request{
+		Query: FormatQuery(queryBuf.String()),
+		Mutations: []*api.Mutation{{
+			SetNquads: setNquads.Bytes(),
+			DelNquads: delNquads.Bytes(),
+		}},
+		CommitNow: d.commitNow,
+	})
+	if err != nil {
+		return errors.Wrap(err, "soft delete request failed")
+	}
+	return d.hooks.afterDelete(d.ctx, uids)
+}
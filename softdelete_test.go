@@ -0,0 +1,125 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210"
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type SoftDeletePost struct {
+	UID       string     `json:"uid,omitempty"`
+	Title     string     `json:"title,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" dgraph:"softdelete"`
+	DType     []string   `json:"dgraph.type,omitempty"`
+}
+
+func TestSoftDeleteQuery(t *testing.T) {
+	query := NewQuery().Model(&[]SoftDeletePost{}).Filter("eq(title, $1)", "dgman")
+	assert.Contains(t, query.String(), "@filter(has(dgraph.type) AND NOT has(deleted_at) AND eq(title, \"dgman\"))")
+
+	query = NewQuery().Model(&[]SoftDeletePost{}).WithDeleted()
+	assert.NotContains(t, query.String(), "deleted_at")
+
+	// models without soft delete are not filtered
+	assert.NotContains(t, NewQuery().Model(&[]OptionsAccount{}).String(), "NOT has(")
+}
+
+func TestSoftDeleteNode(t *testing.T) {
+	defer func() { softDeleteNow = time.Now }()
+	softDeleteNow = func() time.Time {
+		return time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	tx, fake := newFakeTxnContext()
+	tx.config = NewClient().RegisterSoftDelete(&SoftDeletePost{}, &OptionsAccount{}).dg.config
+
+	require.NoError(t, tx.DeleteNode("0x1", "0x2"))
+	require.Len(t, fake.requests, 1)
+	req := fake.requests[0]
+	assert.Contains(t, req.Query, "s_0 as var(func: uid(0x1, 0x2)) @filter(type(SoftDeletePost))")
+	assert.Contains(t, req.Query, "h as var(func: uid(0x1, 0x2)) @filter(NOT (type(SoftDeletePost)))")
+	require.Len(t, req.Mutations, 1)
+	assert.Equal(t, "uid(s_0) <deleted_at> \"2021-01-01T00:00:00Z\"^^<xs:dateTime> .\n", string(req.Mutations[0].SetNquads))
+	assert.Equal(t, "uid(h) * * .\n", string(req.Mutations[0].DelNquads))
+
+	require.NoError(t, tx.HardDelete("0x1"))
+	require.Len(t, fake.requests, 2)
+	assert.Empty(t, fake.requests[1].Query)
+	assert.Equal(t, "<0x1> * * .\n", string(fake.requests[1].Mutations[0].DelNquads))
+}
+
+func TestSoftDeleteNodeInvalidUID(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+	tx.config = NewClient().RegisterSoftDelete(&SoftDeletePost{}).dg.config
+
+	assert.EqualError(t, tx.DeleteNode("0x1) { x as uid } }"), `invalid uid "0x1) { x as uid } }"`)
+	assert.Error(t, tx.HardDelete("0x1", "_:x"))
+	assert.Empty(t, fake.requests)
+}
+
+func TestSoftDeleteNodeUnregistered(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+	tx.config = NewClient().dg.config
+	// querying a soft delete model does not register its node type on a client
+	_ = tx.Get(&SoftDeletePost{}).String()
+
+	require.NoError(t, tx.DeleteNode("0x1"))
+	require.Len(t, fake.requests, 1)
+	assert.Empty(t, fake.requests[0].Query)
+	assert.Equal(t, "<0x1> * * .\n", string(fake.requests[0].Mutations[0].DelNquads))
+}
+
+func TestSoftDeleteNodeDgoClient(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+	tx.config = getClientConfig(dgo.NewDgraphClient(&alterClient{}))
+	// the soft delete types of parsed models are used without a Client
+	_ = tx.Get(&SoftDeletePost{}).String()
+
+	require.NoError(t, tx.DeleteNode("0x1"))
+	require.Len(t, fake.requests, 1)
+	assert.Contains(t, fake.requests[0].Query, "as var(func: uid(0x1)) @filter(type(SoftDeletePost))")
+	assert.Contains(t, string(fake.requests[0].Mutations[0].SetNquads), "<deleted_at>")
+}
+
+func TestSoftDeleteSchemaRegistration(t *testing.T) {
+	dc := &alterClient{}
+	c := NewClient(dc)
+
+	_, err := MutateSchema(c.Dgraph(), &SoftDeletePost{}, &OptionsAccount{})
+	require.NoError(t, err)
+	assert.Equal(t, []softDeleteType{{nodeType: "SoftDeletePost", predicate: "deleted_at"}}, c.NewTxn().softDeleteTypes())
+
+	// other clients are not affected
+	assert.Empty(t, NewClient(dc).NewTxn().softDeleteTypes())
+}
+
+func TestSoftDeleteWhere(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{Json: []byte(`{"data":[{"uid":"0x1"}]}`)})
+
+	// the soft delete type of the model is used without registration
+	uids, err := tx.DeleteWhere(&SoftDeletePost{}, Eq("title", "dgman"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0x1"}, uids)
+	require.Len(t, fake.requests, 2)
+	assert.Contains(t, fake.requests[1].Query, "s_0 as var(func: uid(0x1)) @filter(type(SoftDeletePost))")
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"bytes"
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// Stream streams the nodes of a query to fn one node at a time, e.g: for exporting millions of nodes.
+// The query is paginated by uid with first and after in pages of pageSize nodes, and the nodes of each page
+// are decoded one by one, so only a page of json is held in memory instead of the whole result set.
+// Streaming stops on the first error returned by fn or by a query, or when the context is done.
+//
+//	err := dgman.Stream(ctx, tx.Get(&User{}).Where(dgman.Eq("active", true)), 1000, func(user *User) error {
+//		return encoder.Encode(user)
+//	})
+func Stream[T any](ctx context.Context, q *Query, pageSize int, fn func(node *T) error) error {
+	if pageSize <= 0 {
+		return fmt.Errorf("invalid page size %d", pageSize)
+	}
+	if len(q.order) > 0 {
+		return errors.New("stream orders by uid, cannot be used with order")
+	}
+	if ctx != nil {
+		q.ctx = ctx
+	} else {
+		ctx = context.Background()
+	}
+
+	q.first = pageSize
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result, err := q.executeQuery()
+		if err != nil {
+			return err
+		}
+
+		count, lastUID, err := streamNodes(q, result, fn)
+		if err != nil {
+			return err
+		}
+		if count < pageSize {
+			return nil
+		}
+		if lastUID == "" {
+			return errors.New("stream requires a uid field on the node")
+		}
+		q.after = lastUID
+	}
+}
+
+// streamNodes decodes the nodes of a query result one by one, returning the number of nodes
+// and the uid of the last node
+func streamNodes[T any](q *Query, result []byte, fn func(node *T) error) (count int, lastUID string, err error) {
+	decoder := stdjson.NewDecoder(bytes.NewReader(result))
+	if err := expectDelim(decoder, '{'); err != nil {
+		return 0, "", err
+	}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return 0, "", errors.Wrap(err, "decode query result failed")
+		}
+		if key != q.name {
+			var skipped stdjson.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return 0, "", errors.Wrap(err, "decode query result failed")
+			}
+			continue
+		}
+
+		if err := expectDelim(decoder, '['); err != nil {
+			return 0, "", err
+		}
+		for decoder.More() {
+			var data stdjson.RawMessage
+			if err := decoder.Decode(&data); err != nil {
+				return count, lastUID, errors.Wrap(err, "decode node failed")
+			}
+			node := new(T)
+			if err := unmarshalNodes(data, node, q.aliases); err != nil {
+				return count, lastUID, err
+			}
+			if err := compute(node); err != nil {
+				return count, lastUID, err
+			}
+			if err := fn(node); err != nil {
+				return count, lastUID, err
+			}
+			count++
+			lastUID = nodeUID(reflect.ValueOf(node))
+		}
+		if err := expectDelim(decoder, ']'); err != nil {
+			return count, lastUID, err
+		}
+	}
+	return count, lastUID, nil
+}
+
+func expectDelim(decoder *stdjson.Decoder, delim stdjson.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return errors.Wrap(err, "decode query result failed")
+	}
+	if token != delim {
+		return fmt.Errorf("invalid json result for stream, expected %v got %v", delim, token)
+	}
+	return nil
+}
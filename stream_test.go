@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream(t *testing.T) {
+	tx, fake := newFakeTxnContext(
+		&api.Response{Json: []byte(`{"data":[{"uid":"0x1","username":"a"},{"uid":"0x2","username":"b"}],"extra":[]}`)},
+		&api.Response{Json: []byte(`{"data":[{"uid":"0x3","username":"c"}]}`)},
+	)
+
+	var usernames []string
+	err := Stream(context.Background(), tx.Get(&OptionsAccount{}), 2, func(account *OptionsAccount) error {
+		usernames = append(usernames, account.Username)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, usernames)
+	require.Len(t, fake.requests, 2)
+	assert.Contains(t, fake.requests[0].Query, "first: 2)")
+	assert.Contains(t, fake.requests[1].Query, "first: 2, after: 0x2)")
+}
+
+func TestStreamStop(t *testing.T) {
+	tx, fake := newFakeTxnContext(
+		&api.Response{Json: []byte(`{"data":[{"uid":"0x1"},{"uid":"0x2"}]}`)},
+	)
+
+	stopErr := errors.New("stop")
+	count := 0
+	err := Stream(context.Background(), tx.Get(&OptionsAccount{}), 2, func(account *OptionsAccount) error {
+		count++
+		return stopErr
+	})
+	assert.Equal(t, stopErr, err)
+	assert.Equal(t, 1, count)
+	assert.Len(t, fake.requests, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Stream(ctx, tx.Get(&OptionsAccount{}), 2, func(account *OptionsAccount) error { return nil })
+	assert.Equal(t, context.Canceled, err)
+	assert.Len(t, fake.requests, 1)
+
+	err = Stream(context.Background(), tx.Get(&OptionsAccount{}).OrderAsc("username"), 2, func(account *OptionsAccount) error { return nil })
+	assert.Error(t, err)
+	err = Stream(context.Background(), tx.Get(&OptionsAccount{}), 0, func(account *OptionsAccount) error { return nil })
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+)
+
+// timeoutTxn applies a default timeout to requests with contexts without a deadline
+type timeoutTxn struct {
+	transaction
+	timeout time.Duration
+}
+
+func (t *timeoutTxn) unwrap() transaction {
+	return t.transaction
+}
+
+func (t *timeoutTxn) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, t.timeout)
+}
+
+func (t *timeoutTxn) Query(ctx context.Context, q string) (*api.Response, error) {
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+	return t.transaction.Query(ctx, q)
+}
+
+func (t *timeoutTxn) QueryWithVars(ctx context.Context, q string, vars map[string]string) (*api.Response, error) {
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+	return t.transaction.QueryWithVars(ctx, q, vars)
+}
+
+func (t *timeoutTxn) Mutate(ctx context.Context, mu *api.Mutation) (*api.Response, error) {
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+	return t.transaction.Mutate(ctx, mu)
+}
+
+func (t *timeoutTxn) Do(ctx context.Context, req *api.Request) (*api.Response, error) {
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+	return t.transaction.Do(ctx, req)
+}
+
+func (t *timeoutTxn) Commit(ctx context.Context) error {
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+	return t.transaction.Commit(ctx)
+}
+
+func (t *timeoutTxn) Discard(ctx context.Context) error {
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+	return t.transaction.Discard(ctx)
+}
+
+// withTimeout returns a context with a timeout, or the context when the timeout is not set
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Timeout sets a timeout of the query, overriding the default timeout of the client options,
+// the query is sent with a context with a deadline of the timeout
+func (q *Query) Timeout(d time.Duration) *Query {
+	q.timeout = d
+	return q
+}
+
+// timeout returns the shortest timeout of the query blocks
+func (q *QueryBlock) timeout() time.Duration {
+	var timeout time.Duration
+	for _, block := range q.blocks {
+		if block.timeout > 0 && (timeout == 0 || block.timeout < timeout) {
+			timeout = block.timeout
+		}
+	}
+	return timeout
+}
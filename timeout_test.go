@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deadlineTxn records the deadlines of the request contexts
+type deadlineTxn struct {
+	*fakeTxn
+	deadlines []time.Duration
+}
+
+func (d *deadlineTxn) record(ctx context.Context) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		d.deadlines = append(d.deadlines, 0)
+		return
+	}
+	d.deadlines = append(d.deadlines, time.Until(deadline))
+}
+
+func (d *deadlineTxn) Query(ctx context.Context, q string) (*api.Response, error) {
+	d.record(ctx)
+	return d.fakeTxn.Query(ctx, q)
+}
+
+func (d *deadlineTxn) Mutate(ctx context.Context, mu *api.Mutation) (*api.Response, error) {
+	d.record(ctx)
+	return d.fakeTxn.Mutate(ctx, mu)
+}
+
+func (d *deadlineTxn) Do(ctx context.Context, req *api.Request) (*api.Response, error) {
+	d.record(ctx)
+	return d.fakeTxn.Do(ctx, req)
+}
+
+func newDeadlineTxnContext(responses ...*api.Response) (*TxnContext, *deadlineTxn) {
+	tx, fake := newFakeTxnContext(responses...)
+	txn := &deadlineTxn{fakeTxn: fake}
+	tx.txn = txn
+	return tx, txn
+}
+
+func TestClientOptionsTimeout(t *testing.T) {
+	tx, txn := newDeadlineTxnContext()
+	tx.SetOptions(&ClientOptions{Timeout: time.Minute})
+	// options can be set again without stacking timeouts
+	tx.SetOptions(&ClientOptions{Timeout: time.Second})
+	_, ok := tx.txn.(*timeoutTxn).transaction.(*deadlineTxn)
+	assert.True(t, ok)
+
+	_, err := tx.MutateBasic(&TestModel{Name: "wildan"})
+	require.NoError(t, err)
+
+	// contexts with a deadline are kept
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	tx.WithContext(ctx)
+	_, err = tx.MutateBasic(&TestModel{Name: "wildan"})
+	require.NoError(t, err)
+
+	require.Len(t, txn.deadlines, 2)
+	assert.True(t, txn.deadlines[0] > 0 && txn.deadlines[0] <= time.Second, txn.deadlines[0])
+	assert.True(t, txn.deadlines[1] > time.Minute, txn.deadlines[1])
+}
+
+func TestQueryTimeout(t *testing.T) {
+	tx, txn := newDeadlineTxnContext(
+		&api.Response{Json: []byte(`{"data":[]}`)},
+		&api.Response{Json: []byte(`{"data":[]}`)},
+		&api.Response{Json: []byte(`{"data":[]}`)},
+	)
+	tx.SetOptions(&ClientOptions{Timeout: time.Hour})
+
+	var models []*TestModel
+	require.NoError(t, tx.Get(&models).Timeout(time.Second).Nodes())
+	require.NoError(t, tx.Get(&models).Nodes())
+	require.NoError(t, tx.Query(
+		NewQuery().Model(&models).Timeout(time.Minute),
+		NewQuery().Model(&models).Name("other").Timeout(time.Second),
+	).Scan())
+
+	require.Len(t, txn.deadlines, 3)
+	assert.True(t, txn.deadlines[0] <= time.Second, txn.deadlines[0])
+	assert.True(t, txn.deadlines[1] > time.Minute, txn.deadlines[1])
+	assert.True(t, txn.deadlines[2] <= time.Second, txn.deadlines[2])
+}
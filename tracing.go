@@ -0,0 +1,121 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+)
+
+const (
+	// SpanQuery is the span name of query requests
+	SpanQuery = "dgman.query"
+	// SpanMutation is the span name of mutation requests without a query
+	SpanMutation = "dgman.mutation"
+	// SpanUpsert is the span name of mutation requests with a query, e.g: unique checks
+	SpanUpsert = "dgman.upsert"
+	// SpanDelete is the span name of requests only deleting, with or without a query
+	SpanDelete = "dgman.delete"
+)
+
+// Span is a span around a request, started by a Tracer
+type Span interface {
+	// End ends the span with the metrics of the request, e.g: to set the span attributes and error status
+	End(metrics *RequestMetrics)
+}
+
+// Tracer starts spans around the requests of transactions, e.g: adapting an OpenTelemetry tracer,
+// the request is sent with the returned context, so the span can be propagated
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// SetTracer sets the tracer for requests of transactions created from the client,
+// passing nil removes the tracer of the client. Queries returned from a query cache are not traced.
+func (c *Client) SetTracer(tracer Tracer) *Client {
+	c.dg.config.tracer = tracer
+	return c
+}
+
+// tracingTxn starts a span around each request of a transaction
+type tracingTxn struct {
+	transaction
+	tracer Tracer
+}
+
+// withTracing wraps a transaction to trace its requests, when the tracer is set
+func withTracing(txn transaction, tracer Tracer) transaction {
+	if tracer == nil {
+		return txn
+	}
+	return &tracingTxn{transaction: txn, tracer: tracer}
+}
+
+// isDelete returns whether mutations only delete
+func isDelete(mutations ...*api.Mutation) bool {
+	for _, mu := range mutations {
+		if len(mu.SetJson) > 0 || len(mu.SetNquads) > 0 || len(mu.Set) > 0 {
+			return false
+		}
+	}
+	return len(mutations) > 0
+}
+
+func (t *tracingTxn) unwrap() transaction {
+	return t.transaction
+}
+
+func (t *tracingTxn) trace(ctx context.Context, name, operation string, request func(ctx context.Context) (*api.Response, error)) (*api.Response, error) {
+	spanCtx, span := t.tracer.Start(ctx, name)
+	resp, err := request(spanCtx)
+	span.End(newRequestMetrics(ctx, operation, resp, err))
+	return resp, err
+}
+
+func (t *tracingTxn) Query(ctx context.Context, q string) (*api.Response, error) {
+	return t.trace(ctx, SpanQuery, MetricsQuery, func(ctx context.Context) (*api.Response, error) {
+		return t.transaction.Query(ctx, q)
+	})
+}
+
+func (t *tracingTxn) QueryWithVars(ctx context.Context, q string, vars map[string]string) (*api.Response, error) {
+	return t.trace(ctx, SpanQuery, MetricsQuery, func(ctx context.Context) (*api.Response, error) {
+		return t.transaction.QueryWithVars(ctx, q, vars)
+	})
+}
+
+func (t *tracingTxn) Mutate(ctx context.Context, mu *api.Mutation) (*api.Response, error) {
+	name := SpanMutation
+	if isDelete(mu) {
+		name = SpanDelete
+	}
+	return t.trace(ctx, name, MetricsMutation, func(ctx context.Context) (*api.Response, error) {
+		return t.transaction.Mutate(ctx, mu)
+	})
+}
+
+func (t *tracingTxn) Do(ctx context.Context, req *api.Request) (*api.Response, error) {
+	operation := requestOperation(req)
+	name := "dgman." + operation
+	if isDelete(req.Mutations...) {
+		name = SpanDelete
+	}
+	return t.trace(ctx, name, operation, func(ctx context.Context) (*api.Response, error) {
+		return t.transaction.Do(ctx, req)
+	})
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedSpan struct {
+	name    string
+	metrics *RequestMetrics
+}
+
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &recordedSpan{name: name}
+	r.spans = append(r.spans, span)
+	return ctx, span
+}
+
+func (s *recordedSpan) End(metrics *RequestMetrics) {
+	s.metrics = metrics
+}
+
+func TestTracer(t *testing.T) {
+	dc := &responseClient{responses: []*api.Response{
+		{
+			Json:    []byte(`{"users":[{"uid":"0x1","name":"wildan"}]}`),
+			Latency: &api.Latency{ProcessingNs: 20, TotalNs: 60},
+		},
+		{Uids: map[string]string{"user": "0x2"}},
+		{},
+		{Json: []byte(`{"data":[]}`)},
+	}}
+	client := NewClient(dc)
+	c := client.Dgraph()
+
+	tracer := &recordingTracer{}
+	client.SetTracer(tracer)
+
+	var users []TestModel
+	require.NoError(t, NewReadOnlyTxn(c).Get(&users).Name("users").Nodes())
+	require.Len(t, tracer.spans, 1)
+	assert.Equal(t, SpanQuery, tracer.spans[0].name)
+	assert.Equal(t, "users", tracer.spans[0].metrics.QueryName)
+	assert.Equal(t, "TestModel", tracer.spans[0].metrics.NodeType)
+	assert.Equal(t, 60*time.Nanosecond, tracer.spans[0].metrics.TotalLatency)
+
+	_, err := NewTxn(c).SetCommitNow().Mutate(&TestModel{UID: "_:user", Name: "dolan"})
+	require.NoError(t, err)
+	require.Len(t, tracer.spans, 2)
+	assert.Equal(t, SpanMutation, tracer.spans[1].name)
+	assert.Equal(t, "TestModel", tracer.spans[1].metrics.NodeType)
+
+	require.NoError(t, NewTxn(c).SetCommitNow().HardDelete("0x1"))
+	require.Len(t, tracer.spans, 3)
+	assert.Equal(t, SpanDelete, tracer.spans[2].name)
+
+	client.SetTracer(nil)
+	require.NoError(t, NewReadOnlyTxn(c).Get(&users).Nodes())
+	assert.Len(t, tracer.spans, 3)
+}
+
+func TestTracerBestEffort(t *testing.T) {
+	dc := &queryClient{}
+	client := NewClient(dc).SetTracer(&recordingTracer{})
+
+	tx := client.NewTxn()
+	require.NotNil(t, tx.Txn())
+	var models []TestModel
+	require.NoError(t, tx.Get(&models).BestEffort().Nodes())
+
+	require.NoError(t, client.NewReadOnlyTxn().BestEffort().Get(&models).Nodes())
+	require.Len(t, dc.requests, 2)
+	for _, req := range dc.requests {
+		assert.True(t, req.BestEffort)
+	}
+}
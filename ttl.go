@@ -0,0 +1,148 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ttlModels caches the ttl predicate of model types
+var ttlModels sync.Map
+
+// expiryNow returns the time nodes are expired at, replaceable in tests
+var expiryNow = time.Now
+
+// ttlPredicate returns the ttl predicate of a model type, i.e: the predicate of the datetime field
+// tagged with dgraph:"ttl", returns an empty string when the nodes of the model type do not expire
+func ttlPredicate(modelType reflect.Type) string {
+	modelType = getElemType(modelType)
+	if modelType.Kind() != reflect.Struct {
+		return ""
+	}
+	if predicate, ok := ttlModels.Load(modelType); ok {
+		return predicate.(string)
+	}
+
+	predicate := taggedField(modelType, func(props *rawSchema) bool { return props.TTL })
+	ttlModels.Store(modelType, predicate)
+	return predicate
+}
+
+type expiryModel struct {
+	model     interface{}
+	nodeType  string
+	predicate string
+}
+
+// ExpiryWorker periodically deletes the nodes of models with a ttl predicate, i.e: a datetime field
+// tagged with dgraph:"ttl", which time has passed, e.g: sessions or cache entries with an expires_at
+type ExpiryWorker struct {
+	// Interval is the interval between expiry runs
+	Interval time.Duration
+	// OnExpire is called after the expired nodes of a node type are deleted, with the deleted uids
+	OnExpire func(nodeType string, uids []string)
+	// OnError is called when expiring the nodes of a node type fails, the worker keeps running
+	OnError func(err error)
+
+	models []expiryModel
+	newTxn func(ctx context.Context) *TxnContext
+}
+
+// NewExpiryWorker returns an expiry worker of models, deleting the expired nodes every interval,
+// models without a field tagged with dgraph:"ttl" are rejected
+func NewExpiryWorker(c *Client, interval time.Duration, models ...interface{}) (*ExpiryWorker, error) {
+	return newExpiryWorker(func(ctx context.Context) *TxnContext {
+		return c.NewTxnContext(ctx)
+	}, interval, models...)
+}
+
+func newExpiryWorker(newTxn func(ctx context.Context) *TxnContext, interval time.Duration, models ...interface{}) (*ExpiryWorker, error) {
+	if interval <= 0 {
+		return nil, errors.New("expiry interval must be positive")
+	}
+	if len(models) == 0 {
+		return nil, errors.New("models cannot be empty")
+	}
+
+	w := &ExpiryWorker{Interval: interval, newTxn: newTxn}
+	for _, model := range models {
+		modelType, err := reflectType(model)
+		if err != nil {
+			return nil, err
+		}
+		predicate := ttlPredicate(modelType)
+		if predicate == "" {
+			return nil, fmt.Errorf("model \"%s\" has no ttl field", modelType.Name())
+		}
+		w.models = append(w.models, expiryModel{
+			model:     model,
+			nodeType:  getNodeType(modelType),
+			predicate: predicate,
+		})
+	}
+	return w, nil
+}
+
+// Expire deletes the expired nodes of each model in its own transaction, returning the first error.
+// Nodes of soft delete types are soft deleted, as in DeleteNode.
+func (w *ExpiryWorker) Expire(ctx context.Context) error {
+	var firstErr error
+	for _, expiry := range w.models {
+		if err := w.expire(ctx, expiry); err != nil {
+			if w.OnError != nil {
+				w.OnError(err)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (w *ExpiryWorker) expire(ctx context.Context, expiry expiryModel) error {
+	tx := w.newTxn(ctx).SetCommitNow()
+	uids, err := tx.DeleteWhere(expiry.model, Le(expiry.predicate, expiryNow()))
+	if err != nil {
+		return errors.Wrapf(err, "expire %s failed", expiry.nodeType)
+	}
+	if len(uids) > 0 && w.OnExpire != nil {
+		w.OnExpire(expiry.nodeType, uids)
+	}
+	return nil
+}
+
+// Run expires the nodes on every interval until the context is done, returning the context error.
+// Errors of expiry runs are passed to OnError, and do not stop the worker.
+func (w *ExpiryWorker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = w.Expire(ctx)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type TTLSession struct {
+	UID       string    `json:"uid,omitempty"`
+	Token     string    `json:"token,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitempty" dgraph:"ttl"`
+	DType     []string  `json:"dgraph.type,omitempty"`
+}
+
+func TestTTLSchema(t *testing.T) {
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", &TTLSession{})
+	require.NoError(t, typeSchema.Err())
+	assert.Equal(t, "expires_at: datetime @index(hour) .", typeSchema.Schema["expires_at"].String())
+
+	type InvalidTTL struct {
+		UID       string   `json:"uid,omitempty"`
+		ExpiresAt string   `json:"expires_at,omitempty" dgraph:"ttl"`
+		DType     []string `json:"dgraph.type,omitempty"`
+	}
+	field, _ := reflect.TypeOf(InvalidTTL{}).FieldByName("ExpiresAt")
+	_, err := parseDgraphTag(&field)
+	assert.Error(t, err)
+}
+
+func TestExpiryWorker(t *testing.T) {
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	expiryNow = func() time.Time { return now }
+	defer func() { expiryNow = time.Now }()
+
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"data":[{"uid":"0x1"},{"uid":"0x2"}]}`),
+	})
+	worker, err := newExpiryWorker(func(ctx context.Context) *TxnContext {
+		return tx
+	}, time.Minute, &TTLSession{})
+	require.NoError(t, err)
+
+	var expired []string
+	worker.OnExpire = func(nodeType string, uids []string) {
+		assert.Equal(t, "TTLSession", nodeType)
+		expired = uids
+	}
+	require.NoError(t, worker.Expire(context.Background()))
+	assert.Equal(t, []string{"0x1", "0x2"}, expired)
+
+	require.Len(t, fake.requests, 2)
+	assert.Equal(t, `{
+	data(func: type(TTLSession)) @filter(has(dgraph.type) AND le(expires_at, "2021-01-02T03:04:05Z")) {
+		uid
+	}
+}`, fake.requests[0].Query)
+	assert.Equal(t, "<0x1> * * .\n<0x2> * * .\n", string(fake.requests[1].Mutations[0].DelNquads))
+	assert.True(t, fake.requests[1].CommitNow)
+}
+
+func TestNewExpiryWorkerInvalid(t *testing.T) {
+	newTxn := func(ctx context.Context) *TxnContext { return nil }
+
+	_, err := newExpiryWorker(newTxn, time.Minute, &TestModel{})
+	assert.Error(t, err)
+	_, err = newExpiryWorker(newTxn, 0, &TTLSession{})
+	assert.Error(t, err)
+	_, err = newExpiryWorker(newTxn, time.Minute)
+	assert.Error(t, err)
+}
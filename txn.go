@@ -20,14 +20,46 @@ import (
 	"context"
 
 	"github.com/dgraph-io/dgo/v210"
+	"github.com/dgraph-io/dgo/v210/protos/api"
 	"github.com/pkg/errors"
 )
 
+// DgraphClient is the client used to create transactions and alter the schema, implemented by *dgo.Dgraph.
+// It can be implemented to wrap the dgo client, e.g: for clients created from connection strings, or mocks
+// returning transactions of a dgo client with a fake api.DgraphClient.
+type DgraphClient interface {
+	NewTxn() *dgo.Txn
+	NewReadOnlyTxn() *dgo.Txn
+	Alter(ctx context.Context, op *api.Operation) error
+}
+
+var _ DgraphClient = (*dgo.Dgraph)(nil)
+
+// transaction is the subset of *dgo.Txn methods used to send requests,
+// which allows replacing the dgo transaction in tests
+type transaction interface {
+	Query(ctx context.Context, q string) (*api.Response, error)
+	QueryWithVars(ctx context.Context, q string, vars map[string]string) (*api.Response, error)
+	Mutate(ctx context.Context, mu *api.Mutation) (*api.Response, error)
+	Do(ctx context.Context, req *api.Request) (*api.Response, error)
+	Commit(ctx context.Context) error
+	Discard(ctx context.Context) error
+}
+
 // TxnContext is dgo transaction coupled with context
 type TxnContext struct {
-	txn       *dgo.Txn
-	ctx       context.Context
-	commitNow bool
+	txn        transaction
+	ctx        context.Context
+	client     DgraphClient
+	config     *clientConfig
+	commitNow  bool
+	readOnly   bool
+	bestEffort bool
+	guard      *QueryGuard
+	hooks      *Hooks
+	cache      *QueryCache
+	indexCheck *IndexCheck
+	opts       *ClientOptions
 }
 
 // Commit calls Commit on the dgo transaction.
@@ -42,13 +74,16 @@ func (t *TxnContext) Discard() error {
 
 // BestEffort enables best effort in read-only queries.
 func (t *TxnContext) BestEffort() *TxnContext {
-	t.txn.BestEffort()
+	if txn := unwrapTxn(t.txn); txn != nil {
+		txn.BestEffort()
+	}
+	t.bestEffort = true
 	return t
 }
 
 // Txn returns the dgo transaction
 func (t *TxnContext) Txn() *dgo.Txn {
-	return t.txn
+	return unwrapTxn(t.txn)
 }
 
 // WithContext replaces the current transaction context
@@ -71,17 +106,78 @@ func (t *TxnContext) SetCommitNow() *TxnContext {
 	return t
 }
 
+// SetQueryGuard sets the query guard for queries of the transaction,
+// overriding the query guard set for the client
+func (t *TxnContext) SetQueryGuard(guard *QueryGuard) *TxnContext {
+	t.guard = guard
+	return t
+}
+
+// SetHooks sets the hooks for mutations, deletes and queries of the transaction,
+// overriding the hooks set for the client
+func (t *TxnContext) SetHooks(hooks *Hooks) *TxnContext {
+	t.hooks = hooks
+	return t
+}
+
+// SetQueryCache sets the query cache of the transaction, overriding the query cache set for the client,
+// queries are only cached on read only transactions, passing nil disables caching
+func (t *TxnContext) SetQueryCache(cache *QueryCache) *TxnContext {
+	t.cache = cache
+	t.txn = withOptions(withQueryCache(unwrapOptions(t.txn), cache, t.readOnly), t.opts)
+	return t
+}
+
+// SetIndexCheck sets the index check of the transaction, overriding the index check set for the client,
+// passing nil disables the index check
+func (t *TxnContext) SetIndexCheck(check *IndexCheck) *TxnContext {
+	t.indexCheck = check
+	return t
+}
+
+// SetOptions sets the options of the transaction, overriding the default options of the client
+func (t *TxnContext) SetOptions(opts *ClientOptions) *TxnContext {
+	t.opts = opts
+	t.commitNow = !t.readOnly && opts.CommitNow
+	t.txn = withOptions(t.txn, opts)
+	return t
+}
+
+// Renew returns a new TxnContext with a fresh dgo transaction, keeping the
+// context and options (commit now, read only, best effort) of the current one.
+// Useful for retrying after a transaction is aborted, as a discarded or
+// aborted dgo transaction cannot be reused.
+func (t *TxnContext) Renew() *TxnContext {
+	renewed := &TxnContext{
+		ctx:        t.ctx,
+		client:     t.client,
+		config:     t.config,
+		commitNow:  t.commitNow,
+		readOnly:   t.readOnly,
+		guard:      t.guard,
+		hooks:      t.hooks,
+		cache:      t.cache,
+		indexCheck: t.indexCheck,
+		opts:       t.opts,
+	}
+	renewed.txn = newTransaction(t.client, t.readOnly, t.config, t.cache, t.opts)
+	if t.bestEffort {
+		renewed.BestEffort()
+	}
+	return renewed
+}
+
 // Mutate does a dgraph mutation, with recursive automatic uid injection (on empty uid fields),
 // type injection (using the dgraph.type field), unique checking on fields (if applicable), and returns the created uids.
 // It will return a UniqueError when unique checking fails on a field.
 func (t *TxnContext) Mutate(data interface{}) ([]string, error) {
-	return newMutation(t, data).do()
+	return t.MutateWithOptions(data, MutateOptions{})
 }
 
 // MutateBasic does a dgraph mutation like Mutate, but without any unique checking.
 // This should be quite faster if there is no uniqueness requirement on the node type
 func (t *TxnContext) MutateBasic(data interface{}) ([]string, error) {
-	return newMutation(t, data).mutate()
+	return t.MutateWithOptions(data, MutateOptions{SkipUnique: true})
 }
 
 // MutateOrGet does a dgraph mutation like Mutate, but instead of returning a UniqueError when a node already exists
@@ -89,10 +185,10 @@ func (t *TxnContext) MutateBasic(data interface{}) ([]string, error) {
 // Optionally, a list of predicates can be passed to be specify predicates to be unique checked.
 // A single node type can only have a single upsert predicate.
 func (t *TxnContext) MutateOrGet(data interface{}, predicates ...string) ([]string, error) {
-	mutation := newMutation(t, data)
-	mutation.opcode = mutationMutateOrGet
-	mutation.upsertFields = newSet(predicates...)
-	return mutation.do()
+	return t.MutateWithOptions(data, MutateOptions{
+		OnUniqueConflict: UniqueConflictGet,
+		UpsertPredicates: predicates,
+	})
 }
 
 // Upsert does a dgraph mutation like Mutate, but instead of returning a UniqueError when a node already exists
@@ -100,10 +196,51 @@ func (t *TxnContext) MutateOrGet(data interface{}, predicates ...string) ([]stri
 // Optionally, a list of predicates can be passed to be specify predicates to be unique checked.
 // A single node type can only have a single upsert predicate.
 func (t *TxnContext) Upsert(data interface{}, predicates ...string) ([]string, error) {
-	mutation := newMutation(t, data)
-	mutation.opcode = mutationUpsert
-	mutation.upsertFields = newSet(predicates...)
-	return mutation.do()
+	return t.MutateWithOptions(data, MutateOptions{
+		OnUniqueConflict: UniqueConflictUpdate,
+		UpsertPredicates: predicates,
+	})
+}
+
+// MutateWithResult does a dgraph mutation with the behavior specified by the mutate options, as in MutateWithOptions,
+// returning the uids of the created nodes, and the uids of the mutated nodes by their blank node names
+func (t *TxnContext) MutateWithResult(data interface{}, opts MutateOptions) (*MutateResult, error) {
+	mutation, err := newMutation(t, data, opts)
+	if err != nil {
+		return nil, err
+	}
+	uids, err := mutation.run()
+	if err != nil {
+		return nil, err
+	}
+	return &MutateResult{UIDs: uids, BlankUIDs: mutation.blankUIDs()}, nil
+}
+
+// UpsertByType upserts as in Upsert, returning the uids of the upserted nodes, created or updated, by node type.
+// The data can be a slice mixing node types, e.g: []interface{}, or have edges of mixed node types,
+// the upsert predicate of each node type is resolved from the passed predicates or the client options.
+func (t *TxnContext) UpsertByType(data interface{}, predicates ...string) (map[string][]string, error) {
+	mutation, err := newMutation(t, data, MutateOptions{
+		OnUniqueConflict: UniqueConflictUpdate,
+		UpsertPredicates: predicates,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := mutation.run(); err != nil {
+		return nil, err
+	}
+	return mutation.uidsByType(), nil
+}
+
+// MutateWithOptions does a dgraph mutation with the behavior specified by the mutate options,
+// Mutate, MutateBasic, MutateOrGet and Upsert are shorthands of mutations with specific options.
+func (t *TxnContext) MutateWithOptions(data interface{}, opts MutateOptions) ([]string, error) {
+	mutation, err := newMutation(t, data, opts)
+	if err != nil {
+		return nil, err
+	}
+	return mutation.run()
 }
 
 // Delete will delete nodes using delete parameters, which will generate RDF n-quads for deleting
@@ -123,14 +260,32 @@ func (t *TxnContext) DeleteQuery(query *QueryBlock, params ...*DeleteParams) (De
 	return t.deleteQuery(query, params...)
 }
 
-// DeleteNode will delete a node(s) by its explicit uid
+// DeleteNode will delete a node(s) by its explicit uid,
+// nodes of soft delete types registered on the client, as in Client.RegisterSoftDelete,
+// will have their soft delete predicate set to the current time instead.
+// On dgo clients without a Client, the soft delete types of the models parsed by the process,
+// e.g: by CreateSchema or queries, are used.
 func (t *TxnContext) DeleteNode(uids ...string) error {
 	if len(uids) == 0 {
 		return errors.New("uids cannot be empty")
 	}
+	if err := validateUIDs(uids...); err != nil {
+		return err
+	}
 	return t.deleteNode(uids...)
 }
 
+// HardDelete will delete a node(s) by its explicit uid, including nodes of soft delete types
+func (t *TxnContext) HardDelete(uids ...string) error {
+	if len(uids) == 0 {
+		return errors.New("uids cannot be empty")
+	}
+	if err := validateUIDs(uids...); err != nil {
+		return err
+	}
+	return t.hardDeleteNode(uids...)
+}
+
 // DeleteEdge will delete an edge of a node by predicate, optionally you can pass which edge uids to delete,
 // if none are passed, all edges of that predicate will be deleted
 func (t *TxnContext) DeleteEdge(uid string, predicate string, uids ...string) error {
@@ -139,36 +294,72 @@ func (t *TxnContext) DeleteEdge(uid string, predicate string, uids ...string) er
 
 // Get prepares a query for a model
 func (t *TxnContext) Get(model interface{}) *Query {
-	return &Query{ctx: t.ctx, tx: t.txn, model: model, name: "data"}
+	return &Query{ctx: t.ctx, tx: t.txn, guard: t.guard, hooks: t.hooks, queryTxn: t.queryTxn, depth: t.opts.Depth, aliases: t.opts.PredicateAliases, model: model, name: "data"}
+}
+
+// GetByUID gets the node with the uid into dst, returns ErrNodeNotFound when the node does not exist
+func (t *TxnContext) GetByUID(dst interface{}, uid string) error {
+	return t.Get(dst).UID(uid).Node()
+}
+
+// Find gets the nodes matching a filter into dst, a pointer to a slice of the node type,
+// the filter is validated against the schema tags of the node type, a nil filter gets all nodes of the node type
+func (t *TxnContext) Find(dst interface{}, filter *Filter) error {
+	query := t.Get(dst)
+	if filter != nil {
+		query.Where(filter)
+	}
+	return query.Nodes()
 }
 
 // Query prepares a query with multiple query block
 func (t *TxnContext) Query(query ...*Query) *QueryBlock {
-	return &QueryBlock{ctx: t.ctx, tx: t.txn, blocks: query}
+	return &QueryBlock{ctx: t.ctx, tx: t.txn, guard: t.guard, hooks: t.hooks, queryTxn: t.queryTxn, depth: t.opts.Depth, aliases: t.opts.PredicateAliases, blocks: query}
 }
 
-// NewTxnContext creates a new transaction coupled with a context
-func NewTxnContext(ctx context.Context, c *dgo.Dgraph) *TxnContext {
+// newTransaction creates a dgo transaction of a client, wrapped to log in with ACL, collect metrics, trace requests,
+// write the audit trail, use the query cache, and apply the client options
+func newTransaction(c DgraphClient, readOnly bool, config *clientConfig, cache *QueryCache, opts *ClientOptions) transaction {
+	txn := withAudit(withTracing(withMetrics(withACL(c, readOnly, config.login), config.metrics), config.tracer), config.audit)
+	return withOptions(withQueryCache(txn, cache, readOnly), opts)
+}
+
+// newTxnContext creates a transaction with the configuration of the client, as in getClientConfig
+func newTxnContext(ctx context.Context, c DgraphClient, readOnly bool) *TxnContext {
+	config := getClientConfig(c)
 	return &TxnContext{
-		txn: c.NewTxn(),
-		ctx: ctx,
+		txn:        newTransaction(c, readOnly, config, config.cache, config.opts),
+		ctx:        ctx,
+		client:     c,
+		config:     config,
+		commitNow:  !readOnly && config.opts.CommitNow,
+		readOnly:   readOnly,
+		guard:      config.guard,
+		hooks:      config.hooks,
+		cache:      config.cache,
+		indexCheck: config.indexCheck,
+		opts:       config.opts,
 	}
 }
 
+// NewTxnContext creates a new transaction coupled with a context,
+// with the configuration of the Client when passed the dgo client of Client.Dgraph
+func NewTxnContext(ctx context.Context, c DgraphClient) *TxnContext {
+	return newTxnContext(ctx, c, false)
+}
+
 // NewTxn creates a new transaction
-func NewTxn(c *dgo.Dgraph) *TxnContext {
+func NewTxn(c DgraphClient) *TxnContext {
 	return NewTxnContext(context.Background(), c)
 }
 
-// NewReadOnlyTxnContext creates a new read only transaction coupled with a context
-func NewReadOnlyTxnContext(ctx context.Context, c *dgo.Dgraph) *TxnContext {
-	return &TxnContext{
-		txn: c.NewReadOnlyTxn(),
-		ctx: ctx,
-	}
+// NewReadOnlyTxnContext creates a new read only transaction coupled with a context,
+// with the configuration of the Client when passed the dgo client of Client.Dgraph
+func NewReadOnlyTxnContext(ctx context.Context, c DgraphClient) *TxnContext {
+	return newTxnContext(ctx, c, true)
 }
 
 // NewReadOnlyTxn creates a new read only transaction
-func NewReadOnlyTxn(c *dgo.Dgraph) *TxnContext {
+func NewReadOnlyTxn(c DgraphClient) *TxnContext {
 	return NewReadOnlyTxnContext(context.Background(), c)
 }
@@ -0,0 +1,56 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxnRenew(t *testing.T) {
+	c := newDgraphClient()
+	_, err := CreateSchema(c, &TestModel{})
+	require.NoError(t, err)
+	defer dropAll(c)
+
+	tx := NewTxn(c).SetCommitNow()
+	_, err = tx.Mutate(&TestModel{Name: "wildan"})
+	require.NoError(t, err)
+
+	// the committed transaction cannot be reused
+	_, err = tx.Mutate(&TestModel{Name: "wildan 2"})
+	assert.Error(t, err)
+
+	renewed := tx.Renew()
+	assert.True(t, renewed.commitNow)
+	assert.Equal(t, tx.Context(), renewed.Context())
+
+	model := &TestModel{Name: "wildan 2"}
+	_, err = renewed.Mutate(model)
+	require.NoError(t, err)
+
+	var dst TestModel
+	err = NewReadOnlyTxn(c).Get(&dst).UID(model.UID).Node()
+	require.NoError(t, err)
+	assert.Equal(t, model.Name, dst.Name)
+
+	readOnly := NewReadOnlyTxn(c).BestEffort().Renew()
+	assert.True(t, readOnly.readOnly)
+	assert.True(t, readOnly.bestEffort)
+}
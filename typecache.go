@@ -0,0 +1,115 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// nodeTypeInfo is the parsed schema of a struct type, shared by the mutations of the type
+type nodeTypeInfo struct {
+	uidIndex int
+	schema   []*Schema // maps exported field index to dgraph schema
+	// nodeType is the node type from the dgraph.type field, empty if the struct is not a node type
+	nodeType string
+	// typeName is the node type by getNodeType, to resolve the upsert predicates of the type
+	typeName string
+	// unique are the predicates of the unique fields, and the json field names of overridden predicates
+	unique [][2]string
+}
+
+// nodeTypes caches the parsed schema of struct types, keyed by reflect.Type
+var nodeTypes sync.Map
+
+// getNodeTypeInfo returns the parsed schema of a struct type, parsing the dgraph tags on first use
+func getNodeTypeInfo(structType reflect.Type) (*nodeTypeInfo, error) {
+	if info, ok := nodeTypes.Load(structType); ok {
+		return info.(*nodeTypeInfo), nil
+	}
+
+	info, err := parseNodeTypeInfo(structType)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := nodeTypes.LoadOrStore(structType, info)
+	return actual.(*nodeTypeInfo), nil
+}
+
+func parseNodeTypeInfo(structType reflect.Type) (*nodeTypeInfo, error) {
+	info := &nodeTypeInfo{
+		uidIndex: -1,
+		schema:   make([]*Schema, 0, structType.NumField()),
+		typeName: getNodeType(structType),
+	}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			// unexported field, skip
+			continue
+		}
+
+		predicate, _ := getPredicate(&field)
+		switch predicate {
+		case predicateUid:
+			info.uidIndex = i
+		case predicateDgraphType:
+			info.nodeType = info.typeName
+		}
+
+		schema, err := parseDgraphTag(&field)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse dgraph tag failed on %s.%s", structType.Name(), field.Name)
+		}
+		info.schema = append(info.schema, schema)
+		if schema.Unique {
+			// upsert predicates can be specified by the predicate or the json field name, when overridden
+			info.unique = append(info.unique, [2]string{schema.Predicate, predicate})
+		}
+	}
+	return info, nil
+}
+
+// getMutateType returns the mutate type of a struct type, from the cached schema of the type,
+// with the uid func predicate resolved by the upsert predicates of the mutation
+func (m *mutation) getMutateType(structType reflect.Type) (*mutateType, error) {
+	key := structType.String()
+	if mutateType, ok := m.typeCache[key]; ok {
+		return mutateType, nil
+	}
+
+	info, err := getNodeTypeInfo(structType)
+	if err != nil {
+		return nil, err
+	}
+	mutateType := &mutateType{
+		uidIndex: info.uidIndex,
+		schema:   info.schema,
+		nodeType: info.nodeType,
+	}
+	for _, unique := range info.unique {
+		rank := m.upsertRank(info.typeName, unique[0], unique[1])
+		if mutateType.uidFuncPred == "" || rank > mutateType.uidFuncRank {
+			mutateType.uidFuncPred = unique[0]
+			mutateType.uidFuncRank = rank
+		}
+	}
+	m.typeCache[key] = mutateType
+	return mutateType, nil
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMutateTypeUpsertPredicate(t *testing.T) {
+	tx, _ := newFakeTxnContext()
+	userType := reflect.TypeOf(TestUser{})
+
+	m, err := newMutation(tx, &TestUser{}, MutateOptions{})
+	require.NoError(t, err)
+	mutateType, err := m.getMutateType(userType)
+	require.NoError(t, err)
+	assert.Equal(t, "username", mutateType.uidFuncPred)
+	assert.Equal(t, "User", mutateType.nodeType)
+
+	// the cached schema is shared, the uid func predicate is resolved per mutation
+	m, err = newMutation(tx, &TestUser{}, MutateOptions{UpsertPredicates: []string{"email"}})
+	require.NoError(t, err)
+	upsertType, err := m.getMutateType(userType)
+	require.NoError(t, err)
+	assert.Equal(t, "email", upsertType.uidFuncPred)
+	assert.Equal(t, mutateType.schema, upsertType.schema)
+}
@@ -17,12 +17,20 @@
 package dgman
 
 import (
+	"fmt"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"unsafe"
+
+	jsoniter "github.com/json-iterator/go"
 )
 
 var (
 	uidCleanerRegex = regexp.MustCompile("[^xa-fA-F0-9]+")
+	uidRegex        = regexp.MustCompile("^0x[0-9a-fA-F]+$")
 
 	_ ParamFormatter = (*UID)(nil)
 	_ ParamFormatter = (*UIDs)(nil)
@@ -47,3 +55,148 @@ func (u UIDs) FormatParams() []byte {
 	}
 	return []byte(strings.Join(uids, ", "))
 }
+
+// FormatUID formats a numeric uid, e.g: 0x1f for 31
+func FormatUID(uid uint64) string {
+	return "0x" + strconv.FormatUint(uid, 16)
+}
+
+// ParseUID parses a uid into its numeric value, e.g: 31 for 0x1f
+func ParseUID(uid string) (uint64, error) {
+	if !isUID(uid) {
+		return 0, fmt.Errorf("invalid uid %q", uid)
+	}
+	n, err := strconv.ParseUint(uid[2:], 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid uid %q", uid)
+	}
+	return n, nil
+}
+
+// validateUIDs checks that uids passed by the caller are hex uids, e.g: 0x1f,
+// as they are written into queries
+func validateUIDs(uids ...string) error {
+	for _, uid := range uids {
+		if !uidRegex.MatchString(uid) {
+			return fmt.Errorf("invalid uid %q", uid)
+		}
+	}
+	return nil
+}
+
+// isNumericUID checks whether a uid field holds uids as numbers, i.e: uint64 or int64
+func isNumericUID(kind reflect.Kind) bool {
+	return kind == reflect.Uint64 || kind == reflect.Int64
+}
+
+// isUIDField checks whether a uid field holds uids as strings, e.g: string or UID, or as numbers
+func isUIDField(kind reflect.Kind) bool {
+	return kind == reflect.String || isNumericUID(kind)
+}
+
+// uidValue returns the uid of a uid field, numeric uids are formatted, empty when the uid is not set
+func uidValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Uint64:
+		if v.Uint() != 0 {
+			return FormatUID(v.Uint())
+		}
+	case reflect.Int64:
+		if v.Int() > 0 {
+			return FormatUID(uint64(v.Int()))
+		}
+	}
+	return ""
+}
+
+// setUIDValue sets a uid field, numeric uid fields can only be set to uids, not blank uids or uid funcs
+func setUIDValue(v reflect.Value, uid string) error {
+	if v.Kind() == reflect.String {
+		v.SetString(uid)
+		return nil
+	}
+	n, err := ParseUID(uid)
+	if err != nil {
+		return err
+	}
+	switch v.Kind() {
+	case reflect.Uint64:
+		v.SetUint(n)
+	case reflect.Int64:
+		v.SetInt(int64(n))
+	default:
+		return fmt.Errorf("unsupported uid field type %s", v.Type())
+	}
+	return nil
+}
+
+// marshalingUIDs are the blank uids of numeric uid fields of new nodes by their address,
+// only set while a mutation marshals its data, as numeric fields cannot hold blank uids
+var marshalingUIDs sync.Map
+
+// uidExtension encodes numeric uid fields as uids, e.g: "0x1f", and decodes them from uids
+type uidExtension struct {
+	jsoniter.DummyExtension
+}
+
+func (*uidExtension) UpdateStructDescriptor(structDescriptor *jsoniter.StructDescriptor) {
+	for _, binding := range structDescriptor.Fields {
+		jsonTags := strings.Split(binding.Field.Tag().Get("json"), ",")
+		fieldType := binding.Field.Type().Type1()
+		if jsonTags[0] != predicateUid || !isNumericUID(fieldType.Kind()) {
+			continue
+		}
+		codec := &numericUIDCodec{fieldType: fieldType}
+		binding.Encoder = codec
+		binding.Decoder = codec
+	}
+}
+
+type numericUIDCodec struct {
+	fieldType reflect.Type
+}
+
+func (c *numericUIDCodec) uid(ptr unsafe.Pointer) string {
+	if uid := uidValue(reflect.NewAt(c.fieldType, ptr).Elem()); uid != "" {
+		return uid
+	}
+	if blankUID, ok := marshalingUIDs.Load(uintptr(ptr)); ok {
+		return blankUID.(string)
+	}
+	return ""
+}
+
+func (c *numericUIDCodec) IsEmpty(ptr unsafe.Pointer) bool {
+	return c.uid(ptr) == ""
+}
+
+func (c *numericUIDCodec) Encode(ptr unsafe.Pointer, stream *jsoniter.Stream) {
+	stream.WriteString(c.uid(ptr))
+}
+
+func (c *numericUIDCodec) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
+	field := reflect.NewAt(c.fieldType, ptr).Elem()
+	switch iter.WhatIsNext() {
+	case jsoniter.NumberValue:
+		// numeric uids, e.g: from json encoded by the standard library
+		if field.Kind() == reflect.Uint64 {
+			field.SetUint(iter.ReadUint64())
+		} else {
+			field.SetInt(iter.ReadInt64())
+		}
+		return
+	case jsoniter.StringValue:
+	default:
+		iter.Skip()
+		return
+	}
+	uid := iter.ReadString()
+	if uid == "" {
+		return
+	}
+	if err := setUIDValue(field, uid); err != nil {
+		iter.ReportError("decode uid", err.Error())
+	}
+}
@@ -62,3 +62,43 @@ func TestUIDs_FormatParams(t *testing.T) {
 		})
 	}
 }
+
+func TestParseUID(t *testing.T) {
+	if got := FormatUID(31); got != "0x1f" {
+		t.Errorf("FormatUID(31) = %s, want 0x1f", got)
+	}
+	if got, err := ParseUID("0x1f"); err != nil || got != 31 {
+		t.Errorf("ParseUID(0x1f) = %d, %v, want 31", got, err)
+	}
+	for _, uid := range []string{"", "_:a", "uid(u)", "0xzz"} {
+		if _, err := ParseUID(uid); err == nil {
+			t.Errorf("ParseUID(%q) expected error", uid)
+		}
+	}
+}
+
+func TestNumericUIDJSON(t *testing.T) {
+	type node struct {
+		UID  uint64 `json:"uid,omitempty"`
+		Name string `json:"name,omitempty"`
+	}
+
+	data, err := json.Marshal([]node{{UID: 31, Name: "wildan"}, {Name: "dolan"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"uid":"0x1f","name":"wildan"},{"name":"dolan"}]`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var nodes []node
+	if err := json.Unmarshal([]byte(`[{"uid":"0x1f"},{"uid":32}]`), &nodes); err != nil {
+		t.Fatal(err)
+	}
+	if nodes[0].UID != 31 || nodes[1].UID != 32 {
+		t.Errorf("Unmarshal() = %v, want uids 31 and 32", nodes)
+	}
+	if err := json.Unmarshal([]byte(`{"uid":"_:a"}`), &node{}); err == nil {
+		t.Error("Unmarshal() expected error on blank uid")
+	}
+}
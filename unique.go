@@ -0,0 +1,122 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	stdjson "encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/dolan-in/reflectwalk"
+	"github.com/pkg/errors"
+)
+
+// CheckUnique runs the unique checking queries of a mutation on data, without mutating,
+// and returns a UniqueError for each unique field value that already exists on another node,
+// e.g: for validating forms using the same unique checks as Mutate.
+// The uid fields of data are left unchanged, so data can be mutated afterwards.
+func (t *TxnContext) CheckUnique(data interface{}) ([]*UniqueError, error) {
+	mutation, err := newMutation(t, data, MutateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return mutation.checkUnique()
+}
+
+// uidSnapshot records the uid field values of nodes, to restore the
+// blank uids and uid funcs set while generating a mutation request
+type uidSnapshot struct {
+	fields *[]reflect.Value
+	values *[]string
+}
+
+func (s uidSnapshot) Struct(v reflect.Value, level int) error {
+	return nil
+}
+
+func (s uidSnapshot) StructField(p reflect.Value, f reflect.StructField, v reflect.Value, level int) error {
+	if predicate, _ := getPredicate(&f); predicate != predicateUid || v.Kind() != reflect.String || !v.CanSet() {
+		return nil
+	}
+	*s.fields = append(*s.fields, v)
+	*s.values = append(*s.values, v.String())
+	return nil
+}
+
+func (s uidSnapshot) restore() {
+	for i, field := range *s.fields {
+		field.SetString((*s.values)[i])
+	}
+}
+
+func (m *mutation) checkUnique() ([]*UniqueError, error) {
+	snapshot := uidSnapshot{fields: &[]reflect.Value{}, values: &[]string{}}
+	if err := reflectwalk.Walk(m.data, snapshot); err != nil {
+		return nil, errors.Wrap(err, "uid snapshot failed")
+	}
+	defer snapshot.restore()
+
+	if err := m.generateRequest(); err != nil {
+		return nil, errors.Wrap(err, "generate request failed")
+	}
+	if m.request.Query == "" {
+		// no unique fields
+		return nil, nil
+	}
+
+	resp, err := sendQuery(withMetricsTags(m.txn.ctx, "", m.data), m.txn.txn, m.txn.hooks, m.request.Query, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unique query failed")
+	}
+
+	if resp.Json == nil {
+		return nil, nil
+	}
+
+	var mapNodes map[string][]stdjson.RawMessage
+	if err := json.Unmarshal(resp.Json, &mapNodes); err != nil {
+		return nil, errors.Wrapf(err, `unmarshal queryResponse "%s"`, resp.Json)
+	}
+
+	queryIndexes := make([]string, 0, len(mapNodes))
+	for queryIndex := range mapNodes {
+		queryIndexes = append(queryIndexes, queryIndex)
+	}
+	sort.Strings(queryIndexes)
+
+	var uniqueErrors []*UniqueError
+	for _, queryIndex := range queryIndexes {
+		msg := mapNodes[queryIndex]
+		if len(msg) == 0 {
+			continue
+		}
+
+		id, schemaIndex, err := parseQueryIndex(queryIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		uniqueErr, err := m.uniqueError(id, schemaIndex, msg[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "unmarshal node %s", queryIndex)
+		}
+		if uniqueErr != nil {
+			uniqueErrors = append(uniqueErrors, uniqueErr)
+		}
+	}
+	return uniqueErrors, nil
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckUnique(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{Json: []byte(`{"q_0x5_1":[{"uid":"0x9"}],"q_0x5_2":[]}`)})
+
+	account := OptionsAccount{UID: "0x5", Username: "wildan", Email: "wildan@dolan.in"}
+	conflicts, err := tx.CheckUnique(&account)
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+	assert.Empty(t, fake.requests[0].Mutations)
+	assert.Contains(t, fake.requests[0].Query, "NOT uid(0x5) AND eq(username")
+
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, &UniqueError{NodeType: "OptionsAccount", Field: "username", Value: "wildan", UID: "0x9"}, conflicts[0])
+	assert.Equal(t, "0x5", account.UID)
+}
+
+func TestCheckUniqueNewNode(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	account := OptionsAccount{Username: "wildan", Email: "wildan@dolan.in"}
+	conflicts, err := tx.CheckUnique(&account)
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+	require.Len(t, fake.requests, 1)
+	assert.Contains(t, fake.requests[0].Query, "eq(email")
+	// generated blank uid is not left on the node
+	assert.Empty(t, account.UID)
+}
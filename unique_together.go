@@ -0,0 +1,137 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	uniqueTogetherMu sync.RWMutex
+	// uniqueTogether maps node types to the field indexes of their unique together constraints
+	uniqueTogether = make(map[reflect.Type][][]int)
+)
+
+// UniqueTogether registers a unique constraint spanning multiple predicates of a node type, e.g:
+//
+//	dgman.UniqueTogether(&Booking{}, "room_id", "date")
+//
+// Mutations check that no other node of the node type has the same values on all of the predicates,
+// returning a UniqueError naming all of the predicates. The check is skipped when any of the values is empty.
+func UniqueTogether(model interface{}, predicates ...string) error {
+	modelType := getElemType(reflect.TypeOf(model))
+	if modelType.Kind() != reflect.Struct || !isNodeType(modelType) {
+		return fmt.Errorf("%s is not a node type", modelType)
+	}
+	if len(predicates) < 2 {
+		return errors.New("unique together requires at least 2 predicates")
+	}
+
+	fields := make([]int, len(predicates))
+	for i, predicate := range predicates {
+		index, err := fieldIndex(modelType, predicate)
+		if err != nil {
+			return err
+		}
+		fields[i] = index
+	}
+
+	uniqueTogetherMu.Lock()
+	defer uniqueTogetherMu.Unlock()
+	groups := uniqueTogether[modelType]
+	uniqueTogether[modelType] = append(groups[:len(groups):len(groups)], fields)
+	return nil
+}
+
+// fieldIndex returns the index of the field of a predicate, or of the json field name when overridden
+func fieldIndex(modelType reflect.Type, predicate string) (int, error) {
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		schema, err := parseDgraphTag(&field)
+		if err != nil {
+			return 0, errors.Wrapf(err, "parse dgraph tag failed on %s.%s", modelType.Name(), field.Name)
+		}
+		if jsonName, _ := getPredicate(&field); schema.Predicate == predicate || jsonName == predicate {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%s is not a predicate of %s", predicate, modelType.Name())
+}
+
+func getUniqueTogether(modelType reflect.Type) [][]int {
+	uniqueTogetherMu.RLock()
+	defer uniqueTogetherMu.RUnlock()
+	return uniqueTogether[modelType]
+}
+
+// generateUniqueTogether generates the query and condition checking the unique together fields of a node,
+// which query is indexed after the fields of the node type, skipped when any of the values is empty
+func (m *mutation) generateUniqueTogether(v reflect.Value, id, idFunc string, mutateType *mutateType, index int, fields []int, level int) (query, condition string, err error) {
+	var filters []string
+	if isUIDFunc(idFunc) {
+		// the node is upserted by another unique field
+		filters = append(filters, "NOT "+idFunc)
+	} else if isUID(id) {
+		filters = append(filters, fmt.Sprintf("NOT uid(%s)", id))
+	}
+
+	for _, field := range fields {
+		value := v.Field(field).Interface()
+		if isNull(value) {
+			return "", "", nil
+		}
+		jsonValue, err := json.Marshal(value)
+		if err != nil {
+			return "", "", errors.Wrapf(err, "marshal %v", value)
+		}
+		filters = append(filters, fmt.Sprintf("eq(%s, %s)", mutateType.schema[field].Predicate, jsonValue))
+	}
+	filters = append(filters, fmt.Sprintf("type(%s)", mutateType.nodeType))
+
+	uidListIndex := fmt.Sprintf("u_%s_%d", id, index)
+	query = m.generateUniqueQuery(mutateType, uidListIndex, strings.Join(filters, " AND "), level)
+	return query, fmt.Sprintf("eq(len(%s), 0)", uidListIndex), nil
+}
+
+// newUniqueError returns a UniqueError of a unique field, or of the unique together fields
+// when the schema index is after the fields of the node type
+func newUniqueError(mutateType *mutateType, nodeValue reflect.Value, schemaIndex int, uid string) *UniqueError {
+	if schemaIndex < len(mutateType.schema) {
+		return &UniqueError{
+			NodeType: mutateType.nodeType,
+			Field:    mutateType.schema[schemaIndex].Predicate,
+			Value:    nodeValue.Field(schemaIndex).Interface(),
+			UID:      uid,
+		}
+	}
+
+	fields := getUniqueTogether(nodeValue.Type())[schemaIndex-len(mutateType.schema)]
+	uniqueErr := &UniqueError{NodeType: mutateType.nodeType, UID: uid}
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		uniqueErr.Fields = append(uniqueErr.Fields, mutateType.schema[field].Predicate)
+		values[i] = nodeValue.Field(field).Interface()
+	}
+	uniqueErr.Field = strings.Join(uniqueErr.Fields, ",")
+	uniqueErr.Value = values
+	return uniqueErr
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type TogetherBooking struct {
+	UID    string   `json:"uid,omitempty"`
+	RoomID string   `json:"room_id,omitempty" dgraph:"index=exact"`
+	Date   string   `json:"date,omitempty" dgraph:"index=exact"`
+	Note   string   `json:"note,omitempty"`
+	DType  []string `json:"dgraph.type,omitempty"`
+}
+
+func init() {
+	if err := UniqueTogether(&TogetherBooking{}, "room_id", "date"); err != nil {
+		panic(err)
+	}
+}
+
+func TestUniqueTogetherInvalid(t *testing.T) {
+	assert.EqualError(t, UniqueTogether(&TogetherBooking{}, "room_id"), "unique together requires at least 2 predicates")
+	assert.EqualError(t, UniqueTogether(&TogetherBooking{}, "room_id", "time"), "time is not a predicate of TogetherBooking")
+	assert.Error(t, UniqueTogether(&struct{ Name string }{}, "name", "date"))
+}
+
+func TestUniqueTogetherMutate(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{Json: []byte(`{"q_booking_5":[{"uid":"0x9"}]}`)})
+
+	booking := &TogetherBooking{UID: "_:booking", RoomID: "r1", Date: "2021-01-01"}
+	_, err := tx.Mutate(booking)
+	require.Len(t, fake.requests, 1)
+	assert.Contains(t, fake.requests[0].Query, `q_booking_5(func: type(TogetherBooking), first: 1) @filter(eq(room_id, "r1") AND eq(date, "2021-01-01") AND type(TogetherBooking))`)
+	assert.Equal(t, "@if(eq(len(u_booking_5), 0))", fake.requests[0].Mutations[0].Cond)
+
+	assert.IsType(t, &UniqueError{}, err)
+	assert.Equal(t, &UniqueError{
+		NodeType: "TogetherBooking",
+		Field:    "room_id,date",
+		Fields:   []string{"room_id", "date"},
+		Value:    []interface{}{"r1", "2021-01-01"},
+		UID:      "0x9",
+	}, err)
+	assert.EqualError(t, err, "TogetherBooking with room_id=r1, date=2021-01-01 already exists at uid=0x9")
+}
+
+func TestUniqueTogetherUpdate(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	_, err := tx.Mutate(&TogetherBooking{UID: "0x1", RoomID: "r1", Date: "2021-01-01"})
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+	assert.Contains(t, fake.requests[0].Query, `@filter(NOT uid(0x1) AND eq(room_id, "r1") AND eq(date, "2021-01-01") AND type(TogetherBooking))`)
+}
+
+func TestUniqueTogetherEmptyValue(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	_, err := tx.Mutate(&TogetherBooking{RoomID: "r1", Note: "no date"})
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+	assert.Empty(t, fake.requests[0].Query)
+}
+
+func TestUniqueTogetherCheckUnique(t *testing.T) {
+	tx, _ := newFakeTxnContext(&api.Response{Json: []byte(`{"q_0x1_5":[{"uid":"0x9"}]}`)})
+
+	conflicts, err := tx.CheckUnique(&TogetherBooking{UID: "0x1", RoomID: "r1", Date: "2021-01-01"})
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, []string{"room_id", "date"}, conflicts[0].Fields)
+}
@@ -28,7 +28,18 @@ import (
 	"google.golang.org/grpc"
 )
 
-var json = jsoniter.ConfigCompatibleWithStandardLibrary
+// json is compatible with the standard library, as jsoniter.ConfigCompatibleWithStandardLibrary,
+// with the field encoders registered by RegisterEncoder
+var json = jsoniter.Config{
+	EscapeHTML:             true,
+	SortMapKeys:            true,
+	ValidateJsonRawMessage: true,
+}.Froze()
+
+func init() {
+	json.RegisterExtension(&encoderExtension{})
+	json.RegisterExtension(&uidExtension{})
+}
 
 func newDgraphClient() *dgo.Dgraph {
 	d, err := grpc.Dial(os.Getenv("DGMAN_TEST_DATABASE"), grpc.WithInsecure())
@@ -0,0 +1,251 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/dolan-in/reflectwalk"
+	"github.com/pkg/errors"
+)
+
+// FieldError is a node field failing a validation rule
+type FieldError struct {
+	NodeType string
+	Field    string
+	Value    interface{}
+	// Rule is the failed validation rule, i.e: required, min, max, or pattern
+	Rule string
+	// Param is the parameter of the failed validation rule, e.g: 255 for max=255
+	Param string
+}
+
+func (e FieldError) String() string {
+	if e.Rule == "required" {
+		return fmt.Sprintf("%s.%s is required", e.NodeType, e.Field)
+	}
+	return fmt.Sprintf("%s.%s=%v does not match %s=%s", e.NodeType, e.Field, e.Value, e.Rule, e.Param)
+}
+
+// ValidationError is returned when nodes fail the validation rules defined in their dgraph tags
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		fields[i] = field.String()
+	}
+	return strings.Join(fields, "; ")
+}
+
+type fieldRule struct {
+	index     []int
+	predicate string
+	required  bool
+	min       string
+	max       string
+	minValue  float64
+	maxValue  float64
+	pattern   *regexp.Regexp
+}
+
+// validationRules caches the validation rules of node types
+var validationRules sync.Map
+
+// getValidationRules returns the validation rules of a node type, parsed from the
+// required, min, max, and pattern dgraph tags, including anonymous struct fields
+func getValidationRules(modelType reflect.Type) ([]fieldRule, error) {
+	if rules, ok := validationRules.Load(modelType); ok {
+		return rules.([]fieldRule), nil
+	}
+
+	rules, err := parseValidationRules(modelType, nil)
+	if err != nil {
+		return nil, err
+	}
+	validationRules.Store(modelType, rules)
+	return rules, nil
+}
+
+func parseValidationRules(modelType reflect.Type, parentIndex []int) ([]fieldRule, error) {
+	var rules []fieldRule
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		index := append(append([]int{}, parentIndex...), i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && !isNodeType(field.Type) {
+			anonymousRules, err := parseValidationRules(field.Type, index)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, anonymousRules...)
+			continue
+		}
+
+		dgraphTag := field.Tag.Get(tagName)
+		if dgraphTag == "" {
+			continue
+		}
+		props, err := parseStructTag(dgraphTag)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse dgraph tag failed on %s.%s", modelType.Name(), field.Name)
+		}
+		if !props.Required && props.Min == "" && props.Max == "" && props.Pattern == "" {
+			continue
+		}
+
+		rule := fieldRule{
+			index:    index,
+			required: props.Required,
+			min:      props.Min,
+			max:      props.Max,
+		}
+		rule.predicate, _ = getPredicate(&field)
+		if props.Predicate != "" {
+			rule.predicate = props.Predicate
+		}
+		if rule.min != "" {
+			if rule.minValue, err = strconv.ParseFloat(rule.min, 64); err != nil {
+				return nil, errors.Wrapf(err, "invalid min on %s.%s", modelType.Name(), field.Name)
+			}
+		}
+		if rule.max != "" {
+			if rule.maxValue, err = strconv.ParseFloat(rule.max, 64); err != nil {
+				return nil, errors.Wrapf(err, "invalid max on %s.%s", modelType.Name(), field.Name)
+			}
+		}
+		if props.Pattern != "" {
+			if rule.pattern, err = regexp.Compile(props.Pattern); err != nil {
+				return nil, errors.Wrapf(err, "invalid pattern on %s.%s", modelType.Name(), field.Name)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// validateSize returns the value to compare with min and max, i.e: the length of strings,
+// slices, and maps, or the value of numbers, false when the value has no size
+func validateSize(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func (r *fieldRule) validate(nodeType string, v reflect.Value, isNew bool) []FieldError {
+	fieldError := func(rule, param string) FieldError {
+		return FieldError{NodeType: nodeType, Field: r.predicate, Value: v.Interface(), Rule: rule, Param: param}
+	}
+
+	if isNull(v.Interface()) {
+		// empty values are only checked when required on new nodes,
+		// as updates may leave out fields
+		if r.required && isNew {
+			return []FieldError{fieldError("required", "")}
+		}
+		return nil
+	}
+
+	var fieldErrors []FieldError
+	value := reflect.Indirect(v)
+	if size, ok := validateSize(value); ok {
+		if r.min != "" && size < r.minValue {
+			fieldErrors = append(fieldErrors, fieldError("min", r.min))
+		}
+		if r.max != "" && size > r.maxValue {
+			fieldErrors = append(fieldErrors, fieldError("max", r.max))
+		}
+	}
+	if r.pattern != nil && value.Kind() == reflect.String && !r.pattern.MatchString(value.String()) {
+		fieldErrors = append(fieldErrors, fieldError("pattern", r.pattern.String()))
+	}
+	return fieldErrors
+}
+
+type validateWalker struct {
+	fieldErrors *[]FieldError
+}
+
+func (w validateWalker) Struct(v reflect.Value, level int) error {
+	vType := v.Type()
+	if !v.CanInterface() || !isNodeType(vType) {
+		return nil
+	}
+
+	uid := nodeUID(v)
+	isExisting := isUID(uid) || isUIDFunc(uid)
+	if isExisting && level > 0 {
+		// edges to existing nodes
+		return nil
+	}
+
+	rules, err := getValidationRules(vType)
+	if err != nil {
+		return err
+	}
+	nodeType := getNodeType(vType)
+	for i := range rules {
+		field := v.FieldByIndex(rules[i].index)
+		if !field.CanInterface() {
+			continue
+		}
+		*w.fieldErrors = append(*w.fieldErrors, rules[i].validate(nodeType, field, !isExisting)...)
+	}
+	return nil
+}
+
+func (w validateWalker) StructField(s reflect.Value, f reflect.StructField, v reflect.Value, level int) error {
+	return nil
+}
+
+// Validate validates nodes, including edge nodes, against the validation rules defined in their dgraph tags:
+//
+//	required, the field must not be empty on new nodes
+//	min=n and max=n, the length of strings, slices, and maps, or the value of numbers must be within the range
+//	pattern=regexp, string values must match the regular expression
+//
+// Rules other than required are only checked on non-empty values.
+// Returns a ValidationError listing the failed fields.
+// Validate is called on Mutate, MutateOrGet, Upsert, and MutateWithOptions, before the mutation is sent.
+func Validate(data interface{}) error {
+	var fieldErrors []FieldError
+	if err := reflectwalk.Walk(data, validateWalker{fieldErrors: &fieldErrors}); err != nil {
+		return err
+	}
+	if len(fieldErrors) > 0 {
+		return &ValidationError{Fields: fieldErrors}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ValidatedUser struct {
+	UID      string           `json:"uid,omitempty"`
+	Username string           `json:"username,omitempty" dgraph:"required min=3 max=8 pattern=^[a-z]+$"`
+	Age      int              `json:"age,omitempty" dgraph:"min=13"`
+	Tags     []string         `json:"tags,omitempty" dgraph:"max=2"`
+	Posts    []*ValidatedPost `json:"posts,omitempty"`
+	DType    []string         `json:"dgraph.type,omitempty"`
+}
+
+type ValidatedPost struct {
+	UID   string   `json:"uid,omitempty"`
+	Title string   `json:"title,omitempty" dgraph:"required"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(&ValidatedUser{Username: "wildan", Age: 20, Posts: []*ValidatedPost{{Title: "hello"}}}))
+
+	user := ValidatedUser{
+		Username: "Wildan_Maulana",
+		Age:      10,
+		Tags:     []string{"a", "b", "c"},
+		// edges to existing nodes are not validated
+		Posts: []*ValidatedPost{{}, {UID: "0x1"}},
+	}
+	err := Validate(&user)
+	validationErr, ok := err.(*ValidationError)
+	require.True(t, ok)
+	assert.Equal(t, []FieldError{
+		{NodeType: "ValidatedUser", Field: "username", Value: "Wildan_Maulana", Rule: "max", Param: "8"},
+		{NodeType: "ValidatedUser", Field: "username", Value: "Wildan_Maulana", Rule: "pattern", Param: "^[a-z]+$"},
+		{NodeType: "ValidatedUser", Field: "age", Value: 10, Rule: "min", Param: "13"},
+		{NodeType: "ValidatedUser", Field: "tags", Value: []string{"a", "b", "c"}, Rule: "max", Param: "2"},
+		{NodeType: "ValidatedPost", Field: "title", Value: "", Rule: "required"},
+	}, validationErr.Fields)
+	assert.Contains(t, err.Error(), "ValidatedPost.title is required")
+}
+
+func TestValidateUpdate(t *testing.T) {
+	// required is only checked on new nodes, allowing partial updates
+	assert.NoError(t, Validate(&ValidatedUser{UID: "0x1", Age: 20}))
+	assert.Error(t, Validate(&ValidatedUser{UID: "0x1", Username: "ab"}))
+}
+
+func TestMutateValidation(t *testing.T) {
+	tx, fake := newFakeTxnContext()
+
+	_, err := tx.Mutate(&ValidatedUser{Username: "ab"})
+	assert.IsType(t, &ValidationError{}, err)
+	assert.Empty(t, fake.requests)
+
+	_, err = tx.MutateWithOptions(&ValidatedUser{Username: "ab"}, MutateOptions{SkipValidation: true})
+	assert.NoError(t, err)
+	assert.Len(t, fake.requests, 1)
+}
+
+type quotedRuleUser struct {
+	UID      string   `json:"uid,omitempty"`
+	FullName string   `json:"full_name,omitempty" dgraph:"required pattern=\"^[A-Z][a-z]+ [A-Z][a-z]+$\" max=32"`
+	Phone    string   `json:"phone,omitempty" dgraph:"pattern=\"^\\\\+\\\\d+ \\\\d+$\""`
+	DType    []string `json:"dgraph.type,omitempty"`
+}
+
+func TestValidateQuotedRule(t *testing.T) {
+	// quoted rule values may contain spaces
+	assert.NoError(t, Validate(&quotedRuleUser{FullName: "Wildan Maulana", Phone: "+62 812"}))
+
+	err := Validate(&quotedRuleUser{FullName: "Wildan", Phone: "62812"})
+	validationErr, ok := err.(*ValidationError)
+	require.True(t, ok)
+	assert.Equal(t, []FieldError{
+		{NodeType: "quotedRuleUser", Field: "full_name", Value: "Wildan", Rule: "pattern", Param: "^[A-Z][a-z]+ [A-Z][a-z]+$"},
+		{NodeType: "quotedRuleUser", Field: "phone", Value: "62812", Rule: "pattern", Param: `^\+\d+ \d+$`},
+	}, validationErr.Fields)
+}
+
+type invalidRuleUser struct {
+	UID  string `json:"uid,omitempty"`
+	Name string `json:"name,omitempty" dgraph:"pattern=[a-z"`
+}
+
+func TestValidateInvalidRule(t *testing.T) {
+	err := Validate(&invalidRuleUser{Name: "a"})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "is required")
+	assert.Error(t, WarmTypeCache(&invalidRuleUser{}))
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var aliasRegex = regexp.MustCompile(`^[A-Za-z_][\w.]*$`)
+
+// Value declares a value variable in the query block, from a predicate or an aggregation,
+// e.g: Value("a", "likes") declares "a as likes", the variable can be used in Compute expressions,
+// or referenced in other query blocks with QueryVar("a").Val()
+func (q *Query) Value(varName, value string, params ...interface{}) *Query {
+	if !aliasRegex.MatchString(varName) {
+		q.err = fmt.Errorf("invalid value variable name %q", varName)
+		return q
+	}
+	q.computed = append(q.computed, varName+" as "+parseQueryWithParams(value, params))
+	return q
+}
+
+// ValueVar declares a value variable like Value, returning a reference to the variable,
+// which can be used to order query blocks, e.g: OrderDescVal(score), or passed as a query parameter
+func (q *Query) ValueVar(varName, value string, params ...interface{}) ValueVar {
+	q.Value(varName, value, params...)
+	return QueryVar(varName).Val()
+}
+
+// Compute returns a math expression of value variables in the query results as an alias,
+// e.g: Compute("score", "a + b * $1", 2) returns "score: math(a + b * 2)",
+// which is unmarshaled into fields with the alias json tag, e.g: `json:"score,omitempty"`
+func (q *Query) Compute(alias, expression string, params ...interface{}) *Query {
+	if !aliasRegex.MatchString(alias) {
+		q.err = fmt.Errorf("invalid computed field alias %q", alias)
+		return q
+	}
+	q.computed = append(q.computed, alias+": math("+parseQueryWithParams(expression, params)+")")
+	return q
+}
+
+// Alias returns the value of a value variable in the query results as an alias,
+// e.g: Alias("total", QueryVar("t").Val()) returns "total: val(t)"
+func (q *Query) Alias(alias string, value ValueVar) *Query {
+	if !aliasRegex.MatchString(alias) {
+		q.err = fmt.Errorf("invalid computed field alias %q", alias)
+		return q
+	}
+	q.computed = append(q.computed, alias+": "+value.String())
+	return q
+}
+
+// withComputed adds the value variables and computed fields at the end of the query block,
+// var blocks without a query only contain the value variables
+func (q *Query) withComputed(query string) string {
+	if len(q.computed) == 0 {
+		return query
+	}
+	end := strings.LastIndex(query, "}")
+	if end < 0 {
+		if strings.TrimSpace(query) != "" {
+			return query
+		}
+		query, end = "{}", 1
+	}
+
+	var buffer strings.Builder
+	buffer.WriteString(strings.TrimRight(query[:end], " \t\n"))
+	for _, computed := range q.computed {
+		buffer.WriteString("\n\t\t")
+		buffer.WriteString(computed)
+	}
+	buffer.WriteString("\n\t")
+	buffer.WriteString(query[end:])
+	return buffer.String()
+}
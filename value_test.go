@@ -0,0 +1,144 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ScoredPost struct {
+	UID    string   `json:"uid,omitempty"`
+	Title  string   `json:"title,omitempty"`
+	Likes  int      `json:"likes,omitempty"`
+	Shares int      `json:"shares,omitempty"`
+	Score  float64  `json:"score,omitempty"`
+	Total  int      `json:"total,omitempty"`
+	DType  []string `json:"dgraph.type,omitempty"`
+}
+
+func TestQueryCompute(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"data":[{"uid":"0x1","title":"hello","likes":3,"shares":2,"score":7,"total":5}]}`),
+	})
+
+	var posts []ScoredPost
+	err := tx.Get(&posts).
+		Value("a", "likes").
+		Value("b", "shares").
+		Compute("score", "a + b * $1", 2).
+		Compute("t", "a + b").
+		Alias("total", QueryVar("t").Val()).
+		All(0).
+		Nodes()
+	require.NoError(t, err)
+
+	require.Len(t, fake.requests, 1)
+	assert.Equal(t, `{
+	data(func: type(ScoredPost)) @filter(has(dgraph.type)) {
+		uid
+		dgraph.type
+		expand(_all_)
+		a as likes
+		b as shares
+		score: math(a + b * 2)
+		t: math(a + b)
+		total: val(t)
+	}
+}`, fake.requests[0].Query)
+	assert.Equal(t, []ScoredPost{{
+		UID: "0x1", Title: "hello", Likes: 3, Shares: 2, Score: 7, Total: 5,
+	}}, posts)
+}
+
+func TestQueryComputeNodesAndCount(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"result":[{"uid":"0x1","title":"hello","likes":3,"shares":2,"score":7}],"pageInfo":[{"count":1}]}`),
+	})
+
+	var posts []ScoredPost
+	count, err := tx.Get(&posts).
+		Value("a", "likes").
+		Value("b", "shares").
+		Compute("score", "a + b * $1", 2).
+		First(10).
+		NodesAndCount()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []ScoredPost{{UID: "0x1", Title: "hello", Likes: 3, Shares: 2, Score: 7}}, posts)
+
+	require.Len(t, fake.requests, 1)
+	// the computed fields are returned in the paged result block
+	assert.Contains(t, fake.requests[0].Query, `result(func: uid(filtered), first: 10) @filter(has(dgraph.type)) {
+		uid
+		dgraph.type
+		expand(_all_)
+		a as likes
+		b as shares
+		score: math(a + b * 2)
+	}`)
+}
+
+func TestQueryValueVarBlock(t *testing.T) {
+	query := NewQueryBlock(
+		NewQuery().Model(&[]ScoredPost{}).Var().Value("likes", "count(~likes)"),
+		NewQuery().Model(&[]ScoredPost{}).RootFunc("uid(likes)").OrderDesc("val(likes)").Query(`{ title }`),
+	)
+
+	assert.Equal(t, `{
+	var(func: type(ScoredPost)) @filter(has(dgraph.type)) {
+		likes as count(~likes)
+	}
+	data(func: uid(likes), orderdesc: val(likes)) @filter(has(dgraph.type)) { title }
+}`, query.String())
+}
+
+func TestQueryOrderByValueVar(t *testing.T) {
+	ranked := NewQuery().Model(&[]ScoredPost{}).Var()
+	likes := ranked.ValueVar("likes", "count(~likes)")
+	score := ranked.ValueVar("score", "math(likes + shares)")
+
+	query := NewQueryBlock(
+		ranked,
+		NewQuery().Model(&[]ScoredPost{}).
+			RootFunc("uid(score)").
+			OrderDescVal(score).
+			OrderAscVal(likes).
+			OrderAsc("title").
+			Query(`{ title }`),
+	)
+
+	assert.Equal(t, `{
+	var(func: type(ScoredPost)) @filter(has(dgraph.type)) {
+		likes as count(~likes)
+		score as math(likes + shares)
+	}
+	data(func: uid(score), orderdesc: val(score), orderasc: val(likes), orderasc: title) @filter(has(dgraph.type)) { title }
+}`, query.String())
+}
+
+func TestQueryComputeInvalidAlias(t *testing.T) {
+	for _, alias := range []string{"", "score: uid", "1score", "sc ore"} {
+		query := NewQuery().Model(&[]ScoredPost{}).Compute(alias, "a + b")
+		assert.Error(t, query.err, alias)
+	}
+	query := NewQuery().Model(&[]ScoredPost{}).Value("a) { password }", "likes")
+	assert.Error(t, query.err)
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SimilarityAlias is the alias of the similarity score of the nodes queried with SimilarTo
+	SimilarityAlias = "similarity"
+
+	similarToVectorVar = "$similarvec"
+	similarToValueVar  = "similar_embedding"
+)
+
+var (
+	_ ParamFormatter = Vector{}
+	_ SchemaType     = Vector{}
+)
+
+// Vector is a float32vector value, e.g: an embedding, of predicates and vector similarity functions,
+// marshaled as a quoted list of floats as expected by Dgraph
+type Vector []float32
+
+func (v Vector) String() string {
+	var buffer strings.Builder
+	buffer.WriteByte('[')
+	for i, value := range v {
+		if i > 0 {
+			buffer.WriteString(", ")
+		}
+		buffer.WriteString(strconv.FormatFloat(float64(value), 'f', -1, 32))
+	}
+	buffer.WriteByte(']')
+	return buffer.String()
+}
+
+// FormatParams implements the ParamFormatter interface, vectors are passed as quoted lists of floats
+func (v Vector) FormatParams() []byte {
+	return []byte(strconv.Quote(v.String()))
+}
+
+// SchemaType implements the SchemaType interface
+func (v Vector) SchemaType() string {
+	return "float32vector"
+}
+
+// MarshalJSON marshals the vector as a quoted list of floats
+func (v Vector) MarshalJSON() ([]byte, error) {
+	if v == nil {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.Quote(v.String())), nil
+}
+
+// UnmarshalJSON unmarshals the vector from a list of floats, or a quoted list of floats
+func (v *Vector) UnmarshalJSON(data []byte) error {
+	value := string(data)
+	if value == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+	var values []float32
+	if err := stdjson.Unmarshal([]byte(value), &values); err != nil {
+		return fmt.Errorf("invalid vector %s", data)
+	}
+	*v = values
+	return nil
+}
+
+// norm returns the euclidean norm of the vector
+func (v Vector) norm() float64 {
+	var sum float64
+	for _, value := range v {
+		sum += float64(value) * float64(value)
+	}
+	return math.Sqrt(sum)
+}
+
+// SimilarTo filters the topK nodes most similar to a vector by a float32vector predicate,
+// the predicate requires an hnsw index. Similarity search is a root function, set with Root.
+func SimilarTo(predicate string, topK int, vector []float32) *Filter {
+	return newFilterFunc("similar_to", predicate, topK, Vector(vector))
+}
+
+// SimilarTo queries the topK nodes most similar to a vector by a float32vector predicate with an hnsw index,
+// as the root function, returning the cosine similarity of each node as the similarity alias, which is
+// unmarshaled into fields with the alias json tag, e.g: `json:"similarity,omitempty"`. The vector is passed
+// as a query variable, Vars should be called before SimilarTo, as it adds the vector to the query variables.
+func (q *Query) SimilarTo(predicate string, topK int, vector []float32) *Query {
+	if !aliasRegex.MatchString(predicate) {
+		q.err = fmt.Errorf("invalid vector predicate %q", predicate)
+		return q
+	}
+	if topK <= 0 {
+		q.err = fmt.Errorf("invalid topK %d", topK)
+		return q
+	}
+	norm := Vector(vector).norm()
+	if norm == 0 {
+		q.err = errors.New("similarity requires a non-zero vector")
+		return q
+	}
+	if q.model != nil {
+		if err := SimilarTo(predicate, topK, vector).Validate(q.model); err != nil {
+			q.err = err
+			return q
+		}
+	}
+
+	q.addVar(similarToVectorVar, "float32vector", Vector(vector).String())
+	q.rootFunc = fmt.Sprintf("similar_to(%s, %d, %s)", predicate, topK, similarToVectorVar)
+	q.Value(similarToValueVar, predicate)
+	return q.Compute(SimilarityAlias, fmt.Sprintf("(%[1]s dot %[2]s) / (sqrt(%[1]s dot %[1]s) * %[3]s)",
+		similarToValueVar, similarToVectorVar, strconv.FormatFloat(norm, 'f', -1, 64)))
+}
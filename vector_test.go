@@ -0,0 +1,123 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type VectorProduct struct {
+	UID       string   `json:"uid,omitempty"`
+	Name      string   `json:"name,omitempty" dgraph:"index=exact"`
+	Price     int      `json:"price,omitempty" dgraph:"index=int"`
+	Embedding Vector   `json:"embedding,omitempty" dgraph:"index=hnsw"`
+	Score     float64  `json:"similarity,omitempty"`
+	DType     []string `json:"dgraph.type,omitempty"`
+}
+
+func TestSimilarToBuild(t *testing.T) {
+	filter, err := SimilarTo("embedding", 3, []float32{0.1, -2, 0.25}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, `similar_to(embedding, 3, "[0.1, -2, 0.25]")`, filter)
+}
+
+func TestQueryRoot(t *testing.T) {
+	tx, _ := newFakeTxnContext()
+
+	query := tx.Get(&VectorProduct{}).Root(Eq("name", `"quoted" name`)).Where(Ge("price", 10))
+	require.NoError(t, query.err)
+	assert.Equal(t, `{
+	data(func: eq(name, "\"quoted\" name")) @filter(has(dgraph.type) AND ge(price, 10)) {
+		uid
+		dgraph.type
+		expand(_all_)
+	}
+}`, query.String())
+
+	query = tx.Get(&VectorProduct{}).Root(Between("price", 10, 20)).First(5)
+	require.NoError(t, query.err)
+	assert.Contains(t, query.String(), "data(func: between(price, 10, 20), first: 5)")
+
+	query = tx.Get(&VectorProduct{}).Root(SimilarTo("embedding", 2, []float32{1, 0.5}))
+	require.NoError(t, query.err)
+	assert.Contains(t, query.String(), `data(func: similar_to(embedding, 2, "[1, 0.5]"))`)
+}
+
+func TestQueryRootInvalid(t *testing.T) {
+	tx, _ := newFakeTxnContext()
+
+	query := tx.Get(&VectorProduct{}).Root(Eq("name", "a").Or(Eq("name", "b")))
+	assert.EqualError(t, query.err, `root function must be a single filter function, got (eq(name, "a") OR eq(name, "b"))`)
+
+	query = tx.Get(&VectorProduct{}).Root(Not(Eq("name", "a")))
+	assert.Error(t, query.err)
+
+	query = tx.Get(&VectorProduct{}).Root(Eq("unknown", "a"))
+	assert.EqualError(t, query.err, "predicate unknown in eq filter is not defined in model")
+}
+
+func TestVectorSchema(t *testing.T) {
+	schema := NewTypeSchema()
+	schema.Marshal("", &VectorProduct{})
+	assert.Equal(t, "float32vector", schema.Schema["embedding"].Type)
+}
+
+func TestVectorJSON(t *testing.T) {
+	data, err := json.Marshal(&VectorProduct{Name: "shoe", Embedding: Vector{0.5, 1}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"shoe","embedding":"[0.5, 1]"}`, string(data))
+
+	var products []VectorProduct
+	require.NoError(t, json.Unmarshal([]byte(`[{"embedding":[0.5,1]},{"embedding":"[0.25, 2]"}]`), &products))
+	assert.Equal(t, Vector{0.5, 1}, products[0].Embedding)
+	assert.Equal(t, Vector{0.25, 2}, products[1].Embedding)
+}
+
+func TestQuerySimilarTo(t *testing.T) {
+	tx, fake := newFakeTxnContext(&api.Response{
+		Json: []byte(`{"data":[{"uid":"0x1","name":"shoe","embedding":[3,4],"similarity":1},{"uid":"0x2","name":"sock","embedding":[4,3],"similarity":0.96}]}`),
+	})
+
+	var products []VectorProduct
+	query := tx.Get(&products).SimilarTo("embedding", 2, []float32{3, 4})
+	require.NoError(t, query.err)
+	assert.Equal(t, `query q($similarvec: float32vector){
+	data(func: similar_to(embedding, 2, $similarvec)) @filter(has(dgraph.type)) {
+		uid
+		dgraph.type
+		expand(_all_)
+		similar_embedding as embedding
+		similarity: math((similar_embedding dot $similarvec) / (sqrt(similar_embedding dot similar_embedding) * 5))
+	}
+}`, query.String())
+
+	require.NoError(t, query.Nodes())
+	require.Len(t, fake.requests, 1)
+	assert.Equal(t, map[string]string{"$similarvec": "[3, 4]"}, fake.requests[0].Vars)
+	require.Len(t, products, 2)
+	assert.Equal(t, 1.0, products[0].Score)
+	assert.Equal(t, 0.96, products[1].Score)
+	assert.Equal(t, Vector{4, 3}, products[1].Embedding)
+
+	assert.EqualError(t, tx.Get(&products).SimilarTo("embedding", 0, []float32{1}).err, "invalid topK 0")
+	assert.EqualError(t, tx.Get(&products).SimilarTo("embedding", 1, []float32{0, 0}).err, "similarity requires a non-zero vector")
+	assert.EqualError(t, tx.Get(&products).SimilarTo("vector", 1, []float32{1}).err, "predicate vector in similar_to filter is not defined in model")
+}
@@ -0,0 +1,263 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/pkg/errors"
+)
+
+// Versioning defines the predicate conventions of versioned nodes.
+// A versioned node has a stable identity node, which links to all of its immutable
+// version nodes, and the current version node.
+type Versioning struct {
+	// IdentityType is the node type of identity nodes
+	IdentityType string
+	// CurrentPredicate is the identity node edge to the current version
+	CurrentPredicate string
+	// VersionsPredicate is the identity node edge to all versions
+	VersionsPredicate string
+	// VersionOfPredicate is the version node edge to its identity
+	VersionOfPredicate string
+	// ValidFromPredicate is the version node datetime predicate of when the version is created
+	ValidFromPredicate string
+}
+
+// NewVersioning returns the default versioning conventions, used by the TxnContext versioning helpers
+// unless the client versioning is set with Client.SetVersioning
+func NewVersioning() *Versioning {
+	return &Versioning{
+		IdentityType:       "VersionIdentity",
+		CurrentPredicate:   "version.current",
+		VersionsPredicate:  "version.versions",
+		VersionOfPredicate: "version.of",
+		ValidFromPredicate: "version.valid_from",
+	}
+}
+
+// SetVersioning sets the versioning conventions of the TxnContext versioning helpers of transactions created
+// from the client, passing nil restores the default conventions of NewVersioning
+func (c *Client) SetVersioning(versioning *Versioning) *Client {
+	c.dg.config.versioning = versioning
+	return c
+}
+
+// versioning returns the versioning conventions of the client of the transaction
+func (t *TxnContext) versioning() *Versioning {
+	if t.config != nil && t.config.versioning != nil {
+		return t.config.versioning
+	}
+	return NewVersioning()
+}
+
+// String returns the schema of the versioning predicates and identity type
+func (v *Versioning) String() string {
+	return fmt.Sprintf("%s: uid .\n%s: [uid] .\n%s: uid .\n%s: datetime @index(hour) .\ntype %s {\n\t%s\n\t%s\n}\n",
+		v.CurrentPredicate, v.VersionsPredicate, v.VersionOfPredicate, v.ValidFromPredicate,
+		v.IdentityType, v.CurrentPredicate, v.VersionsPredicate)
+}
+
+// CreateSchema creates the schema of the versioning predicates and identity type
+func (v *Versioning) CreateSchema(c DgraphClient) error {
+	return c.Alter(context.Background(), &api.Operation{Schema: v.String()})
+}
+
+// versionUIDField returns the uid field of versioned data
+func versionUIDField(data interface{}) (reflect.Value, error) {
+	dataValue := reflect.ValueOf(data)
+	if dataValue.Kind() != reflect.Ptr || dataValue.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, errors.New("versioned data must be a pointer to a struct")
+	}
+	dataValue = dataValue.Elem()
+	dataType := dataValue.Type()
+
+	for i := 0; i < dataType.NumField(); i++ {
+		field := dataType.Field(i)
+		if predicate, _ := getPredicate(&field); predicate == predicateUid {
+			return dataValue.Field(i), nil
+		}
+	}
+	return reflect.Value{}, errors.New("versioned data has no uid field")
+}
+
+// setValidFrom sets the field of the valid from predicate of versioned data, if any,
+// so the version mutation sets the same valid from time as the version link
+func (v *Versioning) setValidFrom(data interface{}, validFrom time.Time) {
+	dataValue := reflect.ValueOf(data).Elem()
+	dataType := dataValue.Type()
+	for i := 0; i < dataType.NumField(); i++ {
+		field := dataType.Field(i)
+		if predicate, _ := getPredicate(&field); predicate != v.ValidFromPredicate {
+			continue
+		}
+		switch fieldValue := dataValue.Field(i); fieldValue.Type() {
+		case reflect.TypeOf(validFrom):
+			fieldValue.Set(reflect.ValueOf(validFrom))
+		case reflect.TypeOf(&validFrom):
+			fieldValue.Set(reflect.ValueOf(&validFrom))
+		}
+	}
+}
+
+// Mutate creates a new immutable version node from data, linked to an identity node,
+// and updates the identity current version edge, in a single request.
+// The version node is mutated as in Mutate, calling the mutate hooks, validating, and unique checking
+// the data, where the previous versions of the identity node are excluded from the unique checks.
+// If an identity node uid is not passed, a new identity node is created.
+// The uid field of data is set to the version node uid, and the identity node uid is returned.
+func (v *Versioning) Mutate(tx *TxnContext, data interface{}, identity ...string) (string, error) {
+	uidField, err := versionUIDField(data)
+	if err != nil {
+		return "", err
+	}
+	if len(identity) > 0 {
+		if err := validateUIDs(identity[0]); err != nil {
+			return "", err
+		}
+	}
+	// versions are immutable, always create a new version node
+	uidField.Set(reflect.Zero(uidField.Type()))
+	validFrom := time.Now()
+	v.setValidFrom(data, validFrom)
+
+	m, err := newMutation(tx, data, MutateOptions{})
+	if err != nil {
+		return "", err
+	}
+	if err := m.prepare(); err != nil {
+		return "", err
+	}
+
+	identityUID := "_:identity"
+	identityNode := map[string]interface{}{}
+	if len(identity) > 0 {
+		identityUID = identity[0]
+		m.uniqueExclude = "previous_versions"
+	} else {
+		identityNode[predicateDgraphType] = []string{v.IdentityType}
+	}
+	if err := m.generateRequest(); err != nil {
+		return "", errors.Wrap(err, "generate request failed")
+	}
+	if m.uniqueExclude != "" && len(m.queries) > 0 {
+		m.queries = append(m.queries, fmt.Sprintf("var(func: uid(%s)) {\n%s as %s\n}",
+			identityUID, m.uniqueExclude, v.VersionsPredicate))
+		m.setQuery()
+	}
+
+	// the version is linked when the unique checks of the version mutations pass
+	var conds []string
+	for _, mu := range m.request.Mutations {
+		conds = append(conds, mu.Cond)
+	}
+	cond, err := combineConditions(conds...)
+	if err != nil {
+		return "", err
+	}
+	versionUID := m.uidOf(uidField)
+	identityNode[predicateUid] = identityUID
+	identityNode[v.CurrentPredicate] = node{UID: versionUID}
+	identityNode[v.VersionsPredicate] = []node{{UID: versionUID}}
+	versionNode := map[string]interface{}{
+		predicateUid:         versionUID,
+		v.VersionOfPredicate: node{UID: identityUID},
+		v.ValidFromPredicate: validFrom,
+	}
+	setJSON, err := json.Marshal([]interface{}{identityNode, versionNode})
+	if err != nil {
+		return "", errors.Wrap(err, "marshal version link failed")
+	}
+	m.request.Mutations = append(m.request.Mutations, &api.Mutation{SetJson: setJSON, Cond: cond})
+
+	resp, err := m.send(withMetricsTags(tx.ctx, "", data))
+	if err != nil {
+		return "", errors.Wrap(err, "versioned mutation failed")
+	}
+	if err := m.processResponse(resp); err != nil {
+		return "", err
+	}
+	if uid, ok := resp.Uids["identity"]; ok {
+		identityUID = uid
+	}
+	if err := tx.hooks.afterMutate(tx.ctx, m.opcode.hookOp(), data); err != nil {
+		return identityUID, err
+	}
+	return identityUID, nil
+}
+
+func (v *Versioning) getVersion(tx *TxnContext, model interface{}, versionQuery *Query) error {
+	var result struct {
+		Data []stdjson.RawMessage `json:"data"`
+	}
+
+	query := tx.Query(
+		versionQuery.Var(),
+		NewQuery().
+			Name("data").
+			UID("version").
+			OrderDesc(v.ValidFromPredicate).
+			First(1).
+			Query(fmt.Sprintf("{\n\t\tuid\n\t\tdgraph.type\n\t\texpand(_all_)\n\t\t%s\n\t}", v.ValidFromPredicate)),
+	)
+	if err := query.Scan(&result); err != nil {
+		return err
+	}
+	if len(result.Data) == 0 {
+		return ErrNodeNotFound
+	}
+	return json.Unmarshal(result.Data[0], model)
+}
+
+// Current gets the current version of an identity node into model
+func (v *Versioning) Current(tx *TxnContext, model interface{}, identity string) error {
+	return v.getVersion(tx, model, NewQuery().
+		RootFunc(fmt.Sprintf("uid(%s)", UID(identity).FormatParams())).
+		Query(fmt.Sprintf("{ version as %s }", v.CurrentPredicate)))
+}
+
+// AsOf gets the version of an identity node that was current at a point of time into model,
+// ErrNodeNotFound is returned when no versions exist at the time
+func (v *Versioning) AsOf(tx *TxnContext, model interface{}, identity string, at time.Time) error {
+	return v.getVersion(tx, model, NewQuery().
+		RootFunc(fmt.Sprintf("uid(%s)", UID(identity).FormatParams())).
+		Query(fmt.Sprintf("{ version as %s @filter(le(%s, $1)) }", v.VersionsPredicate, v.ValidFromPredicate), at))
+}
+
+// MutateVersioned creates a new immutable version node from data using the versioning of the client,
+// linked to an identity node, and updates the identity current version edge.
+// If an identity node uid is not passed, a new identity node is created.
+// The uid field of data is set to the version node uid, and the identity node uid is returned.
+func (t *TxnContext) MutateVersioned(data interface{}, identity ...string) (string, error) {
+	return t.versioning().Mutate(t, data, identity...)
+}
+
+// GetCurrentVersion gets the current version of an identity node into model using the versioning of the client
+func (t *TxnContext) GetCurrentVersion(model interface{}, identity string) error {
+	return t.versioning().Current(t, model, identity)
+}
+
+// GetVersionAsOf gets the version of an identity node that was current at a point of time
+// into model using the versioning of the client
+func (t *TxnContext) GetVersionAsOf(model interface{}, identity string, at time.Time) error {
+	return t.versioning().AsOf(t, model, identity, at)
+}
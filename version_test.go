@@ -0,0 +1,152 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/dgo/v210/protos/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type VersionedPrice struct {
+	UID       string    `json:"uid,omitempty"`
+	Amount    int       `json:"amount,omitempty"`
+	ValidFrom time.Time `json:"version.valid_from,omitempty"`
+	DType     []string  `json:"dgraph.type,omitempty"`
+}
+
+type VersionedAccount struct {
+	UID   string   `json:"uid,omitempty"`
+	Email string   `json:"email,omitempty" dgraph:"index=exact unique"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+func TestVersioningMutate(t *testing.T) {
+	atomic.StoreInt32(&blankuid, 0)
+	tx, fake := newFakeTxnContext(&api.Response{Uids: map[string]string{"uid(u_1_1)": "0x9"}})
+	var ops []HookOp
+	tx.SetHooks(&Hooks{
+		BeforeMutate: func(ctx context.Context, op HookOp, node reflect.Value) error {
+			ops = append(ops, op)
+			return nil
+		},
+	})
+
+	account := &VersionedAccount{UID: "0x2", Email: "wildan@dolan.in"}
+	identity, err := tx.MutateVersioned(account, "0x5")
+	require.NoError(t, err)
+	assert.Equal(t, "0x5", identity)
+	assert.Equal(t, "0x9", account.UID)
+	assert.Equal(t, []HookOp{HookMutate}, ops)
+
+	require.Len(t, fake.requests, 1)
+	req := fake.requests[0]
+	// the previous versions are excluded from the unique checks
+	assert.Contains(t, req.Query, "@filter(NOT uid(previous_versions) AND eq(email, \"wildan@dolan.in\") AND type(VersionedAccount))")
+	assert.Contains(t, req.Query, "var(func: uid(0x5)) {\n\t\tprevious_versions as version.versions")
+	require.Len(t, req.Mutations, 2)
+	assert.NotEmpty(t, req.Mutations[0].Cond)
+	// the version is only linked when the unique checks pass
+	assert.Equal(t, req.Mutations[0].Cond, req.Mutations[1].Cond)
+	assert.Contains(t, string(req.Mutations[1].SetJson), `"version.current":{"uid":"uid(u_1_1)"}`)
+
+	t.Run("unique error", func(t *testing.T) {
+		atomic.StoreInt32(&blankuid, 0)
+		tx, _ := newFakeTxnContext(&api.Response{Json: []byte(`{"q_1_1":[{"uid":"0x3"}]}`)})
+		_, err := tx.MutateVersioned(&VersionedAccount{Email: "wildan@dolan.in"})
+		assert.IsType(t, &UniqueError{}, err)
+	})
+
+	t.Run("client versioning", func(t *testing.T) {
+		tx, fake := newFakeTxnContext(&api.Response{Uids: map[string]string{"identity": "0x5"}})
+		versioning := NewVersioning()
+		versioning.CurrentPredicate = "account.current"
+		tx.config = NewClient().SetVersioning(versioning).dg.config
+
+		identity, err := tx.MutateVersioned(&VersionedAccount{Email: "dolan@dolan.in"})
+		require.NoError(t, err)
+		assert.Equal(t, "0x5", identity)
+		require.Len(t, fake.requests, 1)
+		assert.Contains(t, string(fake.requests[0].Mutations[1].SetJson), `"account.current"`)
+	})
+}
+
+type VersionedCounter struct {
+	UID   uint64   `json:"uid,omitempty"`
+	Count int      `json:"count,omitempty"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+func TestVersioningMutateNumericUID(t *testing.T) {
+	atomic.StoreInt32(&blankuid, 0)
+	tx, fake := newFakeTxnContext(&api.Response{Uids: map[string]string{"1": "0x9", "identity": "0x5"}})
+
+	counter := &VersionedCounter{UID: 2, Count: 1}
+	identity, err := tx.MutateVersioned(counter)
+	require.NoError(t, err)
+	assert.Equal(t, "0x5", identity)
+	assert.Equal(t, uint64(9), counter.UID)
+
+	require.Len(t, fake.requests, 1)
+	require.Len(t, fake.requests[0].Mutations, 2)
+	assert.Contains(t, string(fake.requests[0].Mutations[0].SetJson), `"uid":"_:1"`)
+	assert.Contains(t, string(fake.requests[0].Mutations[1].SetJson), `"version.current":{"uid":"_:1"}`)
+}
+
+func TestMutateVersioned(t *testing.T) {
+	c := newDgraphClient()
+	_, err := CreateSchema(c, &VersionedPrice{})
+	require.NoError(t, err)
+	require.NoError(t, NewVersioning().CreateSchema(c))
+	defer dropAll(c)
+
+	first := &VersionedPrice{Amount: 100}
+	identity, err := NewTxn(c).SetCommitNow().MutateVersioned(first)
+	require.NoError(t, err)
+	assert.True(t, isUID(identity))
+	assert.True(t, isUID(first.UID))
+
+	time.Sleep(10 * time.Millisecond)
+	beforeSecond := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	second := &VersionedPrice{Amount: 200}
+	secondIdentity, err := NewTxn(c).SetCommitNow().MutateVersioned(second, identity)
+	require.NoError(t, err)
+	assert.Equal(t, identity, secondIdentity)
+	assert.NotEqual(t, first.UID, second.UID)
+
+	var current VersionedPrice
+	require.NoError(t, NewReadOnlyTxn(c).GetCurrentVersion(&current, identity))
+	assert.Equal(t, second.UID, current.UID)
+	assert.Equal(t, 200, current.Amount)
+
+	var asOf VersionedPrice
+	require.NoError(t, NewReadOnlyTxn(c).GetVersionAsOf(&asOf, identity, beforeSecond))
+	assert.Equal(t, first.UID, asOf.UID)
+	assert.Equal(t, 100, asOf.Amount)
+
+	var tooEarly VersionedPrice
+	err = NewReadOnlyTxn(c).GetVersionAsOf(&tooEarly, identity, beforeSecond.Add(-time.Hour))
+	assert.Equal(t, ErrNodeNotFound, err)
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// WarmTypeCache parses the model types and their edge types ahead of time, e.g: on startup,
+// populating the type caches and json encoders and decoders, so the first request doesn't pay
+// the reflection costs. It returns an error on invalid dgraph tags or schema conflicts,
+// so misconfigured models fail fast during boot.
+func WarmTypeCache(models ...interface{}) error {
+	visited := make(map[reflect.Type]bool)
+	for _, model := range models {
+		modelType, err := reflectType(model)
+		if err != nil {
+			return err
+		}
+		if err := warmType(modelType, visited); err != nil {
+			return err
+		}
+	}
+
+	typeSchema := NewTypeSchema()
+	typeSchema.Marshal("", models...)
+	return typeSchema.Err()
+}
+
+func warmType(modelType reflect.Type, visited map[reflect.Type]bool) error {
+	if modelType.Kind() != reflect.Struct || visited[modelType] {
+		return nil
+	}
+	visited[modelType] = true
+
+	if _, err := getNodeTypeInfo(modelType); err != nil {
+		return err
+	}
+	for i := 0; i < modelType.NumField(); i++ {
+		field := modelType.Field(i)
+		if _, err := parseDgraphTag(&field); err != nil {
+			return errors.Wrapf(err, "parse dgraph tag failed on %s.%s", modelType.Name(), field.Name)
+		}
+
+		fieldType := getElemType(field.Type)
+		if fieldType.Kind() == reflect.Struct && fieldType.PkgPath() != "time" {
+			if err := warmType(fieldType, visited); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := getValidationRules(modelType); err != nil {
+		return err
+	}
+	isComputable(modelType)
+	softDeletePredicate(modelType)
+	ttlPredicate(modelType)
+
+	// json encoders and decoders are cached by type on first use
+	value := reflect.New(modelType).Interface()
+	if _, err := json.Marshal(value); err != nil {
+		return errors.Wrapf(err, "marshal %s failed", modelType.Name())
+	}
+	if err := json.Unmarshal([]byte("{}"), value); err != nil {
+		return errors.Wrapf(err, "unmarshal %s failed", modelType.Name())
+	}
+	return nil
+}
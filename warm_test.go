@@ -0,0 +1,51 @@
+/*
+ * Copyright (C) 2021 Dolan and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dgman
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type WarmInvalidTag struct {
+	UID   string   `json:"uid,omitempty"`
+	Owner string   `json:"owner,omitempty" dgraph:"cardinality=one"`
+	DType []string `json:"dgraph.type,omitempty"`
+}
+
+type WarmEdge struct {
+	UID   string          `json:"uid,omitempty"`
+	Edge  *WarmInvalidTag `json:"edge,omitempty"`
+	DType []string        `json:"dgraph.type,omitempty"`
+}
+
+func TestWarmTypeCache(t *testing.T) {
+	assert.NoError(t, WarmTypeCache(&TestModel{}, []AliasBook{}))
+	for _, model := range []interface{}{TestModel{}, TestEdge{}} {
+		_, ok := nodeTypes.Load(reflect.TypeOf(model))
+		assert.True(t, ok, reflect.TypeOf(model).Name())
+	}
+
+	err := WarmTypeCache(&WarmEdge{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "WarmInvalidTag.Owner")
+	}
+
+	assert.IsType(t, &SchemaConflictError{}, WarmTypeCache(&ConflictingAdmin{}, &ConflictingUser{}))
+}